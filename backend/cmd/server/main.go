@@ -2,49 +2,161 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"app/internal/buildinfo"
 	"app/internal/db"
 	"app/internal/handlers"
+	"app/internal/logging"
+	"app/internal/metrics"
+	"app/internal/models"
+	"app/internal/scheduler"
+	"app/internal/services"
+	"app/internal/tracing"
 	"app/internal/utils"
+	"app/internal/webhook"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 )
 
 func main() {
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: No .env file found or error loading .env file: %v", err)
-		log.Printf("Will use system environment variables instead")
+	// Load .env file if it exists; the result is logged once logging is set up
+	envErr := godotenv.Load()
+
+	// Load configuration from the config file, environment and flags
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	printConfig := flags.Bool("print-config", false, "print the configuration with secrets redacted and exit")
+	config, err := utils.LoadConfig(flags, os.Args[1:])
+	if err != nil {
+		fatal("Failed to load configuration", err)
+	}
+	if *printConfig {
+		out, err := config.Redacted()
+		if err != nil {
+			fatal("Failed to print configuration", err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	// Log JSON records to stderr, at the configured level of each package
+	if _, err := logging.Setup(os.Stderr, logging.Config{Level: config.LogLevel, PackageLevels: config.LogPackageLevels}); err != nil {
+		fatal("Failed to set up logging", err)
+	}
+	if envErr != nil {
+		slog.Warn("No .env file loaded, using system environment variables", "error", envErr)
+	} else {
+		slog.Info("Loaded .env file")
+	}
+
+	// Trace requests, recommendation steps and database calls
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     config.TracingExporter,
+		OTLPEndpoint: config.TracingOTLPEndpoint,
+		SampleRatio:  config.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+
+	// Initialize Supabase client, recording the latency and errors of every call
+	database := metrics.InstrumentDB(db.NewSupabaseClient(config.SupabaseURL, config.SupabaseAnonKey, config.SupabaseServiceKey,
+		config.SupabaseTimeout, config.SupabaseMaxIdleConns), "supabase")
+
+	// Background jobs run on one replica at a time, elected through a Postgres advisory
+	// lock on the direct database connection
+	var elector scheduler.Elector = scheduler.AlwaysLeader{}
+	var lockDB *db.DB
+	if config.DatabaseURL != "" {
+		lockDB, err = db.New(config.DatabaseURL, db.PoolConfig{
+			MaxConns:        int32(config.DatabaseMaxConns),
+			MinConns:        int32(config.DatabaseMinConns),
+			MaxConnLifetime: config.DatabaseMaxConnLifetime,
+			MaxConnIdleTime: config.DatabaseMaxConnIdleTime,
+		})
+		if err != nil {
+			fatal("Failed to connect to database for leader election", err)
+		}
+		elector = db.NewAdvisoryLock(lockDB.Pool, "background-scheduler")
 	} else {
-		log.Println("Successfully loaded .env file")
+		slog.Warn("DATABASE_URL not set, background jobs run without leader election; run a single replica")
 	}
 
-	// Load configuration
-	config, err := utils.LoadConfig()
+	// Customer notifications are queued in the outbox and sent by email and SMS when configured
+	notifiers, err := services.NotifiersFromConfig(config)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to configure notifications", err)
+	}
+	if len(notifiers) == 0 {
+		slog.Warn("Neither SMTP_HOST nor SMS_GATEWAY_URL set, customer notifications are disabled")
+	}
+	// Upfront payments go through the configured provider; LoadConfig only allows the
+	// in-memory fake in development
+	paymentProvider, err := services.PaymentProviderFromConfig(config)
+	if err != nil {
+		fatal("Failed to configure payments", err)
+	}
+	if config.PaymentProvider == utils.PaymentProviderFake {
+		slog.Warn("PAYMENT_PROVIDER is fake, payments are kept in memory and no card is charged")
+	}
+	if config.AdminToken == "" {
+		slog.Warn("ADMIN_TOKEN not set, admin endpoints only accept API keys with the admin scope")
+	}
+	notifications := services.NewNotificationService(database, config.ReminderLead, notifiers...)
+
+	// Order events are written to the outbox with the order change and dispatched to the
+	// subscribed handlers; events that keep failing are dead-lettered for the admin API.
+	// Partner webhooks get their own delivery queue, so a failing partner only retries
+	// its own deliveries.
+	webhooks := services.NewWebhookService(database, webhook.NewSender())
+	dispatcher := services.NewEventDispatcher(database, config.EventMaxAttempts)
+	for _, eventType := range []string{models.EventOrderPlaced, models.EventSlotBooked, models.EventOrderCancelled} {
+		dispatcher.Subscribe(eventType, "notifications", notifications.HandleEvent)
+		dispatcher.Subscribe(eventType, "webhooks", webhooks.HandleEvent)
 	}
 
-	// Initialize Supabase client
-	database := db.NewSupabaseClient(config.SupabaseURL, config.SupabaseAnonKey, config.SupabaseServiceKey)
+	// Expire abandoned holds, settle unfinished payments, purge expired idempotency keys
+	// and quotes, keep install slots generated for the configured horizon, dispatch order
+	// events, queue and send customer notifications, and send partner webhooks. Every job
+	// also runs once at startup.
+	maintenance := services.NewMaintenanceService(database, config.PendingOrderTTL, config.SlotHorizonDays)
+	idempotency := services.NewIdempotencyService(database, config.IdempotencyKeyTTL)
+	quotes := services.NewQuoteService(database, config.QuoteSigningSecret, config.QuoteTTL)
+	jobs := scheduler.New(elector)
+	jobs.Add(scheduler.Job{Name: "expire-holds", Interval: config.SweepInterval, Run: maintenance.ExpireHolds})
+	jobs.Add(scheduler.Job{Name: "reconcile-payments", Interval: config.PaymentReconcileInterval, Run: services.NewPaymentReconciler(database, paymentProvider).Reconcile})
+	jobs.Add(scheduler.Job{Name: "purge-idempotency-keys", Interval: config.PurgeInterval, Run: idempotency.PurgeExpired})
+	jobs.Add(scheduler.Job{Name: "purge-quotes", Interval: config.PurgeInterval, Run: quotes.PurgeExpired})
+	jobs.Add(scheduler.Job{Name: "regenerate-slots", Interval: config.SlotRegenInterval, Run: maintenance.RegenerateSlots})
+	jobs.Add(scheduler.Job{Name: "dispatch-events", Interval: config.EventDispatchInterval, Run: dispatcher.Dispatch})
+	jobs.Add(scheduler.Job{Name: "queue-reminders", Interval: config.ReminderInterval, Run: notifications.QueueReminders})
+	jobs.Add(scheduler.Job{Name: "deliver-notifications", Interval: config.NotificationInterval, Run: notifications.Deliver})
+	jobs.Add(scheduler.Job{Name: "deliver-webhooks", Interval: config.WebhookDeliveryInterval, Run: webhooks.Deliver})
+	if config.RateLimitStore == services.RateLimitStorePostgres {
+		jobs.Add(scheduler.Job{Name: "purge-rate-limits", Interval: config.PurgeInterval, Run: services.NewDBRateLimitStore(database).PurgeExpired})
+	}
+	jobs.Start(context.Background())
 
-	// Create Echo instance
+	// Create Echo instance; requests are logged by the logging middleware
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
 
 	// Setup all routes and middleware
-	handlers.SetupRoutes(e, database)
+	handlers.SetupRoutes(e, database, config, paymentProvider, dispatcher, webhooks)
 
 	// Setup graceful shutdown
 	go func() {
+		build := buildinfo.Get()
+		slog.Info("Server started", "addr", config.GetAddr(), "version", build.Version, "commit", build.Commit)
 		if err := e.Start(config.GetAddr()); err != nil && err != http.ErrServerClosed {
-			e.Logger.Fatal("Server startup failed: ", err)
+			fatal("Server startup failed", err)
 		}
 	}()
 
@@ -52,17 +164,36 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 
 	// Gracefully shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
 	defer cancel()
 
 	if err := e.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+		slog.Error("Server forced to shutdown", "error", err)
+	}
+
+	// Stop background jobs and hand leadership to another replica
+	if err := jobs.Stop(ctx); err != nil {
+		slog.Error("Scheduler forced to stop", "error", err)
+	}
+	if lockDB != nil {
+		lockDB.Close()
+	}
+
+	// Send the remaining spans
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
 	}
 
 	// Close database connection
 	database.Close()
-	log.Println("Server exited")
+	slog.Info("Server exited")
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -1,11 +1,18 @@
 package api
 
+import "time"
+
 // RecommendationRequest represents the input for recommendation calculation
 type RecommendationRequest struct {
 	UserID     int                `json:"user_id" validate:"required"`
 	AddressID  string             `json:"address_id" validate:"required"`
 	Household  []HouseholdLineDTO `json:"household" validate:"required,min=1,dive"`
 	PreferTech []string           `json:"prefer_tech,omitempty"`
+	CouponCode string             `json:"coupon_code,omitempty" validate:"omitempty,max=64"`
+
+	// MaxCommitmentMonths is the longest commitment the customer accepts; 0, the default,
+	// prices every plan without commitment
+	MaxCommitmentMonths int `json:"max_commitment_months,omitempty" validate:"min=0,max=36"`
 }
 
 // HouseholdLineDTO represents a single household line input
@@ -29,6 +36,38 @@ type RecommendationCandidateDTO struct {
 	Savings      float64                    `json:"savings"`
 	Reasoning    string                     `json:"reasoning"`
 	Discounts    RecommendationDiscountsDTO `json:"discounts"`
+
+	// FirstMonthTotal is the first month's price, after time-limited promotions too
+	FirstMonthTotal float64 `json:"first_month_total"`
+
+	// Commitment is the contract the plans are priced for
+	Commitment CommitmentDTO `json:"commitment"`
+
+	// QuoteID identifies this candidate's price at checkout until QuoteExpiresAt
+	QuoteID        string     `json:"quote_id,omitempty"`
+	QuoteExpiresAt *time.Time `json:"quote_expires_at,omitempty"`
+
+	// ScheduledPrices is what the same plans cost when signed after upcoming catalog
+	// changes that affect them
+	ScheduledPrices []ScheduledPriceDTO `json:"scheduled_prices,omitempty"`
+}
+
+// CommitmentDTO is the commitment of a candidate: its length in months, 0 without
+// commitment, what the whole contract costs, and the fee for terminating it early.
+// Without commitment the contract is the first month.
+type CommitmentDTO struct {
+	Months         int     `json:"months"`
+	ContractValue  float64 `json:"contract_value"`
+	TerminationFee float64 `json:"termination_fee"`
+}
+
+// ScheduledPriceDTO is a candidate's price from a scheduled catalog change on. A
+// candidate whose plans are no longer sold then is not available.
+type ScheduledPriceDTO struct {
+	EffectiveFrom time.Time `json:"effective_from"`
+	Available     bool      `json:"available"`
+	MonthlyTotal  float64   `json:"monthly_total,omitempty"`
+	Savings       float64   `json:"savings,omitempty"`
 }
 
 // RecommendationItemsDTO represents the components of a recommendation
@@ -49,14 +88,31 @@ type MobilePlanAssignmentDTO struct {
 
 // RecommendationDiscountsDTO represents applied discounts
 type RecommendationDiscountsDTO struct {
-	LineDiscount   float64 `json:"line_discount"`   // extra line discount amount
-	BundleDiscount float64 `json:"bundle_discount"` // bundle discount amount
-	TotalDiscount  float64 `json:"total_discount"`  // sum of all discounts
+	LineDiscount      float64 `json:"line_discount"`      // extra line discount amount
+	BundleDiscount    float64 `json:"bundle_discount"`    // bundle discount amount
+	PromotionDiscount float64 `json:"promotion_discount"` // promotions taken off every month
+	TotalDiscount     float64 `json:"total_discount"`     // sum of all discounts
+
+	// Promotions lists every promotion applied, including those for the first months only
+	Promotions []PromotionDTO `json:"promotions,omitempty"`
+}
+
+// PromotionDTO is a campaign applied to a candidate: Amount off every month, or for the
+// first Months months only
+type PromotionDTO struct {
+	CampaignID   int     `json:"campaign_id"`
+	Name         string  `json:"name"`
+	Code         string  `json:"code,omitempty"`
+	DiscountType string  `json:"discount_type"`
+	AppliesTo    string  `json:"applies_to"`
+	Amount       float64 `json:"amount"`
+	Months       int     `json:"months,omitempty"`
 }
 
 // Plan DTOs
 type MobilePlanDTO struct {
 	PlanID       int     `json:"plan_id"`
+	Version      int     `json:"version"`
 	PlanName     string  `json:"plan_name"`
 	QuotaGB      float64 `json:"quota_gb"`
 	QuotaMin     float64 `json:"quota_min"`
@@ -67,6 +123,7 @@ type MobilePlanDTO struct {
 
 type HomePlanDTO struct {
 	HomeID       int     `json:"home_id"`
+	Version      int     `json:"version"`
 	Name         string  `json:"name"`
 	Tech         string  `json:"tech"`
 	DownMbps     int     `json:"down_mbps"`
@@ -76,23 +133,156 @@ type HomePlanDTO struct {
 
 type TVPlanDTO struct {
 	TVID            int     `json:"tv_id"`
+	Version         int     `json:"version"`
 	Name            string  `json:"name"`
 	HDHoursIncluded float64 `json:"hd_hours_included"`
 	MonthlyPrice    float64 `json:"monthly_price"`
 }
 
-// CheckoutRequest represents the checkout request
+// CoverageBatchRequest represents a batch coverage lookup request
+type CoverageBatchRequest struct {
+	AddressIDs []string `json:"address_ids" validate:"required,min=1,max=100,dive,required"`
+}
+
+// CheckoutRequest represents the checkout request. The customer, address, items and
+// price all come from the quote issued by the recommendation endpoint.
 type CheckoutRequest struct {
-	UserID        int                        `json:"user_id" validate:"required"`
-	SelectedCombo RecommendationCandidateDTO `json:"selected_combo" validate:"required"`
-	SlotID        string                     `json:"slot_id" validate:"required"`
-	AddressID     string                     `json:"address_id" validate:"required"`
+	QuoteID string           `json:"quote_id" validate:"required,max=128"`
+	SlotID  string           `json:"slot_id" validate:"required"`
+	Payment PaymentMethodDTO `json:"payment" validate:"required"`
+
+	// CouponCode applies a coupon that was not entered for the recommendation
+	CouponCode string `json:"coupon_code,omitempty" validate:"omitempty,max=64"`
+}
+
+// PaymentMethodDTO represents the card used for the upfront payment
+type PaymentMethodDTO struct {
+	CardNumber  string `json:"card_number" validate:"required,min=12,max=19,numeric"`
+	ExpiryMonth int    `json:"expiry_month" validate:"required,min=1,max=12"`
+	ExpiryYear  int    `json:"expiry_year" validate:"required,min=2000,max=2100"`
+	CVC         string `json:"cvc" validate:"required,min=3,max=4,numeric"`
+	HolderName  string `json:"holder_name,omitempty" validate:"max=100"`
 }
 
 // CheckoutResponse represents the checkout response
 type CheckoutResponse struct {
-	Status  string `json:"status"`
-	OrderID string `json:"order_id"`
+	Status        string  `json:"status"`
+	OrderID       string  `json:"order_id"`
+	UpfrontAmount float64 `json:"upfront_amount"`
+	PaymentStatus string  `json:"payment_status"`
+}
+
+// RescheduleRequest represents a request to move an installation appointment
+type RescheduleRequest struct {
+	SlotID string `json:"slot_id" validate:"required"`
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// CancelOrderRequest represents a request to cancel an order
+type CancelOrderRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// CreateWebhookRequest represents a request to subscribe an endpoint to order events.
+// Without event types the endpoint receives every event.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types,omitempty" validate:"max=3,dive,oneof=OrderPlaced SlotBooked OrderCancelled"`
+}
+
+// CreateAPIKeyRequest represents a request to issue a partner API key. Without a rate
+// limit the key is held to each route group's limit; without a burst it may use its whole
+// per-minute limit at once.
+type CreateAPIKeyRequest struct {
+	Name               string     `json:"name" validate:"required,max=100"`
+	Scopes             []string   `json:"scopes" validate:"required,min=1,max=3,dive,oneof=recommendation:read checkout:write admin"`
+	RateLimitPerMinute *int       `json:"rate_limit_per_minute,omitempty" validate:"omitempty,min=1,max=100000"`
+	RateLimitBurst     *int       `json:"rate_limit_burst,omitempty" validate:"omitempty,min=1,max=100000"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+}
+
+// RotateAPIKeyRequest represents a request to replace an API key. The old key keeps
+// working for GracePeriodSeconds, or API_KEY_ROTATION_GRACE when it is not set; zero
+// stops it at once.
+type RotateAPIKeyRequest struct {
+	GracePeriodSeconds *int `json:"grace_period_seconds,omitempty" validate:"omitempty,min=0,max=2592000"`
+}
+
+// MobilePlanRequest represents a mobile plan created or replaced by an administrator
+type MobilePlanRequest struct {
+	PlanName     string            `json:"plan_name" validate:"required,max=255"`
+	QuotaGB      *float64          `json:"quota_gb" validate:"required,gte=0"`
+	QuotaMin     *float64          `json:"quota_min" validate:"required,gte=0"`
+	MonthlyPrice *float64          `json:"monthly_price" validate:"required,gte=0"`
+	OverageGB    float64           `json:"overage_gb" validate:"gte=0"`
+	OverageMin   float64           `json:"overage_min" validate:"gte=0"`
+	Terms        []PlanTermRequest `json:"terms,omitempty" validate:"max=4,unique=Months,dive"`
+}
+
+// PlanTermRequest represents a commitment offer of a plan: its monthly price for a
+// commitment of Months months and the fee for terminating early
+type PlanTermRequest struct {
+	Months         int      `json:"months" validate:"required,oneof=12 24"`
+	MonthlyPrice   *float64 `json:"monthly_price" validate:"required,gte=0"`
+	TerminationFee float64  `json:"termination_fee" validate:"gte=0"`
+}
+
+// HomePlanRequest represents a home internet plan created or replaced by an administrator
+type HomePlanRequest struct {
+	Name         string            `json:"name" validate:"required,max=255"`
+	Tech         string            `json:"tech" validate:"required,oneof=fiber vdsl fwa"`
+	DownMbps     int               `json:"down_mbps" validate:"required,gt=0"`
+	MonthlyPrice *float64          `json:"monthly_price" validate:"required,gte=0"`
+	InstallFee   float64           `json:"install_fee" validate:"gte=0"`
+	Terms        []PlanTermRequest `json:"terms,omitempty" validate:"max=4,unique=Months,dive"`
+}
+
+// TVPlanRequest represents a TV plan created or replaced by an administrator
+type TVPlanRequest struct {
+	Name            string            `json:"name" validate:"required,max=255"`
+	HDHoursIncluded *float64          `json:"hd_hours_included" validate:"required,gte=0"`
+	MonthlyPrice    *float64          `json:"monthly_price" validate:"required,gte=0"`
+	Terms           []PlanTermRequest `json:"terms,omitempty" validate:"max=4,unique=Months,dive"`
+}
+
+// BundlingRuleRequest represents a bundling rule created or replaced by an administrator
+type BundlingRuleRequest struct {
+	RuleType        string   `json:"rule_type" validate:"required,oneof=line_discount bundle_discount"`
+	Description     string   `json:"description" validate:"required"`
+	DiscountPercent *float64 `json:"discount_percent" validate:"required,gte=0,lte=100"`
+	AppliesTo       string   `json:"applies_to" validate:"required,oneof=mobile home tv total"`
+}
+
+// CoverageRequest represents the coverage of an address created or replaced by an
+// administrator. When replacing, the address comes from the path.
+type CoverageRequest struct {
+	AddressID string `json:"address_id" param:"id" validate:"required,max=50"`
+	City      string `json:"city" validate:"required,max=100"`
+	District  string `json:"district" validate:"required,max=100"`
+	Fiber     bool   `json:"fiber"`
+	VDSL      bool   `json:"vdsl"`
+	FWA       bool   `json:"fwa"`
+}
+
+// CampaignRequest represents a promotional campaign created or replaced by an
+// administrator. Campaigns without a code apply automatically; empty lists do not limit
+// eligibility.
+type CampaignRequest struct {
+	Name           string     `json:"name" validate:"required,max=255"`
+	Code           *string    `json:"code" validate:"omitempty,min=3,max=64,alphanum,uppercase"`
+	DiscountType   string     `json:"discount_type" validate:"required,oneof=percent fixed free_months"`
+	DiscountValue  float64    `json:"discount_value" validate:"required,gt=0"`
+	DurationMonths *int       `json:"duration_months" validate:"omitempty,gt=0"`
+	AppliesTo      string     `json:"applies_to" validate:"required,oneof=mobile home tv total"`
+	Cities         []string   `json:"cities" validate:"omitempty,dive,required,max=100"`
+	Techs          []string   `json:"techs" validate:"omitempty,dive,oneof=fiber vdsl fwa"`
+	MobilePlanIDs  []int      `json:"mobile_plan_ids" validate:"omitempty,dive,gt=0"`
+	HomePlanIDs    []int      `json:"home_plan_ids" validate:"omitempty,dive,gt=0"`
+	TVPlanIDs      []int      `json:"tv_plan_ids" validate:"omitempty,dive,gt=0"`
+	NewCustomers   bool       `json:"new_customers_only"`
+	Stackable      *bool      `json:"stackable" validate:"required"`
+	StartsAt       time.Time  `json:"starts_at" validate:"required"`
+	EndsAt         *time.Time `json:"ends_at" validate:"omitempty,gtfield=StartsAt"`
 }
 
 // ErrorResponse represents API error response
@@ -100,8 +290,20 @@ type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
 }
 
+// ErrorDetail describes an API error. Fields lists the invalid request fields of a
+// validation error, and RequestID matches the X-Request-ID response header.
 type ErrorDetail struct {
-	Code    string   `json:"code"`
-	Message string   `json:"message"`
-	Details []string `json:"details,omitempty"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []string     `json:"details,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError describes one invalid request field. Field is the JSON path, such as
+// household[0].expected_gb.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error is an error response. Handlers return it and the central error handler writes
+// it as an ErrorResponse with Status. Cause is logged but never sent to the client.
+type Error struct {
+	Status int
+	Detail ErrorDetail
+	Cause  error
+}
+
+// NewError creates an error response
+func NewError(status int, code, message string, details ...string) *Error {
+	return &Error{
+		Status: status,
+		Detail: ErrorDetail{Code: code, Message: message, Details: details},
+	}
+}
+
+// BadRequest creates a 400 error response for a request that could not be parsed
+func BadRequest(code, message string, cause error) *Error {
+	e := NewError(http.StatusBadRequest, code, message, cause.Error())
+	e.Cause = cause
+	return e
+}
+
+// ValidationFailed creates a 400 VALIDATION_FAILED error response listing the invalid fields
+func ValidationFailed(message string, fields []FieldError) *Error {
+	e := NewError(http.StatusBadRequest, "VALIDATION_FAILED", message)
+	e.Detail.Fields = fields
+	for _, f := range fields {
+		e.Detail.Details = append(e.Detail.Details, f.Message)
+	}
+	return e
+}
+
+// WithCause records the error that led to the response
+func (e *Error) WithCause(cause error) *Error {
+	e.Cause = cause
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Detail.Code, e.Cause)
+	}
+	return e.Detail.Code + ": " + e.Detail.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
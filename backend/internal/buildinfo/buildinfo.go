@@ -0,0 +1,70 @@
+// Package buildinfo reports the version and commit the server was built from. Release
+// builds set them with
+//
+//	go build -ldflags "-X app/internal/buildinfo.Version=v1.4.0 -X app/internal/buildinfo.Commit=$(git rev-parse HEAD)" ./cmd/server
+//
+// Otherwise they are read from the VCS information Go embeds in binaries built from a
+// git checkout.
+package buildinfo
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Set at build time with -ldflags -X
+var (
+	Version string
+	Commit  string
+)
+
+// Info describes the build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Modified  bool   `json:"modified,omitempty"` // built with uncommitted changes
+	GoVersion string `json:"go_version"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build information, "dev" and "unknown" when nothing was recorded
+func Get() Info {
+	once.Do(func() {
+		info = read(Version, Commit, debug.ReadBuildInfo)
+	})
+	return info
+}
+
+// read combines the ldflags values with the embedded build information
+func read(version, commit string, readBuildInfo func() (*debug.BuildInfo, bool)) Info {
+	result := Info{Version: version, Commit: commit}
+
+	if build, ok := readBuildInfo(); ok {
+		result.GoVersion = build.GoVersion
+		if result.Version == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			result.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if result.Commit == "" {
+					result.Commit = setting.Value
+				}
+			case "vcs.modified":
+				result.Modified = setting.Value == "true" && commit == ""
+			}
+		}
+	}
+
+	if result.Version == "" {
+		result.Version = "dev"
+	}
+	if result.Commit == "" {
+		result.Commit = "unknown"
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	embedded := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.24.2",
+			Main:      debug.Module{Version: "(devel)"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "3b4eabf0"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+
+	got := read("", "", embedded)
+	want := Info{Version: "dev", Commit: "3b4eabf0", Modified: true, GoVersion: "go1.24.2"}
+	if got != want {
+		t.Errorf("read without ldflags = %+v, want %+v", got, want)
+	}
+
+	got = read("v1.4.0", "9477730c", embedded)
+	want = Info{Version: "v1.4.0", Commit: "9477730c", GoVersion: "go1.24.2"}
+	if got != want {
+		t.Errorf("read with ldflags = %+v, want %+v", got, want)
+	}
+
+	got = read("", "", func() (*debug.BuildInfo, bool) { return nil, false })
+	if got.Version != "dev" || got.Commit != "unknown" {
+		t.Errorf("read without build info = %+v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLock provides leader election through a session-level Postgres advisory lock.
+// The replica whose connection holds the lock is the leader. The connection is taken out
+// of the pool while the lock is held, so leadership is lost as soon as that session dies.
+type AdvisoryLock struct {
+	pool *pgxpool.Pool
+	key  int64
+	conn *pgx.Conn
+}
+
+// NewAdvisoryLock creates an advisory lock identified by name. Replicas using the same
+// name compete for the same lock.
+func NewAdvisoryLock(pool *pgxpool.Pool, name string) *AdvisoryLock {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	return &AdvisoryLock{
+		pool: pool,
+		key:  int64(h.Sum64()),
+	}
+}
+
+// TryLead reports whether this process holds the lock, trying to acquire it without
+// blocking if it does not. It is not safe for concurrent use.
+func (l *AdvisoryLock) TryLead(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		if err := l.conn.Ping(ctx); err == nil {
+			return true, nil
+		}
+		// The session is gone and the lock with it
+		l.conn.Close(context.Background())
+		l.conn = nil
+	}
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&acquired); err != nil {
+		conn.Release()
+		return false, fmt.Errorf("failed to try advisory lock: %w", err)
+	}
+
+	if !acquired {
+		conn.Release()
+		return false, nil
+	}
+
+	l.conn = conn.Hijack()
+	return true, nil
+}
+
+// Resign releases the lock if it is held
+func (l *AdvisoryLock) Resign(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+
+	defer func() {
+		l.conn.Close(context.Background())
+		l.conn = nil
+	}()
+
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error kinds. Every sentinel error below matches one of them with errors.Is, so callers
+// can handle a whole class of failures, such as anything that was not found, without
+// knowing each error.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrUnavailable  = errors.New("database unavailable")
+)
+
+// Errors raised by the database functions and lookups
+var (
+	ErrSlotUnavailable      = NewError(ErrConflict, "install slot is not available")
+	ErrOrderNotFound        = NewError(ErrNotFound, "order not found")
+	ErrOrderNotModifiable   = NewError(ErrConflict, "order cannot be modified")
+	ErrChangeWindowClosed   = NewError(ErrConflict, "appointment change window has closed")
+	ErrSlotNotFound         = NewError(ErrNotFound, "install slot not found")
+	ErrQuoteNotFound        = NewError(ErrNotFound, "quote not found")
+	ErrEventNotFound        = NewError(ErrNotFound, "event not found")
+	ErrWebhookNotFound      = NewError(ErrNotFound, "webhook subscription not found")
+	ErrAPIKeyNotFound       = NewError(ErrNotFound, "API key not found")
+	ErrAPIKeyRevoked        = NewError(ErrConflict, "API key is revoked")
+	ErrCatalogEntryNotFound = NewError(ErrNotFound, "catalog entry not found")
+	ErrCatalogEntryConflict = NewError(ErrConflict, "catalog entry conflicts with existing data")
+	ErrInvalidCatalogEntry  = NewError(ErrInvalidInput, "invalid catalog entry")
+	ErrUserNotFound         = NewError(ErrNotFound, "user not found")
+	ErrCoverageNotFound     = NewError(ErrNotFound, "coverage not found")
+)
+
+// NewError returns a sentinel error with message that also matches kind with errors.Is
+func NewError(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
+
+// kindError is a sentinel error belonging to one of the error kinds
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// appErrorCodes maps the custom SQLSTATEs raised by database functions to errors
+var appErrorCodes = map[string]error{
+	"AP001": ErrSlotUnavailable,
+	"AP002": ErrOrderNotFound,
+	"AP003": ErrOrderNotModifiable,
+	"AP004": ErrChangeWindowClosed,
+	"AP005": ErrCatalogEntryNotFound,
+	"AP006": ErrCatalogEntryConflict,
+	"AP007": ErrInvalidCatalogEntry,
+	"AP008": ErrAPIKeyNotFound,
+	"AP009": ErrAPIKeyRevoked,
+}
+
+// postgrestError represents an error response from the PostgREST API
+type postgrestError struct {
+	Status  int
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Body    string
+}
+
+func (e *postgrestError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.Status, e.Body)
+}
+
+// Unwrap matches gateway errors, which mean PostgREST or the database behind it is
+// down, with ErrUnavailable
+func (e *postgrestError) Unwrap() error {
+	switch e.Status {
+	case 502, 503, 504:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// translateError converts database function errors into the package's sentinel errors,
+// keeping the original error message, and marks connection failures with ErrUnavailable.
+// Other errors are returned unchanged.
+func translateError(err error) error {
+	var code, message string
+
+	var pgErr *pgconn.PgError
+	var restErr *postgrestError
+	var connErr *pgconn.ConnectError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &pgErr):
+		if unavailableCode(pgErr.Code) {
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		code, message = pgErr.Code, pgErr.Message
+	case errors.As(err, &restErr):
+		code, message = restErr.Code, restErr.Message
+	case errors.As(err, &connErr), errors.As(err, &netErr):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	default:
+		return err
+	}
+
+	if sentinel, ok := appErrorCodes[code]; ok {
+		return &appError{sentinel: sentinel, message: message}
+	}
+
+	return err
+}
+
+// unavailableCode reports whether a SQLSTATE means the database cannot serve requests
+// right now: connection exceptions, insufficient resources and operator intervention
+// such as a shutdown
+func unavailableCode(code string) bool {
+	return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "53") || strings.HasPrefix(code, "57P")
+}
+
+// appError carries the database's message while matching a sentinel with errors.Is
+type appError struct {
+	sentinel error
+	message  string
+}
+
+func (e *appError) Error() string {
+	return e.message
+}
+
+func (e *appError) Unwrap() error {
+	return e.sentinel
+}
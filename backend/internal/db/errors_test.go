@@ -0,0 +1,107 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "PostgreSQL slot unavailable",
+			err:      &pgconn.PgError{Code: "AP001", Message: "install slot S1 is not available for address A1001"},
+			expected: ErrSlotUnavailable,
+		},
+		{
+			name:     "PostgREST order not found",
+			err:      &postgrestError{Status: 400, Code: "AP002", Message: "order ORD-1 not found"},
+			expected: ErrOrderNotFound,
+		},
+		{
+			name:     "Wrapped change window error",
+			err:      fmt.Errorf("rpc: %w", &pgconn.PgError{Code: "AP004", Message: "closed"}),
+			expected: ErrChangeWindowClosed,
+		},
+		{
+			name:     "Catalog entry still referenced",
+			err:      &postgrestError{Status: 409, Code: "AP006", Message: "update or delete on table \"home_plans\" violates foreign key constraint"},
+			expected: ErrCatalogEntryConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translated := translateError(tt.err)
+			if !errors.Is(translated, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, translated)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorPassesThroughOtherErrors(t *testing.T) {
+	original := &pgconn.PgError{Code: "23505", Message: "duplicate key"}
+
+	if translated := translateError(original); translated != error(original) {
+		t.Errorf("Expected unrelated errors unchanged, got %v", translated)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{ErrOrderNotFound, ErrNotFound},
+		{ErrCoverageNotFound, ErrNotFound},
+		{fmt.Errorf("user 7: %w", ErrUserNotFound), ErrNotFound},
+		{ErrSlotUnavailable, ErrConflict},
+		{ErrChangeWindowClosed, ErrConflict},
+		{translateError(&pgconn.PgError{Code: "AP003", Message: "order ORD-1 is cancelled"}), ErrConflict},
+	}
+
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.kind) {
+			t.Errorf("Expected %q to match %v", tt.err, tt.kind)
+		}
+	}
+
+	if errors.Is(ErrOrderNotFound, ErrConflict) {
+		t.Error("Expected an error to match only its own kind")
+	}
+	if ErrOrderNotFound.Error() != "order not found" {
+		t.Errorf("Expected the sentinel's own message, got %q", ErrOrderNotFound.Error())
+	}
+}
+
+func TestUnavailableErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		unavailable bool
+	}{
+		{"Connection refused", translateError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{"Admin shutdown", translateError(&pgconn.PgError{Code: "57P01", Message: "terminating connection"}), true},
+		{"Too many connections", translateError(&pgconn.PgError{Code: "53300", Message: "too many clients"}), true},
+		{"Gateway timeout", &postgrestError{Status: 504}, true},
+		{"Service unavailable", fmt.Errorf("failed to get coverage: %w", &postgrestError{Status: 503}), true},
+		{"Bad request", &postgrestError{Status: 400}, false},
+		{"Duplicate key", translateError(&pgconn.PgError{Code: "23505"}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errors.Is(tt.err, ErrUnavailable) != tt.unavailable {
+				t.Errorf("Expected unavailable=%v for %v", tt.unavailable, tt.err)
+			}
+		})
+	}
+}
@@ -2,17 +2,82 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"app/internal/models"
 )
 
+// ExpectedSchemaVersion is the schema_version this build needs: the number of the latest
+// migration in db/supabase/migrations. Raise it with every migration the code relies on.
+const ExpectedSchemaVersion = 26
+
 // DatabaseInterface defines the interface for database operations
 type DatabaseInterface interface {
 	Health(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int, error)
 	Close()
 	GetUser(ctx context.Context, userID int) (*models.User, error)
 	GetCoverage(ctx context.Context, addressID string) (*models.Coverage, error)
+	GetCoverageBatch(ctx context.Context, addressIDs []string) (map[string]*models.Coverage, error)
+	GetDistrictCoverage(ctx context.Context) ([]models.DistrictCoverage, error)
 	GetHousehold(ctx context.Context, userID int) ([]models.Household, error)
 	GetInstallSlots(ctx context.Context, addressID, tech string) ([]models.InstallSlot, error)
-	GetCatalog(ctx context.Context) (*models.Catalog, error)
+	SearchInstallSlots(ctx context.Context, query models.SlotQuery) ([]models.InstallSlot, error)
+	GetInstallSlot(ctx context.Context, slotID string) (*models.InstallSlot, error)
+	GetCatalog(ctx context.Context, at time.Time) (*models.Catalog, error)
+	GetCrews(ctx context.Context) ([]models.Crew, error)
+	UpsertInstallSlots(ctx context.Context, slots []models.InstallSlot) (int, error)
+	PlaceOrder(ctx context.Context, order *models.Order) (*models.Order, error)
+	ConfirmOrder(ctx context.Context, orderID, paymentID string) (*models.Order, error)
+	SetPaymentStatus(ctx context.Context, orderID, status string) error
+	GetOrder(ctx context.Context, orderID string) (*models.Order, error)
+	RescheduleOrder(ctx context.Context, orderID, newSlotID string, cutoffSeconds int, reason string) (*models.Order, error)
+	CancelOrder(ctx context.Context, orderID, reason string) (*models.Order, error)
+	ExpirePendingOrders(ctx context.Context, maxAgeSeconds int) (int, error)
+	ListUnreconciledOrders(ctx context.Context, minAgeSeconds, limit int) ([]models.Order, error)
+	ClaimIdempotencyKey(ctx context.Context, key, requestHash string, ttlSeconds int) (*models.IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, key string, statusCode int, body string) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	PurgeIdempotencyKeys(ctx context.Context) (int, error)
+	SaveQuotes(ctx context.Context, quotes []models.Quote) error
+	GetQuote(ctx context.Context, quoteID string) (*models.Quote, error)
+	PurgeExpiredQuotes(ctx context.Context) (int, error)
+	GetAppointmentHistory(ctx context.Context, orderID string) ([]models.AppointmentChange, error)
+	GetUpcomingAppointments(ctx context.Context, leadSeconds int) ([]models.Order, error)
+	EnqueueNotifications(ctx context.Context, notifications []models.Notification) error
+	ClaimNotifications(ctx context.Context, limit, leaseSeconds int) ([]models.Notification, error)
+	MarkNotificationSent(ctx context.Context, id int64) error
+	MarkNotificationFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error
+	ClaimEvents(ctx context.Context, limit, leaseSeconds int) ([]models.Event, error)
+	MarkEventDelivered(ctx context.Context, id int64) error
+	MarkEventFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error
+	ListEvents(ctx context.Context, status string, limit int) ([]models.Event, error)
+	RequeueEvent(ctx context.Context, id int64) (*models.Event, error)
+	CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+	EnqueueWebhookDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	ClaimWebhookDeliveries(ctx context.Context, limit, leaseSeconds int) ([]models.WebhookDelivery, error)
+	MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) error
+	MarkWebhookFailed(ctx context.Context, id int64, statusCode *int, lastError string, retryAt *time.Time) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error)
+	TakeRateLimitToken(ctx context.Context, key string, ratePerSecond float64, burst int) (*models.RateLimitDecision, error)
+	PurgeRateLimitBuckets(ctx context.Context) (int, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetAPIKey(ctx context.Context, id int64) (*models.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error)
+	RotateAPIKey(ctx context.Context, id int64, prefix, keyHash string, expireOldAt time.Time) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64) error
+	ApplyCatalogChange(ctx context.Context, change models.CatalogChange) (*models.CatalogAuditEntry, error)
+	ListCatalogAudit(ctx context.Context, entity string, limit int) ([]models.CatalogAuditEntry, error)
+	ListCampaigns(ctx context.Context, at time.Time) ([]models.Campaign, error)
+	IsNewCustomer(ctx context.Context, userID int) (bool, error)
 }
+
+// Compile-time checks that both backends satisfy the interface
+var (
+	_ DatabaseInterface = (*DB)(nil)
+	_ DatabaseInterface = (*SupabaseClient)(nil)
+)
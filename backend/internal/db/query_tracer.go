@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"app/internal/logging"
+	"app/internal/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer traces every pgx query as a span named after its SQL operation, e.g.
+// postgres SELECT, and logs it at debug level. Query arguments are not logged.
+type queryTracer struct{}
+
+type queryStartKey struct{}
+
+// TraceQueryStart starts the span of a query
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracing.Tracer(tracerName).Start(ctx, "postgres "+sqlOperation(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, at: time.Now()})
+}
+
+// queryStart is the statement and start time of a query, for its log record
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+// TraceQueryEnd ends the span of a query with its outcome
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	tracing.RecordError(span, data.Err)
+	if data.Err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+
+	if start, ok := ctx.Value(queryStartKey{}).(queryStart); ok {
+		logging.For(ctx, "db").Debug("Postgres query", "sql", start.sql,
+			"duration_ms", float64(time.Since(start.at).Microseconds())/1000, "error", data.Err)
+	}
+}
+
+// sqlOperation returns the first keyword of a statement, e.g. SELECT
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyColumns lists the api_keys columns in the order scanAPIKey expects them
+const apiKeyColumns = `id, name, key_prefix, key_hash, scopes, rate_limit_per_minute, rate_limit_burst,
+	expires_at, revoked_at, last_used_at, rotated_from, created_at`
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row pgx.Row) (models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		&k.Scopes,
+		&k.RateLimitPerMinute,
+		&k.RateLimitBurst,
+		&k.ExpiresAt,
+		&k.RevokedAt,
+		&k.LastUsedAt,
+		&k.RotatedFrom,
+		&k.CreatedAt,
+	)
+	return k, err
+}
+
+// getAPIKey returns the key selected by the where clause, described by what in errors
+func (db *DB) getAPIKey(ctx context.Context, where, what string, arg interface{}) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE ` + where
+
+	key, err := scanAPIKey(db.Pool.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("API key %s: %w", what, ErrAPIKeyNotFound)
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// CreateAPIKey stores a key and returns it with its ID
+func (db *DB) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (name, key_prefix, key_hash, scopes, rate_limit_per_minute, rate_limit_burst, expires_at, rotated_from)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + apiKeyColumns
+
+	created, err := scanAPIKey(db.Pool.QueryRow(ctx, query, key.Name, key.Prefix, key.KeyHash, key.Scopes,
+		key.RateLimitPerMinute, key.RateLimitBurst, key.ExpiresAt, key.RotatedFrom))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", translateError(err))
+	}
+
+	return &created, nil
+}
+
+// GetAPIKey returns the key with id
+func (db *DB) GetAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	return db.getAPIKey(ctx, `id = $1`, fmt.Sprint(id), id)
+}
+
+// GetAPIKeyByPrefix returns the key with prefix
+func (db *DB) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return db.getAPIKey(ctx, `key_prefix = $1`, prefix, prefix)
+}
+
+// ListAPIKeys returns every key, oldest first
+func (db *DB) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+
+	keys, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.APIKey, error) {
+		return scanAPIKey(rows)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key and returns it. Revoking a revoked key keeps its original
+// revocation time.
+func (db *DB) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("API key %d: %w", id, ErrAPIKeyNotFound)
+		}
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// RotateAPIKey issues the replacement of key id, with the same name, scopes, limits and
+// expiry and the given prefix and hash, and lets key id expire at expireOldAt unless it
+// expires earlier, atomically
+func (db *DB) RotateAPIKey(ctx context.Context, id int64, prefix, keyHash string, expireOldAt time.Time) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM rotate_api_key($1, $2, $3, $4)`
+
+	created, err := scanAPIKey(db.Pool.QueryRow(ctx, query, id, prefix, keyHash, expireOldAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", translateError(err))
+	}
+
+	return &created, nil
+}
+
+// TouchAPIKey records that a key was just used
+func (db *DB) TouchAPIKey(ctx context.Context, id int64) error {
+	if _, err := db.Pool.Exec(ctx, `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to touch API key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// campaignColumns lists the campaigns columns in the order scanCampaign expects them
+const campaignColumns = `campaign_id, name, code, discount_type, discount_value::float8, duration_months,
+	applies_to, cities, techs, mobile_plan_ids, home_plan_ids, tv_plan_ids, new_customers_only,
+	stackable, starts_at, ends_at`
+
+// scanCampaign scans a row selected with campaignColumns
+func scanCampaign(row pgx.Row) (models.Campaign, error) {
+	var c models.Campaign
+	err := row.Scan(
+		&c.CampaignID,
+		&c.Name,
+		&c.Code,
+		&c.DiscountType,
+		&c.DiscountValue,
+		&c.DurationMonths,
+		&c.AppliesTo,
+		&c.Cities,
+		&c.Techs,
+		&c.MobilePlanIDs,
+		&c.HomePlanIDs,
+		&c.TVPlanIDs,
+		&c.NewCustomers,
+		&c.Stackable,
+		&c.StartsAt,
+		&c.EndsAt,
+	)
+	return c, err
+}
+
+// ListCampaigns returns the campaigns that have not ended at a time, including those
+// starting later, by ID. The zero time lists every campaign.
+func (db *DB) ListCampaigns(ctx context.Context, at time.Time) ([]models.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns
+		WHERE $1::timestamptz IS NULL OR ends_at IS NULL OR ends_at > $1
+		ORDER BY campaign_id`
+
+	var arg *time.Time
+	if !at.IsZero() {
+		arg = &at
+	}
+
+	campaigns, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.Campaign, error) {
+		return scanCampaign(rows)
+	}, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaigns: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+// IsNewCustomer reports whether a user has neither current services nor an order that
+// was not cancelled
+func (db *DB) IsNewCustomer(ctx context.Context, userID int) (bool, error) {
+	var isNew bool
+	if err := db.Pool.QueryRow(ctx, `SELECT is_new_customer($1)`, userID).Scan(&isNew); err != nil {
+		return false, fmt.Errorf("failed to check for a new customer: %w", err)
+	}
+
+	return isNew, nil
+}
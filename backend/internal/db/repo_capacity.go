@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetCrews retrieves all active crews with their technicians, service areas and working hours
+func (db *DB) GetCrews(ctx context.Context) ([]models.Crew, error) {
+	crews, err := queryRows(ctx, db, `SELECT crew_id, name, active FROM crews WHERE active ORDER BY crew_id`,
+		func(rows pgx.Rows) (models.Crew, error) {
+			var c models.Crew
+			err := rows.Scan(&c.CrewID, &c.Name, &c.Active)
+			return c, err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query crews: %w", err)
+	}
+
+	technicians, err := queryRows(ctx, db, `SELECT technician_id, crew_id, name, techs, active FROM technicians WHERE active ORDER BY technician_id`,
+		func(rows pgx.Rows) (models.Technician, error) {
+			var t models.Technician
+			err := rows.Scan(&t.TechnicianID, &t.CrewID, &t.Name, &t.Techs, &t.Active)
+			return t, err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query technicians: %w", err)
+	}
+
+	areas, err := queryRows(ctx, db, `SELECT crew_id, city, district FROM crew_service_areas ORDER BY crew_id, city, district`,
+		func(rows pgx.Rows) (models.ServiceArea, error) {
+			var a models.ServiceArea
+			err := rows.Scan(&a.CrewID, &a.City, &a.District)
+			return a, err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query crew service areas: %w", err)
+	}
+
+	hours, err := queryRows(ctx, db, `SELECT crew_id, weekday, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI') FROM crew_working_hours ORDER BY crew_id, weekday, start_time`,
+		func(rows pgx.Rows) (models.WorkingHours, error) {
+			var h models.WorkingHours
+			err := rows.Scan(&h.CrewID, &h.Weekday, &h.StartTime, &h.EndTime)
+			return h, err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query crew working hours: %w", err)
+	}
+
+	return assembleCrews(crews, technicians, areas, hours), nil
+}
+
+// UpsertInstallSlots inserts generated slots, leaving slots that already exist untouched so
+// that capacity already booked is never reset. Returns the number of newly created slots.
+func (db *DB) UpsertInstallSlots(ctx context.Context, slots []models.InstallSlot) (int, error) {
+	if len(slots) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(slots))
+	crewIDs := make([]*int, len(slots))
+	starts := make([]time.Time, len(slots))
+	ends := make([]time.Time, len(slots))
+	techs := make([]string, len(slots))
+	capacities := make([]int, len(slots))
+	for i, slot := range slots {
+		ids[i] = slot.SlotID
+		crewIDs[i] = slot.CrewID
+		starts[i] = slot.SlotStart
+		ends[i] = slot.SlotEnd
+		techs[i] = slot.Tech
+		capacities[i] = slot.Capacity
+	}
+
+	query := `
+		INSERT INTO install_slots (slot_id, crew_id, slot_start, slot_end, tech, capacity, remaining_capacity)
+		SELECT id, crew_id, slot_start, slot_end, tech, capacity, capacity
+		FROM unnest($1::text[], $2::int[], $3::timestamptz[], $4::timestamptz[], $5::text[], $6::int[])
+			AS s(id, crew_id, slot_start, slot_end, tech, capacity)
+		ON CONFLICT (slot_id) DO NOTHING
+	`
+
+	tag, err := db.Pool.Exec(ctx, query, ids, crewIDs, starts, ends, techs, capacities)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert install slots: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// queryRows runs a query and scans every row with the given function
+func queryRows[T any](ctx context.Context, db *DB, query string, scan func(pgx.Rows) (T, error), args ...interface{}) ([]T, error) {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var result []T
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result = append(result, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// assembleCrews attaches technicians, service areas and working hours to their crews.
+// Rows belonging to crews that are not in the list (e.g. inactive crews) are dropped.
+func assembleCrews(crews []models.Crew, technicians []models.Technician, areas []models.ServiceArea, hours []models.WorkingHours) []models.Crew {
+	index := make(map[int]int, len(crews))
+	for i, crew := range crews {
+		index[crew.CrewID] = i
+	}
+
+	for _, t := range technicians {
+		if i, ok := index[t.CrewID]; ok {
+			crews[i].Technicians = append(crews[i].Technicians, t)
+		}
+	}
+
+	for _, a := range areas {
+		if i, ok := index[a.CrewID]; ok {
+			crews[i].ServiceAreas = append(crews[i].ServiceAreas, a)
+		}
+	}
+
+	for _, h := range hours {
+		if i, ok := index[h.CrewID]; ok {
+			crews[i].WorkingHours = append(crews[i].WorkingHours, h)
+		}
+	}
+
+	return crews
+}
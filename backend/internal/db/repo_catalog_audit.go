@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// catalogAuditColumns lists the catalog_audit_log columns in the order
+// scanCatalogAuditEntry expects them
+const catalogAuditColumns = `id, entity, entity_key, action, actor, before, after, created_at`
+
+// scanCatalogAuditEntry scans a row selected with catalogAuditColumns
+func scanCatalogAuditEntry(row pgx.Row) (models.CatalogAuditEntry, error) {
+	var e models.CatalogAuditEntry
+	err := row.Scan(
+		&e.ID,
+		&e.Entity,
+		&e.EntityKey,
+		&e.Action,
+		&e.Actor,
+		&e.Before,
+		&e.After,
+		&e.CreatedAt,
+	)
+	return e, err
+}
+
+// ApplyCatalogChange creates, updates or deletes a catalog row and records the change in
+// the audit log, in one transaction
+func (db *DB) ApplyCatalogChange(ctx context.Context, change models.CatalogChange) (*models.CatalogAuditEntry, error) {
+	query := `SELECT ` + catalogAuditColumns + ` FROM apply_catalog_change($1, $2, $3, $4, $5, $6)`
+
+	entry, err := scanCatalogAuditEntry(db.Pool.QueryRow(ctx, query,
+		change.Entity, change.Action, nullableString(change.Key), change.Data, change.Actor, change.EffectiveFrom))
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s %s entry: %w", change.Action, change.Entity, translateError(err))
+	}
+
+	return &entry, nil
+}
+
+// ListCatalogAudit returns up to limit audit entries of entity, or of every entity when
+// entity is empty, newest first
+func (db *DB) ListCatalogAudit(ctx context.Context, entity string, limit int) ([]models.CatalogAuditEntry, error) {
+	query := `SELECT ` + catalogAuditColumns + ` FROM catalog_audit_log
+		WHERE $1::text IS NULL OR entity = $1
+		ORDER BY id DESC LIMIT $2`
+
+	entries, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.CatalogAuditEntry, error) {
+		return scanCatalogAuditEntry(rows)
+	}, nullableString(entity), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog audit log: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// eventColumns lists the outbox columns in the order scanEvent expects them
+const eventColumns = `id, event_type, aggregate_id, payload, status, attempts, last_error,
+	next_attempt_at, created_at, delivered_at`
+
+// scanEvent scans a row selected with eventColumns
+func scanEvent(row pgx.Row) (models.Event, error) {
+	var e models.Event
+	err := row.Scan(
+		&e.ID,
+		&e.EventType,
+		&e.AggregateID,
+		&e.Payload,
+		&e.Status,
+		&e.Attempts,
+		&e.LastError,
+		&e.NextAttemptAt,
+		&e.CreatedAt,
+		&e.DeliveredAt,
+	)
+	return e, err
+}
+
+// ClaimEvents leases up to limit due outbox events for leaseSeconds and counts the attempt
+func (db *DB) ClaimEvents(ctx context.Context, limit, leaseSeconds int) ([]models.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM claim_events($1, $2) ORDER BY id`
+
+	events, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.Event, error) {
+		return scanEvent(rows)
+	}, limit, leaseSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkEventDelivered records that every handler processed the event
+func (db *DB) MarkEventDelivered(ctx context.Context, id int64) error {
+	query := `UPDATE outbox SET status = 'delivered', delivered_at = NOW(), last_error = NULL WHERE id = $1`
+
+	if _, err := db.Pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark event delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEventFailed records a failed dispatch. The event is retried at retryAt, or moved
+// to the dead-letter state when retryAt is nil.
+func (db *DB) MarkEventFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error {
+	query := `
+		UPDATE outbox
+		SET last_error = $2,
+			status = CASE WHEN $3::timestamptz IS NULL THEN 'dead' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3::timestamptz, next_attempt_at)
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, id, lastError, retryAt); err != nil {
+		return fmt.Errorf("failed to mark event failed: %w", err)
+	}
+
+	return nil
+}
+
+// ListEvents returns up to limit outbox events with the status, newest first
+func (db *DB) ListEvents(ctx context.Context, status string, limit int) ([]models.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM outbox WHERE status = $1 ORDER BY id DESC LIMIT $2`
+
+	events, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.Event, error) {
+		return scanEvent(rows)
+	}, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	return events, nil
+}
+
+// RequeueEvent moves a dead event back to pending with a fresh set of attempts
+func (db *DB) RequeueEvent(ctx context.Context, id int64) (*models.Event, error) {
+	query := `
+		UPDATE outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND status = 'dead'
+		RETURNING ` + eventColumns
+
+	event, err := scanEvent(db.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("dead event %d: %w", id, ErrEventNotFound)
+		}
+		return nil, fmt.Errorf("failed to requeue event: %w", err)
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/models"
+)
+
+// ClaimIdempotencyKey stores key for a request with the given hash. It returns claimed =
+// true when the key was new (or had expired); otherwise the existing record is returned.
+func (db *DB) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, ttlSeconds int) (*models.IdempotencyRecord, bool, error) {
+	query := `
+		SELECT idempotency_key, request_hash, status_code, response_body, created_at, expires_at, claimed
+		FROM claim_idempotency_key($1, $2, $3)
+	`
+
+	var record models.IdempotencyRecord
+	var claimed bool
+	err := db.Pool.QueryRow(ctx, query, key, requestHash, ttlSeconds).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+		&claimed,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	return &record, claimed, nil
+}
+
+// SaveIdempotentResponse stores the response of the request that claimed key
+func (db *DB) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, body string) error {
+	query := `UPDATE idempotency_keys SET status_code = $2, response_body = $3 WHERE idempotency_key = $1`
+
+	if _, err := db.Pool.Exec(ctx, query, key, statusCode, body); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKey removes key so the request can be retried from scratch
+func (db *DB) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE idempotency_key = $1`, key); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeIdempotencyKeys deletes expired keys and returns how many were removed
+func (db *DB) PurgeIdempotencyKeys(ctx context.Context) (int, error) {
+	var purged int
+	if err := db.Pool.QueryRow(ctx, `SELECT purge_idempotency_keys()`).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// notificationColumns lists the notification_outbox columns in the order scanNotification expects them
+const notificationColumns = `id, dedupe_key, order_id, kind, channel, recipient, subject, body, status,
+	attempts, last_error, next_attempt_at, created_at, sent_at`
+
+// scanNotification scans a row selected with notificationColumns
+func scanNotification(row pgx.Row) (models.Notification, error) {
+	var n models.Notification
+	err := row.Scan(
+		&n.ID,
+		&n.DedupeKey,
+		&n.OrderID,
+		&n.Kind,
+		&n.Channel,
+		&n.Recipient,
+		&n.Subject,
+		&n.Body,
+		&n.Status,
+		&n.Attempts,
+		&n.LastError,
+		&n.NextAttemptAt,
+		&n.CreatedAt,
+		&n.SentAt,
+	)
+	return n, err
+}
+
+// EnqueueNotifications queues notifications for delivery. Notifications whose dedupe key
+// was queued before are skipped.
+func (db *DB) EnqueueNotifications(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO notification_outbox (dedupe_key, order_id, kind, channel, recipient, subject, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (dedupe_key) DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for _, n := range notifications {
+		batch.Queue(query, n.DedupeKey, n.OrderID, n.Kind, n.Channel, n.Recipient, n.Subject, n.Body)
+	}
+
+	if err := db.Pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to enqueue notifications: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimNotifications leases up to limit due notifications for leaseSeconds and counts the attempt
+func (db *DB) ClaimNotifications(ctx context.Context, limit, leaseSeconds int) ([]models.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM claim_notifications($1, $2)`
+
+	notifications, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.Notification, error) {
+		return scanNotification(rows)
+	}, limit, leaseSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationSent records a successful delivery
+func (db *DB) MarkNotificationSent(ctx context.Context, id int64) error {
+	query := `
+		UPDATE notification_outbox
+		SET status = 'sent', sent_at = NOW(), last_error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+
+	return nil
+}
+
+// MarkNotificationFailed records a failed delivery. The notification is retried at
+// retryAt, or marked failed for good when retryAt is nil.
+func (db *DB) MarkNotificationFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error {
+	query := `
+		UPDATE notification_outbox
+		SET last_error = $2,
+			status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3::timestamptz, next_attempt_at)
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, id, lastError, retryAt); err != nil {
+		return fmt.Errorf("failed to mark notification failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetUpcomingAppointments returns confirmed orders whose installation starts within the
+// next leadSeconds and was booked before that window opened
+func (db *DB) GetUpcomingAppointments(ctx context.Context, leadSeconds int) ([]models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM upcoming_appointments($1)`
+
+	orders, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.Order, error) {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return models.Order{}, err
+		}
+		return *order, nil
+	}, leadSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query upcoming appointments: %w", err)
+	}
+
+	return orders, nil
+}
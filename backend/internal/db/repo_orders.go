@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// orderColumns lists the orders columns in the order scanOrder expects them
+const orderColumns = `order_id, user_id, address_id, slot_id, tech, status, combo_label,
+	monthly_total::float8, items, promotions, commitment_months, termination_fee::float8, slot_released, cancel_reason, upfront_amount::float8,
+	payment_id, payment_status, created_at, updated_at`
+
+// scanOrder scans a row selected with orderColumns
+func scanOrder(row pgx.Row) (*models.Order, error) {
+	var o models.Order
+	err := row.Scan(
+		&o.OrderID,
+		&o.UserID,
+		&o.AddressID,
+		&o.SlotID,
+		&o.Tech,
+		&o.Status,
+		&o.ComboLabel,
+		&o.MonthlyTotal,
+		&o.Items,
+		&o.Promotions,
+		&o.CommitmentMonths,
+		&o.TerminationFee,
+		&o.SlotReleased,
+		&o.CancelReason,
+		&o.UpfrontAmount,
+		&o.PaymentID,
+		&o.PaymentStatus,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// PlaceOrder claims the order's install slot and stores the order as pending, atomically.
+// order.Tech, when set, restricts the slot to that technology.
+func (db *DB) PlaceOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM place_order($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	placed, err := scanOrder(db.Pool.QueryRow(ctx, query,
+		order.OrderID,
+		order.UserID,
+		order.AddressID,
+		order.SlotID,
+		order.Tech,
+		order.ComboLabel,
+		order.MonthlyTotal,
+		order.Items,
+		order.UpfrontAmount,
+		order.Promotions,
+		order.CommitmentMonths,
+		order.TerminationFee,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", translateError(err))
+	}
+
+	return placed, nil
+}
+
+// ConfirmOrder confirms a pending order whose upfront payment was authorised
+func (db *DB) ConfirmOrder(ctx context.Context, orderID, paymentID string) (*models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM confirm_order($1, $2)`
+
+	order, err := scanOrder(db.Pool.QueryRow(ctx, query, orderID, paymentID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm order: %w", translateError(err))
+	}
+
+	return order, nil
+}
+
+// SetPaymentStatus records the latest payment status of an order
+func (db *DB) SetPaymentStatus(ctx context.Context, orderID, status string) error {
+	query := `UPDATE orders SET payment_status = $2, updated_at = NOW() WHERE order_id = $1`
+
+	tag, err := db.Pool.Exec(ctx, query, orderID, status)
+	if err != nil {
+		return fmt.Errorf("failed to set payment status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("order %s: %w", orderID, ErrOrderNotFound)
+	}
+
+	return nil
+}
+
+// GetOrder retrieves an order by ID
+func (db *DB) GetOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE order_id = $1`
+
+	order, err := scanOrder(db.Pool.QueryRow(ctx, query, orderID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("order %s: %w", orderID, ErrOrderNotFound)
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+// RescheduleOrder atomically releases the order's current slot and claims newSlotID.
+// Both slots must start more than cutoffSeconds from now.
+func (db *DB) RescheduleOrder(ctx context.Context, orderID, newSlotID string, cutoffSeconds int, reason string) (*models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM reschedule_order($1, $2, $3, $4)`
+
+	order, err := scanOrder(db.Pool.QueryRow(ctx, query, orderID, newSlotID, cutoffSeconds, nullableString(reason)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reschedule order: %w", translateError(err))
+	}
+
+	return order, nil
+}
+
+// CancelOrder cancels an order and releases its slot if the installation has not started
+func (db *DB) CancelOrder(ctx context.Context, orderID, reason string) (*models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM cancel_order($1, $2)`
+
+	order, err := scanOrder(db.Pool.QueryRow(ctx, query, orderID, nullableString(reason)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel order: %w", translateError(err))
+	}
+
+	return order, nil
+}
+
+// GetAppointmentHistory retrieves every appointment change of an order, oldest first
+func (db *DB) GetAppointmentHistory(ctx context.Context, orderID string) ([]models.AppointmentChange, error) {
+	query := `
+		SELECT id, order_id, action, old_slot_id, new_slot_id, reason, created_at
+		FROM appointment_history
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+
+	history, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.AppointmentChange, error) {
+		var c models.AppointmentChange
+		err := rows.Scan(&c.ID, &c.OrderID, &c.Action, &c.OldSlotID, &c.NewSlotID, &c.Reason, &c.CreatedAt)
+		return c, err
+	}, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appointment history: %w", err)
+	}
+
+	return history, nil
+}
+
+// nullableString maps an empty string to SQL NULL
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// ExpirePendingOrders cancels pending orders older than maxAgeSeconds and releases their
+// install slots. Returns the number of orders expired.
+func (db *DB) ExpirePendingOrders(ctx context.Context, maxAgeSeconds int) (int, error) {
+	var expired int
+	if err := db.Pool.QueryRow(ctx, `SELECT expire_pending_orders($1)`, maxAgeSeconds).Scan(&expired); err != nil {
+		return 0, fmt.Errorf("failed to expire pending orders: %w", err)
+	}
+
+	return expired, nil
+}
+
+// ListUnreconciledOrders returns up to limit orders whose payment needs settling with the
+// provider and that have not changed for minAgeSeconds: cancelled orders whose payment
+// may still hold money and confirmed orders whose payment was not captured
+func (db *DB) ListUnreconciledOrders(ctx context.Context, minAgeSeconds, limit int) ([]models.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM unreconciled_orders($1, $2)`
+
+	orders, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.Order, error) {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return models.Order{}, err
+		}
+		return *order, nil
+	}, minAgeSeconds, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unreconciled orders: %w", err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveQuotes stores the quotes issued for a recommendation
+func (db *DB) SaveQuotes(ctx context.Context, quotes []models.Quote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO quotes (quote_id, user_id, address_id, catalog_version, household_hash,
+			combo_label, monthly_total, tech, items, expires_at, promotions, commitment_months, termination_fee)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::jsonb, '[]'), $12, $13)
+	`
+
+	batch := &pgx.Batch{}
+	for _, q := range quotes {
+		batch.Queue(query, q.QuoteID, q.UserID, q.AddressID, q.CatalogVersion, q.HouseholdHash,
+			q.ComboLabel, q.MonthlyTotal, q.Tech, q.Items, q.ExpiresAt, q.Promotions, q.CommitmentMonths, q.TerminationFee)
+	}
+
+	if err := db.Pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to save quotes: %w", err)
+	}
+
+	return nil
+}
+
+// GetQuote retrieves a quote by ID, including expired ones
+func (db *DB) GetQuote(ctx context.Context, quoteID string) (*models.Quote, error) {
+	query := `
+		SELECT quote_id, user_id, address_id, catalog_version, household_hash, combo_label,
+			monthly_total::float8, tech, items, promotions, commitment_months, termination_fee::float8,
+			expires_at, created_at
+		FROM quotes
+		WHERE quote_id = $1
+	`
+
+	var q models.Quote
+	err := db.Pool.QueryRow(ctx, query, quoteID).Scan(
+		&q.QuoteID,
+		&q.UserID,
+		&q.AddressID,
+		&q.CatalogVersion,
+		&q.HouseholdHash,
+		&q.ComboLabel,
+		&q.MonthlyTotal,
+		&q.Tech,
+		&q.Items,
+		&q.Promotions,
+		&q.CommitmentMonths,
+		&q.TerminationFee,
+		&q.ExpiresAt,
+		&q.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("quote %s: %w", quoteID, ErrQuoteNotFound)
+		}
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	return &q, nil
+}
+
+// PurgeExpiredQuotes deletes expired quotes and returns how many were removed
+func (db *DB) PurgeExpiredQuotes(ctx context.Context) (int, error) {
+	var purged int
+	if err := db.Pool.QueryRow(ctx, `SELECT purge_expired_quotes()`).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("failed to purge expired quotes: %w", err)
+	}
+
+	return purged, nil
+}
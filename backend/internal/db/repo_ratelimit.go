@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/models"
+)
+
+// TakeRateLimitToken takes a token from the shared bucket key, which holds at most burst
+// tokens and refills at ratePerSecond
+func (db *DB) TakeRateLimitToken(ctx context.Context, key string, ratePerSecond float64, burst int) (*models.RateLimitDecision, error) {
+	query := `SELECT allowed, remaining, retry_after_ms FROM take_rate_limit_token($1, $2, $3)`
+
+	var decision models.RateLimitDecision
+	err := db.Pool.QueryRow(ctx, query, key, ratePerSecond, burst).Scan(
+		&decision.Allowed,
+		&decision.Remaining,
+		&decision.RetryAfterMS,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return &decision, nil
+}
+
+// PurgeRateLimitBuckets deletes buckets unused for an hour and returns how many were removed
+func (db *DB) PurgeRateLimitBuckets(ctx context.Context) (int, error) {
+	var purged int
+	if err := db.Pool.QueryRow(ctx, `SELECT purge_rate_limit_buckets()`).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("failed to purge rate limit buckets: %w", err)
+	}
+
+	return purged, nil
+}
@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"app/internal/models"
 
@@ -12,8 +14,8 @@ import (
 // GetUser retrieves a user by ID with their address information
 func (db *DB) GetUser(ctx context.Context, userID int) (*models.User, error) {
 	query := `
-		SELECT user_id, name, address_id, current_bundle_label, created_at
-		FROM users 
+		SELECT user_id, name, address_id, current_bundle_label, email, phone, locale, created_at
+		FROM users
 		WHERE user_id = $1
 	`
 
@@ -23,14 +25,17 @@ func (db *DB) GetUser(ctx context.Context, userID int) (*models.User, error) {
 		&user.Name,
 		&user.AddressID,
 		&user.CurrentBundleLabel,
+		&user.Email,
+		&user.Phone,
+		&user.Locale,
 		&user.CreatedAt,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user with ID %d not found", userID)
+			return nil, fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", translateError(err))
 	}
 
 	return &user, nil
@@ -95,56 +100,97 @@ func (db *DB) GetCoverage(ctx context.Context, addressID string) (*models.Covera
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("coverage for address %s not found", addressID)
+			return nil, fmt.Errorf("coverage for address %s: %w", addressID, ErrCoverageNotFound)
 		}
-		return nil, fmt.Errorf("failed to get coverage: %w", err)
+		return nil, fmt.Errorf("failed to get coverage: %w", translateError(err))
 	}
 
 	return &coverage, nil
 }
 
-// CatalogData represents all catalog information
-type CatalogData struct {
-	Coverage      []models.Coverage     `json:"coverage"`
-	MobilePlans   []models.MobilePlan   `json:"mobile_plans"`
-	HomePlans     []models.HomePlan     `json:"home_plans"`
-	TVPlans       []models.TVPlan       `json:"tv_plans"`
-	BundlingRules []models.BundlingRule `json:"bundling_rules"`
-	InstallSlots  []models.InstallSlot  `json:"install_slots"`
-}
-
-// GetCatalog retrieves all catalog data (plans, rules, coverage)
-func (db *DB) GetCatalog(ctx context.Context) (*CatalogData, error) {
-	catalog := &CatalogData{}
+// GetCoverageBatch retrieves coverage information for several addresses in one query.
+// Addresses without a coverage row are simply absent from the returned map.
+func (db *DB) GetCoverageBatch(ctx context.Context, addressIDs []string) (map[string]*models.Coverage, error) {
+	query := `
+		SELECT address_id, city, district, fiber, vdsl, fwa
+		FROM coverage 
+		WHERE address_id = ANY($1)
+	`
 
-	// Get coverage data
-	coverageQuery := `SELECT address_id, city, district, fiber, vdsl, fwa FROM coverage ORDER BY city, district`
-	rows, err := db.Pool.Query(ctx, coverageQuery)
+	rows, err := db.Pool.Query(ctx, query, addressIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query coverage: %w", err)
+		return nil, fmt.Errorf("failed to query coverage batch: %w", err)
 	}
+	defer rows.Close()
 
+	coverage := make(map[string]*models.Coverage, len(addressIDs))
 	for rows.Next() {
 		var c models.Coverage
 		err := rows.Scan(&c.AddressID, &c.City, &c.District, &c.Fiber, &c.VDSL, &c.FWA)
 		if err != nil {
-			rows.Close()
-			return nil, fmt.Errorf("failed to scan coverage: %w", err)
+			return nil, fmt.Errorf("failed to scan coverage row: %w", err)
 		}
-		catalog.Coverage = append(catalog.Coverage, c)
+		coverage[c.AddressID] = &c
 	}
-	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate coverage rows: %w", err)
+	}
+
+	return coverage, nil
+}
+
+// GetDistrictCoverage retrieves per city/district technology availability counts
+func (db *DB) GetDistrictCoverage(ctx context.Context) ([]models.DistrictCoverage, error) {
+	query := `
+		SELECT city, district, addresses, fiber_addresses, vdsl_addresses, fwa_addresses
+		FROM coverage_district_stats
+		ORDER BY city, district
+	`
+
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query district coverage: %w", err)
+	}
+	defer rows.Close()
+
+	var districts []models.DistrictCoverage
+	for rows.Next() {
+		var d models.DistrictCoverage
+		err := rows.Scan(&d.City, &d.District, &d.Addresses, &d.FiberAddresses, &d.VDSLAddresses, &d.FWAAddresses)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan district coverage row: %w", err)
+		}
+		districts = append(districts, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate district coverage rows: %w", err)
+	}
+
+	return districts, nil
+}
+
+// inEffectAt filters plan and rule versions to those in effect at $1
+const inEffectAt = `WHERE effective_from <= $1 AND (effective_to IS NULL OR effective_to > $1)`
+
+// GetCatalog retrieves the plan and rule versions in effect at a time, and when that
+// catalog next changes
+func (db *DB) GetCatalog(ctx context.Context, at time.Time) (*models.Catalog, error) {
+	catalog := &models.Catalog{}
 
 	// Get mobile plans
-	mobileQuery := `SELECT plan_id, plan_name, quota_gb, quota_min, monthly_price, overage_gb, overage_min FROM mobile_plans ORDER BY monthly_price`
-	rows, err = db.Pool.Query(ctx, mobileQuery)
+	mobileQuery := `SELECT plan_id, plan_name, quota_gb, quota_min, monthly_price, overage_gb, overage_min, COALESCE(terms, '[]'), version,
+		effective_from, effective_to
+		FROM mobile_plans ` + inEffectAt + ` ORDER BY monthly_price`
+	rows, err := db.Pool.Query(ctx, mobileQuery, at)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query mobile plans: %w", err)
 	}
 
 	for rows.Next() {
 		var mp models.MobilePlan
-		err := rows.Scan(&mp.PlanID, &mp.PlanName, &mp.QuotaGB, &mp.QuotaMin, &mp.MonthlyPrice, &mp.OverageGB, &mp.OverageMin)
+		err := rows.Scan(&mp.PlanID, &mp.PlanName, &mp.QuotaGB, &mp.QuotaMin, &mp.MonthlyPrice, &mp.OverageGB, &mp.OverageMin, &mp.Terms, &mp.Version, &mp.EffectiveFrom, &mp.EffectiveTo)
 		if err != nil {
 			rows.Close()
 			return nil, fmt.Errorf("failed to scan mobile plan: %w", err)
@@ -154,15 +200,16 @@ func (db *DB) GetCatalog(ctx context.Context) (*CatalogData, error) {
 	rows.Close()
 
 	// Get home plans
-	homeQuery := `SELECT home_id, name, tech, down_mbps, monthly_price, install_fee FROM home_plans ORDER BY tech, monthly_price`
-	rows, err = db.Pool.Query(ctx, homeQuery)
+	homeQuery := `SELECT home_id, name, tech, down_mbps, monthly_price, install_fee, COALESCE(terms, '[]'), version, effective_from, effective_to
+		FROM home_plans ` + inEffectAt + ` ORDER BY tech, monthly_price`
+	rows, err = db.Pool.Query(ctx, homeQuery, at)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query home plans: %w", err)
 	}
 
 	for rows.Next() {
 		var hp models.HomePlan
-		err := rows.Scan(&hp.HomeID, &hp.Name, &hp.Tech, &hp.DownMbps, &hp.MonthlyPrice, &hp.InstallFee)
+		err := rows.Scan(&hp.HomeID, &hp.Name, &hp.Tech, &hp.DownMbps, &hp.MonthlyPrice, &hp.InstallFee, &hp.Terms, &hp.Version, &hp.EffectiveFrom, &hp.EffectiveTo)
 		if err != nil {
 			rows.Close()
 			return nil, fmt.Errorf("failed to scan home plan: %w", err)
@@ -172,15 +219,16 @@ func (db *DB) GetCatalog(ctx context.Context) (*CatalogData, error) {
 	rows.Close()
 
 	// Get TV plans
-	tvQuery := `SELECT tv_id, name, hd_hours_included, monthly_price FROM tv_plans ORDER BY monthly_price`
-	rows, err = db.Pool.Query(ctx, tvQuery)
+	tvQuery := `SELECT tv_id, name, hd_hours_included, monthly_price, COALESCE(terms, '[]'), version, effective_from, effective_to
+		FROM tv_plans ` + inEffectAt + ` ORDER BY monthly_price`
+	rows, err = db.Pool.Query(ctx, tvQuery, at)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query TV plans: %w", err)
 	}
 
 	for rows.Next() {
 		var tp models.TVPlan
-		err := rows.Scan(&tp.TVID, &tp.Name, &tp.HDHoursIncluded, &tp.MonthlyPrice)
+		err := rows.Scan(&tp.TVID, &tp.Name, &tp.HDHoursIncluded, &tp.MonthlyPrice, &tp.Terms, &tp.Version, &tp.EffectiveFrom, &tp.EffectiveTo)
 		if err != nil {
 			rows.Close()
 			return nil, fmt.Errorf("failed to scan TV plan: %w", err)
@@ -190,15 +238,16 @@ func (db *DB) GetCatalog(ctx context.Context) (*CatalogData, error) {
 	rows.Close()
 
 	// Get bundling rules
-	rulesQuery := `SELECT rule_id, rule_type, description, discount_percent, applies_to FROM bundling_rules ORDER BY rule_type, discount_percent`
-	rows, err = db.Pool.Query(ctx, rulesQuery)
+	rulesQuery := `SELECT rule_id, rule_type, description, discount_percent, applies_to, version, effective_from, effective_to
+		FROM bundling_rules ` + inEffectAt + ` ORDER BY rule_type, discount_percent`
+	rows, err = db.Pool.Query(ctx, rulesQuery, at)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query bundling rules: %w", err)
 	}
 
 	for rows.Next() {
 		var br models.BundlingRule
-		err := rows.Scan(&br.RuleID, &br.RuleType, &br.Description, &br.DiscountPercent, &br.AppliesTo)
+		err := rows.Scan(&br.RuleID, &br.RuleType, &br.Description, &br.DiscountPercent, &br.AppliesTo, &br.Version, &br.EffectiveFrom, &br.EffectiveTo)
 		if err != nil {
 			rows.Close()
 			return nil, fmt.Errorf("failed to scan bundling rule: %w", err)
@@ -207,19 +256,92 @@ func (db *DB) GetCatalog(ctx context.Context) (*CatalogData, error) {
 	}
 	rows.Close()
 
+	if err := db.Pool.QueryRow(ctx, `SELECT catalog_valid_until($1)`, at).Scan(&catalog.ValidUntil); err != nil {
+		return nil, fmt.Errorf("failed to query catalog changes: %w", err)
+	}
+
 	return catalog, nil
 }
 
-// GetInstallSlots retrieves available installation slots for an address and technology
+// GetInstallSlots retrieves installation slots with remaining capacity for an address and
+// technology, including crew slots of every crew serving the address's district
 func (db *DB) GetInstallSlots(ctx context.Context, addressID string, tech string) ([]models.InstallSlot, error) {
+	return db.SearchInstallSlots(ctx, models.SlotQuery{AddressID: addressID, Techs: []string{tech}})
+}
+
+// GetInstallSlot retrieves a single install slot by ID regardless of its remaining capacity
+func (db *DB) GetInstallSlot(ctx context.Context, slotID string) (*models.InstallSlot, error) {
 	query := `
-		SELECT slot_id, address_id, slot_start, slot_end, tech, available
-		FROM install_slots 
-		WHERE address_id = $1 AND tech = $2 AND available = true
-		ORDER BY slot_start
+		SELECT slot_id, COALESCE(address_id, ''), crew_id, slot_start, slot_end, tech, capacity, remaining_capacity
+		FROM install_slots
+		WHERE slot_id = $1
 	`
 
-	rows, err := db.Pool.Query(ctx, query, addressID, tech)
+	var slot models.InstallSlot
+	err := db.Pool.QueryRow(ctx, query, slotID).Scan(
+		&slot.SlotID,
+		&slot.AddressID,
+		&slot.CrewID,
+		&slot.SlotStart,
+		&slot.SlotEnd,
+		&slot.Tech,
+		&slot.Capacity,
+		&slot.RemainingCapacity,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("install slot %s: %w", slotID, ErrSlotNotFound)
+		}
+		return nil, fmt.Errorf("failed to get install slot: %w", err)
+	}
+
+	return &slot, nil
+}
+
+// SearchInstallSlots retrieves installation slots with remaining capacity for an address,
+// filtered by technology, start date range and time of day, ordered by start time
+func (db *DB) SearchInstallSlots(ctx context.Context, q models.SlotQuery) ([]models.InstallSlot, error) {
+	conditions := []string{"address_id = $1", "remaining_capacity > 0"}
+	args := []interface{}{q.AddressID}
+
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(q.Techs) > 0 {
+		conditions = append(conditions, "tech = ANY("+addArg(q.Techs)+")")
+	}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "slot_start >= "+addArg(q.From))
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "slot_start < "+addArg(q.To))
+	}
+	if len(q.TimesOfDay) > 0 {
+		var ranges []string
+		for _, tod := range q.TimesOfDay {
+			hours := models.TimeOfDayHours[tod]
+			ranges = append(ranges, fmt.Sprintf("(local_start_hour >= %s AND local_start_hour < %s)", addArg(hours.From), addArg(hours.To)))
+		}
+		conditions = append(conditions, "("+strings.Join(ranges, " OR ")+")")
+	}
+
+	query := `
+		SELECT slot_id, address_id, crew_id, slot_start, slot_end, tech, capacity, remaining_capacity
+		FROM address_install_slots
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY slot_start, tech, slot_id`
+
+	if q.Limit > 0 {
+		query += " LIMIT " + addArg(q.Limit)
+	}
+	if q.Offset > 0 {
+		query += " OFFSET " + addArg(q.Offset)
+	}
+
+	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query install slots: %w", err)
 	}
@@ -231,10 +353,12 @@ func (db *DB) GetInstallSlots(ctx context.Context, addressID string, tech string
 		err := rows.Scan(
 			&slot.SlotID,
 			&slot.AddressID,
+			&slot.CrewID,
 			&slot.SlotStart,
 			&slot.SlotEnd,
 			&slot.Tech,
-			&slot.Available,
+			&slot.Capacity,
+			&slot.RemainingCapacity,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan install slot: %w", err)
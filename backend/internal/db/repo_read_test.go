@@ -52,12 +52,13 @@ func NewMockDB() *MockDB {
 		installSlots: map[string][]models.InstallSlot{
 			"A1001-fiber": {
 				{
-					SlotID:    "S1",
-					AddressID: "A1001",
-					SlotStart: time.Now().Add(24 * time.Hour),
-					SlotEnd:   time.Now().Add(27 * time.Hour),
-					Tech:      "fiber",
-					Available: true,
+					SlotID:            "S1",
+					AddressID:         "A1001",
+					SlotStart:         time.Now().Add(24 * time.Hour),
+					SlotEnd:           time.Now().Add(27 * time.Hour),
+					Tech:              "fiber",
+					Capacity:          1,
+					RemainingCapacity: 1,
 				},
 			},
 		},
@@ -165,8 +166,8 @@ func TestGetInstallSlots(t *testing.T) {
 		t.Errorf("Expected Tech 'fiber', got %s", slot.Tech)
 	}
 
-	if !slot.Available {
-		t.Error("Expected Available to be true")
+	if slot.RemainingCapacity <= 0 {
+		t.Error("Expected RemainingCapacity to be positive")
 	}
 }
 
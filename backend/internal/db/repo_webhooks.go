@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// webhookSubscriptionColumns lists the webhook_subscriptions columns in the order
+// scanWebhookSubscription expects them
+const webhookSubscriptionColumns = `id, url, secret, event_types, created_at`
+
+// webhookDeliveryColumns lists the webhook_deliveries columns in the order
+// scanWebhookDelivery expects them
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts,
+	last_status_code, last_error, next_attempt_at, created_at, delivered_at`
+
+// scanWebhookSubscription scans a row selected with webhookSubscriptionColumns
+func scanWebhookSubscription(row pgx.Row) (models.WebhookSubscription, error) {
+	var s models.WebhookSubscription
+	err := row.Scan(
+		&s.ID,
+		&s.URL,
+		&s.Secret,
+		&s.EventTypes,
+		&s.CreatedAt,
+	)
+	return s, err
+}
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns
+func scanWebhookDelivery(row pgx.Row) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := row.Scan(
+		&d.ID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.NextAttemptAt,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	)
+	return d, err
+}
+
+// CreateWebhookSubscription stores a subscription and returns it with its ID
+func (db *DB) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, event_types)
+		VALUES ($1, $2, $3)
+		RETURNING ` + webhookSubscriptionColumns
+
+	eventTypes := sub.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	created, err := scanWebhookSubscription(db.Pool.QueryRow(ctx, query, sub.URL, sub.Secret, eventTypes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return &created, nil
+}
+
+// ListWebhookSubscriptions returns every subscription, oldest first
+func (db *DB) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY id`
+
+	subs, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.WebhookSubscription, error) {
+		return scanWebhookSubscription(rows)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes a subscription together with its delivery log
+func (db *DB) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("webhook subscription %d: %w", id, ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+// EnqueueWebhookDeliveries queues deliveries for sending. A delivery of an event that was
+// already queued for the subscription is skipped.
+func (db *DB) EnqueueWebhookDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		batch.Queue(query, d.SubscriptionID, d.EventID, d.EventType, d.Payload)
+	}
+
+	if err := db.Pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries leases up to limit due deliveries for leaseSeconds and counts the attempt
+func (db *DB) ClaimWebhookDeliveries(ctx context.Context, limit, leaseSeconds int) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM claim_webhook_deliveries($1, $2)`
+
+	deliveries, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.WebhookDelivery, error) {
+		return scanWebhookDelivery(rows)
+	}, limit, leaseSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkWebhookDelivered records a delivery the subscriber accepted with statusCode
+func (db *DB) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', delivered_at = NOW(), last_status_code = $2, last_error = NULL
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, id, statusCode); err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed records a failed attempt. statusCode is nil when the subscriber did
+// not respond. The delivery is retried at retryAt, or marked failed for good when retryAt
+// is nil.
+func (db *DB) MarkWebhookFailed(ctx context.Context, id int64, statusCode *int, lastError string, retryAt *time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET last_status_code = $2,
+			last_error = $3,
+			status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($4::timestamptz, next_attempt_at)
+		WHERE id = $1
+	`
+
+	if _, err := db.Pool.Exec(ctx, query, id, statusCode, lastError, retryAt); err != nil {
+		return fmt.Errorf("failed to mark webhook failed: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries returns up to limit deliveries of the subscription, newest first
+func (db *DB) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2`
+
+	deliveries, err := queryRows(ctx, db, query, func(rows pgx.Rows) (models.WebhookDelivery, error) {
+		return scanWebhookDelivery(rows)
+	}, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
@@ -3,9 +3,10 @@ package db
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"app/internal/logging"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -14,8 +15,16 @@ type DB struct {
 	Pool *pgxpool.Pool
 }
 
+// PoolConfig sizes the database connection pool
+type PoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+}
+
 // New creates a new database connection pool
-func New(databaseURL string) (*DB, error) {
+func New(databaseURL string, poolConfig PoolConfig) (*DB, error) {
 	// Configure connection pool
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
@@ -23,10 +32,13 @@ func New(databaseURL string) (*DB, error) {
 	}
 
 	// Set pool configuration
-	config.MaxConns = 10
-	config.MinConns = 2
-	config.MaxConnLifetime = time.Hour
-	config.MaxConnIdleTime = time.Minute * 30
+	config.MaxConns = poolConfig.MaxConns
+	config.MinConns = poolConfig.MinConns
+	config.MaxConnLifetime = poolConfig.MaxConnLifetime
+	config.MaxConnIdleTime = poolConfig.MaxConnIdleTime
+
+	// Trace every query
+	config.ConnConfig.Tracer = queryTracer{}
 
 	// Create connection pool
 	pool, err := pgxpool.NewWithConfig(context.Background(), config)
@@ -43,7 +55,7 @@ func New(databaseURL string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("DB connected")
+	logging.For(ctx, "db").Info("DB connected")
 
 	return &DB{Pool: pool}, nil
 }
@@ -52,10 +64,20 @@ func New(databaseURL string) (*DB, error) {
 func (db *DB) Close() {
 	if db.Pool != nil {
 		db.Pool.Close()
-		log.Println("DB connection pool closed")
+		logging.For(context.Background(), "db").Info("DB connection pool closed")
 	}
 }
 
+// SchemaVersion returns the highest migration version recorded in schema_version
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := db.Pool.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return version, nil
+}
+
 // Health checks if the database connection is healthy
 func (db *DB) Health(ctx context.Context) error {
 	if db.Pool == nil {
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"app/internal/models"
+)
+
+// CreateAPIKey stores a key and returns it with its ID
+func (s *SupabaseClient) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	type apiKeyRow struct {
+		Name               string     `json:"name"`
+		Prefix             string     `json:"key_prefix"`
+		KeyHash            string     `json:"key_hash"`
+		Scopes             []string   `json:"scopes"`
+		RateLimitPerMinute *int       `json:"rate_limit_per_minute"`
+		RateLimitBurst     *int       `json:"rate_limit_burst"`
+		ExpiresAt          *time.Time `json:"expires_at"`
+		RotatedFrom        *int64     `json:"rotated_from"`
+	}
+
+	row := apiKeyRow{
+		Name:               key.Name,
+		Prefix:             key.Prefix,
+		KeyHash:            key.KeyHash,
+		Scopes:             key.Scopes,
+		RateLimitPerMinute: key.RateLimitPerMinute,
+		RateLimitBurst:     key.RateLimitBurst,
+		ExpiresAt:          key.ExpiresAt,
+		RotatedFrom:        key.RotatedFrom,
+	}
+
+	var created []models.APIKey
+	if err := s.post(ctx, "api_keys", row, "return=representation", &created); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	if len(created) == 0 {
+		return nil, fmt.Errorf("failed to create API key: no row returned")
+	}
+
+	return &created[0], nil
+}
+
+// GetAPIKey returns the key with id
+func (s *SupabaseClient) GetAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.get(ctx, fmt.Sprintf("api_keys?id=eq.%d", id), &keys); err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("API key %d: %w", id, ErrAPIKeyNotFound)
+	}
+
+	return &keys[0], nil
+}
+
+// GetAPIKeyByPrefix returns the key with prefix
+func (s *SupabaseClient) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.get(ctx, "api_keys?key_prefix=eq."+url.QueryEscape(prefix), &keys); err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("API key %s: %w", prefix, ErrAPIKeyNotFound)
+	}
+
+	return &keys[0], nil
+}
+
+// ListAPIKeys returns every key, oldest first
+func (s *SupabaseClient) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.get(ctx, "api_keys?order=id", &keys); err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key and returns it. Revoking a revoked key keeps its original
+// revocation time.
+func (s *SupabaseClient) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	endpoint := fmt.Sprintf("api_keys?id=eq.%d&revoked_at=is.null", id)
+	update := map[string]interface{}{"revoked_at": time.Now().UTC()}
+
+	var revoked []models.APIKey
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=representation", &revoked); err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if len(revoked) == 0 {
+		// Already revoked, or no such key
+		return s.GetAPIKey(ctx, id)
+	}
+
+	return &revoked[0], nil
+}
+
+// RotateAPIKey issues the replacement of key id, with the same name, scopes, limits and
+// expiry and the given prefix and hash, and lets key id expire at expireOldAt unless it
+// expires earlier, atomically
+func (s *SupabaseClient) RotateAPIKey(ctx context.Context, id int64, prefix, keyHash string, expireOldAt time.Time) (*models.APIKey, error) {
+	args := map[string]interface{}{
+		"p_id":            id,
+		"p_key_prefix":    prefix,
+		"p_key_hash":      keyHash,
+		"p_expire_old_at": expireOldAt.UTC(),
+	}
+
+	var created models.APIKey
+	if err := s.post(ctx, "rpc/rotate_api_key", args, "", &created); err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", translateError(err))
+	}
+
+	return &created, nil
+}
+
+// TouchAPIKey records that a key was just used
+func (s *SupabaseClient) TouchAPIKey(ctx context.Context, id int64) error {
+	endpoint := fmt.Sprintf("api_keys?id=eq.%d", id)
+	update := map[string]interface{}{"last_used_at": time.Now().UTC()}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to touch API key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"app/internal/models"
+)
+
+// ListCampaigns returns the campaigns that have not ended at a time, including those
+// starting later, by ID. The zero time lists every campaign.
+func (s *SupabaseClient) ListCampaigns(ctx context.Context, at time.Time) ([]models.Campaign, error) {
+	endpoint := "campaigns?order=campaign_id"
+	if !at.IsZero() {
+		ts := at.UTC().Format(time.RFC3339Nano)
+		endpoint += "&or=" + url.QueryEscape(`(ends_at.is.null,ends_at.gt."`+ts+`")`)
+	}
+
+	var campaigns []models.Campaign
+	if err := s.get(ctx, endpoint, &campaigns); err != nil {
+		return nil, fmt.Errorf("failed to list campaigns: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+// IsNewCustomer reports whether a user has neither current services nor an order that
+// was not cancelled
+func (s *SupabaseClient) IsNewCustomer(ctx context.Context, userID int) (bool, error) {
+	var isNew bool
+	args := map[string]interface{}{"p_user_id": userID}
+	if err := s.post(ctx, "rpc/is_new_customer", args, "", &isNew); err != nil {
+		return false, fmt.Errorf("failed to check for a new customer: %w", err)
+	}
+
+	return isNew, nil
+}
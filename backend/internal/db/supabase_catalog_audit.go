@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"app/internal/models"
+)
+
+// ApplyCatalogChange creates, updates or deletes a catalog row and records the change in
+// the audit log, in one transaction
+func (s *SupabaseClient) ApplyCatalogChange(ctx context.Context, change models.CatalogChange) (*models.CatalogAuditEntry, error) {
+	args := map[string]interface{}{
+		"p_entity":         change.Entity,
+		"p_action":         change.Action,
+		"p_key":            nullableString(change.Key),
+		"p_data":           change.Data,
+		"p_actor":          change.Actor,
+		"p_effective_from": change.EffectiveFrom,
+	}
+
+	var entry models.CatalogAuditEntry
+	if err := s.post(ctx, "rpc/apply_catalog_change", args, "", &entry); err != nil {
+		return nil, fmt.Errorf("failed to %s %s entry: %w", change.Action, change.Entity, translateError(err))
+	}
+
+	return &entry, nil
+}
+
+// ListCatalogAudit returns up to limit audit entries of entity, or of every entity when
+// entity is empty, newest first
+func (s *SupabaseClient) ListCatalogAudit(ctx context.Context, entity string, limit int) ([]models.CatalogAuditEntry, error) {
+	endpoint := fmt.Sprintf("catalog_audit_log?order=id.desc&limit=%d", limit)
+	if entity != "" {
+		endpoint += "&entity=eq." + url.QueryEscape(entity)
+	}
+
+	var entries []models.CatalogAuditEntry
+	if err := s.get(ctx, endpoint, &entries); err != nil {
+		return nil, fmt.Errorf("failed to list catalog audit log: %w", err)
+	}
+
+	return entries, nil
+}
@@ -1,16 +1,31 @@
 package db
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"app/internal/logging"
 	"app/internal/models"
+	"app/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracerName names the tracer of Supabase calls and pgx queries
+const tracerName = "app/internal/db"
+
 // SupabaseClient represents a client for Supabase REST API
 type SupabaseClient struct {
 	baseURL    string
@@ -19,14 +34,19 @@ type SupabaseClient struct {
 	httpClient *http.Client
 }
 
-// NewSupabaseClient creates a new Supabase client
-func NewSupabaseClient(baseURL, anonKey, serviceKey string) *SupabaseClient {
+// NewSupabaseClient creates a new Supabase client. Requests time out after timeout and
+// up to maxIdleConns connections are kept open for reuse.
+func NewSupabaseClient(baseURL, anonKey, serviceKey string, timeout time.Duration, maxIdleConns int) *SupabaseClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConns
+
 	return &SupabaseClient{
 		baseURL:    baseURL,
 		anonKey:    anonKey,
 		serviceKey: serviceKey,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   timeout,
+			Transport: transport,
 		},
 	}
 }
@@ -56,6 +76,21 @@ func (s *SupabaseClient) Health(ctx context.Context) error {
 	return nil
 }
 
+// SchemaVersion returns the highest migration version recorded in schema_version
+func (s *SupabaseClient) SchemaVersion(ctx context.Context) (int, error) {
+	var rows []struct {
+		Version int `json:"version"`
+	}
+	if err := s.get(ctx, "schema_version?select=version&order=version.desc&limit=1", &rows); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	return rows[0].Version, nil
+}
+
 // Close closes the client (no-op for HTTP client)
 func (s *SupabaseClient) Close() {
 	// No resources to close for HTTP client
@@ -63,9 +98,46 @@ func (s *SupabaseClient) Close() {
 
 // Helper method to make GET requests
 func (s *SupabaseClient) get(ctx context.Context, endpoint string, result interface{}) error {
+	return s.do(ctx, http.MethodGet, endpoint, nil, "", result)
+}
+
+// Helper method to make POST requests. prefer is passed as the PostgREST Prefer header
+// (e.g. "resolution=ignore-duplicates"); result may be nil when no body is expected.
+func (s *SupabaseClient) post(ctx context.Context, endpoint string, body interface{}, prefer string, result interface{}) error {
+	return s.do(ctx, http.MethodPost, endpoint, body, prefer, result)
+}
+
+// do sends a request to the PostgREST API and decodes the JSON response into result
+func (s *SupabaseClient) do(ctx context.Context, method, endpoint string, body interface{}, prefer string, result interface{}) (err error) {
 	url := s.baseURL + "/rest/v1/" + endpoint
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	// Trace the call under the table or RPC it targets, without the query filters
+	resource, _, _ := strings.Cut(endpoint, "?")
+	ctx, span := tracing.Tracer(tracerName).Start(ctx, "supabase "+method+" "+resource,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("supabase.endpoint", resource),
+			semconv.HTTPRequestMethodKey.String(method),
+		),
+	)
+	start := time.Now()
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+		logging.For(ctx, "db").Debug("Supabase call", "method", method, "endpoint", resource,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000, "error", err)
+	}()
+
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -73,24 +145,38 @@ func (s *SupabaseClient) get(ctx context.Context, endpoint string, result interf
 	req.Header.Set("apikey", s.serviceKey)
 	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
 	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return fmt.Errorf("request failed: %w: %w", ErrUnavailable, err)
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		restErr := &postgrestError{Status: resp.StatusCode, Body: string(body)}
+		_ = json.Unmarshal(body, restErr) // best effort: code and message are optional
+		return restErr
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if err := json.Unmarshal(body, result); err != nil {
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
@@ -107,7 +193,7 @@ func (s *SupabaseClient) GetUser(ctx context.Context, userID int) (*models.User,
 	}
 
 	if len(users) == 0 {
-		return nil, fmt.Errorf("user %d not found", userID)
+		return nil, fmt.Errorf("user %d: %w", userID, ErrUserNotFound)
 	}
 
 	return &users[0], nil
@@ -123,12 +209,40 @@ func (s *SupabaseClient) GetCoverage(ctx context.Context, addressID string) (*mo
 	}
 
 	if len(coverage) == 0 {
-		return nil, fmt.Errorf("coverage for address %s not found", addressID)
+		return nil, fmt.Errorf("coverage for address %s: %w", addressID, ErrCoverageNotFound)
 	}
 
 	return &coverage[0], nil
 }
 
+// GetCoverageBatch retrieves coverage information for several addresses with a single
+// address_id=in.(...) request. Addresses without a coverage row are absent from the map.
+func (s *SupabaseClient) GetCoverageBatch(ctx context.Context, addressIDs []string) (map[string]*models.Coverage, error) {
+	endpoint := "coverage?address_id=" + inFilter(addressIDs)
+
+	var rows []models.Coverage
+	if err := s.get(ctx, endpoint, &rows); err != nil {
+		return nil, fmt.Errorf("failed to get coverage batch: %w", err)
+	}
+
+	coverage := make(map[string]*models.Coverage, len(rows))
+	for i := range rows {
+		coverage[rows[i].AddressID] = &rows[i]
+	}
+
+	return coverage, nil
+}
+
+// GetDistrictCoverage retrieves per city/district technology availability counts
+func (s *SupabaseClient) GetDistrictCoverage(ctx context.Context) ([]models.DistrictCoverage, error) {
+	var districts []models.DistrictCoverage
+	if err := s.get(ctx, "coverage_district_stats?order=city,district", &districts); err != nil {
+		return nil, fmt.Errorf("failed to get district coverage: %w", err)
+	}
+
+	return districts, nil
+}
+
 // GetHousehold retrieves household information for a user
 func (s *SupabaseClient) GetHousehold(ctx context.Context, userID int) ([]models.Household, error) {
 	endpoint := fmt.Sprintf("household?user_id=eq.%d", userID)
@@ -141,41 +255,183 @@ func (s *SupabaseClient) GetHousehold(ctx context.Context, userID int) ([]models
 	return household, nil
 }
 
-// GetInstallSlots retrieves install slots for an address and technology
+// GetInstallSlots retrieves install slots with remaining capacity for an address and
+// technology, including crew slots of every crew serving the address's district
 func (s *SupabaseClient) GetInstallSlots(ctx context.Context, addressID, tech string) ([]models.InstallSlot, error) {
-	endpoint := fmt.Sprintf("install_slots?address_id=eq.%s&tech=eq.%s&available=eq.true&order=slot_start", addressID, tech)
+	return s.SearchInstallSlots(ctx, models.SlotQuery{AddressID: addressID, Techs: []string{tech}})
+}
+
+// GetInstallSlot retrieves a single install slot by ID regardless of its remaining capacity
+func (s *SupabaseClient) GetInstallSlot(ctx context.Context, slotID string) (*models.InstallSlot, error) {
+	endpoint := "install_slots?slot_id=eq." + url.QueryEscape(slotID) + "&limit=1"
 
 	var slots []models.InstallSlot
 	if err := s.get(ctx, endpoint, &slots); err != nil {
+		return nil, fmt.Errorf("failed to get install slot: %w", err)
+	}
+
+	if len(slots) == 0 {
+		return nil, fmt.Errorf("install slot %s: %w", slotID, ErrSlotNotFound)
+	}
+
+	return &slots[0], nil
+}
+
+// SearchInstallSlots retrieves installation slots with remaining capacity for an address,
+// filtered by technology, start date range and time of day, ordered by start time
+func (s *SupabaseClient) SearchInstallSlots(ctx context.Context, q models.SlotQuery) ([]models.InstallSlot, error) {
+	params := []string{
+		"select=slot_id,address_id,crew_id,slot_start,slot_end,tech,capacity,remaining_capacity",
+		"address_id=eq." + url.QueryEscape(q.AddressID),
+		"remaining_capacity=gt.0",
+	}
+
+	if len(q.Techs) > 0 {
+		params = append(params, "tech="+inFilter(q.Techs))
+	}
+	if !q.From.IsZero() {
+		params = append(params, "slot_start=gte."+url.QueryEscape(q.From.Format(time.RFC3339)))
+	}
+	if !q.To.IsZero() {
+		params = append(params, "slot_start=lt."+url.QueryEscape(q.To.Format(time.RFC3339)))
+	}
+	if len(q.TimesOfDay) > 0 {
+		var ranges []string
+		for _, tod := range q.TimesOfDay {
+			hours := models.TimeOfDayHours[tod]
+			ranges = append(ranges, fmt.Sprintf("and(local_start_hour.gte.%d,local_start_hour.lt.%d)", hours.From, hours.To))
+		}
+		params = append(params, "or="+url.QueryEscape("("+strings.Join(ranges, ",")+")"))
+	}
+
+	params = append(params, "order=slot_start,tech,slot_id")
+	if q.Limit > 0 {
+		params = append(params, "limit="+strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		params = append(params, "offset="+strconv.Itoa(q.Offset))
+	}
+
+	var slots []models.InstallSlot
+	if err := s.get(ctx, "address_install_slots?"+strings.Join(params, "&"), &slots); err != nil {
 		return nil, fmt.Errorf("failed to get install slots: %w", err)
 	}
 
 	return slots, nil
 }
 
-// GetCatalog retrieves all plan catalogs
-func (s *SupabaseClient) GetCatalog(ctx context.Context) (*models.Catalog, error) {
+// GetCrews retrieves all active crews with their technicians, service areas and working hours
+func (s *SupabaseClient) GetCrews(ctx context.Context) ([]models.Crew, error) {
+	var crews []models.Crew
+	if err := s.get(ctx, "crews?active=eq.true&order=crew_id", &crews); err != nil {
+		return nil, fmt.Errorf("failed to get crews: %w", err)
+	}
+
+	var technicians []models.Technician
+	if err := s.get(ctx, "technicians?active=eq.true&order=technician_id", &technicians); err != nil {
+		return nil, fmt.Errorf("failed to get technicians: %w", err)
+	}
+
+	var areas []models.ServiceArea
+	if err := s.get(ctx, "crew_service_areas?order=crew_id,city,district", &areas); err != nil {
+		return nil, fmt.Errorf("failed to get crew service areas: %w", err)
+	}
+
+	var hours []models.WorkingHours
+	if err := s.get(ctx, "crew_working_hours?order=crew_id,weekday,start_time", &hours); err != nil {
+		return nil, fmt.Errorf("failed to get crew working hours: %w", err)
+	}
+
+	return assembleCrews(crews, technicians, areas, hours), nil
+}
+
+// UpsertInstallSlots inserts generated slots, leaving slots that already exist untouched so
+// that capacity already booked is never reset. Returns the number of newly created slots.
+func (s *SupabaseClient) UpsertInstallSlots(ctx context.Context, slots []models.InstallSlot) (int, error) {
+	if len(slots) == 0 {
+		return 0, nil
+	}
+
+	type slotRow struct {
+		SlotID            string    `json:"slot_id"`
+		CrewID            *int      `json:"crew_id"`
+		SlotStart         time.Time `json:"slot_start"`
+		SlotEnd           time.Time `json:"slot_end"`
+		Tech              string    `json:"tech"`
+		Capacity          int       `json:"capacity"`
+		RemainingCapacity int       `json:"remaining_capacity"`
+	}
+
+	rows := make([]slotRow, len(slots))
+	for i, slot := range slots {
+		rows[i] = slotRow{
+			SlotID:            slot.SlotID,
+			CrewID:            slot.CrewID,
+			SlotStart:         slot.SlotStart,
+			SlotEnd:           slot.SlotEnd,
+			Tech:              slot.Tech,
+			Capacity:          slot.Capacity,
+			RemainingCapacity: slot.Capacity,
+		}
+	}
+
+	// With ignore-duplicates only the inserted rows are returned
+	var inserted []struct {
+		SlotID string `json:"slot_id"`
+	}
+	err := s.post(ctx, "install_slots?on_conflict=slot_id&select=slot_id", rows, "resolution=ignore-duplicates,return=representation", &inserted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert install slots: %w", err)
+	}
+
+	return len(inserted), nil
+}
+
+// GetCatalog retrieves the plan and rule versions in effect at a time, and when that
+// catalog next changes
+func (s *SupabaseClient) GetCatalog(ctx context.Context, at time.Time) (*models.Catalog, error) {
 	var catalog models.Catalog
 
+	// Versions started by at and not yet ended. The time is quoted inside or=() because it
+	// contains dots and colons.
+	ts := at.UTC().Format(time.RFC3339Nano)
+	inEffect := "?effective_from=lte." + url.QueryEscape(ts) +
+		"&or=" + url.QueryEscape(`(effective_to.is.null,effective_to.gt."`+ts+`")`)
+
 	// Get mobile plans
-	if err := s.get(ctx, "mobile_plans", &catalog.MobilePlans); err != nil {
+	if err := s.get(ctx, "mobile_plans"+inEffect, &catalog.MobilePlans); err != nil {
 		return nil, fmt.Errorf("failed to get mobile plans: %w", err)
 	}
 
 	// Get home plans
-	if err := s.get(ctx, "home_plans", &catalog.HomePlans); err != nil {
+	if err := s.get(ctx, "home_plans"+inEffect, &catalog.HomePlans); err != nil {
 		return nil, fmt.Errorf("failed to get home plans: %w", err)
 	}
 
 	// Get TV plans
-	if err := s.get(ctx, "tv_plans", &catalog.TVPlans); err != nil {
+	if err := s.get(ctx, "tv_plans"+inEffect, &catalog.TVPlans); err != nil {
 		return nil, fmt.Errorf("failed to get TV plans: %w", err)
 	}
 
 	// Get bundling rules
-	if err := s.get(ctx, "bundling_rules", &catalog.BundlingRules); err != nil {
+	if err := s.get(ctx, "bundling_rules"+inEffect, &catalog.BundlingRules); err != nil {
 		return nil, fmt.Errorf("failed to get bundling rules: %w", err)
 	}
 
+	args := map[string]interface{}{"p_at": ts}
+	if err := s.post(ctx, "rpc/catalog_valid_until", args, "", &catalog.ValidUntil); err != nil {
+		return nil, fmt.Errorf("failed to get catalog changes: %w", err)
+	}
+
 	return &catalog, nil
 }
+
+// inFilter builds a PostgREST in.(...) filter value. Each value is double-quoted so that
+// commas and parentheses inside IDs cannot break the list, then URL-escaped.
+func inFilter(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+	}
+	return url.QueryEscape("in.(" + strings.Join(quoted, ",") + ")")
+}
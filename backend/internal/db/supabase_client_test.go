@@ -0,0 +1,20 @@
+package db
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestInFilter(t *testing.T) {
+	got := inFilter([]string{"A1001", "A,1002"})
+
+	decoded, err := url.QueryUnescape(got)
+	if err != nil {
+		t.Fatalf("Filter is not valid query escaping: %v", err)
+	}
+
+	expected := `in.("A1001","A,1002")`
+	if decoded != expected {
+		t.Errorf("Expected %s, got %s", expected, decoded)
+	}
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"app/internal/models"
+)
+
+// ClaimEvents leases up to limit due outbox events for leaseSeconds and counts the attempt
+func (s *SupabaseClient) ClaimEvents(ctx context.Context, limit, leaseSeconds int) ([]models.Event, error) {
+	args := map[string]interface{}{
+		"p_limit":         limit,
+		"p_lease_seconds": leaseSeconds,
+	}
+
+	var events []models.Event
+	if err := s.post(ctx, "rpc/claim_events?order=id", args, "", &events); err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkEventDelivered records that every handler processed the event
+func (s *SupabaseClient) MarkEventDelivered(ctx context.Context, id int64) error {
+	endpoint := fmt.Sprintf("outbox?id=eq.%d", id)
+	update := map[string]interface{}{
+		"status":       models.EventDelivered,
+		"delivered_at": time.Now().UTC(),
+		"last_error":   nil,
+	}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to mark event delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEventFailed records a failed dispatch. The event is retried at retryAt, or moved
+// to the dead-letter state when retryAt is nil.
+func (s *SupabaseClient) MarkEventFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error {
+	endpoint := fmt.Sprintf("outbox?id=eq.%d", id)
+	update := map[string]interface{}{
+		"status":     models.EventDead,
+		"last_error": lastError,
+	}
+	if retryAt != nil {
+		update["status"] = models.EventPending
+		update["next_attempt_at"] = retryAt.UTC()
+	}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to mark event failed: %w", err)
+	}
+
+	return nil
+}
+
+// ListEvents returns up to limit outbox events with the status, newest first
+func (s *SupabaseClient) ListEvents(ctx context.Context, status string, limit int) ([]models.Event, error) {
+	endpoint := fmt.Sprintf("outbox?status=eq.%s&order=id.desc&limit=%d", url.QueryEscape(status), limit)
+
+	var events []models.Event
+	if err := s.get(ctx, endpoint, &events); err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	return events, nil
+}
+
+// RequeueEvent moves a dead event back to pending with a fresh set of attempts
+func (s *SupabaseClient) RequeueEvent(ctx context.Context, id int64) (*models.Event, error) {
+	endpoint := fmt.Sprintf("outbox?id=eq.%d&status=eq.%s", id, models.EventDead)
+	update := map[string]interface{}{
+		"status":          models.EventPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now().UTC(),
+	}
+
+	var events []models.Event
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=representation", &events); err != nil {
+		return nil, fmt.Errorf("failed to requeue event: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("dead event %d: %w", id, ErrEventNotFound)
+	}
+
+	return &events[0], nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"app/internal/models"
+)
+
+// ClaimIdempotencyKey stores key for a request with the given hash. It returns claimed =
+// true when the key was new (or had expired); otherwise the existing record is returned.
+func (s *SupabaseClient) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, ttlSeconds int) (*models.IdempotencyRecord, bool, error) {
+	args := map[string]interface{}{
+		"p_key":          key,
+		"p_request_hash": requestHash,
+		"p_ttl_seconds":  ttlSeconds,
+	}
+
+	var rows []struct {
+		models.IdempotencyRecord
+		Claimed bool `json:"claimed"`
+	}
+	if err := s.post(ctx, "rpc/claim_idempotency_key", args, "", &rows); err != nil {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: no row returned for %s", key)
+	}
+
+	return &rows[0].IdempotencyRecord, rows[0].Claimed, nil
+}
+
+// SaveIdempotentResponse stores the response of the request that claimed key
+func (s *SupabaseClient) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, body string) error {
+	endpoint := "idempotency_keys?idempotency_key=eq." + url.QueryEscape(key)
+	update := map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": body,
+	}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKey removes key so the request can be retried from scratch
+func (s *SupabaseClient) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	endpoint := "idempotency_keys?idempotency_key=eq." + url.QueryEscape(key)
+
+	if err := s.do(ctx, http.MethodDelete, endpoint, nil, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeIdempotencyKeys deletes expired keys and returns how many were removed
+func (s *SupabaseClient) PurgeIdempotencyKeys(ctx context.Context) (int, error) {
+	var purged int
+	if err := s.post(ctx, "rpc/purge_idempotency_keys", map[string]interface{}{}, "", &purged); err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	return purged, nil
+}
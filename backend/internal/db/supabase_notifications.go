@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"app/internal/models"
+)
+
+// EnqueueNotifications queues notifications for delivery. Notifications whose dedupe key
+// was queued before are skipped.
+func (s *SupabaseClient) EnqueueNotifications(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	type notificationRow struct {
+		DedupeKey string  `json:"dedupe_key"`
+		OrderID   *string `json:"order_id"`
+		Kind      string  `json:"kind"`
+		Channel   string  `json:"channel"`
+		Recipient string  `json:"recipient"`
+		Subject   *string `json:"subject"`
+		Body      string  `json:"body"`
+	}
+
+	rows := make([]notificationRow, len(notifications))
+	for i, n := range notifications {
+		rows[i] = notificationRow{
+			DedupeKey: n.DedupeKey,
+			OrderID:   n.OrderID,
+			Kind:      n.Kind,
+			Channel:   n.Channel,
+			Recipient: n.Recipient,
+			Subject:   n.Subject,
+			Body:      n.Body,
+		}
+	}
+
+	if err := s.post(ctx, "notification_outbox?on_conflict=dedupe_key", rows, "resolution=ignore-duplicates,return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to enqueue notifications: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimNotifications leases up to limit due notifications for leaseSeconds and counts the attempt
+func (s *SupabaseClient) ClaimNotifications(ctx context.Context, limit, leaseSeconds int) ([]models.Notification, error) {
+	args := map[string]interface{}{
+		"p_limit":         limit,
+		"p_lease_seconds": leaseSeconds,
+	}
+
+	var notifications []models.Notification
+	if err := s.post(ctx, "rpc/claim_notifications", args, "", &notifications); err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationSent records a successful delivery
+func (s *SupabaseClient) MarkNotificationSent(ctx context.Context, id int64) error {
+	endpoint := fmt.Sprintf("notification_outbox?id=eq.%d", id)
+	update := map[string]interface{}{
+		"status":     models.NotificationSent,
+		"sent_at":    time.Now().UTC(),
+		"last_error": nil,
+	}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+
+	return nil
+}
+
+// MarkNotificationFailed records a failed delivery. The notification is retried at
+// retryAt, or marked failed for good when retryAt is nil.
+func (s *SupabaseClient) MarkNotificationFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error {
+	endpoint := fmt.Sprintf("notification_outbox?id=eq.%d", id)
+	update := map[string]interface{}{
+		"status":     models.NotificationFailed,
+		"last_error": lastError,
+	}
+	if retryAt != nil {
+		update["status"] = models.NotificationPending
+		update["next_attempt_at"] = retryAt.UTC()
+	}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to mark notification failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetUpcomingAppointments returns confirmed orders whose installation starts within the
+// next leadSeconds and was booked before that window opened
+func (s *SupabaseClient) GetUpcomingAppointments(ctx context.Context, leadSeconds int) ([]models.Order, error) {
+	args := map[string]interface{}{
+		"p_lead_seconds": leadSeconds,
+	}
+
+	var orders []models.Order
+	if err := s.post(ctx, "rpc/upcoming_appointments", args, "", &orders); err != nil {
+		return nil, fmt.Errorf("failed to query upcoming appointments: %w", err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"app/internal/models"
+)
+
+// PlaceOrder claims the order's install slot and stores the order as pending, atomically.
+// order.Tech, when set, restricts the slot to that technology.
+func (s *SupabaseClient) PlaceOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	args := map[string]interface{}{
+		"p_order_id":          order.OrderID,
+		"p_user_id":           order.UserID,
+		"p_address_id":        order.AddressID,
+		"p_slot_id":           order.SlotID,
+		"p_tech":              order.Tech,
+		"p_combo_label":       order.ComboLabel,
+		"p_monthly_total":     order.MonthlyTotal,
+		"p_items":             order.Items,
+		"p_upfront_amount":    order.UpfrontAmount,
+		"p_promotions":        order.Promotions,
+		"p_commitment_months": order.CommitmentMonths,
+		"p_termination_fee":   order.TerminationFee,
+	}
+
+	var placed models.Order
+	if err := s.post(ctx, "rpc/place_order", args, "", &placed); err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", translateError(err))
+	}
+
+	return &placed, nil
+}
+
+// ConfirmOrder confirms a pending order whose upfront payment was authorised
+func (s *SupabaseClient) ConfirmOrder(ctx context.Context, orderID, paymentID string) (*models.Order, error) {
+	args := map[string]interface{}{
+		"p_order_id":   orderID,
+		"p_payment_id": paymentID,
+	}
+
+	var order models.Order
+	if err := s.post(ctx, "rpc/confirm_order", args, "", &order); err != nil {
+		return nil, fmt.Errorf("failed to confirm order: %w", translateError(err))
+	}
+
+	return &order, nil
+}
+
+// SetPaymentStatus records the latest payment status of an order
+func (s *SupabaseClient) SetPaymentStatus(ctx context.Context, orderID, status string) error {
+	endpoint := "orders?order_id=eq." + url.QueryEscape(orderID) + "&select=order_id"
+	update := map[string]interface{}{
+		"payment_status": status,
+		"updated_at":     time.Now().UTC(),
+	}
+
+	var updated []struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=representation", &updated); err != nil {
+		return fmt.Errorf("failed to set payment status: %w", err)
+	}
+	if len(updated) == 0 {
+		return fmt.Errorf("order %s: %w", orderID, ErrOrderNotFound)
+	}
+
+	return nil
+}
+
+// GetOrder retrieves an order by ID
+func (s *SupabaseClient) GetOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	endpoint := "orders?order_id=eq." + url.QueryEscape(orderID) + "&limit=1"
+
+	var orders []models.Order
+	if err := s.get(ctx, endpoint, &orders); err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("order %s: %w", orderID, ErrOrderNotFound)
+	}
+
+	return &orders[0], nil
+}
+
+// RescheduleOrder atomically releases the order's current slot and claims newSlotID.
+// Both slots must start more than cutoffSeconds from now.
+func (s *SupabaseClient) RescheduleOrder(ctx context.Context, orderID, newSlotID string, cutoffSeconds int, reason string) (*models.Order, error) {
+	args := map[string]interface{}{
+		"p_order_id":       orderID,
+		"p_new_slot_id":    newSlotID,
+		"p_cutoff_seconds": cutoffSeconds,
+		"p_reason":         nullableString(reason),
+	}
+
+	var order models.Order
+	if err := s.post(ctx, "rpc/reschedule_order", args, "", &order); err != nil {
+		return nil, fmt.Errorf("failed to reschedule order: %w", translateError(err))
+	}
+
+	return &order, nil
+}
+
+// CancelOrder cancels an order and releases its slot if the installation has not started
+func (s *SupabaseClient) CancelOrder(ctx context.Context, orderID, reason string) (*models.Order, error) {
+	args := map[string]interface{}{
+		"p_order_id": orderID,
+		"p_reason":   nullableString(reason),
+	}
+
+	var order models.Order
+	if err := s.post(ctx, "rpc/cancel_order", args, "", &order); err != nil {
+		return nil, fmt.Errorf("failed to cancel order: %w", translateError(err))
+	}
+
+	return &order, nil
+}
+
+// GetAppointmentHistory retrieves every appointment change of an order, oldest first
+func (s *SupabaseClient) GetAppointmentHistory(ctx context.Context, orderID string) ([]models.AppointmentChange, error) {
+	endpoint := "appointment_history?order_id=eq." + url.QueryEscape(orderID) + "&order=created_at,id"
+
+	var history []models.AppointmentChange
+	if err := s.get(ctx, endpoint, &history); err != nil {
+		return nil, fmt.Errorf("failed to get appointment history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ExpirePendingOrders cancels pending orders older than maxAgeSeconds and releases their
+// install slots. Returns the number of orders expired.
+func (s *SupabaseClient) ExpirePendingOrders(ctx context.Context, maxAgeSeconds int) (int, error) {
+	args := map[string]interface{}{
+		"p_max_age_seconds": maxAgeSeconds,
+	}
+
+	var expired int
+	if err := s.post(ctx, "rpc/expire_pending_orders", args, "", &expired); err != nil {
+		return 0, fmt.Errorf("failed to expire pending orders: %w", err)
+	}
+
+	return expired, nil
+}
+
+// ListUnreconciledOrders returns up to limit orders whose payment needs settling with the
+// provider and that have not changed for minAgeSeconds: cancelled orders whose payment
+// may still hold money and confirmed orders whose payment was not captured
+func (s *SupabaseClient) ListUnreconciledOrders(ctx context.Context, minAgeSeconds, limit int) ([]models.Order, error) {
+	args := map[string]interface{}{
+		"p_min_age_seconds": minAgeSeconds,
+		"p_limit":           limit,
+	}
+
+	var orders []models.Order
+	if err := s.post(ctx, "rpc/unreconciled_orders", args, "", &orders); err != nil {
+		return nil, fmt.Errorf("failed to query unreconciled orders: %w", err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"app/internal/models"
+)
+
+// SaveQuotes stores the quotes issued for a recommendation
+func (s *SupabaseClient) SaveQuotes(ctx context.Context, quotes []models.Quote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	type quoteRow struct {
+		QuoteID        string          `json:"quote_id"`
+		UserID         int             `json:"user_id"`
+		AddressID      string          `json:"address_id"`
+		CatalogVersion string          `json:"catalog_version"`
+		HouseholdHash  string          `json:"household_hash"`
+		ComboLabel     string          `json:"combo_label"`
+		MonthlyTotal   float64         `json:"monthly_total"`
+		Tech           *string         `json:"tech"`
+		Items          json.RawMessage `json:"items"`
+		Promotions     json.RawMessage `json:"promotions,omitempty"`
+		Commitment     int             `json:"commitment_months"`
+		TerminationFee float64         `json:"termination_fee"`
+		ExpiresAt      time.Time       `json:"expires_at"`
+	}
+
+	rows := make([]quoteRow, len(quotes))
+	for i, q := range quotes {
+		rows[i] = quoteRow{
+			QuoteID:        q.QuoteID,
+			UserID:         q.UserID,
+			AddressID:      q.AddressID,
+			CatalogVersion: q.CatalogVersion,
+			HouseholdHash:  q.HouseholdHash,
+			ComboLabel:     q.ComboLabel,
+			MonthlyTotal:   q.MonthlyTotal,
+			Tech:           q.Tech,
+			Items:          q.Items,
+			Promotions:     q.Promotions,
+			Commitment:     q.CommitmentMonths,
+			TerminationFee: q.TerminationFee,
+			ExpiresAt:      q.ExpiresAt,
+		}
+	}
+
+	if err := s.post(ctx, "quotes", rows, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to save quotes: %w", err)
+	}
+
+	return nil
+}
+
+// GetQuote retrieves a quote by ID, including expired ones
+func (s *SupabaseClient) GetQuote(ctx context.Context, quoteID string) (*models.Quote, error) {
+	endpoint := "quotes?quote_id=eq." + url.QueryEscape(quoteID) + "&limit=1"
+
+	var quotes []models.Quote
+	if err := s.get(ctx, endpoint, &quotes); err != nil {
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("quote %s: %w", quoteID, ErrQuoteNotFound)
+	}
+
+	return &quotes[0], nil
+}
+
+// PurgeExpiredQuotes deletes expired quotes and returns how many were removed
+func (s *SupabaseClient) PurgeExpiredQuotes(ctx context.Context) (int, error) {
+	var purged int
+	if err := s.post(ctx, "rpc/purge_expired_quotes", map[string]interface{}{}, "", &purged); err != nil {
+		return 0, fmt.Errorf("failed to purge expired quotes: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/models"
+)
+
+// TakeRateLimitToken takes a token from the shared bucket key, which holds at most burst
+// tokens and refills at ratePerSecond
+func (s *SupabaseClient) TakeRateLimitToken(ctx context.Context, key string, ratePerSecond float64, burst int) (*models.RateLimitDecision, error) {
+	args := map[string]interface{}{
+		"p_key":   key,
+		"p_rate":  ratePerSecond,
+		"p_burst": burst,
+	}
+
+	var rows []models.RateLimitDecision
+	if err := s.post(ctx, "rpc/take_rate_limit_token", args, "", &rows); err != nil {
+		return nil, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("failed to take rate limit token: no row returned for %s", key)
+	}
+
+	return &rows[0], nil
+}
+
+// PurgeRateLimitBuckets deletes buckets unused for an hour and returns how many were removed
+func (s *SupabaseClient) PurgeRateLimitBuckets(ctx context.Context) (int, error) {
+	var purged int
+	if err := s.post(ctx, "rpc/purge_rate_limit_buckets", map[string]interface{}{}, "", &purged); err != nil {
+		return 0, fmt.Errorf("failed to purge rate limit buckets: %w", err)
+	}
+
+	return purged, nil
+}
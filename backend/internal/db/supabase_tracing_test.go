@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSupabaseCallSpans(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterNone}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if strings.HasPrefix(r.URL.Path, "/rest/v1/orders") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"user_id": 7}]`))
+	}))
+	defer server.Close()
+
+	client := NewSupabaseClient(server.URL, "anon", "service", 5*time.Second, 2)
+	if _, err := client.GetUser(context.Background(), 7); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.GetOrder(context.Background(), "ORD-1"); err == nil {
+		t.Fatal("Expected the 503 to fail the call")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected a span per call, got %d", len(spans))
+	}
+	for i, expected := range []struct {
+		name     string
+		endpoint string
+		status   int64
+		failed   bool
+	}{
+		{"supabase GET users", "users", http.StatusOK, false},
+		{"supabase GET orders", "orders", http.StatusServiceUnavailable, true},
+	} {
+		span := spans[i]
+		attrs := attribute.NewSet(span.Attributes()...)
+		endpoint, _ := attrs.Value("supabase.endpoint")
+		status, _ := attrs.Value("http.response.status_code")
+
+		if span.Name() != expected.name || endpoint.AsString() != expected.endpoint || status.AsInt64() != expected.status {
+			t.Errorf("Expected %s on %s with %d, got %s on %s with %d",
+				expected.name, expected.endpoint, expected.status, span.Name(), endpoint.AsString(), status.AsInt64())
+		}
+		if (span.Status().Code == codes.Error) != expected.failed {
+			t.Errorf("%s: unexpected span status %v", span.Name(), span.Status())
+		}
+		if !strings.Contains(traceparents[i], span.SpanContext().TraceID().String()) {
+			t.Errorf("Expected the traceparent of %s to be sent, got %q", span.Name(), traceparents[i])
+		}
+	}
+}
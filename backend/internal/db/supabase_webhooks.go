@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"app/internal/models"
+)
+
+// CreateWebhookSubscription stores a subscription and returns it with its ID
+func (s *SupabaseClient) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	type subscriptionRow struct {
+		URL        string   `json:"url"`
+		Secret     string   `json:"secret"`
+		EventTypes []string `json:"event_types"`
+	}
+
+	row := subscriptionRow{URL: sub.URL, Secret: sub.Secret, EventTypes: sub.EventTypes}
+	if row.EventTypes == nil {
+		row.EventTypes = []string{}
+	}
+
+	var created []models.WebhookSubscription
+	if err := s.post(ctx, "webhook_subscriptions", row, "return=representation", &created); err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	if len(created) == 0 {
+		return nil, fmt.Errorf("failed to create webhook subscription: no row returned")
+	}
+
+	return &created[0], nil
+}
+
+// ListWebhookSubscriptions returns every subscription, oldest first
+func (s *SupabaseClient) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := s.get(ctx, "webhook_subscriptions?order=id", &subs); err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes a subscription together with its delivery log
+func (s *SupabaseClient) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	endpoint := fmt.Sprintf("webhook_subscriptions?id=eq.%d", id)
+
+	var deleted []models.WebhookSubscription
+	if err := s.do(ctx, http.MethodDelete, endpoint, nil, "return=representation", &deleted); err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if len(deleted) == 0 {
+		return fmt.Errorf("webhook subscription %d: %w", id, ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+// EnqueueWebhookDeliveries queues deliveries for sending. A delivery of an event that was
+// already queued for the subscription is skipped.
+func (s *SupabaseClient) EnqueueWebhookDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	type deliveryRow struct {
+		SubscriptionID int64           `json:"subscription_id"`
+		EventID        int64           `json:"event_id"`
+		EventType      string          `json:"event_type"`
+		Payload        json.RawMessage `json:"payload"`
+	}
+
+	rows := make([]deliveryRow, len(deliveries))
+	for i, d := range deliveries {
+		rows[i] = deliveryRow{
+			SubscriptionID: d.SubscriptionID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Payload:        d.Payload,
+		}
+	}
+
+	if err := s.post(ctx, "webhook_deliveries?on_conflict=subscription_id,event_id", rows, "resolution=ignore-duplicates,return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries leases up to limit due deliveries for leaseSeconds and counts the attempt
+func (s *SupabaseClient) ClaimWebhookDeliveries(ctx context.Context, limit, leaseSeconds int) ([]models.WebhookDelivery, error) {
+	args := map[string]interface{}{
+		"p_limit":         limit,
+		"p_lease_seconds": leaseSeconds,
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := s.post(ctx, "rpc/claim_webhook_deliveries", args, "", &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkWebhookDelivered records a delivery the subscriber accepted with statusCode
+func (s *SupabaseClient) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) error {
+	endpoint := fmt.Sprintf("webhook_deliveries?id=eq.%d", id)
+	update := map[string]interface{}{
+		"status":           models.WebhookDeliveryDelivered,
+		"delivered_at":     time.Now().UTC(),
+		"last_status_code": statusCode,
+		"last_error":       nil,
+	}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed records a failed attempt. statusCode is nil when the subscriber did
+// not respond. The delivery is retried at retryAt, or marked failed for good when retryAt
+// is nil.
+func (s *SupabaseClient) MarkWebhookFailed(ctx context.Context, id int64, statusCode *int, lastError string, retryAt *time.Time) error {
+	endpoint := fmt.Sprintf("webhook_deliveries?id=eq.%d", id)
+	update := map[string]interface{}{
+		"status":           models.WebhookDeliveryFailed,
+		"last_status_code": statusCode,
+		"last_error":       lastError,
+	}
+	if retryAt != nil {
+		update["status"] = models.WebhookDeliveryPending
+		update["next_attempt_at"] = retryAt.UTC()
+	}
+
+	if err := s.do(ctx, http.MethodPatch, endpoint, update, "return=minimal", nil); err != nil {
+		return fmt.Errorf("failed to mark webhook failed: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries returns up to limit deliveries of the subscription, newest first
+func (s *SupabaseClient) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	endpoint := fmt.Sprintf("webhook_deliveries?subscription_id=eq.%d&order=id.desc&limit=%d", subscriptionID, limit)
+
+	var deliveries []models.WebhookDelivery
+	if err := s.get(ctx, endpoint, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
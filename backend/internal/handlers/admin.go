@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/api"
+	"app/internal/models"
+	"app/internal/services"
+	"app/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// defaultDeadLetterLimit is how many dead events are listed when no limit is given
+const defaultDeadLetterLimit = 50
+
+// maxDeadLetterLimit caps the number of dead events listed at once
+const maxDeadLetterLimit = 500
+
+// defaultDeliveryLogLimit is how many webhook deliveries are listed when no limit is given
+const defaultDeliveryLogLimit = 50
+
+// AdminAuthMiddleware restricts a route group to requests carrying the admin token as a
+// bearer token, or an API key with the admin scope. Without a configured token only such
+// API keys are accepted. Every admitted request is logged with the credential it used,
+// apart from the name a token holder claims.
+func AdminAuthMiddleware(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if key := requestAPIKey(c); key != nil {
+				if !key.HasScope(models.ScopeAdmin) {
+					return insufficientScope(models.ScopeAdmin)
+				}
+				return admitAdmin(c, next)
+			}
+
+			if token == "" {
+				return api.NewError(http.StatusNotFound, "ADMIN_DISABLED", "Admin endpoints are disabled",
+					"Set ADMIN_TOKEN to enable them")
+			}
+
+			provided, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				return api.NewError(http.StatusUnauthorized, "UNAUTHORIZED", "A valid admin token is required",
+					"Send Authorization: Bearer <ADMIN_TOKEN>")
+			}
+
+			return admitAdmin(c, next)
+		}
+	}
+}
+
+// admitAdmin logs who makes an authenticated admin request and passes it on
+func admitAdmin(c echo.Context, next echo.HandlerFunc) error {
+	principal, claimedUser := adminIdentity(c)
+	logger(c).Info("Admin request", "method", c.Request().Method, "route", c.Path(),
+		"principal", principal, "claimed_user", claimedUser)
+	return next(c)
+}
+
+// AdminHandler handles operational requests for administrators
+type AdminHandler struct {
+	dispatcher *services.EventDispatcher
+	webhooks   *services.WebhookService
+	apiKeys    *services.APIKeyService
+	catalog    *services.CatalogAdminService
+	validator  *utils.Validator
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(dispatcher *services.EventDispatcher, webhooks *services.WebhookService, apiKeys *services.APIKeyService, catalog *services.CatalogAdminService, validator *utils.Validator) *AdminHandler {
+	return &AdminHandler{
+		dispatcher: dispatcher,
+		webhooks:   webhooks,
+		apiKeys:    apiKeys,
+		catalog:    catalog,
+		validator:  validator,
+	}
+}
+
+// GetDeadLetters handles GET /api/admin/events/dead-letter
+func (h *AdminHandler) GetDeadLetters(c echo.Context) error {
+	limit := defaultDeadLetterLimit
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDeadLetterLimit {
+			return api.NewError(http.StatusBadRequest, "INVALID_DEAD_LETTER_QUERY", "Invalid dead-letter query",
+				"limit must be a number between 1 and "+strconv.Itoa(maxDeadLetterLimit))
+		}
+		limit = parsed
+	}
+
+	events, err := h.dispatcher.DeadLetters(c.Request().Context(), limit)
+	if err != nil {
+		return failed(err, "DEAD_LETTER_LOOKUP_FAILED", "Failed to list dead-letter events")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// PostRequeueEvent handles POST /api/admin/events/:id/requeue
+func (h *AdminHandler) PostRequeueEvent(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return api.NewError(http.StatusBadRequest, "INVALID_EVENT_ID", "Invalid event ID", c.Param("id"))
+	}
+
+	event, err := h.dispatcher.Requeue(c.Request().Context(), id)
+	if err != nil {
+		return failed(err, "REQUEUE_FAILED", "Failed to requeue event")
+	}
+
+	logger(c).Info("Requeued event", "event_type", event.EventType, "event_id", event.ID, "aggregate_id", event.AggregateID)
+	return c.JSON(http.StatusOK, event)
+}
+
+// PostWebhook handles POST /api/admin/webhooks
+func (h *AdminHandler) PostWebhook(c echo.Context) error {
+	var req api.CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse webhook request", err)
+	}
+
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("Webhook validation failed", fields)
+	}
+
+	sub, err := h.webhooks.Subscribe(c.Request().Context(), req.URL, req.EventTypes)
+	if err != nil {
+		return failed(err, "WEBHOOK_CREATE_FAILED", "Failed to create webhook subscription")
+	}
+
+	logger(c).Info("Created webhook", "subscription_id", sub.ID, "url", sub.URL)
+	return c.JSON(http.StatusCreated, sub)
+}
+
+// GetWebhooks handles GET /api/admin/webhooks
+func (h *AdminHandler) GetWebhooks(c echo.Context) error {
+	subs, err := h.webhooks.Subscriptions(c.Request().Context())
+	if err != nil {
+		return failed(err, "WEBHOOK_LOOKUP_FAILED", "Failed to list webhook subscriptions")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"webhooks": subs,
+		"count":    len(subs),
+	})
+}
+
+// DeleteWebhook handles DELETE /api/admin/webhooks/:id
+func (h *AdminHandler) DeleteWebhook(c echo.Context) error {
+	id, err := webhookID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhooks.Unsubscribe(c.Request().Context(), id); err != nil {
+		return failed(err, "WEBHOOK_DELETE_FAILED", "Failed to delete webhook subscription")
+	}
+
+	logger(c).Info("Deleted webhook", "subscription_id", id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries handles GET /api/admin/webhooks/:id/deliveries
+func (h *AdminHandler) GetWebhookDeliveries(c echo.Context) error {
+	id, err := webhookID(c)
+	if err != nil {
+		return err
+	}
+
+	limit := defaultDeliveryLogLimit
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDeadLetterLimit {
+			return api.NewError(http.StatusBadRequest, "INVALID_DELIVERY_QUERY", "Invalid delivery log query",
+				"limit must be a number between 1 and "+strconv.Itoa(maxDeadLetterLimit))
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhooks.Deliveries(c.Request().Context(), id, limit)
+	if err != nil {
+		return failed(err, "DELIVERY_LOOKUP_FAILED", "Failed to list webhook deliveries")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// PostAPIKey handles POST /api/admin/api-keys
+func (h *AdminHandler) PostAPIKey(c echo.Context) error {
+	var req api.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse API key request", err)
+	}
+
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("API key validation failed", fields)
+	}
+
+	key, err := h.apiKeys.Create(c.Request().Context(), req.Name, req.Scopes, req.RateLimitPerMinute, req.RateLimitBurst, req.ExpiresAt)
+	if err != nil {
+		return failed(err, "API_KEY_CREATE_FAILED", "Failed to create API key")
+	}
+
+	logger(c).Info("Created API key", "api_key_id", key.ID, "prefix", key.Prefix, "scopes", key.Scopes)
+	return c.JSON(http.StatusCreated, key)
+}
+
+// GetAPIKeys handles GET /api/admin/api-keys
+func (h *AdminHandler) GetAPIKeys(c echo.Context) error {
+	keys, err := h.apiKeys.Keys(c.Request().Context())
+	if err != nil {
+		return failed(err, "API_KEY_LOOKUP_FAILED", "Failed to list API keys")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+// DeleteAPIKey handles DELETE /api/admin/api-keys/:id, revoking the key
+func (h *AdminHandler) DeleteAPIKey(c echo.Context) error {
+	id, err := apiKeyID(c)
+	if err != nil {
+		return err
+	}
+
+	key, err := h.apiKeys.Revoke(c.Request().Context(), id)
+	if err != nil {
+		return failed(err, "API_KEY_REVOKE_FAILED", "Failed to revoke API key")
+	}
+
+	logger(c).Info("Revoked API key", "api_key_id", key.ID, "prefix", key.Prefix)
+	return c.JSON(http.StatusOK, key)
+}
+
+// PostRotateAPIKey handles POST /api/admin/api-keys/:id/rotate
+func (h *AdminHandler) PostRotateAPIKey(c echo.Context) error {
+	id, err := apiKeyID(c)
+	if err != nil {
+		return err
+	}
+
+	var req api.RotateAPIKeyRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse API key rotation request", err)
+		}
+	}
+
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("API key rotation validation failed", fields)
+	}
+
+	var grace *time.Duration
+	if req.GracePeriodSeconds != nil {
+		period := time.Duration(*req.GracePeriodSeconds) * time.Second
+		grace = &period
+	}
+
+	key, err := h.apiKeys.Rotate(c.Request().Context(), id, grace)
+	if err != nil {
+		return failed(err, "API_KEY_ROTATE_FAILED", "Failed to rotate API key")
+	}
+
+	logger(c).Info("Rotated API key", "api_key_id", key.ID, "rotated_from", id, "prefix", key.Prefix)
+	return c.JSON(http.StatusCreated, key)
+}
+
+// apiKeyID parses the :id parameter
+func apiKeyID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, api.NewError(http.StatusBadRequest, "INVALID_API_KEY_ID", "Invalid API key ID", c.Param("id"))
+	}
+	return id, nil
+}
+
+// webhookID parses the :id parameter
+func webhookID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, api.NewError(http.StatusBadRequest, "INVALID_WEBHOOK_ID", "Invalid webhook ID", c.Param("id"))
+	}
+	return id, nil
+}
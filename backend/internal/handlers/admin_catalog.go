@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/api"
+	"app/internal/services"
+	"app/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderAdminUser optionally names the person using the shared admin token, for the
+// catalog audit log. Anyone holding the token can send any name, so it is recorded as
+// unverified; admins that need to be told apart get admin-scoped API keys of their own.
+const HeaderAdminUser = "X-Admin-User"
+
+// maxAdminUserLength caps the X-Admin-User name recorded in the audit log
+const maxAdminUserLength = 100
+
+// defaultCatalogAuditLimit is how many audit entries are listed when no limit is given
+const defaultCatalogAuditLimit = 50
+
+// catalogEntity is a catalog table managed through /api/admin/catalog/:entity, with
+// how its rows are read from request bodies and whether its keys are numeric IDs
+type catalogEntity struct {
+	table      string
+	bind       func(c echo.Context, validator *utils.Validator) (interface{}, error)
+	numericKey bool
+}
+
+// catalogEntities maps the :entity path segments to their tables
+var catalogEntities = map[string]catalogEntity{
+	"mobile-plans":   {services.CatalogMobilePlans, bindCatalogRow[api.MobilePlanRequest], true},
+	"home-plans":     {services.CatalogHomePlans, bindCatalogRow[api.HomePlanRequest], true},
+	"tv-plans":       {services.CatalogTVPlans, bindCatalogRow[api.TVPlanRequest], true},
+	"bundling-rules": {services.CatalogBundlingRules, bindCatalogRow[api.BundlingRuleRequest], true},
+	"coverage":       {services.CatalogCoverage, bindCatalogRow[api.CoverageRequest], false},
+	"campaigns":      {services.CatalogCampaigns, bindCatalogRow[api.CampaignRequest], true},
+}
+
+// bindCatalogRow binds and validates a catalog request body of type T
+func bindCatalogRow[T any](c echo.Context, validator *utils.Validator) (interface{}, error) {
+	var req T
+	if err := c.Bind(&req); err != nil {
+		return nil, api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse catalog entry", err)
+	}
+
+	if fields := validator.ValidateFields(req); fields != nil {
+		return nil, api.ValidationFailed("Catalog entry validation failed", fields)
+	}
+
+	return req, nil
+}
+
+// GetCatalog handles GET /api/admin/catalog, returning the catalog as stored. The at
+// query parameter looks at the catalog in effect at another time, past or scheduled.
+func (h *AdminHandler) GetCatalog(c echo.Context) error {
+	at := time.Now()
+	value, err := catalogTime(c, "at")
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		at = *value
+	}
+
+	catalog, err := h.catalog.Catalog(c.Request().Context(), at)
+	if err != nil {
+		return failed(err, "CATALOG_LOOKUP_FAILED", "Failed to load the catalog")
+	}
+
+	return c.JSON(http.StatusOK, catalog)
+}
+
+// GetCampaigns handles GET /api/admin/catalog/campaigns, listing every campaign. They are
+// not part of the plan catalog.
+func (h *AdminHandler) GetCampaigns(c echo.Context) error {
+	campaigns, err := h.catalog.Campaigns(c.Request().Context())
+	if err != nil {
+		return failed(err, "CAMPAIGN_LOOKUP_FAILED", "Failed to list campaigns")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"campaigns": campaigns,
+		"count":     len(campaigns),
+	})
+}
+
+// GetCatalogAudit handles GET /api/admin/catalog/audit
+func (h *AdminHandler) GetCatalogAudit(c echo.Context) error {
+	var table string
+	if name := c.QueryParam("entity"); name != "" {
+		entity, err := lookupCatalogEntity(name)
+		if err != nil {
+			return err
+		}
+		table = entity.table
+	}
+
+	limit := defaultCatalogAuditLimit
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDeadLetterLimit {
+			return api.NewError(http.StatusBadRequest, "INVALID_AUDIT_QUERY", "Invalid catalog audit query",
+				"limit must be a number between 1 and "+strconv.Itoa(maxDeadLetterLimit))
+		}
+		limit = parsed
+	}
+
+	entries, err := h.catalog.Audit(c.Request().Context(), table, limit)
+	if err != nil {
+		return failed(err, "AUDIT_LOOKUP_FAILED", "Failed to list the catalog audit log")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// PostCatalogEntry handles POST /api/admin/catalog/:entity
+func (h *AdminHandler) PostCatalogEntry(c echo.Context) error {
+	entity, err := lookupCatalogEntity(c.Param("entity"))
+	if err != nil {
+		return err
+	}
+
+	effectiveFrom, err := catalogTime(c, "effective_from")
+	if err != nil {
+		return err
+	}
+
+	row, err := entity.bind(c, h.validator)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.catalog.Create(c.Request().Context(), entity.table, adminActor(c), row, effectiveFrom)
+	if err != nil {
+		return failed(err, "CATALOG_CREATE_FAILED", "Failed to create catalog entry")
+	}
+
+	logger(c).Info("Created catalog entry", "entity", entry.Entity, "entity_key", entry.EntityKey, "actor", entry.Actor)
+	return c.JSON(http.StatusCreated, entry)
+}
+
+// PutCatalogEntry handles PUT /api/admin/catalog/:entity/:id, replacing the entry. Plans
+// and rules get a new version, from the effective_from query parameter when given.
+func (h *AdminHandler) PutCatalogEntry(c echo.Context) error {
+	entity, key, err := catalogEntry(c)
+	if err != nil {
+		return err
+	}
+
+	effectiveFrom, err := catalogTime(c, "effective_from")
+	if err != nil {
+		return err
+	}
+
+	row, err := entity.bind(c, h.validator)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.catalog.Update(c.Request().Context(), entity.table, key, adminActor(c), row, effectiveFrom)
+	if err != nil {
+		return failed(err, "CATALOG_UPDATE_FAILED", "Failed to update catalog entry")
+	}
+
+	logger(c).Info("Updated catalog entry", "entity", entry.Entity, "entity_key", entry.EntityKey, "actor", entry.Actor)
+	return c.JSON(http.StatusOK, entry)
+}
+
+// DeleteCatalogEntry handles DELETE /api/admin/catalog/:entity/:id. Plans and rules are
+// retired, from the effective_from query parameter when given.
+func (h *AdminHandler) DeleteCatalogEntry(c echo.Context) error {
+	entity, key, err := catalogEntry(c)
+	if err != nil {
+		return err
+	}
+
+	effectiveFrom, err := catalogTime(c, "effective_from")
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.catalog.Delete(c.Request().Context(), entity.table, key, adminActor(c), effectiveFrom)
+	if err != nil {
+		return failed(err, "CATALOG_DELETE_FAILED", "Failed to delete catalog entry")
+	}
+
+	logger(c).Info("Deleted catalog entry", "entity", entry.Entity, "entity_key", entry.EntityKey, "actor", entry.Actor)
+	return c.JSON(http.StatusOK, entry)
+}
+
+// lookupCatalogEntity returns the catalog entity named by a path segment
+func lookupCatalogEntity(name string) (catalogEntity, error) {
+	entity, ok := catalogEntities[name]
+	if !ok {
+		names := make([]string, 0, len(catalogEntities))
+		for n := range catalogEntities {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return catalogEntity{}, api.NewError(http.StatusNotFound, "UNKNOWN_CATALOG_ENTITY", "Unknown catalog entity",
+			fmt.Sprintf("%q is not one of %s", name, strings.Join(names, ", ")))
+	}
+	return entity, nil
+}
+
+// catalogEntry parses the :entity and :id parameters
+func catalogEntry(c echo.Context) (catalogEntity, string, error) {
+	entity, err := lookupCatalogEntity(c.Param("entity"))
+	if err != nil {
+		return catalogEntity{}, "", err
+	}
+
+	key := c.Param("id")
+	if _, err := strconv.Atoi(key); entity.numericKey && err != nil {
+		return catalogEntity{}, "", api.NewError(http.StatusBadRequest, "INVALID_CATALOG_ID", "Invalid catalog entry ID", key)
+	}
+	return entity, key, nil
+}
+
+// catalogTime parses the RFC 3339 time in query parameter name, nil when it is not given
+func catalogTime(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, api.NewError(http.StatusBadRequest, "INVALID_CATALOG_TIME", "Invalid catalog time",
+			name+" must be an RFC 3339 time such as 2026-11-01T00:00:00+03:00")
+	}
+	return &t, nil
+}
+
+// adminActor names who makes an admin request, for the audit log: the API key, or the
+// admin token followed by the unverified X-Admin-User its holder sent
+func adminActor(c echo.Context) string {
+	principal, user := adminIdentity(c)
+	if user == "" {
+		return principal
+	}
+	return principal + " (unverified " + HeaderAdminUser + ": " + user + ")"
+}
+
+// adminIdentity returns the credential an admin request was authenticated with, the API
+// key or the admin token, and for the admin token the X-Admin-User name its holder
+// claims, if any. An API key cannot claim a name.
+func adminIdentity(c echo.Context) (principal, claimedUser string) {
+	if key := requestAPIKey(c); key != nil {
+		return fmt.Sprintf("api_key:%d (%s)", key.ID, key.Name), ""
+	}
+
+	user := strings.TrimSpace(c.Request().Header.Get(HeaderAdminUser))
+	if len(user) > maxAdminUserLength {
+		user = user[:maxAdminUserLength]
+	}
+	return "admin_token", user
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/internal/api"
+	"app/internal/models"
+	"app/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// catalogRequest sends a catalog admin request whose validation fails before it reaches
+// the database
+func catalogRequest(method, path, body string) (int, api.ErrorResponse) {
+	handler := NewAdminHandler(nil, nil, nil, nil, utils.NewValidator())
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/catalog/:entity", handler.PostCatalogEntry)
+	e.PUT("/catalog/:entity/:id", handler.PutCatalogEntry)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var resp api.ErrorResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func TestCatalogEntryValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"negative price", http.MethodPost, "/catalog/mobile-plans",
+			`{"plan_name": "Basic", "quota_gb": 10, "quota_min": 500, "monthly_price": -1}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"missing price", http.MethodPost, "/catalog/tv-plans",
+			`{"name": "Sports", "hd_hours_included": 10}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"unknown tech", http.MethodPost, "/catalog/home-plans",
+			`{"name": "Cable 100", "tech": "cable", "down_mbps": 100, "monthly_price": 300}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"discount over 100", http.MethodPut, "/catalog/bundling-rules/3",
+			`{"rule_type": "bundle_discount", "description": "Bundle", "discount_percent": 120, "applies_to": "total"}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"commitment term of 18 months", http.MethodPost, "/catalog/home-plans",
+			`{"name": "Fiber 100", "tech": "fiber", "down_mbps": 100, "monthly_price": 120, "terms": [{"months": 18, "monthly_price": 100}]}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"commitment term twice", http.MethodPut, "/catalog/tv-plans/1",
+			`{"name": "Basic", "hd_hours_included": 30, "monthly_price": 39.90, "terms": [{"months": 12, "monthly_price": 34.90}, {"months": 12, "monthly_price": 29.90}]}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"coverage without city", http.MethodPut, "/catalog/coverage/A1001",
+			`{"district": "Kadikoy", "fiber": true}`, http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"lower case coupon code", http.MethodPost, "/catalog/campaigns",
+			`{"name": "Welcome", "code": "welcome", "discount_type": "percent", "discount_value": 10, "applies_to": "total", "stackable": true, "starts_at": "2026-11-01T00:00:00Z"}`,
+			http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"campaign ending before it starts", http.MethodPut, "/catalog/campaigns/2",
+			`{"name": "Welcome", "discount_type": "fixed", "discount_value": 10, "applies_to": "total", "stackable": false, "starts_at": "2026-11-01T00:00:00Z", "ends_at": "2026-10-01T00:00:00Z"}`,
+			http.StatusBadRequest, "VALIDATION_FAILED"},
+		{"non-numeric campaign ID", http.MethodPut, "/catalog/campaigns/x",
+			`{}`, http.StatusBadRequest, "INVALID_CATALOG_ID"},
+		{"non-numeric plan ID", http.MethodPut, "/catalog/tv-plans/abc",
+			`{}`, http.StatusBadRequest, "INVALID_CATALOG_ID"},
+		{"effective date without time", http.MethodPut, "/catalog/tv-plans/3?effective_from=2026-11-01",
+			`{"name": "Sports", "hd_hours_included": 10, "monthly_price": 99}`, http.StatusBadRequest, "INVALID_CATALOG_TIME"},
+		{"unknown entity", http.MethodPost, "/catalog/users",
+			`{}`, http.StatusNotFound, "UNKNOWN_CATALOG_ENTITY"},
+	}
+
+	for _, tt := range tests {
+		status, resp := catalogRequest(tt.method, tt.path, tt.body)
+		if status != tt.status || resp.Error.Code != tt.code {
+			t.Errorf("%s: expected %d %s, got %d %s", tt.name, tt.status, tt.code, status, resp.Error.Code)
+		}
+	}
+}
+
+func TestAdminActor(t *testing.T) {
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderAdminUser, " ayse ")
+	if actor := adminActor(e.NewContext(req, httptest.NewRecorder())); actor != "admin_token (unverified X-Admin-User: ayse)" {
+		t.Errorf("Expected the admin token and the name its holder claims, got %q", actor)
+	}
+	if actor := adminActor(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())); actor != "admin_token" {
+		t.Errorf("Expected the admin token alone, got %q", actor)
+	}
+
+	// An API key cannot claim to be someone else
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.Set(contextAPIKey, &models.APIKey{ID: 4, Name: "Ops"})
+	if actor := adminActor(c); actor != "api_key:4 (Ops)" {
+		t.Errorf("Expected the API key, got %q", actor)
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"app/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AnalyticsHandler handles business analytics requests
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetCoverageAnalytics handles GET /api/analytics/coverage
+func (h *AnalyticsHandler) GetCoverageAnalytics(c echo.Context) error {
+	report, err := h.analyticsService.CoverageAnalytics(c.Request().Context())
+	if err != nil {
+		return failed(err, "ANALYTICS_FAILED", "Failed to compute coverage analytics")
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
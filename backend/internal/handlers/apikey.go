@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"app/internal/api"
+	"app/internal/models"
+	"app/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderAPIKey carries the API key of partners calling the API server-to-server
+const HeaderAPIKey = "X-API-Key"
+
+// contextAPIKey is the echo.Context key of the authenticated *models.APIKey
+const contextAPIKey = "api_key"
+
+// APIKeyMiddleware authenticates requests carrying an X-API-Key header and stores the key
+// for RequireScope, the admin endpoints and rate limits. Requests without the header go
+// through anonymously, as before API keys; requests with a key that is not valid are
+// rejected with 401 INVALID_API_KEY.
+func APIKeyMiddleware(keys *services.APIKeyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			raw := c.Request().Header.Get(HeaderAPIKey)
+			if raw == "" {
+				return next(c)
+			}
+
+			key, err := keys.Authenticate(c.Request().Context(), raw)
+			if err != nil {
+				return err
+			}
+
+			c.Set(contextAPIKey, key)
+			logger(c).Debug("Authenticated API key", "api_key_id", key.ID)
+			return next(c)
+		}
+	}
+}
+
+// RequireScope rejects requests made with an API key that does not grant scope with 403
+// INSUFFICIENT_SCOPE. API keys are the only authentication of the API, so requests
+// without one are anonymous: they are served, as the frontend's are, unless keyRequired
+// is set, which rejects them with 401 API_KEY_REQUIRED.
+func RequireScope(scope string, keyRequired bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := requestAPIKey(c)
+			if key == nil {
+				if keyRequired {
+					return apiKeyRequired()
+				}
+				return next(c)
+			}
+			if !key.HasScope(scope) {
+				return insufficientScope(scope)
+			}
+			return next(c)
+		}
+	}
+}
+
+// requestAPIKey returns the API key the request was authenticated with, or nil
+func requestAPIKey(c echo.Context) *models.APIKey {
+	key, _ := c.Get(contextAPIKey).(*models.APIKey)
+	return key
+}
+
+// apiKeyRequired is the error for requests without an API key when one is required
+func apiKeyRequired() error {
+	return api.NewError(http.StatusUnauthorized, "API_KEY_REQUIRED", "An API key is required",
+		"Send the key in the "+HeaderAPIKey+" header")
+}
+
+// insufficientScope is the error for API keys without scope
+func insufficientScope(scope string) error {
+	return api.NewError(http.StatusForbidden, "INSUFFICIENT_SCOPE", "The API key does not grant access to this endpoint",
+		"Requires the "+scope+" scope")
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"app/internal/models"
+	"app/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// withAPIKey authenticates every request with key, as APIKeyMiddleware does for a valid
+// X-API-Key header
+func withAPIKey(key *models.APIKey) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if key != nil {
+				c.Set(contextAPIKey, key)
+			}
+			return next(c)
+		}
+	}
+}
+
+// serve sends GET / to a server that runs middleware before answering 204
+func serve(key *models.APIKey, header string, middleware ...echo.MiddlewareFunc) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, append([]echo.MiddlewareFunc{withAPIKey(key)}, middleware...)...)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(echo.HeaderAuthorization, header)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireScope(t *testing.T) {
+	reader := &models.APIKey{ID: 1, Scopes: []string{models.ScopeRecommendationRead}}
+
+	if rec := serve(reader, "", RequireScope(models.ScopeRecommendationRead, false)); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected a key with the scope to be allowed, got %d", rec.Code)
+	}
+	if rec := serve(reader, "", RequireScope(models.ScopeCheckoutWrite, false)); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a key without the scope, got %d", rec.Code)
+	}
+	if rec := serve(nil, "", RequireScope(models.ScopeCheckoutWrite, false)); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected anonymous requests to be served, got %d", rec.Code)
+	}
+	if rec := serve(nil, "", RequireScope(models.ScopeCheckoutWrite, true)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for anonymous requests when a key is required, got %d", rec.Code)
+	}
+	if rec := serve(reader, "", RequireScope(models.ScopeRecommendationRead, true)); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected a key with the scope to be allowed when a key is required, got %d", rec.Code)
+	}
+}
+
+func TestAdminAuthMiddlewareAcceptsAdminKeys(t *testing.T) {
+	token := "0123456789abcdef0123456789abcdef"
+	admin := &models.APIKey{ID: 1, Scopes: []string{models.ScopeAdmin}}
+	partner := &models.APIKey{ID: 2, Scopes: []string{models.ScopeRecommendationRead}}
+
+	tests := []struct {
+		name   string
+		key    *models.APIKey
+		token  string
+		header string
+		want   int
+	}{
+		{"admin token", nil, token, "Bearer " + token, http.StatusNoContent},
+		{"admin key", admin, token, "", http.StatusNoContent},
+		{"admin key without admin token", admin, "", "", http.StatusNoContent},
+		{"partner key", partner, token, "Bearer " + token, http.StatusForbidden},
+		{"no credentials", nil, token, "", http.StatusUnauthorized},
+		{"disabled", nil, "", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := serve(tt.key, tt.header, AdminAuthMiddleware(tt.token)); rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestRateLimitMiddlewareUsesAPIKeyLimit(t *testing.T) {
+	limiter := services.NewRateLimiter(services.NewMemoryRateLimitStore(), map[string]services.RateLimit{
+		services.RateLimitAPI: {PerMinute: 60, Burst: 1},
+	})
+	perMinute, burst := 600, 3
+	key := &models.APIKey{ID: 7, RateLimitPerMinute: &perMinute, RateLimitBurst: &burst}
+
+	limit := RateLimitMiddleware(limiter, services.RateLimitAPI, ClientKey)
+	for i := 0; i < 3; i++ {
+		rec := serve(key, "", limit)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("Request %d: expected the key's burst of 3 to apply, got %d", i+1, rec.Code)
+		}
+		if rec.Header().Get(HeaderRateLimitLimit) != "600" {
+			t.Errorf("Expected X-RateLimit-Limit 600, got %q", rec.Header().Get(HeaderRateLimitLimit))
+		}
+	}
+	if rec := serve(key, "", limit); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 once the key's burst is used, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/payments"
+	"app/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// errorMapping is the API error for errors matching err with errors.Is. details, when
+// set, builds the error's details from the request and the error.
+type errorMapping struct {
+	err     error
+	status  int
+	code    string
+	message string
+	details func(c echo.Context, err error) []string
+}
+
+// errorMappings maps service, database and payment errors to API errors. The first match
+// wins, so specific errors come before the error kinds they belong to.
+var errorMappings = []errorMapping{
+	{db.ErrOrderNotFound, http.StatusNotFound, "ORDER_NOT_FOUND", "Order not found", param("id")},
+	{db.ErrCoverageNotFound, http.StatusNotFound, "COVERAGE_NOT_FOUND", "Coverage information not found for the specified address", param("address_id")},
+	{db.ErrUserNotFound, http.StatusNotFound, "USER_NOT_FOUND", "User not found", nil},
+	{services.ErrInvalidQuote, http.StatusBadRequest, "INVALID_QUOTE", "The quote ID is not valid", errorText},
+	{db.ErrQuoteNotFound, http.StatusNotFound, "QUOTE_NOT_FOUND", "Quote not found", fixed("Request a new recommendation to get a quote")},
+	{services.ErrQuoteExpired, http.StatusGone, "QUOTE_EXPIRED", "The quote has expired", fixed("Request a new recommendation to get current prices")},
+	{services.ErrQuoteStale, http.StatusConflict, "QUOTE_STALE", "Prices have changed since the quote was issued", fixed("Request a new recommendation to get current prices")},
+	{services.ErrInvalidCoupon, http.StatusBadRequest, "INVALID_COUPON", "The coupon code is not valid", errorText},
+	{services.ErrCouponNotApplicable, http.StatusBadRequest, "COUPON_NOT_APPLICABLE", "The coupon does not apply to this offer", errorText},
+	{payments.ErrInvalidCard, http.StatusBadRequest, "INVALID_CARD", "The card details are not valid", nil},
+	{payments.ErrDeclined, http.StatusPaymentRequired, "PAYMENT_DECLINED", "The payment was declined", fixed("The order was cancelled and the install slot released")},
+	{payments.ErrAuthenticationRequired, http.StatusPaymentRequired, "PAYMENT_AUTHENTICATION_REQUIRED", "The card requires 3-D Secure authentication, which is not supported yet", fixed("The order was cancelled and the install slot released")},
+	{payments.ErrTimeout, http.StatusGatewayTimeout, "PAYMENT_TIMEOUT", "The payment provider did not respond in time", fixed("Please retry the checkout")},
+	{services.ErrNoAppointment, http.StatusNotFound, "APPOINTMENT_NOT_FOUND", "The order has no installation appointment", param("id")},
+	{db.ErrSlotNotFound, http.StatusNotFound, "APPOINTMENT_NOT_FOUND", "The order has no installation appointment", param("id")},
+	{db.ErrSlotUnavailable, http.StatusConflict, "SLOT_UNAVAILABLE", "The selected install slot is not available", errorText},
+	{db.ErrOrderNotModifiable, http.StatusConflict, "ORDER_NOT_MODIFIABLE", "The order can no longer be changed", errorText},
+	{services.ErrInvalidSlotSearch, http.StatusBadRequest, "INVALID_SLOT_QUERY", "Invalid install slot query", errorText},
+	{db.ErrEventNotFound, http.StatusNotFound, "EVENT_NOT_FOUND", "Dead-letter event not found", fixed("Only dead events can be requeued")},
+	{db.ErrWebhookNotFound, http.StatusNotFound, "WEBHOOK_NOT_FOUND", "Webhook subscription not found", nil},
+	{services.ErrInvalidAPIKey, http.StatusUnauthorized, "INVALID_API_KEY", "The API key is not valid", errorText},
+	{services.ErrInvalidAPIKeyRequest, http.StatusBadRequest, "INVALID_API_KEY_REQUEST", "Invalid API key request", errorText},
+	{services.ErrAPIKeyRevoked, http.StatusConflict, "API_KEY_REVOKED", "The API key is revoked", fixed("Create a new key instead of rotating a revoked one")},
+	{db.ErrAPIKeyNotFound, http.StatusNotFound, "API_KEY_NOT_FOUND", "API key not found", param("id")},
+	{db.ErrCatalogEntryNotFound, http.StatusNotFound, "CATALOG_ENTRY_NOT_FOUND", "Catalog entry not found", param("id")},
+	{db.ErrCatalogEntryConflict, http.StatusConflict, "CATALOG_ENTRY_CONFLICT", "The catalog entry conflicts with existing data", errorText},
+	{db.ErrInvalidCatalogEntry, http.StatusBadRequest, "INVALID_CATALOG_ENTRY", "Invalid catalog entry", errorText},
+	{services.ErrIdempotencyKeyReused, http.StatusConflict, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used with a different request body", header(HeaderIdempotencyKey)},
+	{services.ErrIdempotencyKeyInProgress, http.StatusConflict, "IDEMPOTENCY_KEY_IN_PROGRESS", "A request with this Idempotency-Key is still being processed", header(HeaderIdempotencyKey)},
+
+	// Error kinds, for errors without a specific mapping
+	{services.ErrNotFound, http.StatusNotFound, "NOT_FOUND", "The requested resource was not found", nil},
+	{services.ErrInvalidInput, http.StatusBadRequest, "INVALID_INPUT", "The request is not valid", errorText},
+	{services.ErrConflict, http.StatusConflict, "CONFLICT", "The request conflicts with the current state", errorText},
+	{services.ErrUnauthorized, http.StatusUnauthorized, "UNAUTHORIZED", "The request is not authenticated", errorText},
+	{services.ErrUnavailable, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "The service is temporarily unavailable", fixed("Please retry shortly")},
+}
+
+// param returns the path parameter as details, if present
+func param(name string) func(echo.Context, error) []string {
+	return func(c echo.Context, _ error) []string {
+		if value := c.Param(name); value != "" {
+			return []string{value}
+		}
+		return nil
+	}
+}
+
+// header returns the request header as details, if present
+func header(name string) func(echo.Context, error) []string {
+	return func(c echo.Context, _ error) []string {
+		if value := c.Request().Header.Get(name); value != "" {
+			return []string{value}
+		}
+		return nil
+	}
+}
+
+// fixed returns the same details for every error
+func fixed(details ...string) func(echo.Context, error) []string {
+	return func(echo.Context, error) []string {
+		return details
+	}
+}
+
+// errorText returns the error message as details
+func errorText(_ echo.Context, err error) []string {
+	return []string{err.Error()}
+}
+
+// failure is an unexpected error of an operation. It carries the code and message
+// reported when the error has no mapping of its own, in place of the generic 500.
+type failure struct {
+	err     error
+	code    string
+	message string
+}
+
+// failed wraps err with the code and message reported when err is not a known error
+func failed(err error, code, message string) error {
+	return &failure{err: err, code: code, message: message}
+}
+
+func (f *failure) Error() string {
+	return f.err.Error()
+}
+
+func (f *failure) Unwrap() error {
+	return f.err
+}
+
+// HTTPErrorHandler writes every error returned by a handler or middleware as an
+// api.ErrorResponse carrying the request ID. API errors are written as they are, known
+// service errors with their mapped status and code, Echo errors (unknown routes, bad
+// methods) with their status, and everything else as a 500 that is logged.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	apiErr := toAPIError(c, err)
+	apiErr.Detail.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		logger(c).Error("Request failed", "method", c.Request().Method, "route", c.Path(), "error", err)
+	}
+
+	var writeErr error
+	if c.Request().Method == http.MethodHead {
+		writeErr = c.NoContent(apiErr.Status)
+	} else {
+		writeErr = c.JSON(apiErr.Status, api.ErrorResponse{Error: apiErr.Detail})
+	}
+	if writeErr != nil {
+		logger(c).Error("Failed to write error response", "error", writeErr)
+	}
+}
+
+// toAPIError converts err into the API error to write
+func toAPIError(c echo.Context, err error) *api.Error {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			mapped := api.NewError(m.status, m.code, m.message).WithCause(err)
+			if m.details != nil {
+				mapped.Detail.Details = m.details(c, err)
+			}
+			return mapped
+		}
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return api.NewError(httpErr.Code, statusCode(httpErr.Code), fmt.Sprint(httpErr.Message)).WithCause(err)
+	}
+
+	var f *failure
+	if errors.As(err, &f) {
+		return api.NewError(http.StatusInternalServerError, f.code, f.message).WithCause(err)
+	}
+
+	return api.NewError(http.StatusInternalServerError, "INTERNAL_ERROR", "An internal error occurred").WithCause(err)
+}
+
+// statusCode returns the error code for an HTTP status, e.g. METHOD_NOT_ALLOWED
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "HTTP_ERROR"
+	}
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_").Replace(text))
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"app/internal/api"
+	"app/internal/db"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		details []string
+	}{
+		{
+			name:    "specific error",
+			err:     fmt.Errorf("failed to load order: %w", db.ErrOrderNotFound),
+			status:  http.StatusNotFound,
+			code:    "ORDER_NOT_FOUND",
+			details: []string{"ORD-1"},
+		},
+		{
+			name:   "error kind",
+			err:    db.NewError(db.ErrConflict, "already shipped"),
+			status: http.StatusConflict,
+			code:   "CONFLICT",
+		},
+		{
+			name:   "unavailable database",
+			err:    failed(fmt.Errorf("%w: connection refused", db.ErrUnavailable), "ORDER_FAILED", "Failed to process the order"),
+			status: http.StatusServiceUnavailable,
+			code:   "SERVICE_UNAVAILABLE",
+		},
+		{
+			name:   "failed operation",
+			err:    failed(errors.New("boom"), "ORDER_FAILED", "Failed to process the order"),
+			status: http.StatusInternalServerError,
+			code:   "ORDER_FAILED",
+		},
+		{
+			name:   "api error",
+			err:    api.NewError(http.StatusUnauthorized, "UNAUTHORIZED", "A valid admin token is required"),
+			status: http.StatusUnauthorized,
+			code:   "UNAUTHORIZED",
+		},
+		{
+			name:   "echo error",
+			err:    echo.ErrMethodNotAllowed,
+			status: http.StatusMethodNotAllowed,
+			code:   "METHOD_NOT_ALLOWED",
+		},
+		{
+			name:   "unknown error",
+			err:    errors.New("boom"),
+			status: http.StatusInternalServerError,
+			code:   "INTERNAL_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = HTTPErrorHandler
+			e.Use(middleware.RequestID())
+			e.GET("/api/orders/:id", func(echo.Context) error { return tt.err })
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/ORD-1", nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+
+			var body api.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Expected a JSON error body, got %q", rec.Body.String())
+			}
+			if body.Error.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, body.Error.Code)
+			}
+			if tt.details != nil && fmt.Sprint(body.Error.Details) != fmt.Sprint(tt.details) {
+				t.Errorf("Expected details %v, got %v", tt.details, body.Error.Details)
+			}
+			if body.Error.RequestID == "" || body.Error.RequestID != rec.Header().Get(echo.HeaderXRequestID) {
+				t.Errorf("Expected the X-Request-ID %q in the body, got %q", rec.Header().Get(echo.HeaderXRequestID), body.Error.RequestID)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorHandlerValidationFields(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/api/recommendation", func(echo.Context) error {
+		return api.ValidationFailed("Request validation failed", []api.FieldError{
+			{Field: "household[1].expected_gb", Rule: "min", Message: "expected_gb must be at least 0"},
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/recommendation", nil))
+
+	var body api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON error body, got %q", rec.Body.String())
+	}
+	if rec.Code != http.StatusBadRequest || body.Error.Code != "VALIDATION_FAILED" {
+		t.Fatalf("Expected 400 VALIDATION_FAILED, got %d %s", rec.Code, body.Error.Code)
+	}
+	if len(body.Error.Fields) != 1 || body.Error.Fields[0].Field != "household[1].expected_gb" || body.Error.Fields[0].Rule != "min" {
+		t.Errorf("Expected the invalid field in the body, got %+v", body.Error.Fields)
+	}
+}
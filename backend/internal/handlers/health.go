@@ -2,30 +2,80 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
-	"app/internal/db"
+	"app/internal/buildinfo"
+	"app/internal/services"
+	"app/internal/tracing"
 
 	"github.com/labstack/echo/v4"
 )
 
-// HealthHandler handles health check requests
+// HealthHandler handles liveness, readiness and health detail requests
 type HealthHandler struct {
-	db db.DatabaseInterface
+	health    *services.HealthService
+	startedAt time.Time
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(database db.DatabaseInterface) *HealthHandler {
+func NewHealthHandler(health *services.HealthService) *HealthHandler {
 	return &HealthHandler{
-		db: database,
+		health:    health,
+		startedAt: time.Now(),
 	}
 }
 
-// GetHealth handles GET /health
+// GetLivez handles GET /livez. It only reports that the process serves requests and
+// never checks dependencies, so a database outage does not get the pod restarted.
+func (h *HealthHandler) GetLivez(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": "ok",
+	})
+}
+
+// GetReadyz handles GET /readyz, answering 503 until the database is reachable, the
+// catalog is loaded and the schema is migrated
+func (h *HealthHandler) GetReadyz(c echo.Context) error {
+	report := h.health.Check(c.Request().Context())
+
+	checks := make(map[string]string, len(report.Dependencies))
+	for name, dependency := range report.Dependencies {
+		checks[name] = "ok"
+		if !dependency.Healthy {
+			checks[name] = "failed"
+		}
+	}
+
+	status, code := "ready", http.StatusOK
+	if !report.Ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	return c.JSON(code, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// GetHealthDetails handles GET /health/details with the latency and the time of the last
+// error of every dependency, and the build the server runs. Error texts are only logged.
+func (h *HealthHandler) GetHealthDetails(c echo.Context) error {
+	report := h.health.Check(c.Request().Context())
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"service":        tracing.ServiceName,
+		"build":          buildinfo.Get(),
+		"uptime_seconds": int(time.Since(h.startedAt).Seconds()),
+		"ready":          report.Ready,
+		"dependencies":   report.Dependencies,
+	})
+}
+
+// GetHealth handles GET /health, kept for existing monitors. It reports the database
+// check of the readiness report.
 func (h *HealthHandler) GetHealth(c echo.Context) error {
-	ctx := c.Request().Context()
+	report := h.health.Check(c.Request().Context())
 
-	// Check database connectivity
-	if err := h.db.Health(ctx); err != nil {
+	if !report.Dependencies[services.CheckDatabase].Healthy {
 		return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
 			"status":   "error",
 			"database": "unhealthy",
@@ -36,7 +86,7 @@ func (h *HealthHandler) GetHealth(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"status":   "ok",
 		"database": "connected",
-		"service":  "recommendation-api",
-		"version":  "1.0.0",
+		"service":  tracing.ServiceName,
+		"version":  buildinfo.Get().Version,
 	})
 }
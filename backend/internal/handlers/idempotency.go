@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"app/internal/api"
+	"app/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderIdempotencyKey is the request header carrying the client's idempotency key
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed marks responses replayed from a stored idempotency key
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+// responseRecorder copies everything written to the response into a buffer
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware makes a route safe to retry. Requests with an Idempotency-Key
+// header are processed once; retries with the same key and body get the stored response
+// back, while the same key with a different body is rejected with 409. Server errors are
+// not stored, so the request can be retried with the same key. Requests without the
+// header are processed as usual. Keys are scoped to the client sending them as ClientKey
+// identifies it, its API key or else its IP address, so clients choosing the same key do
+// not see each other's responses.
+func IdempotencyMiddleware(idempotency *services.IdempotencyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(HeaderIdempotencyKey)
+			if header == "" {
+				return next(c)
+			}
+
+			if len(header) > services.MaxIdempotencyKeyLength {
+				return api.NewError(http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY", "Idempotency-Key header is too long",
+					"Keys may be at most 255 characters")
+			}
+
+			// Read the body for hashing and put it back for the handler. The body is
+			// limited to MaxRequestBodySize by SetupRoutes.
+			body, err := io.ReadAll(c.Request().Body)
+			if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+				return err
+			}
+			if err != nil {
+				return api.BadRequest("INVALID_REQUEST_BODY", "Failed to read request body", err)
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			key := ClientKey(c) + ":" + header
+			hash := services.HashRequest(c.Request().Method, c.Path(), body)
+			record, err := idempotency.Begin(c.Request().Context(), key, hash)
+			switch {
+			case err != nil:
+				return failed(err, "IDEMPOTENCY_FAILED", "Failed to check the Idempotency-Key")
+			case record != nil:
+				c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+				return c.Blob(*record.StatusCode, echo.MIMEApplicationJSONCharsetUTF8, []byte(*record.ResponseBody))
+			}
+
+			// Store the response even if the client went away before it was written
+			storeCtx := context.WithoutCancel(c.Request().Context())
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			completed := false
+			defer func() {
+				if !completed {
+					if err := idempotency.Abandon(storeCtx, key); err != nil {
+						logger(c).Error("Failed to release idempotency key", "key", key, "error", err)
+					}
+				}
+			}()
+
+			// Write handler errors here so error responses are recorded like any other
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			if status := c.Response().Status; status < http.StatusInternalServerError {
+				// Keep the key even if storing fails: retries then see it as in progress
+				// until it expires instead of repeating a request that already succeeded
+				completed = true
+				if err := idempotency.Complete(storeCtx, key, status, recorder.body.Bytes()); err != nil {
+					logger(c).Error("Failed to store idempotent response", "key", key, "error", err)
+				}
+			}
+
+			return nil
+		}
+	}
+}
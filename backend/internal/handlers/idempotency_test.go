@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// idempotencyDB keeps idempotency keys in memory
+type idempotencyDB struct {
+	db.DatabaseInterface
+	keys map[string]*models.IdempotencyRecord
+}
+
+func (d *idempotencyDB) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, ttlSeconds int) (*models.IdempotencyRecord, bool, error) {
+	if existing, ok := d.keys[key]; ok {
+		return existing, false, nil
+	}
+	record := &models.IdempotencyRecord{Key: key, RequestHash: requestHash, ExpiresAt: time.Now().Add(time.Duration(ttlSeconds) * time.Second)}
+	d.keys[key] = record
+	return record, true, nil
+}
+
+func (d *idempotencyDB) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, body string) error {
+	d.keys[key].StatusCode = &statusCode
+	d.keys[key].ResponseBody = &body
+	return nil
+}
+
+func (d *idempotencyDB) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	delete(d.keys, key)
+	return nil
+}
+
+// checkoutBody is a checkout request for the quote
+func checkoutBody(quoteID string) string {
+	return `{"quote_id": "` + quoteID + `", "slot_id": "C1-fiber-20260302T0600Z",
+		"payment": {"card_number": "4242424242424242", "expiry_month": 12, "expiry_year": 2030, "cvc": "123"}}`
+}
+
+// idempotentServer serves POST /checkout behind IdempotencyMiddleware for requests made
+// with apiKey, if any. The handler numbers the orders it creates, and fails while fail
+// is set.
+type idempotentServer struct {
+	*echo.Echo
+	orders int
+	fail   bool
+}
+
+func newIdempotentServer(idempotency *services.IdempotencyService, apiKey *models.APIKey) *idempotentServer {
+	s := &idempotentServer{Echo: echo.New()}
+	s.HTTPErrorHandler = HTTPErrorHandler
+	s.IPExtractor = echo.ExtractIPDirect()
+	s.Use(middleware.BodyLimit(MaxRequestBodySize))
+	s.POST("/checkout", func(c echo.Context) error {
+		var req api.CheckoutRequest
+		if err := c.Bind(&req); err != nil {
+			return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse checkout request", err)
+		}
+		if s.fail {
+			return errors.New("connection reset")
+		}
+		s.orders++
+		return c.JSON(http.StatusCreated, map[string]string{"order_id": fmt.Sprintf("ORD-%d", s.orders), "quote_id": req.QuoteID})
+	}, withAPIKey(apiKey), IdempotencyMiddleware(idempotency))
+	return s
+}
+
+// checkout sends body from ip with the Idempotency-Key key, without a Content-Length so
+// the body limit applies while the body is read
+func (s *idempotentServer) checkout(ip, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(HeaderIdempotencyKey, key)
+	req.RemoteAddr = ip + ":40000"
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddlewareReplaysResponses(t *testing.T) {
+	database := &idempotencyDB{keys: make(map[string]*models.IdempotencyRecord)}
+	server := newIdempotentServer(services.NewIdempotencyService(database, time.Hour), nil)
+
+	first := server.checkout("203.0.113.7", "retry-1", checkoutBody("QT-1"))
+	retry := server.checkout("203.0.113.7", "retry-1", checkoutBody("QT-1"))
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected the retry to replay %d %s, got %d %s", first.Code, first.Body, retry.Code, retry.Body)
+	}
+	if retry.Header().Get(HeaderIdempotentReplayed) != "true" || server.orders != 1 {
+		t.Errorf("Expected one order and a replayed retry, got %d orders", server.orders)
+	}
+
+	// The same key with another body is rejected
+	var resp api.ErrorResponse
+	rec := server.checkout("203.0.113.7", "retry-1", checkoutBody("QT-2"))
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusConflict || resp.Error.Code != "IDEMPOTENCY_KEY_REUSED" {
+		t.Errorf("Expected 409 IDEMPOTENCY_KEY_REUSED, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestIdempotencyMiddlewareAbandonsKeysOnServerErrors(t *testing.T) {
+	database := &idempotencyDB{keys: make(map[string]*models.IdempotencyRecord)}
+	server := newIdempotentServer(services.NewIdempotencyService(database, time.Hour), nil)
+
+	server.fail = true
+	if rec := server.checkout("203.0.113.7", "retry-1", checkoutBody("QT-1")); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected the first attempt to fail, got %d", rec.Code)
+	}
+	if len(database.keys) != 0 {
+		t.Errorf("Expected the key to be released after a server error, got %d keys", len(database.keys))
+	}
+
+	server.fail = false
+	if rec := server.checkout("203.0.113.7", "retry-1", checkoutBody("QT-1")); rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("Expected the retry to be processed, got %d", rec.Code)
+	}
+}
+
+func TestIdempotencyMiddlewareScopesKeysPerClient(t *testing.T) {
+	database := &idempotencyDB{keys: make(map[string]*models.IdempotencyRecord)}
+	idempotency := services.NewIdempotencyService(database, time.Hour)
+	partnerA := newIdempotentServer(idempotency, &models.APIKey{ID: 1})
+	partnerB := newIdempotentServer(idempotency, &models.APIKey{ID: 2})
+	anonymous := newIdempotentServer(idempotency, nil)
+
+	// Partners with the same key and body each get their own order, even from one address
+	partnerA.checkout("203.0.113.7", "order-1", checkoutBody("QT-1"))
+	if rec := partnerB.checkout("203.0.113.7", "order-1", checkoutBody("QT-1")); rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("Expected another API key's request to be processed, got %d replayed=%q", rec.Code, rec.Header().Get(HeaderIdempotentReplayed))
+	}
+
+	// So do keyless clients at different addresses, with the same key and the same body
+	anonymous.checkout("198.51.100.4", "order-1", checkoutBody("QT-1"))
+	rec := anonymous.checkout("198.51.100.5", "order-1", checkoutBody("QT-1"))
+	if rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("Expected another keyless client's request to be processed, got %d replayed=%q", rec.Code, rec.Header().Get(HeaderIdempotentReplayed))
+	}
+	if partnerB.orders != 1 || anonymous.orders != 2 || len(database.keys) != 4 {
+		t.Errorf("Expected every client to create its own order and record, got %d and %d orders, %d records",
+			partnerB.orders, anonymous.orders, len(database.keys))
+	}
+}
+
+func TestIdempotencyMiddlewareRejectsOversizedBodies(t *testing.T) {
+	database := &idempotencyDB{keys: make(map[string]*models.IdempotencyRecord)}
+	server := newIdempotentServer(services.NewIdempotencyService(database, time.Hour), nil)
+
+	rec := server.checkout("203.0.113.7", "retry-1", `{"quote_id": "QT-1", "padding": "`+strings.Repeat("x", 2<<20)+`"}`)
+	if rec.Code != http.StatusRequestEntityTooLarge || len(database.keys) != 0 || server.orders != 0 {
+		t.Errorf("Expected 413 without claiming the key, got %d with %d keys", rec.Code, len(database.keys))
+	}
+}
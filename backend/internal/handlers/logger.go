@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"app/internal/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// logger returns the logger of the request for the handlers package, carrying its
+// request ID
+func logger(c echo.Context) *slog.Logger {
+	return logging.For(c.Request().Context(), "handlers")
+}
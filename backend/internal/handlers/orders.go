@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/services"
+	"app/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OrderHandler handles checkout and installation appointment requests
+type OrderHandler struct {
+	orderService *services.OrderService
+	validator    *utils.Validator
+}
+
+// NewOrderHandler creates a new order handler
+func NewOrderHandler(orderService *services.OrderService, validator *utils.Validator) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+		validator:    validator,
+	}
+}
+
+// PostCheckout handles POST /api/checkout
+func (h *OrderHandler) PostCheckout(c echo.Context) error {
+	// Parse request body
+	var req api.CheckoutRequest
+	if err := c.Bind(&req); err != nil {
+		return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse checkout request", err)
+	}
+
+	// Validate request
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("Checkout validation failed", fields)
+	}
+
+	// Create the order and book the install slot
+	order, err := h.orderService.PlaceOrder(c.Request().Context(), &req)
+	if err != nil {
+		return h.orderError(err)
+	}
+
+	logger(c).Info("Checkout completed", "user_id", order.UserID, "order_id", order.OrderID, "quote_id", req.QuoteID)
+
+	paymentStatus := ""
+	if order.PaymentStatus != nil {
+		paymentStatus = *order.PaymentStatus
+	}
+
+	return c.JSON(http.StatusOK, api.CheckoutResponse{
+		Status:        "success",
+		OrderID:       order.OrderID,
+		UpfrontAmount: order.UpfrontAmount,
+		PaymentStatus: paymentStatus,
+	})
+}
+
+// PostReschedule handles POST /api/orders/:id/reschedule
+func (h *OrderHandler) PostReschedule(c echo.Context) error {
+	orderID := c.Param("id")
+
+	var req api.RescheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse reschedule request", err)
+	}
+
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("Reschedule validation failed", fields)
+	}
+
+	order, err := h.orderService.Reschedule(c.Request().Context(), orderID, &req)
+	if err != nil {
+		return h.orderError(err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// PostCancel handles POST /api/orders/:id/cancel
+func (h *OrderHandler) PostCancel(c echo.Context) error {
+	orderID := c.Param("id")
+
+	// The body is optional; an empty body cancels without a reason
+	var req api.CancelOrderRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse cancel request", err)
+		}
+	}
+
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("Cancel validation failed", fields)
+	}
+
+	order, err := h.orderService.Cancel(c.Request().Context(), orderID, &req)
+	if err != nil {
+		return h.orderError(err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// GetAppointmentICS handles GET /api/orders/:id/appointment.ics
+func (h *OrderHandler) GetAppointmentICS(c echo.Context) error {
+	orderID := c.Param("id")
+
+	ics, err := h.orderService.AppointmentCalendar(c.Request().Context(), orderID)
+	if err != nil {
+		return h.orderError(err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+orderID+`-appointment.ics"`)
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", ics)
+}
+
+// orderError returns the API error for a failed order operation. Errors with a mapping of
+// their own are left to HTTPErrorHandler; the change window error carries the cutoff.
+func (h *OrderHandler) orderError(err error) error {
+	if errors.Is(err, db.ErrChangeWindowClosed) {
+		return api.NewError(http.StatusUnprocessableEntity, "CHANGE_WINDOW_CLOSED",
+			"Appointments cannot be changed this close to the installation",
+			"Changes are accepted until "+h.orderService.RescheduleCutoff().String()+" before the slot starts",
+		).WithCause(err)
+	}
+
+	return failed(err, "ORDER_FAILED", "Failed to process the order")
+}
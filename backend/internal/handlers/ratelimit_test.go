@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/internal/api"
+	"app/internal/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// newRateLimitedServer serves POST /recommendation behind the body limit and the
+// recommendation limits, echoing the request body
+func newRateLimitedServer(limits map[string]services.RateLimit) *echo.Echo {
+	limiter := services.NewRateLimiter(services.NewMemoryRateLimitStore(), limits)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.Use(middleware.BodyLimit(MaxRequestBodySize))
+	e.POST("/recommendation", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, body)
+	},
+		RateLimitMiddleware(limiter, services.RateLimitRecommendation, ClientKey),
+		RateLimitMiddleware(limiter, services.RateLimitRecommendationUser, UserKey),
+	)
+	return e
+}
+
+// post sends a recommendation request for userID from ip
+func post(e *echo.Echo, ip, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/recommendation", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.RemoteAddr = ip + ":40000"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitMiddlewareBurstPerClient(t *testing.T) {
+	e := newRateLimitedServer(map[string]services.RateLimit{
+		services.RateLimitRecommendation: {PerMinute: 6, Burst: 3},
+	})
+
+	var statuses []int
+	for i := 0; i < 5; i++ {
+		statuses = append(statuses, post(e, "203.0.113.7", `{"user_id": 1}`).Code)
+	}
+	want := []int{200, 200, 200, 429, 429}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("Expected statuses %v for a burst of 5, got %v", want, statuses)
+		}
+	}
+
+	rec := post(e, "203.0.113.7", `{"user_id": 1}`)
+	if rec.Header().Get(HeaderRetryAfter) != "10" {
+		t.Errorf("Expected Retry-After 10 at 6 requests per minute, got %q", rec.Header().Get(HeaderRetryAfter))
+	}
+	if rec.Header().Get(HeaderRateLimitLimit) != "6" || rec.Header().Get(HeaderRateLimitRemaining) != "0" {
+		t.Errorf("Unexpected rate limit headers: %v", rec.Header())
+	}
+	var body api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != "RATE_LIMITED" {
+		t.Errorf("Expected a RATE_LIMITED error, got %s", rec.Body.String())
+	}
+
+	// Another client is not affected by the burst
+	if rec := post(e, "198.51.100.4", `{"user_id": 1}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected another client to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewarePerUserAcrossClients(t *testing.T) {
+	e := newRateLimitedServer(map[string]services.RateLimit{
+		services.RateLimitRecommendationUser: {PerMinute: 60, Burst: 2},
+	})
+
+	// The same user requested from many addresses is limited once its bucket is empty
+	for i, ip := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		rec := post(e, ip, `{"user_id": 42, "address_id": "A1001"}`)
+		if want := map[bool]int{true: 200, false: 429}[i < 2]; rec.Code != want {
+			t.Errorf("Request %d: expected %d, got %d", i+1, want, rec.Code)
+		}
+		if rec.Code == http.StatusOK && !strings.Contains(rec.Body.String(), "A1001") {
+			t.Errorf("Expected the handler to read the body, got %q", rec.Body.String())
+		}
+	}
+
+	// Other users and bodies without a user_id are not limited by it
+	if rec := post(e, "203.0.113.1", `{"user_id": 43}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected another user to be allowed, got %d", rec.Code)
+	}
+	if rec := post(e, "203.0.113.1", `{}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected a body without user_id to reach the handler, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewareRejectsOversizedBodies(t *testing.T) {
+	e := newRateLimitedServer(map[string]services.RateLimit{
+		services.RateLimitRecommendationUser: {PerMinute: 60, Burst: 1},
+	})
+
+	// Without a Content-Length the limit applies while UserKey reads the body, which
+	// stops at the limit; the handler then fails to read it too
+	body := `{"user_id": 42, "padding": "` + strings.Repeat("x", 2<<20) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/recommendation", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a 2 MB body, got %d", rec.Code)
+	}
+}
@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"app/internal/api"
 	"app/internal/services"
@@ -29,39 +33,19 @@ func (h *RecommendationHandler) GetRecommendations(c echo.Context) error {
 	// Parse request body
 	var req api.RecommendationRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "INVALID_REQUEST_BODY",
-				Message: "Failed to parse request body",
-				Details: []string{err.Error()},
-			},
-		})
+		return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse request body", err)
 	}
 
 	// Validate request
-	if validationErrors := h.validator.ValidateStruct(req); validationErrors != nil {
-		return c.JSON(http.StatusBadRequest, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "VALIDATION_FAILED",
-				Message: "Request validation failed",
-				Details: validationErrors,
-			},
-		})
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("Request validation failed", fields)
 	}
 
-	// Process recommendation request
+	// Process recommendation request; unknown users and addresses are 404s, an
+	// unreachable database a 503
 	response, err := h.recommendationService.ProcessRecommendationRequest(c.Request().Context(), &req)
 	if err != nil {
-		// Log error for debugging
-		c.Logger().Errorf("Recommendation processing failed: %v", err)
-
-		return c.JSON(http.StatusInternalServerError, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "RECOMMENDATION_FAILED",
-				Message: "Failed to generate recommendations",
-				Details: []string{"An internal error occurred while processing your request"},
-			},
-		})
+		return failed(err, "RECOMMENDATION_FAILED", "Failed to generate recommendations")
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -71,108 +55,135 @@ func (h *RecommendationHandler) GetRecommendations(c echo.Context) error {
 func (h *RecommendationHandler) GetCoverage(c echo.Context) error {
 	addressID := c.Param("address_id")
 	if addressID == "" {
-		return c.JSON(http.StatusBadRequest, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "MISSING_ADDRESS_ID",
-				Message: "Address ID is required",
-			},
-		})
+		return api.NewError(http.StatusBadRequest, "MISSING_ADDRESS_ID", "Address ID is required")
 	}
 
-	// Get coverage info
+	// Get coverage info; unknown addresses are 404s, an unreachable database a 503
 	coverageService := services.NewCoverageService(h.recommendationService.GetDB())
 	coverageInfo, err := coverageService.GetCoverageInfo(c.Request().Context(), addressID)
 	if err != nil {
-		c.Logger().Errorf("Coverage lookup failed for address %s: %v", addressID, err)
-
-		return c.JSON(http.StatusNotFound, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "COVERAGE_NOT_FOUND",
-				Message: "Coverage information not found for the specified address",
-				Details: []string{addressID},
-			},
-		})
+		return failed(err, "COVERAGE_LOOKUP_FAILED", "Failed to retrieve coverage information")
 	}
 
 	return c.JSON(http.StatusOK, coverageInfo)
 }
 
-// GetInstallSlots handles GET /api/install-slots/:address_id?tech=fiber
+// PostCoverageBatch handles POST /api/coverage/batch
+func (h *RecommendationHandler) PostCoverageBatch(c echo.Context) error {
+	// Parse request body
+	var req api.CoverageBatchRequest
+	if err := c.Bind(&req); err != nil {
+		return api.BadRequest("INVALID_REQUEST_BODY", "Failed to parse request body", err)
+	}
+
+	// Validate request
+	if fields := h.validator.ValidateFields(req); fields != nil {
+		return api.ValidationFailed("Request validation failed", fields)
+	}
+
+	coverageService := services.NewCoverageService(h.recommendationService.GetDB())
+	result, err := coverageService.GetCoverageInfoBatch(c.Request().Context(), req.AddressIDs)
+	if err != nil {
+		return failed(err, "COVERAGE_LOOKUP_FAILED", "Failed to retrieve coverage information")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetInstallSlots handles GET /api/install-slots/:address_id
+// Query parameters: tech (repeatable or comma-separated; defaults to every technology
+// available at the address), from/to (YYYY-MM-DD or RFC 3339), time_of_day (morning,
+// afternoon, evening; repeatable or comma-separated), limit, offset and earliest=true.
 func (h *RecommendationHandler) GetInstallSlots(c echo.Context) error {
 	addressID := c.Param("address_id")
 	if addressID == "" {
-		return c.JSON(http.StatusBadRequest, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "MISSING_ADDRESS_ID",
-				Message: "Address ID is required",
-			},
-		})
+		return api.NewError(http.StatusBadRequest, "MISSING_ADDRESS_ID", "Address ID is required")
 	}
 
-	tech := c.QueryParam("tech")
-	if tech == "" {
-		tech = "fiber" // Default to fiber
+	params, err := parseSlotSearchParams(c)
+	if err != nil {
+		return api.BadRequest("INVALID_SLOT_QUERY", "Invalid install slot query", err)
 	}
+	params.AddressID = addressID
 
-	// Get install slots
-	slots, err := h.recommendationService.GetDB().GetInstallSlots(c.Request().Context(), addressID, tech)
+	// Search install slots
+	database := h.recommendationService.GetDB()
+	slotService := services.NewInstallSlotService(database, services.NewCoverageService(database))
+	result, err := slotService.Search(c.Request().Context(), params)
 	if err != nil {
-		c.Logger().Errorf("Install slots lookup failed for address %s, tech %s: %v", addressID, tech, err)
+		return failed(fmt.Errorf("install slots for address %s, techs %v: %w", addressID, params.Techs, err),
+			"SLOTS_LOOKUP_FAILED", "Failed to retrieve install slots")
+	}
 
-		return c.JSON(http.StatusInternalServerError, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "SLOTS_LOOKUP_FAILED",
-				Message: "Failed to retrieve install slots",
-			},
-		})
+	return c.JSON(http.StatusOK, result)
+}
+
+// parseSlotSearchParams reads install slot search filters from the query string
+func parseSlotSearchParams(c echo.Context) (services.SlotSearchParams, error) {
+	var params services.SlotSearchParams
+	var err error
+
+	params.Techs = splitQueryList(c.QueryParams()["tech"])
+	params.TimesOfDay = splitQueryList(c.QueryParams()["time_of_day"])
+
+	if params.From, err = parseSlotTime(c.QueryParam("from"), false); err != nil {
+		return params, fmt.Errorf("from: %w", err)
+	}
+	if params.To, err = parseSlotTime(c.QueryParam("to"), true); err != nil {
+		return params, fmt.Errorf("to: %w", err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"address_id": addressID,
-		"tech":       tech,
-		"slots":      slots,
-	})
+	if value := c.QueryParam("limit"); value != "" {
+		if params.Limit, err = strconv.Atoi(value); err != nil {
+			return params, fmt.Errorf("limit must be a number")
+		}
+	}
+	if value := c.QueryParam("offset"); value != "" {
+		if params.Offset, err = strconv.Atoi(value); err != nil {
+			return params, fmt.Errorf("offset must be a number")
+		}
+	}
+	if value := c.QueryParam("earliest"); value != "" {
+		if params.Earliest, err = strconv.ParseBool(value); err != nil {
+			return params, fmt.Errorf("earliest must be true or false")
+		}
+	}
+
+	return params, nil
 }
 
-// PostCheckout handles POST /api/checkout
-func (h *RecommendationHandler) PostCheckout(c echo.Context) error {
-	// Parse request body
-	var req api.CheckoutRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "INVALID_REQUEST_BODY",
-				Message: "Failed to parse checkout request",
-				Details: []string{err.Error()},
-			},
-		})
+// splitQueryList flattens repeated and comma-separated query values
+func splitQueryList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(strings.ToLower(item)); item != "" {
+				result = append(result, item)
+			}
+		}
 	}
+	return result
+}
 
-	// Validate request
-	if validationErrors := h.validator.ValidateStruct(req); validationErrors != nil {
-		return c.JSON(http.StatusBadRequest, api.ErrorResponse{
-			Error: api.ErrorDetail{
-				Code:    "VALIDATION_FAILED",
-				Message: "Checkout validation failed",
-				Details: validationErrors,
-			},
-		})
+// parseSlotTime parses an RFC 3339 timestamp or a YYYY-MM-DD date in Istanbul time.
+// For an upper bound a plain date is inclusive, so it resolves to the next midnight.
+func parseSlotTime(value string, upperBound bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
 	}
 
-	// For now, return a mock successful checkout
-	// In a real implementation, this would process payment, create orders, etc.
-	response := api.CheckoutResponse{
-		Status:  "success",
-		OrderID: "ORD-" + generateOrderID(),
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
 	}
 
-	c.Logger().Infof("Checkout completed for user %d: %s", req.UserID, response.OrderID)
+	day, err := time.ParseInLocation("2006-01-02", value, utils.IstanbulLocation())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
 
-	return c.JSON(http.StatusOK, response)
-}
+	if upperBound {
+		day = day.AddDate(0, 0, 1)
+	}
 
-// generateOrderID generates a simple order ID (in production, use a proper ID generator)
-func generateOrderID() string {
-	// Simple timestamp-based ID for demo purposes
-	return "12345678"
+	return day, nil
 }
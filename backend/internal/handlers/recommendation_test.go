@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"app/internal/api"
+	"app/internal/services"
+	"app/internal/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestParseSlotSearchParams(t *testing.T) {
+	istanbul := utils.IstanbulLocation()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected services.SlotSearchParams
+		wantErr  bool
+	}{
+		{"no filters", "", services.SlotSearchParams{}, false},
+		{"repeated and comma-separated lists", "tech=Fiber,vdsl&tech=fwa&time_of_day=morning,%20evening",
+			services.SlotSearchParams{Techs: []string{"fiber", "vdsl", "fwa"}, TimesOfDay: []string{"morning", "evening"}}, false},
+		{"date range", "from=2026-03-02&to=2026-03-03",
+			services.SlotSearchParams{
+				From: time.Date(2026, 3, 2, 0, 0, 0, 0, istanbul),
+				To:   time.Date(2026, 3, 4, 0, 0, 0, 0, istanbul),
+			}, false},
+		{"paging", "limit=10&offset=20&earliest=true", services.SlotSearchParams{Limit: 10, Offset: 20, Earliest: true}, false},
+		{"invalid from", "from=tomorrow", services.SlotSearchParams{}, true},
+		{"invalid to", "to=2026-13-01", services.SlotSearchParams{}, true},
+		{"invalid limit", "limit=ten", services.SlotSearchParams{}, true},
+		{"invalid offset", "offset=-", services.SlotSearchParams{}, true},
+		{"invalid earliest", "earliest=first", services.SlotSearchParams{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			params, err := parseSlotSearchParams(c)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", params)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(params.Techs, tt.expected.Techs) || !reflect.DeepEqual(params.TimesOfDay, tt.expected.TimesOfDay) ||
+				!params.From.Equal(tt.expected.From) || !params.To.Equal(tt.expected.To) ||
+				params.Limit != tt.expected.Limit || params.Offset != tt.expected.Offset || params.Earliest != tt.expected.Earliest {
+				t.Errorf("Expected %+v, got %+v", tt.expected, params)
+			}
+		})
+	}
+}
+
+func TestParseSlotTime(t *testing.T) {
+	istanbul := utils.IstanbulLocation()
+
+	tests := []struct {
+		value      string
+		upperBound bool
+		expected   time.Time
+	}{
+		{"", false, time.Time{}},
+		{"2026-03-02", false, time.Date(2026, 3, 2, 0, 0, 0, 0, istanbul)},
+		{"2026-03-02", true, time.Date(2026, 3, 3, 0, 0, 0, 0, istanbul)},
+		{"2026-03-02T09:30:00Z", false, time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)},
+		{"2026-03-02T09:30:00Z", true, time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		parsed, err := parseSlotTime(tt.value, tt.upperBound)
+		if err != nil {
+			t.Errorf("parseSlotTime(%q, %v): %v", tt.value, tt.upperBound, err)
+			continue
+		}
+		if !parsed.Equal(tt.expected) {
+			t.Errorf("parseSlotTime(%q, %v) = %v, want %v", tt.value, tt.upperBound, parsed, tt.expected)
+		}
+	}
+
+	for _, value := range []string{"02.03.2026", "2026-03-02 09:30", "now"} {
+		if _, err := parseSlotTime(value, false); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+// TestGetInstallSlotsInvalidQuery covers queries rejected before the database is used
+func TestGetInstallSlotsInvalidQuery(t *testing.T) {
+	handler := NewRecommendationHandler(services.NewRecommendationService(nil, nil, nil, nil, nil), utils.NewValidator())
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.GET("/install-slots/:address_id", handler.GetInstallSlots)
+
+	for _, query := range []string{
+		"tech=cable",
+		"time_of_day=night",
+		"from=tomorrow",
+		"limit=ten",
+		"limit=1000",
+		"from=2026-03-03&to=2026-03-01",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/install-slots/A1001?"+query, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var resp api.ErrorResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusBadRequest || resp.Error.Code != "INVALID_SLOT_QUERY" {
+			t.Errorf("%s: expected 400 INVALID_SLOT_QUERY, got %d %s", query, rec.Code, resp.Error.Code)
+		}
+	}
+}
@@ -2,45 +2,126 @@ package handlers
 
 import (
 	"app/internal/db"
+	"app/internal/logging"
+	"app/internal/metrics"
+	"app/internal/models"
+	"app/internal/payments"
 	"app/internal/services"
+	"app/internal/tracing"
 	"app/internal/utils"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-// SetupRoutes configures all HTTP routes and middleware
-func SetupRoutes(e *echo.Echo, database db.DatabaseInterface) {
+// MaxRequestBodySize limits request bodies. UserKey and IdempotencyMiddleware read bodies
+// whole before the handler does.
+const MaxRequestBodySize = "1M"
+
+// SetupRoutes configures all HTTP routes and middleware. Checkout takes payments through
+// paymentProvider. The admin endpoints inspect the events of dispatcher and manage the
+// subscriptions of webhooks, both shared with the background jobs.
+func SetupRoutes(e *echo.Echo, database db.DatabaseInterface, config *utils.Config, paymentProvider payments.PaymentProvider, dispatcher *services.EventDispatcher, webhooks *services.WebhookService) {
 	// Create services
 	coverageService := services.NewCoverageService(database)
-	recommendationService := services.NewRecommendationService(database, coverageService)
+	quoteService := services.NewQuoteService(database, config.QuoteSigningSecret, config.QuoteTTL)
+	catalogCache := services.NewCatalogCache(database, config.CatalogCacheTTL)
+	promotionService := services.NewPromotionService(database)
+	recommendationService := services.NewRecommendationService(database, coverageService, quoteService, catalogCache, promotionService)
+	analyticsService := services.NewAnalyticsService(database)
+	orderService := services.NewOrderService(database, quoteService, promotionService, paymentProvider, config.RescheduleCutoff)
+	idempotencyService := services.NewIdempotencyService(database, config.IdempotencyKeyTTL)
+	healthService := services.NewHealthService(database, catalogCache, config.HealthCheckInterval)
+	rateLimiter := services.RateLimiterFromConfig(database, config)
+	apiKeyService := services.NewAPIKeyService(database, config.APIKeyRotationGrace)
+	catalogAdminService := services.NewCatalogAdminService(database, catalogCache)
 	validator := utils.NewValidator()
 
 	// Create handlers
-	healthHandler := NewHealthHandler(database)
+	healthHandler := NewHealthHandler(healthService)
 	recommendationHandler := NewRecommendationHandler(recommendationService, validator)
+	analyticsHandler := NewAnalyticsHandler(analyticsService)
+	orderHandler := NewOrderHandler(orderService, validator)
+	adminHandler := NewAdminHandler(dispatcher, webhooks, apiKeyService, catalogAdminService, validator)
+
+	// Errors returned by handlers and middleware are written by HTTPErrorHandler, with
+	// the request ID set by the RequestID middleware
+	e.HTTPErrorHandler = HTTPErrorHandler
 
-	// Middleware
-	e.Use(middleware.Logger())
+	// Client IPs, used by rate limits, are taken from X-Forwarded-For only when it was
+	// added by a proxy on a private network, so clients cannot pick their own
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
+
+	// Middleware; the logging middleware runs after tracing so request logs carry the
+	// trace ID, and bodies are limited before any route middleware reads them
+	e.Use(middleware.RequestID())
+	e.Use(tracing.Middleware())
+	e.Use(logging.Middleware())
 	e.Use(middleware.Recover())
+	e.Use(metrics.Middleware())
+	e.Use(middleware.BodyLimit(MaxRequestBodySize))
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:3000", "https://localhost:3000"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowOrigins:  config.CORSOrigins,
+		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization", HeaderAPIKey, HeaderAdminUser, HeaderIdempotencyKey},
+		ExposeHeaders: []string{HeaderIdempotentReplayed, echo.HeaderXRequestID, HeaderRetryAfter, HeaderRateLimitLimit, HeaderRateLimitRemaining},
 	}))
 
-	// Health check
+	// Liveness and readiness probes, health details and Prometheus metrics
+	e.GET("/livez", healthHandler.GetLivez)
+	e.GET("/readyz", healthHandler.GetReadyz)
 	e.GET("/health", healthHandler.GetHealth)
+	e.GET("/health/details", healthHandler.GetHealthDetails)
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+
+	// Rate limits per route group; recommendations are also limited per user
+	recommendationLimit := RateLimitMiddleware(rateLimiter, services.RateLimitRecommendation, ClientKey)
+	recommendationUserLimit := RateLimitMiddleware(rateLimiter, services.RateLimitRecommendationUser, UserKey)
+	checkoutLimit := RateLimitMiddleware(rateLimiter, services.RateLimitCheckout, ClientKey)
+	apiLimit := RateLimitMiddleware(rateLimiter, services.RateLimitAPI, ClientKey)
 
-	// API routes
-	api := e.Group("/api")
+	// Scopes partner API keys need; requests without a key are served anonymously unless
+	// API_KEY_REQUIRED is set
+	readScope := RequireScope(models.ScopeRecommendationRead, config.APIKeyRequired)
+	writeScope := RequireScope(models.ScopeCheckoutWrite, config.APIKeyRequired)
+
+	// API routes, authenticated with an X-API-Key header when one is sent
+	api := e.Group("/api", APIKeyMiddleware(apiKeyService))
 	{
 		// Recommendation endpoints
-		api.POST("/recommendation", recommendationHandler.GetRecommendations)
-		api.POST("/checkout", recommendationHandler.PostCheckout)
+		api.POST("/recommendation", recommendationHandler.GetRecommendations, readScope, recommendationLimit, recommendationUserLimit)
+		api.POST("/checkout", orderHandler.PostCheckout, writeScope, checkoutLimit, IdempotencyMiddleware(idempotencyService))
+
+		// Order endpoints
+		api.POST("/orders/:id/reschedule", orderHandler.PostReschedule, writeScope, checkoutLimit)
+		api.POST("/orders/:id/cancel", orderHandler.PostCancel, writeScope, checkoutLimit)
+		api.GET("/orders/:id/appointment.ics", orderHandler.GetAppointmentICS, writeScope, apiLimit)
 
 		// Utility endpoints
-		api.GET("/coverage/:address_id", recommendationHandler.GetCoverage)
-		api.GET("/install-slots/:address_id", recommendationHandler.GetInstallSlots)
+		api.POST("/coverage/batch", recommendationHandler.PostCoverageBatch, readScope, apiLimit)
+		api.GET("/coverage/:address_id", recommendationHandler.GetCoverage, readScope, apiLimit)
+		api.GET("/install-slots/:address_id", recommendationHandler.GetInstallSlots, readScope, apiLimit)
+
+		// Analytics endpoints
+		api.GET("/analytics/coverage", analyticsHandler.GetCoverageAnalytics, readScope, apiLimit)
+
+		// Admin endpoints, authenticated with ADMIN_TOKEN or an API key with the admin scope
+		admin := api.Group("/admin", AdminAuthMiddleware(config.AdminToken))
+		admin.GET("/events/dead-letter", adminHandler.GetDeadLetters)
+		admin.POST("/events/:id/requeue", adminHandler.PostRequeueEvent)
+		admin.POST("/webhooks", adminHandler.PostWebhook)
+		admin.GET("/webhooks", adminHandler.GetWebhooks)
+		admin.DELETE("/webhooks/:id", adminHandler.DeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", adminHandler.GetWebhookDeliveries)
+		admin.POST("/api-keys", adminHandler.PostAPIKey)
+		admin.GET("/api-keys", adminHandler.GetAPIKeys)
+		admin.DELETE("/api-keys/:id", adminHandler.DeleteAPIKey)
+		admin.POST("/api-keys/:id/rotate", adminHandler.PostRotateAPIKey)
+		admin.GET("/catalog", adminHandler.GetCatalog)
+		admin.GET("/catalog/audit", adminHandler.GetCatalogAudit)
+		admin.GET("/catalog/campaigns", adminHandler.GetCampaigns)
+		admin.POST("/catalog/:entity", adminHandler.PostCatalogEntry)
+		admin.PUT("/catalog/:entity/:id", adminHandler.PutCatalogEntry)
+		admin.DELETE("/catalog/:entity/:id", adminHandler.DeleteCatalogEntry)
 	}
 }
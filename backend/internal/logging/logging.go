@@ -0,0 +1,159 @@
+// Package logging sets up structured JSON logging with log/slog. Handlers, services and
+// database clients log through the logger of their context, which carries the request ID
+// and trace ID of the request they serve, with a level configurable per package.
+// Attributes holding personal data, such as names and addresses, are redacted.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// PackageKey is the attribute naming the package a log record comes from, e.g. db
+const PackageKey = "package"
+
+// Redacted replaces the value of attributes holding personal data
+const Redacted = "[REDACTED]"
+
+// piiKeys are the attribute keys whose values are personal data. Identifiers such as
+// user_id and address_id are kept, so records can still be correlated.
+var piiKeys = map[string]bool{
+	"name":      true,
+	"full_name": true,
+	"user_name": true,
+	"email":     true,
+	"phone":     true,
+	"address":   true,
+	"street":    true,
+	"city":      true,
+	"district":  true,
+	"recipient": true,
+}
+
+// Config sets the log levels
+type Config struct {
+	Level         string   // debug, info, warn or error
+	PackageLevels []string // per-package overrides such as db=debug
+}
+
+// levels returns the default level and the per-package levels of config
+func (config Config) levels() (slog.Level, map[string]slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return 0, nil, fmt.Errorf("invalid log level %q", config.Level)
+	}
+
+	packages := make(map[string]slog.Level, len(config.PackageLevels))
+	for _, entry := range config.PackageLevels {
+		pkg, raw, ok := strings.Cut(entry, "=")
+		var packageLevel slog.Level
+		if !ok || pkg == "" || packageLevel.UnmarshalText([]byte(raw)) != nil {
+			return 0, nil, fmt.Errorf("invalid package log level %q, expected package=level such as db=debug", entry)
+		}
+		packages[pkg] = packageLevel
+	}
+	return level, packages, nil
+}
+
+// Validate checks the levels of config
+func (config Config) Validate() error {
+	_, _, err := config.levels()
+	return err
+}
+
+// NewLogger creates a logger writing JSON records to w
+func NewLogger(w io.Writer, config Config) (*slog.Logger, error) {
+	level, packages, err := config.levels()
+	if err != nil {
+		return nil, err
+	}
+
+	json := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redact,
+	})
+	return slog.New(&handler{Handler: json, level: level, packages: packages}), nil
+}
+
+// Setup creates a logger writing to w and makes it the default of slog and of the log
+// package, so code that does not log through a context uses it too
+func Setup(w io.Writer, config Config) (*slog.Logger, error) {
+	logger, err := NewLogger(w, config)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// redact replaces the values of personal data attributes
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if piiKeys[attr.Key] && attr.Value.Kind() != slog.KindGroup {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+// handler filters records by the level of the package given by the PackageKey attribute
+// of the logger, falling back to the default level
+type handler struct {
+	slog.Handler
+	level    slog.Level
+	packages map[string]slog.Level
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	for _, attr := range attrs {
+		if attr.Key != PackageKey {
+			continue
+		}
+		if level, ok := h.packages[attr.Value.String()]; ok {
+			next.level = level
+		}
+	}
+	next.Handler = h.Handler.WithAttrs(attrs)
+	return &next
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.Handler = h.Handler.WithGroup(name)
+	return &next
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of ctx, or the default logger outside of a request or job
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// For returns the logger of ctx for the named package, e.g. services, logging at the
+// level configured for that package
+func For(ctx context.Context, pkg string) *slog.Logger {
+	return FromContext(ctx).With(PackageKey, pkg)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
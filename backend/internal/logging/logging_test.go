@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// records decodes the JSON records written to buf
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("record %q is not JSON: %v", line, err)
+		}
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestLoggerRedactsPersonalData(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, Config{Level: "info"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	logger.Info("User loaded", "user_id", 7, "name", "Ayşe Yılmaz",
+		slog.Group("user", "email", "ayse@example.com", "address_id", "A-1"))
+
+	got := records(t, &buf)[0]
+	if got["name"] != Redacted {
+		t.Errorf("name = %v, want %s", got["name"], Redacted)
+	}
+	user := got["user"].(map[string]any)
+	if user["email"] != Redacted {
+		t.Errorf("user.email = %v, want %s", user["email"], Redacted)
+	}
+	if got["user_id"] != float64(7) || user["address_id"] != "A-1" {
+		t.Errorf("identifiers were redacted: %v", got)
+	}
+}
+
+func TestLoggerPackageLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, Config{Level: "info", PackageLevels: []string{"db=debug", "scheduler=error"}})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	ctx := WithLogger(context.Background(), logger)
+
+	For(ctx, "db").Debug("db debug")
+	For(ctx, "services").Debug("services debug")
+	For(ctx, "services").Info("services info")
+	For(ctx, "scheduler").Warn("scheduler warn")
+
+	var messages []string
+	for _, record := range records(t, &buf) {
+		messages = append(messages, record["msg"].(string))
+	}
+	if want := "db debug,services info"; strings.Join(messages, ",") != want {
+		t.Errorf("logged %v, want %s", messages, want)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, config := range []Config{
+		{Level: "verbose"},
+		{Level: "info", PackageLevels: []string{"db"}},
+		{Level: "info", PackageLevels: []string{"db=loud"}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", config)
+		}
+	}
+}
+
+func TestMiddlewareAttachesRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, Config{Level: "info"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	e := echo.New()
+	e.Use(middleware.RequestID(), Middleware())
+	e.GET("/orders/:id", func(c echo.Context) error {
+		if RequestID(c.Request().Context()) != "req-1" {
+			t.Errorf("RequestID = %q, want req-1", RequestID(c.Request().Context()))
+		}
+		For(c.Request().Context(), "handlers").Info("Order loaded")
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := records(t, &buf)
+	if len(got) != 2 {
+		t.Fatalf("logged %d records, want 2: %s", len(got), buf.String())
+	}
+	for _, record := range got {
+		if record["request_id"] != "req-1" {
+			t.Errorf("record %v has no request ID", record)
+		}
+	}
+	access := got[1]
+	if access["msg"] != "request completed" || access["route"] != "/orders/:id" || access["status"] != float64(http.StatusNoContent) {
+		t.Errorf("access record = %v", access)
+	}
+}
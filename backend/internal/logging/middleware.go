@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware attaches a logger carrying the request ID, and the trace ID when the request
+// is traced, to the request context, and logs every request once it completes. It runs
+// after the RequestID and tracing middleware. Errors are written by the error handler
+// first so their status is logged, then returned for the tracing middleware to record.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := c.Response().Header().Get(echo.HeaderXRequestID)
+
+			logger := slog.Default().With("request_id", id)
+			if span := trace.SpanContextFromContext(req.Context()); span.HasTraceID() {
+				logger = logger.With("trace_id", span.TraceID().String())
+			}
+			ctx := WithLogger(context.WithValue(req.Context(), requestIDKey{}, id), logger)
+			c.SetRequest(req.WithContext(ctx))
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			res := c.Response()
+			level := slog.LevelInfo
+			if res.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			For(ctx, "http").LogAttrs(ctx, level, "request completed",
+				slog.String("method", req.Method),
+				slog.String("route", c.Path()),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", res.Status),
+				slog.Int64("bytes", res.Size),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			)
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// instrumentedDB records the latency and errors of every call to the wrapped database
+type instrumentedDB struct {
+	next    db.DatabaseInterface
+	backend string
+}
+
+// InstrumentDB wraps database so every call is recorded in DBCallDuration and, when it
+// fails, DBCallErrors, labelled with backend (e.g. supabase or postgres)
+func InstrumentDB(database db.DatabaseInterface, backend string) db.DatabaseInterface {
+	return &instrumentedDB{next: database, backend: backend}
+}
+
+// observe records a call to method that started at start and failed with *err, if set
+func (d *instrumentedDB) observe(method string, start time.Time, err *error) {
+	DBCallDuration.WithLabelValues(d.backend, method).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		DBCallErrors.WithLabelValues(d.backend, method, errorKind(*err)).Inc()
+	}
+}
+
+// errorKind returns the metric label of the kind of a database error
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, db.ErrConflict):
+		return "conflict"
+	case errors.Is(err, db.ErrInvalidInput):
+		return "invalid_input"
+	case errors.Is(err, db.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "other"
+	}
+}
+
+func (d *instrumentedDB) Health(ctx context.Context) (err error) {
+	defer d.observe("Health", time.Now(), &err)
+	return d.next.Health(ctx)
+}
+
+func (d *instrumentedDB) SchemaVersion(ctx context.Context) (_ int, err error) {
+	defer d.observe("SchemaVersion", time.Now(), &err)
+	return d.next.SchemaVersion(ctx)
+}
+
+func (d *instrumentedDB) Close() {
+	d.next.Close()
+}
+
+func (d *instrumentedDB) GetUser(ctx context.Context, userID int) (_ *models.User, err error) {
+	defer d.observe("GetUser", time.Now(), &err)
+	return d.next.GetUser(ctx, userID)
+}
+
+func (d *instrumentedDB) GetCoverage(ctx context.Context, addressID string) (_ *models.Coverage, err error) {
+	defer d.observe("GetCoverage", time.Now(), &err)
+	return d.next.GetCoverage(ctx, addressID)
+}
+
+func (d *instrumentedDB) GetCoverageBatch(ctx context.Context, addressIDs []string) (_ map[string]*models.Coverage, err error) {
+	defer d.observe("GetCoverageBatch", time.Now(), &err)
+	return d.next.GetCoverageBatch(ctx, addressIDs)
+}
+
+func (d *instrumentedDB) GetDistrictCoverage(ctx context.Context) (_ []models.DistrictCoverage, err error) {
+	defer d.observe("GetDistrictCoverage", time.Now(), &err)
+	return d.next.GetDistrictCoverage(ctx)
+}
+
+func (d *instrumentedDB) GetHousehold(ctx context.Context, userID int) (_ []models.Household, err error) {
+	defer d.observe("GetHousehold", time.Now(), &err)
+	return d.next.GetHousehold(ctx, userID)
+}
+
+func (d *instrumentedDB) GetInstallSlots(ctx context.Context, addressID, tech string) (_ []models.InstallSlot, err error) {
+	defer d.observe("GetInstallSlots", time.Now(), &err)
+	return d.next.GetInstallSlots(ctx, addressID, tech)
+}
+
+func (d *instrumentedDB) SearchInstallSlots(ctx context.Context, query models.SlotQuery) (_ []models.InstallSlot, err error) {
+	defer d.observe("SearchInstallSlots", time.Now(), &err)
+	return d.next.SearchInstallSlots(ctx, query)
+}
+
+func (d *instrumentedDB) GetInstallSlot(ctx context.Context, slotID string) (_ *models.InstallSlot, err error) {
+	defer d.observe("GetInstallSlot", time.Now(), &err)
+	return d.next.GetInstallSlot(ctx, slotID)
+}
+
+func (d *instrumentedDB) GetCatalog(ctx context.Context, at time.Time) (_ *models.Catalog, err error) {
+	defer d.observe("GetCatalog", time.Now(), &err)
+	return d.next.GetCatalog(ctx, at)
+}
+
+func (d *instrumentedDB) GetCrews(ctx context.Context) (_ []models.Crew, err error) {
+	defer d.observe("GetCrews", time.Now(), &err)
+	return d.next.GetCrews(ctx)
+}
+
+func (d *instrumentedDB) UpsertInstallSlots(ctx context.Context, slots []models.InstallSlot) (_ int, err error) {
+	defer d.observe("UpsertInstallSlots", time.Now(), &err)
+	return d.next.UpsertInstallSlots(ctx, slots)
+}
+
+func (d *instrumentedDB) PlaceOrder(ctx context.Context, order *models.Order) (_ *models.Order, err error) {
+	defer d.observe("PlaceOrder", time.Now(), &err)
+	return d.next.PlaceOrder(ctx, order)
+}
+
+func (d *instrumentedDB) ConfirmOrder(ctx context.Context, orderID, paymentID string) (_ *models.Order, err error) {
+	defer d.observe("ConfirmOrder", time.Now(), &err)
+	return d.next.ConfirmOrder(ctx, orderID, paymentID)
+}
+
+func (d *instrumentedDB) SetPaymentStatus(ctx context.Context, orderID, status string) (err error) {
+	defer d.observe("SetPaymentStatus", time.Now(), &err)
+	return d.next.SetPaymentStatus(ctx, orderID, status)
+}
+
+func (d *instrumentedDB) GetOrder(ctx context.Context, orderID string) (_ *models.Order, err error) {
+	defer d.observe("GetOrder", time.Now(), &err)
+	return d.next.GetOrder(ctx, orderID)
+}
+
+func (d *instrumentedDB) RescheduleOrder(ctx context.Context, orderID, newSlotID string, cutoffSeconds int, reason string) (_ *models.Order, err error) {
+	defer d.observe("RescheduleOrder", time.Now(), &err)
+	return d.next.RescheduleOrder(ctx, orderID, newSlotID, cutoffSeconds, reason)
+}
+
+func (d *instrumentedDB) CancelOrder(ctx context.Context, orderID, reason string) (_ *models.Order, err error) {
+	defer d.observe("CancelOrder", time.Now(), &err)
+	return d.next.CancelOrder(ctx, orderID, reason)
+}
+
+func (d *instrumentedDB) ExpirePendingOrders(ctx context.Context, maxAgeSeconds int) (_ int, err error) {
+	defer d.observe("ExpirePendingOrders", time.Now(), &err)
+	return d.next.ExpirePendingOrders(ctx, maxAgeSeconds)
+}
+
+func (d *instrumentedDB) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, ttlSeconds int) (_ *models.IdempotencyRecord, _ bool, err error) {
+	defer d.observe("ClaimIdempotencyKey", time.Now(), &err)
+	return d.next.ClaimIdempotencyKey(ctx, key, requestHash, ttlSeconds)
+}
+
+func (d *instrumentedDB) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, body string) (err error) {
+	defer d.observe("SaveIdempotentResponse", time.Now(), &err)
+	return d.next.SaveIdempotentResponse(ctx, key, statusCode, body)
+}
+
+func (d *instrumentedDB) DeleteIdempotencyKey(ctx context.Context, key string) (err error) {
+	defer d.observe("DeleteIdempotencyKey", time.Now(), &err)
+	return d.next.DeleteIdempotencyKey(ctx, key)
+}
+
+func (d *instrumentedDB) PurgeIdempotencyKeys(ctx context.Context) (_ int, err error) {
+	defer d.observe("PurgeIdempotencyKeys", time.Now(), &err)
+	return d.next.PurgeIdempotencyKeys(ctx)
+}
+
+func (d *instrumentedDB) SaveQuotes(ctx context.Context, quotes []models.Quote) (err error) {
+	defer d.observe("SaveQuotes", time.Now(), &err)
+	return d.next.SaveQuotes(ctx, quotes)
+}
+
+func (d *instrumentedDB) GetQuote(ctx context.Context, quoteID string) (_ *models.Quote, err error) {
+	defer d.observe("GetQuote", time.Now(), &err)
+	return d.next.GetQuote(ctx, quoteID)
+}
+
+func (d *instrumentedDB) PurgeExpiredQuotes(ctx context.Context) (_ int, err error) {
+	defer d.observe("PurgeExpiredQuotes", time.Now(), &err)
+	return d.next.PurgeExpiredQuotes(ctx)
+}
+
+func (d *instrumentedDB) GetAppointmentHistory(ctx context.Context, orderID string) (_ []models.AppointmentChange, err error) {
+	defer d.observe("GetAppointmentHistory", time.Now(), &err)
+	return d.next.GetAppointmentHistory(ctx, orderID)
+}
+
+func (d *instrumentedDB) ListUnreconciledOrders(ctx context.Context, minAgeSeconds, limit int) (_ []models.Order, err error) {
+	defer d.observe("ListUnreconciledOrders", time.Now(), &err)
+	return d.next.ListUnreconciledOrders(ctx, minAgeSeconds, limit)
+}
+
+func (d *instrumentedDB) GetUpcomingAppointments(ctx context.Context, leadSeconds int) (_ []models.Order, err error) {
+	defer d.observe("GetUpcomingAppointments", time.Now(), &err)
+	return d.next.GetUpcomingAppointments(ctx, leadSeconds)
+}
+
+func (d *instrumentedDB) EnqueueNotifications(ctx context.Context, notifications []models.Notification) (err error) {
+	defer d.observe("EnqueueNotifications", time.Now(), &err)
+	return d.next.EnqueueNotifications(ctx, notifications)
+}
+
+func (d *instrumentedDB) ClaimNotifications(ctx context.Context, limit, leaseSeconds int) (_ []models.Notification, err error) {
+	defer d.observe("ClaimNotifications", time.Now(), &err)
+	return d.next.ClaimNotifications(ctx, limit, leaseSeconds)
+}
+
+func (d *instrumentedDB) MarkNotificationSent(ctx context.Context, id int64) (err error) {
+	defer d.observe("MarkNotificationSent", time.Now(), &err)
+	return d.next.MarkNotificationSent(ctx, id)
+}
+
+func (d *instrumentedDB) MarkNotificationFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) (err error) {
+	defer d.observe("MarkNotificationFailed", time.Now(), &err)
+	return d.next.MarkNotificationFailed(ctx, id, lastError, retryAt)
+}
+
+func (d *instrumentedDB) ClaimEvents(ctx context.Context, limit, leaseSeconds int) (_ []models.Event, err error) {
+	defer d.observe("ClaimEvents", time.Now(), &err)
+	return d.next.ClaimEvents(ctx, limit, leaseSeconds)
+}
+
+func (d *instrumentedDB) MarkEventDelivered(ctx context.Context, id int64) (err error) {
+	defer d.observe("MarkEventDelivered", time.Now(), &err)
+	return d.next.MarkEventDelivered(ctx, id)
+}
+
+func (d *instrumentedDB) MarkEventFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) (err error) {
+	defer d.observe("MarkEventFailed", time.Now(), &err)
+	return d.next.MarkEventFailed(ctx, id, lastError, retryAt)
+}
+
+func (d *instrumentedDB) ListEvents(ctx context.Context, status string, limit int) (_ []models.Event, err error) {
+	defer d.observe("ListEvents", time.Now(), &err)
+	return d.next.ListEvents(ctx, status, limit)
+}
+
+func (d *instrumentedDB) RequeueEvent(ctx context.Context, id int64) (_ *models.Event, err error) {
+	defer d.observe("RequeueEvent", time.Now(), &err)
+	return d.next.RequeueEvent(ctx, id)
+}
+
+func (d *instrumentedDB) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) (_ *models.WebhookSubscription, err error) {
+	defer d.observe("CreateWebhookSubscription", time.Now(), &err)
+	return d.next.CreateWebhookSubscription(ctx, sub)
+}
+
+func (d *instrumentedDB) ListWebhookSubscriptions(ctx context.Context) (_ []models.WebhookSubscription, err error) {
+	defer d.observe("ListWebhookSubscriptions", time.Now(), &err)
+	return d.next.ListWebhookSubscriptions(ctx)
+}
+
+func (d *instrumentedDB) DeleteWebhookSubscription(ctx context.Context, id int64) (err error) {
+	defer d.observe("DeleteWebhookSubscription", time.Now(), &err)
+	return d.next.DeleteWebhookSubscription(ctx, id)
+}
+
+func (d *instrumentedDB) EnqueueWebhookDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) (err error) {
+	defer d.observe("EnqueueWebhookDeliveries", time.Now(), &err)
+	return d.next.EnqueueWebhookDeliveries(ctx, deliveries)
+}
+
+func (d *instrumentedDB) ClaimWebhookDeliveries(ctx context.Context, limit, leaseSeconds int) (_ []models.WebhookDelivery, err error) {
+	defer d.observe("ClaimWebhookDeliveries", time.Now(), &err)
+	return d.next.ClaimWebhookDeliveries(ctx, limit, leaseSeconds)
+}
+
+func (d *instrumentedDB) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) (err error) {
+	defer d.observe("MarkWebhookDelivered", time.Now(), &err)
+	return d.next.MarkWebhookDelivered(ctx, id, statusCode)
+}
+
+func (d *instrumentedDB) MarkWebhookFailed(ctx context.Context, id int64, statusCode *int, lastError string, retryAt *time.Time) (err error) {
+	defer d.observe("MarkWebhookFailed", time.Now(), &err)
+	return d.next.MarkWebhookFailed(ctx, id, statusCode, lastError, retryAt)
+}
+
+func (d *instrumentedDB) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) (_ []models.WebhookDelivery, err error) {
+	defer d.observe("ListWebhookDeliveries", time.Now(), &err)
+	return d.next.ListWebhookDeliveries(ctx, subscriptionID, limit)
+}
+
+func (d *instrumentedDB) TakeRateLimitToken(ctx context.Context, key string, ratePerSecond float64, burst int) (_ *models.RateLimitDecision, err error) {
+	defer d.observe("TakeRateLimitToken", time.Now(), &err)
+	return d.next.TakeRateLimitToken(ctx, key, ratePerSecond, burst)
+}
+
+func (d *instrumentedDB) PurgeRateLimitBuckets(ctx context.Context) (_ int, err error) {
+	defer d.observe("PurgeRateLimitBuckets", time.Now(), &err)
+	return d.next.PurgeRateLimitBuckets(ctx)
+}
+
+func (d *instrumentedDB) CreateAPIKey(ctx context.Context, key *models.APIKey) (_ *models.APIKey, err error) {
+	defer d.observe("CreateAPIKey", time.Now(), &err)
+	return d.next.CreateAPIKey(ctx, key)
+}
+
+func (d *instrumentedDB) GetAPIKey(ctx context.Context, id int64) (_ *models.APIKey, err error) {
+	defer d.observe("GetAPIKey", time.Now(), &err)
+	return d.next.GetAPIKey(ctx, id)
+}
+
+func (d *instrumentedDB) GetAPIKeyByPrefix(ctx context.Context, prefix string) (_ *models.APIKey, err error) {
+	defer d.observe("GetAPIKeyByPrefix", time.Now(), &err)
+	return d.next.GetAPIKeyByPrefix(ctx, prefix)
+}
+
+func (d *instrumentedDB) ListAPIKeys(ctx context.Context) (_ []models.APIKey, err error) {
+	defer d.observe("ListAPIKeys", time.Now(), &err)
+	return d.next.ListAPIKeys(ctx)
+}
+
+func (d *instrumentedDB) RevokeAPIKey(ctx context.Context, id int64) (_ *models.APIKey, err error) {
+	defer d.observe("RevokeAPIKey", time.Now(), &err)
+	return d.next.RevokeAPIKey(ctx, id)
+}
+
+func (d *instrumentedDB) RotateAPIKey(ctx context.Context, id int64, prefix, keyHash string, expireOldAt time.Time) (_ *models.APIKey, err error) {
+	defer d.observe("RotateAPIKey", time.Now(), &err)
+	return d.next.RotateAPIKey(ctx, id, prefix, keyHash, expireOldAt)
+}
+
+func (d *instrumentedDB) TouchAPIKey(ctx context.Context, id int64) (err error) {
+	defer d.observe("TouchAPIKey", time.Now(), &err)
+	return d.next.TouchAPIKey(ctx, id)
+}
+
+func (d *instrumentedDB) ApplyCatalogChange(ctx context.Context, change models.CatalogChange) (_ *models.CatalogAuditEntry, err error) {
+	defer d.observe("ApplyCatalogChange", time.Now(), &err)
+	return d.next.ApplyCatalogChange(ctx, change)
+}
+
+func (d *instrumentedDB) ListCatalogAudit(ctx context.Context, entity string, limit int) (_ []models.CatalogAuditEntry, err error) {
+	defer d.observe("ListCatalogAudit", time.Now(), &err)
+	return d.next.ListCatalogAudit(ctx, entity, limit)
+}
+
+func (d *instrumentedDB) ListCampaigns(ctx context.Context, at time.Time) (_ []models.Campaign, err error) {
+	defer d.observe("ListCampaigns", time.Now(), &err)
+	return d.next.ListCampaigns(ctx, at)
+}
+
+func (d *instrumentedDB) IsNewCustomer(ctx context.Context, userID int) (_ bool, err error) {
+	defer d.observe("IsNewCustomer", time.Now(), &err)
+	return d.next.IsNewCustomer(ctx, userID)
+}
@@ -0,0 +1,101 @@
+// Package metrics exposes Prometheus metrics for the HTTP API, the recommendation engine
+// and database calls on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "recommendation_api"
+
+// Registry holds the application metrics together with the Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	// HTTPRequestDuration is the duration of HTTP requests by route template and status
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RecommendationDuration is the duration of complete recommendation requests
+	RecommendationDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "recommendation_duration_seconds",
+		Help:      "Duration of recommendation requests, including quote issuing.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// RecommendationStepDuration is the duration of each step of the recommendation pipeline
+	RecommendationStepDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "recommendation_step_duration_seconds",
+		Help:      "Duration of recommendation pipeline steps (coverage, candidates, pricing, selection, scheduled_prices, quotes).",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"step"})
+
+	// RecommendationCandidates is the number of bundle candidates priced per recommendation
+	RecommendationCandidates = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "recommendation_candidates",
+		Help:      "Number of bundle candidates priced per recommendation request.",
+		Buckets:   prometheus.LinearBuckets(0, 10, 11),
+	})
+
+	// CatalogCacheRequests counts catalog cache lookups by result, hit or miss
+	CatalogCacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "catalog_cache_requests_total",
+		Help:      "Catalog cache lookups by result (hit, miss).",
+	}, []string{"result"})
+
+	// RateLimitedRequests counts requests refused by a rate limit, by route group
+	RateLimitedRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests refused with 429 by route group (recommendation, recommendation_user, checkout, api).",
+	}, []string{"group"})
+
+	// DBCallDuration is the duration of database calls by backend and method
+	DBCallDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_call_duration_seconds",
+		Help:      "Duration of database calls by backend and DatabaseInterface method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "method"})
+
+	// DBCallErrors counts failed database calls by backend, method and error kind
+	DBCallErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_call_errors_total",
+		Help:      "Failed database calls by backend, method and error kind (not_found, conflict, invalid_input, unavailable, other).",
+	}, []string{"backend", "method", "kind"})
+)
+
+// ObserveRecommendationStep records the duration of a recommendation step started at start
+func ObserveRecommendationStep(step string, start time.Time) {
+	RecommendationStepDuration.WithLabelValues(step).Observe(time.Since(start).Seconds())
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/tracing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// stubDB answers GetUser and GetOrder; other methods are not called
+type stubDB struct {
+	db.DatabaseInterface
+}
+
+func (stubDB) GetUser(_ context.Context, userID int) (*models.User, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("user %d: %w", userID, db.ErrUserNotFound)
+	}
+	return &models.User{UserID: userID}, nil
+}
+
+func (stubDB) GetOrder(context.Context, string) (*models.Order, error) {
+	return nil, fmt.Errorf("%w: connection refused", db.ErrUnavailable)
+}
+
+func TestInstrumentDB(t *testing.T) {
+	database := InstrumentDB(stubDB{}, "test")
+	ctx := context.Background()
+
+	user, err := database.GetUser(ctx, 7)
+	if err != nil || user.UserID != 7 {
+		t.Fatalf("Expected the wrapped result, got %+v, %v", user, err)
+	}
+	if _, err := database.GetUser(ctx, 0); !errors.Is(err, db.ErrUserNotFound) {
+		t.Fatalf("Expected the wrapped error, got %v", err)
+	}
+	if _, err := database.GetOrder(ctx, "ORD-1"); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if calls := testutil.CollectAndCount(DBCallDuration, "recommendation_api_db_call_duration_seconds"); calls < 2 {
+		t.Errorf("Expected a latency series per method, got %d", calls)
+	}
+	if n := testutil.ToFloat64(DBCallErrors.WithLabelValues("test", "GetUser", "not_found")); n != 1 {
+		t.Errorf("Expected one not_found error for GetUser, got %v", n)
+	}
+	if n := testutil.ToFloat64(DBCallErrors.WithLabelValues("test", "GetOrder", "unavailable")); n != 1 {
+		t.Errorf("Expected one unavailable error for GetOrder, got %v", n)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/orders/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/metrics", echo.WrapHandler(Handler()))
+
+	for _, path := range []string{"/api/orders/ORD-1", "/api/orders/ORD-2", "/api/orders/missing", "/nowhere"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, expected := range []string{
+		`recommendation_api_http_request_duration_seconds_count{method="GET",route="/api/orders/:id",status="200"} 2`,
+		`recommendation_api_http_request_duration_seconds_count{method="GET",route="/api/orders/:id",status="404"} 1`,
+		`recommendation_api_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %s in /metrics", expected)
+		}
+	}
+}
+
+func TestMiddlewarePassesErrorsToTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	e := echo.New()
+	e.Use(tracing.Middleware(), Middleware())
+	e.GET("/api/orders/:id", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "database unavailable")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/ORD-1", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the error written once with status 503, got %d", rec.Code)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected the server span, got %d spans", len(spans))
+	}
+	if events := spans[0].Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("Expected the handler error recorded on the span, got %v", events)
+	}
+}
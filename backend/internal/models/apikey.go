@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// API key scopes
+const (
+	ScopeRecommendationRead = "recommendation:read" // recommendations, coverage, install slots and analytics
+	ScopeCheckoutWrite      = "checkout:write"      // checkout and the order endpoints
+	ScopeAdmin              = "admin"               // the admin endpoints
+)
+
+// APIKey represents a key partners use to call the API server-to-server
+type APIKey struct {
+	ID                 int64      `json:"id" db:"id"`
+	Name               string     `json:"name" db:"name"`
+	Prefix             string     `json:"key_prefix" db:"key_prefix"`       // public part of the key, identifies it in listings
+	KeyHash            string     `json:"key_hash,omitempty" db:"key_hash"` // SHA-256 of the key, never returned by the API
+	Key                string     `json:"key,omitempty" db:"-"`             // the key itself, only returned when it is created
+	Scopes             []string   `json:"scopes" db:"scopes"`
+	RateLimitPerMinute *int       `json:"rate_limit_per_minute" db:"rate_limit_per_minute"` // nil for the route group's limit
+	RateLimitBurst     *int       `json:"rate_limit_burst" db:"rate_limit_burst"`
+	ExpiresAt          *time.Time `json:"expires_at" db:"expires_at"`
+	RevokedAt          *time.Time `json:"revoked_at" db:"revoked_at"`
+	LastUsedAt         *time.Time `json:"last_used_at" db:"last_used_at"`
+	RotatedFrom        *int64     `json:"rotated_from" db:"rotated_from"` // the key this key replaced
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired reports whether the key has expired at now
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
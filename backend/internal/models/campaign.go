@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Campaign discount types
+const (
+	DiscountPercent    = "percent"     // DiscountValue percent off the component
+	DiscountFixed      = "fixed"       // DiscountValue off the component
+	DiscountFreeMonths = "free_months" // the component is free for DiscountValue months
+)
+
+// Campaign is a promotion taking a discount off one component of an offer. Campaigns
+// without a code apply to every eligible offer; coded ones only when the customer enters
+// the code. Empty eligibility lists do not limit who gets the campaign.
+type Campaign struct {
+	CampaignID     int        `json:"campaign_id" db:"campaign_id"`
+	Name           string     `json:"name" db:"name"`
+	Code           *string    `json:"code,omitempty" db:"code"` // upper case, nil for automatic campaigns
+	DiscountType   string     `json:"discount_type" db:"discount_type"`
+	DiscountValue  float64    `json:"discount_value" db:"discount_value"`
+	DurationMonths *int       `json:"duration_months,omitempty" db:"duration_months"` // nil for every month
+	AppliesTo      string     `json:"applies_to" db:"applies_to"`                     // mobile, home, tv, total
+	Cities         []string   `json:"cities" db:"cities"`
+	Techs          []string   `json:"techs" db:"techs"`
+	MobilePlanIDs  []int      `json:"mobile_plan_ids" db:"mobile_plan_ids"`
+	HomePlanIDs    []int      `json:"home_plan_ids" db:"home_plan_ids"`
+	TVPlanIDs      []int      `json:"tv_plan_ids" db:"tv_plan_ids"`
+	NewCustomers   bool       `json:"new_customers_only" db:"new_customers_only"`
+	Stackable      bool       `json:"stackable" db:"stackable"` // combines with other stackable campaigns
+	StartsAt       time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at,omitempty" db:"ends_at"` // exclusive, nil while open ended
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Catalog change actions
+const (
+	CatalogActionCreate = "create"
+	CatalogActionUpdate = "update"
+	CatalogActionDelete = "delete"
+)
+
+// CatalogChange is a change to one catalog row made through the admin API
+type CatalogChange struct {
+	Entity string          // the table: mobile_plans, home_plans, tv_plans, bundling_rules or coverage
+	Action string          // create, update, delete
+	Key    string          // the row's key; empty when creating
+	Data   json.RawMessage // the whole row keyed by column; nil when deleting
+	Actor  string          // who made the change
+
+	// EffectiveFrom schedules a plan or rule change; nil makes it take effect now
+	EffectiveFrom *time.Time
+}
+
+// CatalogAuditEntry records a catalog change with the row before and after it
+type CatalogAuditEntry struct {
+	ID        int64           `json:"id" db:"id"`
+	Entity    string          `json:"entity" db:"entity"`
+	EntityKey string          `json:"entity_key" db:"entity_key"`
+	Action    string          `json:"action" db:"action"`
+	Actor     string          `json:"actor" db:"actor"`
+	Before    json.RawMessage `json:"before" db:"before"` // null for creates
+	After     json.RawMessage `json:"after" db:"after"`   // null for deletes
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types written to the outbox by the order functions
+const (
+	EventSlotBooked     = "SlotBooked"
+	EventOrderPlaced    = "OrderPlaced"
+	EventOrderCancelled = "OrderCancelled"
+)
+
+// Outbox event statuses
+const (
+	EventPending   = "pending"
+	EventDelivered = "delivered"
+	EventDead      = "dead"
+)
+
+// Event represents a domain event in the transactional outbox
+type Event struct {
+	ID            int64           `json:"id" db:"id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	AggregateID   string          `json:"aggregate_id" db:"aggregate_id"` // order ID
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Status        string          `json:"status" db:"status"` // pending, delivered, dead
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     *string         `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at" db:"delivered_at"`
+}
+
+// SlotBookedPayload is the payload of a SlotBooked event. PreviousSlotID is set when the
+// appointment was rescheduled.
+type SlotBookedPayload struct {
+	OrderID        string    `json:"order_id"`
+	SlotID         string    `json:"slot_id"`
+	PreviousSlotID *string   `json:"previous_slot_id"`
+	SlotStart      time.Time `json:"slot_start"`
+	SlotEnd        time.Time `json:"slot_end"`
+	Reason         *string   `json:"reason,omitempty"`
+}
+
+// OrderPlacedPayload is the payload of an OrderPlaced event
+type OrderPlacedPayload struct {
+	OrderID       string  `json:"order_id"`
+	UserID        int     `json:"user_id"`
+	SlotID        *string `json:"slot_id"`
+	ComboLabel    string  `json:"combo_label"`
+	MonthlyTotal  float64 `json:"monthly_total"`
+	UpfrontAmount float64 `json:"upfront_amount"`
+	PaymentID     string  `json:"payment_id"`
+}
+
+// OrderCancelledPayload is the payload of an OrderCancelled event
+type OrderCancelledPayload struct {
+	OrderID        string  `json:"order_id"`
+	PreviousStatus string  `json:"previous_status"` // pending, confirmed
+	Reason         *string `json:"reason"`
+	SlotID         *string `json:"slot_id"`
+	SlotReleased   bool    `json:"slot_released"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is a stored Idempotency-Key with the response of the request that
+// claimed it. StatusCode and ResponseBody are nil while that request is still running.
+type IdempotencyRecord struct {
+	Key          string    `json:"idempotency_key" db:"idempotency_key"`
+	RequestHash  string    `json:"request_hash" db:"request_hash"`
+	StatusCode   *int      `json:"status_code" db:"status_code"`
+	ResponseBody *string   `json:"response_body" db:"response_body"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+}
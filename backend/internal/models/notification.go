@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Notification statuses
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+// Notification kinds
+const (
+	NotificationOrderConfirmed         = "order_confirmed"
+	NotificationAppointmentRescheduled = "appointment_rescheduled"
+	NotificationOrderCancelled         = "order_cancelled"
+	NotificationInstallationReminder   = "installation_reminder"
+)
+
+// Notification represents a rendered message queued in the notification outbox
+type Notification struct {
+	ID            int64      `json:"id" db:"id"`
+	DedupeKey     string     `json:"dedupe_key" db:"dedupe_key"` // one message per key, however often it is queued
+	OrderID       *string    `json:"order_id" db:"order_id"`
+	Kind          string     `json:"kind" db:"kind"`
+	Channel       string     `json:"channel" db:"channel"` // email, sms
+	Recipient     string     `json:"recipient" db:"recipient"`
+	Subject       *string    `json:"subject" db:"subject"`
+	Body          string     `json:"body" db:"body"`
+	Status        string     `json:"status" db:"status"` // pending, sent, failed
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     *string    `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	SentAt        *time.Time `json:"sent_at" db:"sent_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Order statuses
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusCancelled = "cancelled"
+)
+
+// Appointment history actions
+const (
+	AppointmentBooked      = "booked"
+	AppointmentRescheduled = "rescheduled"
+	AppointmentCancelled   = "cancelled"
+)
+
+// Order represents a placed order and its booked installation slot
+type Order struct {
+	OrderID      string          `json:"order_id" db:"order_id"`
+	UserID       int             `json:"user_id" db:"user_id"`
+	AddressID    string          `json:"address_id" db:"address_id"`
+	SlotID       *string         `json:"slot_id" db:"slot_id"`
+	Tech         *string         `json:"tech" db:"tech"`
+	Status       string          `json:"status" db:"status"` // pending, confirmed, cancelled
+	ComboLabel   string          `json:"combo_label" db:"combo_label"`
+	MonthlyTotal float64         `json:"monthly_total" db:"monthly_total"`
+	Items        json.RawMessage `json:"items" db:"items"`
+	SlotReleased bool            `json:"slot_released" db:"slot_released"`
+	CancelReason *string         `json:"cancel_reason,omitempty" db:"cancel_reason"`
+	Promotions   json.RawMessage `json:"promotions" db:"promotions"` // copied from the quote
+
+	CommitmentMonths int     `json:"commitment_months" db:"commitment_months"` // copied from the quote, 0 without commitment
+	TerminationFee   float64 `json:"termination_fee" db:"termination_fee"`
+
+	UpfrontAmount float64 `json:"upfront_amount" db:"upfront_amount"` // install fees plus the first month
+	PaymentID     *string `json:"payment_id,omitempty" db:"payment_id"`
+	PaymentStatus *string `json:"payment_status,omitempty" db:"payment_status"` // unknown, authorized, captured, refunded, failed
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AppointmentChange represents one entry of an order's appointment history
+type AppointmentChange struct {
+	ID        int64     `json:"id" db:"id"`
+	OrderID   string    `json:"order_id" db:"order_id"`
+	Action    string    `json:"action" db:"action"` // booked, rescheduled, cancelled
+	OldSlotID *string   `json:"old_slot_id,omitempty" db:"old_slot_id"`
+	NewSlotID *string   `json:"new_slot_id,omitempty" db:"new_slot_id"`
+	Reason    *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -2,58 +2,90 @@ package models
 
 import "time"
 
-// MobilePlan represents a mobile plan in the catalog
+// MobilePlan represents a version of a mobile plan in the catalog. A plan keeps its ID
+// across versions; each version has its own price and is in effect from EffectiveFrom
+// until EffectiveTo.
 type MobilePlan struct {
-	PlanID       int     `json:"plan_id" db:"plan_id"`
-	PlanName     string  `json:"plan_name" db:"plan_name"`
-	QuotaGB      float64 `json:"quota_gb" db:"quota_gb"`
-	QuotaMin     float64 `json:"quota_min" db:"quota_min"`
-	MonthlyPrice float64 `json:"monthly_price" db:"monthly_price"`
-	OverageGB    float64 `json:"overage_gb" db:"overage_gb"`
-	OverageMin   float64 `json:"overage_min" db:"overage_min"`
+	PlanID        int        `json:"plan_id" db:"plan_id"`
+	PlanName      string     `json:"plan_name" db:"plan_name"`
+	QuotaGB       float64    `json:"quota_gb" db:"quota_gb"`
+	QuotaMin      float64    `json:"quota_min" db:"quota_min"`
+	MonthlyPrice  float64    `json:"monthly_price" db:"monthly_price"`
+	OverageGB     float64    `json:"overage_gb" db:"overage_gb"`
+	OverageMin    float64    `json:"overage_min" db:"overage_min"`
+	Terms         []PlanTerm `json:"terms,omitempty" db:"terms"`
+	Version       int        `json:"version" db:"version"`
+	EffectiveFrom time.Time  `json:"effective_from" db:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty" db:"effective_to"` // exclusive, nil while open ended
 }
 
-// HomePlan represents a home internet plan in the catalog
+// PlanTerm is a commitment offer of a plan: its monthly price when the customer commits
+// for Months months, and the fee for terminating the contract before they end. A plan's
+// MonthlyPrice is its price without commitment.
+type PlanTerm struct {
+	Months         int     `json:"months"`
+	MonthlyPrice   float64 `json:"monthly_price"`
+	TerminationFee float64 `json:"termination_fee"`
+}
+
+// HomePlan represents a version of a home internet plan in the catalog
 type HomePlan struct {
-	HomeID       int     `json:"home_id" db:"home_id"`
-	Name         string  `json:"name" db:"name"`
-	Tech         string  `json:"tech" db:"tech"` // fiber, vdsl, fwa
-	DownMbps     int     `json:"down_mbps" db:"down_mbps"`
-	MonthlyPrice float64 `json:"monthly_price" db:"monthly_price"`
-	InstallFee   float64 `json:"install_fee" db:"install_fee"`
+	HomeID        int        `json:"home_id" db:"home_id"`
+	Name          string     `json:"name" db:"name"`
+	Tech          string     `json:"tech" db:"tech"` // fiber, vdsl, fwa
+	DownMbps      int        `json:"down_mbps" db:"down_mbps"`
+	MonthlyPrice  float64    `json:"monthly_price" db:"monthly_price"`
+	InstallFee    float64    `json:"install_fee" db:"install_fee"`
+	Terms         []PlanTerm `json:"terms,omitempty" db:"terms"`
+	Version       int        `json:"version" db:"version"`
+	EffectiveFrom time.Time  `json:"effective_from" db:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty" db:"effective_to"`
 }
 
-// TVPlan represents a TV plan in the catalog
+// TVPlan represents a version of a TV plan in the catalog
 type TVPlan struct {
-	TVID            int     `json:"tv_id" db:"tv_id"`
-	Name            string  `json:"name" db:"name"`
-	HDHoursIncluded float64 `json:"hd_hours_included" db:"hd_hours_included"`
-	MonthlyPrice    float64 `json:"monthly_price" db:"monthly_price"`
+	TVID            int        `json:"tv_id" db:"tv_id"`
+	Name            string     `json:"name" db:"name"`
+	HDHoursIncluded float64    `json:"hd_hours_included" db:"hd_hours_included"`
+	MonthlyPrice    float64    `json:"monthly_price" db:"monthly_price"`
+	Terms           []PlanTerm `json:"terms,omitempty" db:"terms"`
+	Version         int        `json:"version" db:"version"`
+	EffectiveFrom   time.Time  `json:"effective_from" db:"effective_from"`
+	EffectiveTo     *time.Time `json:"effective_to,omitempty" db:"effective_to"`
 }
 
-// BundlingRule represents a bundling rule for discounts
+// BundlingRule represents a version of a bundling rule for discounts
 type BundlingRule struct {
-	RuleID          int     `json:"rule_id" db:"rule_id"`
-	RuleType        string  `json:"rule_type" db:"rule_type"` // line_discount, bundle_discount
-	Description     string  `json:"description" db:"description"`
-	DiscountPercent float64 `json:"discount_percent" db:"discount_percent"`
-	AppliesTo       string  `json:"applies_to" db:"applies_to"` // mobile, home, tv, total
+	RuleID          int        `json:"rule_id" db:"rule_id"`
+	RuleType        string     `json:"rule_type" db:"rule_type"` // line_discount, bundle_discount
+	Description     string     `json:"description" db:"description"`
+	DiscountPercent float64    `json:"discount_percent" db:"discount_percent"`
+	AppliesTo       string     `json:"applies_to" db:"applies_to"` // mobile, home, tv, total
+	Version         int        `json:"version" db:"version"`
+	EffectiveFrom   time.Time  `json:"effective_from" db:"effective_from"`
+	EffectiveTo     *time.Time `json:"effective_to,omitempty" db:"effective_to"`
 }
 
-// InstallSlot represents an installation time slot
+// InstallSlot represents an installation time slot. Hand-seeded slots belong to a single
+// address; generated slots belong to a crew and serve every address in its service areas.
 type InstallSlot struct {
-	SlotID    string    `json:"slot_id" db:"slot_id"`
-	AddressID string    `json:"address_id" db:"address_id"`
-	SlotStart time.Time `json:"slot_start" db:"slot_start"`
-	SlotEnd   time.Time `json:"slot_end" db:"slot_end"`
-	Tech      string    `json:"tech" db:"tech"` // fiber, vdsl, fwa
-	Available bool      `json:"available" db:"available"`
+	SlotID            string    `json:"slot_id" db:"slot_id"`
+	AddressID         string    `json:"address_id,omitempty" db:"address_id"`
+	CrewID            *int      `json:"crew_id,omitempty" db:"crew_id"`
+	SlotStart         time.Time `json:"slot_start" db:"slot_start"`
+	SlotEnd           time.Time `json:"slot_end" db:"slot_end"`
+	Tech              string    `json:"tech" db:"tech"` // fiber, vdsl, fwa
+	Capacity          int       `json:"capacity" db:"capacity"`
+	RemainingCapacity int       `json:"remaining_capacity" db:"remaining_capacity"`
 }
 
-// Catalog represents all available plans and rules
+// Catalog represents the plans and rules in effect at a time
 type Catalog struct {
 	MobilePlans   []MobilePlan   `json:"mobile_plans"`
 	HomePlans     []HomePlan     `json:"home_plans"`
 	TVPlans       []TVPlan       `json:"tv_plans"`
 	BundlingRules []BundlingRule `json:"bundling_rules"`
+
+	// ValidUntil is when a scheduled change next takes effect, nil when none is
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
 }
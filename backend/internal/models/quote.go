@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Quote is a priced recommendation candidate that checkout can turn into an order.
+// The price is bound to the catalog version and household it was computed for.
+type Quote struct {
+	QuoteID          string          `json:"quote_id" db:"quote_id"`
+	UserID           int             `json:"user_id" db:"user_id"`
+	AddressID        string          `json:"address_id" db:"address_id"`
+	CatalogVersion   string          `json:"catalog_version" db:"catalog_version"`
+	HouseholdHash    string          `json:"household_hash" db:"household_hash"`
+	ComboLabel       string          `json:"combo_label" db:"combo_label"`
+	MonthlyTotal     float64         `json:"monthly_total" db:"monthly_total"`
+	Tech             *string         `json:"tech" db:"tech"`
+	Items            json.RawMessage `json:"items" db:"items"`
+	Promotions       json.RawMessage `json:"promotions" db:"promotions"`               // the promotions applied, as api.PromotionDTO
+	CommitmentMonths int             `json:"commitment_months" db:"commitment_months"` // 0 without commitment
+	TerminationFee   float64         `json:"termination_fee" db:"termination_fee"`     // for terminating before the commitment ends
+	ExpiresAt        time.Time       `json:"expires_at" db:"expires_at"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// RateLimitDecision is the outcome of taking a token from a rate limit bucket.
+// Remaining is the number of whole tokens left; RetryAfterMS is how long until the next
+// token when the request is refused.
+type RateLimitDecision struct {
+	Allowed      bool `json:"allowed" db:"allowed"`
+	Remaining    int  `json:"remaining" db:"remaining"`
+	RetryAfterMS int  `json:"retry_after_ms" db:"retry_after_ms"`
+}
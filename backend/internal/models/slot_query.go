@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Time-of-day buckets for install slot searches, based on the slot's local start hour
+const (
+	TimeOfDayMorning   = "morning"   // starts before 12:00
+	TimeOfDayAfternoon = "afternoon" // starts 12:00-16:59
+	TimeOfDayEvening   = "evening"   // starts 17:00 or later
+)
+
+// HourRange is a half-open [From, To) range of local start hours
+type HourRange struct {
+	From int
+	To   int
+}
+
+// TimeOfDayHours maps each time-of-day bucket to the local start hours it covers
+var TimeOfDayHours = map[string]HourRange{
+	TimeOfDayMorning:   {From: 0, To: 12},
+	TimeOfDayAfternoon: {From: 12, To: 17},
+	TimeOfDayEvening:   {From: 17, To: 24},
+}
+
+// SlotQuery describes an install slot search for a single address.
+// Zero values mean "no filter" for Techs, From, To and TimesOfDay; Limit 0 means no limit.
+type SlotQuery struct {
+	AddressID  string
+	Techs      []string
+	From       time.Time // inclusive lower bound on slot start
+	To         time.Time // exclusive upper bound on slot start
+	TimesOfDay []string
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,35 @@
+package models
+
+// Crew represents an installation crew with its technicians and calendar
+type Crew struct {
+	CrewID       int            `json:"crew_id" db:"crew_id"`
+	Name         string         `json:"name" db:"name"`
+	Active       bool           `json:"active" db:"active"`
+	Technicians  []Technician   `json:"technicians"`
+	ServiceAreas []ServiceArea  `json:"service_areas"`
+	WorkingHours []WorkingHours `json:"working_hours"`
+}
+
+// Technician represents a field technician and the technologies they can install
+type Technician struct {
+	TechnicianID int      `json:"technician_id" db:"technician_id"`
+	CrewID       int      `json:"crew_id" db:"crew_id"`
+	Name         string   `json:"name" db:"name"`
+	Techs        []string `json:"techs" db:"techs"` // fiber, vdsl, fwa
+	Active       bool     `json:"active" db:"active"`
+}
+
+// ServiceArea represents a district a crew is dispatched to
+type ServiceArea struct {
+	CrewID   int    `json:"crew_id" db:"crew_id"`
+	City     string `json:"city" db:"city"`
+	District string `json:"district" db:"district"`
+}
+
+// WorkingHours represents a crew's working window on one weekday
+type WorkingHours struct {
+	CrewID    int    `json:"crew_id" db:"crew_id"`
+	Weekday   int    `json:"weekday" db:"weekday"`       // 0 = Sunday, as time.Weekday
+	StartTime string `json:"start_time" db:"start_time"` // HH:MM or HH:MM:SS
+	EndTime   string `json:"end_time" db:"end_time"`     // HH:MM or HH:MM:SS
+}
@@ -8,6 +8,9 @@ type User struct {
 	Name               string    `json:"name" db:"name"`
 	AddressID          string    `json:"address_id" db:"address_id"`
 	CurrentBundleLabel *string   `json:"current_bundle_label" db:"current_bundle_label"`
+	Email              *string   `json:"email" db:"email"`
+	Phone              *string   `json:"phone" db:"phone"`
+	Locale             string    `json:"locale" db:"locale"` // tr, en
 	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -21,6 +24,16 @@ type Coverage struct {
 	FWA       bool   `json:"fwa" db:"fwa"`
 }
 
+// DistrictCoverage represents aggregated technology availability for a city district
+type DistrictCoverage struct {
+	City           string `json:"city" db:"city"`
+	District       string `json:"district" db:"district"`
+	Addresses      int    `json:"addresses" db:"addresses"`
+	FiberAddresses int    `json:"fiber_addresses" db:"fiber_addresses"`
+	VDSLAddresses  int    `json:"vdsl_addresses" db:"vdsl_addresses"`
+	FWAAddresses   int    `json:"fwa_addresses" db:"fwa_addresses"`
+}
+
 // Household represents a household member and their usage patterns
 type Household struct {
 	ID          int     `json:"id" db:"id"`
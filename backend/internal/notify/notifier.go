@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Notification channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// ErrRejected marks a send that will never succeed, such as an invalid recipient, so it
+// is not retried
+var ErrRejected = errors.New("message rejected")
+
+// Message is a rendered notification addressed to one recipient
+type Message struct {
+	To      string
+	Subject string // ignored by SMS
+	Body    string
+}
+
+// Notifier delivers messages over one channel
+type Notifier interface {
+	// Channel returns the channel the notifier delivers over, ChannelEmail or ChannelSMS
+	Channel() string
+	// Send delivers the message. Errors wrapping ErrRejected are permanent.
+	Send(ctx context.Context, msg Message) error
+}
+
+// rejected wraps err so it matches ErrRejected
+func rejected(err error) error {
+	return fmt.Errorf("%w: %v", ErrRejected, err)
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultSMSTimeout is the HTTP timeout of the SMS gateway client
+const defaultSMSTimeout = 10 * time.Second
+
+// SMSConfig configures the SMS gateway notifier
+type SMSConfig struct {
+	URL    string // endpoint messages are POSTed to
+	Token  string // sent as a bearer token
+	Sender string // sender ID shown to the customer
+}
+
+// SMSNotifier sends text messages through an HTTP SMS gateway. Each message is POSTed
+// as JSON {"from", "to", "text"}; any 2xx response means the gateway accepted it.
+type SMSNotifier struct {
+	config SMSConfig
+	client *http.Client
+}
+
+// NewSMSNotifier creates an SMS gateway notifier
+func NewSMSNotifier(config SMSConfig) *SMSNotifier {
+	return &SMSNotifier{
+		config: config,
+		client: &http.Client{Timeout: defaultSMSTimeout},
+	}
+}
+
+// Channel returns ChannelSMS
+func (n *SMSNotifier) Channel() string {
+	return ChannelSMS
+}
+
+// Send delivers the message body. Client errors other than 429 are returned as
+// ErrRejected since resending the same request cannot succeed.
+func (n *SMSNotifier) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"from": n.config.Sender,
+		"to":   msg.To,
+		"text": msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode SMS: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.Token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("SMS gateway request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("SMS gateway returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return rejected(err)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSMSNotifierSend(t *testing.T) {
+	var got map[string]string
+	var auth string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer gateway.Close()
+
+	notifier := NewSMSNotifier(SMSConfig{URL: gateway.URL, Token: "secret", Sender: "TURKCELL"})
+	if err := notifier.Send(context.Background(), Message{To: "+905551000004", Subject: "ignored", Body: "Kurulum yarın"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if auth != "Bearer secret" {
+		t.Errorf("Expected bearer token, got %q", auth)
+	}
+	if got["from"] != "TURKCELL" || got["to"] != "+905551000004" || got["text"] != "Kurulum yarın" {
+		t.Errorf("Unexpected payload: %v", got)
+	}
+}
+
+func TestSMSNotifierErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		permanent bool
+	}{
+		{"Invalid number", http.StatusBadRequest, true},
+		{"Unauthorised", http.StatusUnauthorized, true},
+		{"Rate limited", http.StatusTooManyRequests, false},
+		{"Gateway down", http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, tt.name, tt.status)
+			}))
+			defer gateway.Close()
+
+			err := NewSMSNotifier(SMSConfig{URL: gateway.URL}).Send(context.Background(), Message{To: "+905551000004", Body: "Hi"})
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if errors.Is(err, ErrRejected) != tt.permanent {
+				t.Errorf("Expected permanent=%v, got %v", tt.permanent, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a minimal SMTP server recording the messages it receives. Recipients
+// listed in reject are refused with a permanent 550 reply.
+type fakeSMTPServer struct {
+	listener net.Listener
+	reject   map[string]bool
+
+	mu       sync.Mutex
+	messages []receivedMail
+}
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := &fakeSMTPServer{listener: listener, reject: make(map[string]bool)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+
+	var current receivedMail
+	tp.PrintfLine("220 localhost fake SMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 localhost")
+		case "MAIL":
+			current = receivedMail{from: addressArg(line)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			to := addressArg(line)
+			if s.reject[to] {
+				tp.PrintfLine("550 No such user")
+				continue
+			}
+			current.to = append(current.to, to)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			tp.PrintfLine("250 Queued")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// addressArg extracts the address from "MAIL FROM:<a@b>" or "RCPT TO:<a@b>"
+func addressArg(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func newTestSMTPNotifier(t *testing.T, server *fakeSMTPServer) *SMTPNotifier {
+	t.Helper()
+
+	notifier, err := NewSMTPNotifier(SMTPConfig{
+		Host: "127.0.0.1",
+		Port: server.port(),
+		From: "Turkcell <no-reply@turkcell.com.tr>",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	return notifier
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	notifier := newTestSMTPNotifier(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := notifier.Send(ctx, Message{
+		To:      "Ayşe Kaya <ayse.kaya@example.com>",
+		Subject: "Siparişiniz alındı",
+		Body:    "Merhaba Ayşe,\nKurulum randevunuz: 16 Aralık 2024 Pazartesi 09:00-12:00",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	received := server.received()
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(received))
+	}
+	got := received[0]
+	if got.from != "no-reply@turkcell.com.tr" || len(got.to) != 1 || got.to[0] != "ayse.kaya@example.com" {
+		t.Errorf("Unexpected envelope: from %q to %v", got.from, got.to)
+	}
+
+	parsed, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(got.data)))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Siparişiniz alındı" {
+		t.Errorf("Expected decoded Turkish subject, got %q (%v)", subject, err)
+	}
+	if ct := parsed.Header.Get("Content-Type"); !strings.Contains(ct, "utf-8") {
+		t.Errorf("Expected UTF-8 content type, got %q", ct)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if !strings.Contains(string(body), "16 Aralık 2024 Pazartesi") {
+		t.Errorf("Expected body to survive encoding, got %q", body)
+	}
+}
+
+func TestSMTPNotifierRejectedRecipient(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.reject["nobody@example.com"] = true
+	notifier := newTestSMTPNotifier(t, server)
+
+	err := notifier.Send(context.Background(), Message{To: "nobody@example.com", Subject: "Hi", Body: "Hi"})
+	if !errors.Is(err, ErrRejected) {
+		t.Errorf("Expected ErrRejected for a 550 reply, got %v", err)
+	}
+
+	err = notifier.Send(context.Background(), Message{To: "not an address", Subject: "Hi", Body: "Hi"})
+	if !errors.Is(err, ErrRejected) {
+		t.Errorf("Expected ErrRejected for an invalid address, got %v", err)
+	}
+}
+
+func TestSMTPNotifierConnectionFailureIsRetryable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	notifier, err := NewSMTPNotifier(SMTPConfig{Host: "127.0.0.1", Port: port, From: "no-reply@turkcell.com.tr"})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	err = notifier.Send(context.Background(), Message{To: "a@example.com", Subject: "Hi", Body: "Hi"})
+	if err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("Expected a retryable error, got %v", err)
+	}
+}
+
+func TestNewSMTPNotifierRejectsInvalidFrom(t *testing.T) {
+	if _, err := NewSMTPNotifier(SMTPConfig{Host: "localhost", Port: 25, From: "invalid"}); err == nil {
+		t.Error("Expected an invalid From address to be rejected")
+	}
+}
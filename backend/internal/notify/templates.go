@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+// Supported locales. Customers without a supported locale get Turkish messages.
+const (
+	LocaleTR = "tr"
+	LocaleEN = "en"
+)
+
+// TemplateData is the input of every notification template
+type TemplateData struct {
+	CustomerName  string
+	OrderID       string
+	ComboLabel    string
+	MonthlyTotal  float64
+	UpfrontAmount float64
+	SlotStart     *time.Time // nil when the order has no install slot
+	SlotEnd       *time.Time
+	Reason        string
+}
+
+// Rendered is a notification rendered for every channel
+type Rendered struct {
+	Subject string
+	Email   string
+	SMS     string
+}
+
+// messageTemplate holds the subject, email body and SMS text of one kind in one locale
+type messageTemplate struct {
+	subject string
+	email   string
+	sms     string
+}
+
+var templates = map[string]map[string]messageTemplate{
+	LocaleTR: {
+		models.NotificationOrderConfirmed: {
+			subject: "Siparişiniz alındı ({{.OrderID}})",
+			email: `Merhaba {{.CustomerName}},
+
+{{.ComboLabel}} siparişiniz onaylandı.
+
+Sipariş numarası: {{.OrderID}}
+Aylık ücret: {{money .MonthlyTotal}}
+Bugün tahsil edilen: {{money .UpfrontAmount}}
+{{- if .SlotStart}}
+Kurulum randevusu: {{appointment .SlotStart .SlotEnd}}
+{{- end}}
+
+Bizi tercih ettiğiniz için teşekkür ederiz.
+Turkcell`,
+			sms: `Turkcell: {{.OrderID}} numaralı siparişiniz onaylandı.{{if .SlotStart}} Kurulum: {{appointment .SlotStart .SlotEnd}}.{{end}}`,
+		},
+		models.NotificationAppointmentRescheduled: {
+			subject: "Kurulum randevunuz değişti ({{.OrderID}})",
+			email: `Merhaba {{.CustomerName}},
+
+{{.OrderID}} numaralı siparişinizin kurulum randevusu güncellendi.
+
+Yeni randevu: {{appointment .SlotStart .SlotEnd}}
+
+Turkcell`,
+			sms: `Turkcell: {{.OrderID}} siparişinizin yeni kurulum randevusu: {{appointment .SlotStart .SlotEnd}}.`,
+		},
+		models.NotificationOrderCancelled: {
+			subject: "Siparişiniz iptal edildi ({{.OrderID}})",
+			email: `Merhaba {{.CustomerName}},
+
+{{.OrderID}} numaralı siparişiniz iptal edildi.{{if .Reason}}
+İptal nedeni: {{.Reason}}{{end}}
+{{- if .UpfrontAmount}}
+Ödediğiniz {{money .UpfrontAmount}} kartınıza iade edilecektir.
+{{- end}}
+
+Turkcell`,
+			sms: `Turkcell: {{.OrderID}} numaralı siparişiniz iptal edildi.`,
+		},
+		models.NotificationInstallationReminder: {
+			subject: "Yarınki kurulum randevunuz ({{.OrderID}})",
+			email: `Merhaba {{.CustomerName}},
+
+{{.ComboLabel}} kurulumunuz için teknisyenimiz {{appointment .SlotStart .SlotEnd}} arasında adresinizde olacak.
+
+Randevuyu değiştirmeniz gerekirse lütfen bizimle iletişime geçin.
+
+Turkcell`,
+			sms: `Turkcell hatırlatma: kurulum randevunuz {{appointment .SlotStart .SlotEnd}}. Sipariş: {{.OrderID}}`,
+		},
+	},
+	LocaleEN: {
+		models.NotificationOrderConfirmed: {
+			subject: "Your order is confirmed ({{.OrderID}})",
+			email: `Hello {{.CustomerName}},
+
+Your {{.ComboLabel}} order is confirmed.
+
+Order number: {{.OrderID}}
+Monthly price: {{money .MonthlyTotal}}
+Charged today: {{money .UpfrontAmount}}
+{{- if .SlotStart}}
+Installation appointment: {{appointment .SlotStart .SlotEnd}}
+{{- end}}
+
+Thank you for choosing us.
+Turkcell`,
+			sms: `Turkcell: your order {{.OrderID}} is confirmed.{{if .SlotStart}} Installation: {{appointment .SlotStart .SlotEnd}}.{{end}}`,
+		},
+		models.NotificationAppointmentRescheduled: {
+			subject: "Your installation appointment has changed ({{.OrderID}})",
+			email: `Hello {{.CustomerName}},
+
+The installation appointment for order {{.OrderID}} has been updated.
+
+New appointment: {{appointment .SlotStart .SlotEnd}}
+
+Turkcell`,
+			sms: `Turkcell: the new installation appointment for order {{.OrderID}} is {{appointment .SlotStart .SlotEnd}}.`,
+		},
+		models.NotificationOrderCancelled: {
+			subject: "Your order has been cancelled ({{.OrderID}})",
+			email: `Hello {{.CustomerName}},
+
+Your order {{.OrderID}} has been cancelled.{{if .Reason}}
+Reason: {{.Reason}}{{end}}
+{{- if .UpfrontAmount}}
+The {{money .UpfrontAmount}} you paid will be refunded to your card.
+{{- end}}
+
+Turkcell`,
+			sms: `Turkcell: your order {{.OrderID}} has been cancelled.`,
+		},
+		models.NotificationInstallationReminder: {
+			subject: "Your installation is tomorrow ({{.OrderID}})",
+			email: `Hello {{.CustomerName}},
+
+Our technician will be at your address {{appointment .SlotStart .SlotEnd}} to install your {{.ComboLabel}}.
+
+Please contact us if you need to change the appointment.
+
+Turkcell`,
+			sms: `Turkcell reminder: your installation is {{appointment .SlotStart .SlotEnd}}. Order: {{.OrderID}}`,
+		},
+	},
+}
+
+// Render renders the notification kind in locale for every channel
+func Render(kind, locale string, data TemplateData) (*Rendered, error) {
+	if _, ok := templates[locale]; !ok {
+		locale = LocaleTR
+	}
+
+	tmpl, ok := templates[locale][kind]
+	if !ok {
+		return nil, fmt.Errorf("no %s template for notification kind %q", locale, kind)
+	}
+
+	funcs := template.FuncMap{
+		"money":       formatMoney(locale),
+		"appointment": formatAppointment(locale),
+	}
+
+	var rendered Rendered
+	for _, part := range []struct {
+		name, text string
+		out        *string
+	}{
+		{"subject", tmpl.subject, &rendered.Subject},
+		{"email", tmpl.email, &rendered.Email},
+		{"sms", tmpl.sms, &rendered.SMS},
+	} {
+		t, err := template.New(kind + "." + part.name).Funcs(funcs).Parse(part.text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template of %s: %w", part.name, kind, err)
+		}
+
+		var out strings.Builder
+		if err := t.Execute(&out, data); err != nil {
+			return nil, fmt.Errorf("failed to render %s template of %s: %w", part.name, kind, err)
+		}
+		*part.out = out.String()
+	}
+
+	return &rendered, nil
+}
+
+var (
+	trMonths   = []string{"Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran", "Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık"}
+	trWeekdays = []string{"Pazar", "Pazartesi", "Salı", "Çarşamba", "Perşembe", "Cuma", "Cumartesi"}
+)
+
+// formatMoney formats an amount in Turkish lira, e.g. "1.234,50 TL" or "TRY 1,234.50"
+func formatMoney(locale string) func(float64) string {
+	return func(amount float64) string {
+		formatted := fmt.Sprintf("%.2f", amount)
+		whole, fraction := formatted[:len(formatted)-3], formatted[len(formatted)-2:]
+
+		// Group the whole part in thousands
+		var grouped strings.Builder
+		for i, digit := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				if locale == LocaleEN {
+					grouped.WriteByte(',')
+				} else {
+					grouped.WriteByte('.')
+				}
+			}
+			grouped.WriteRune(digit)
+		}
+
+		if locale == LocaleEN {
+			return "TRY " + grouped.String() + "." + fraction
+		}
+		return grouped.String() + "," + fraction + " TL"
+	}
+}
+
+// formatAppointment formats an installation window in Istanbul time, e.g.
+// "16 Aralık 2024 Pazartesi 09:00-12:00" or "Monday 16 December 2024, 09:00-12:00"
+func formatAppointment(locale string) func(start, end *time.Time) string {
+	return func(start, end *time.Time) string {
+		if start == nil {
+			return ""
+		}
+
+		loc := utils.IstanbulLocation()
+		s := start.In(loc)
+		window := s.Format("15:04")
+		if end != nil {
+			window += "-" + end.In(loc).Format("15:04")
+		}
+
+		if locale == LocaleEN {
+			return s.Format("Monday 2 January 2006") + ", " + window
+		}
+		return fmt.Sprintf("%d %s %d %s %s", s.Day(), trMonths[s.Month()-1], s.Year(), trWeekdays[s.Weekday()], window)
+	}
+}
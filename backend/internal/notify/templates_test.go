@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/models"
+)
+
+func templateData() TemplateData {
+	start := time.Date(2024, 12, 16, 6, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Hour)
+	return TemplateData{
+		CustomerName:  "Ayşe Kaya",
+		OrderID:       "ORD-3F9A0C1B2D4E",
+		ComboLabel:    "Mobile + Fiber",
+		MonthlyTotal:  1242.73,
+		UpfrontAmount: 1441.73,
+		SlotStart:     &start,
+		SlotEnd:       &end,
+	}
+}
+
+func TestRenderAllKindsAndLocales(t *testing.T) {
+	kinds := []string{
+		models.NotificationOrderConfirmed,
+		models.NotificationAppointmentRescheduled,
+		models.NotificationOrderCancelled,
+		models.NotificationInstallationReminder,
+	}
+
+	for _, locale := range []string{LocaleTR, LocaleEN} {
+		for _, kind := range kinds {
+			rendered, err := Render(kind, locale, templateData())
+			if err != nil {
+				t.Fatalf("%s/%s: unexpected error: %v", locale, kind, err)
+			}
+			if !strings.Contains(rendered.Subject, "ORD-3F9A0C1B2D4E") || !strings.Contains(rendered.SMS, "ORD-3F9A0C1B2D4E") {
+				t.Errorf("%s/%s: expected order ID in subject and SMS, got %+v", locale, kind, rendered)
+			}
+			if rendered.Email == "" {
+				t.Errorf("%s/%s: empty email body", locale, kind)
+			}
+		}
+	}
+}
+
+func TestRenderLocalisesDatesAndAmounts(t *testing.T) {
+	tr, err := Render(models.NotificationOrderConfirmed, LocaleTR, templateData())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Slots are shown in Istanbul time (UTC+3)
+	if !strings.Contains(tr.Email, "16 Aralık 2024 Pazartesi 09:00-12:00") {
+		t.Errorf("Expected Turkish appointment, got:\n%s", tr.Email)
+	}
+	if !strings.Contains(tr.Email, "1.242,73 TL") {
+		t.Errorf("Expected Turkish amount, got:\n%s", tr.Email)
+	}
+
+	en, err := Render(models.NotificationOrderConfirmed, LocaleEN, templateData())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(en.Email, "Monday 16 December 2024, 09:00-12:00") {
+		t.Errorf("Expected English appointment, got:\n%s", en.Email)
+	}
+	if !strings.Contains(en.Email, "TRY 1,242.73") {
+		t.Errorf("Expected English amount, got:\n%s", en.Email)
+	}
+}
+
+func TestRenderFallsBackToTurkish(t *testing.T) {
+	rendered, err := Render(models.NotificationOrderCancelled, "de", templateData())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(rendered.Subject, "iptal") {
+		t.Errorf("Expected Turkish subject, got %q", rendered.Subject)
+	}
+}
+
+func TestRenderOmitsMissingSlot(t *testing.T) {
+	data := templateData()
+	data.SlotStart, data.SlotEnd = nil, nil
+
+	rendered, err := Render(models.NotificationOrderConfirmed, LocaleEN, data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(rendered.Email, "Installation appointment") || strings.Contains(rendered.SMS, "Installation:") {
+		t.Errorf("Expected no appointment line, got:\n%s\n%s", rendered.Email, rendered.SMS)
+	}
+}
+
+func TestRenderUnknownKind(t *testing.T) {
+	if _, err := Render("unknown", LocaleTR, templateData()); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+}
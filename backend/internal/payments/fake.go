@@ -0,0 +1,164 @@
+package payments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Magic card numbers understood by FakeProvider. Any other card passing the Luhn check
+// is approved. CardTimeout is authorised but its answer is lost, as when a gateway times
+// out after taking the payment.
+const (
+	CardApproved               = "4242424242424242"
+	CardDeclined               = "4000000000000002"
+	CardAuthenticationRequired = "4000000000003220"
+	CardTimeout                = "4000000000000119"
+)
+
+// FakeProvider is a deterministic in-memory PaymentProvider for local development and
+// tests. Outcomes depend only on the card number, and payment IDs only on the reference,
+// so retrying an authorisation for the same order returns the same payment.
+type FakeProvider struct {
+	mu       sync.Mutex
+	payments map[string]*Payment
+}
+
+// NewFakeProvider creates an empty fake provider
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{payments: make(map[string]*Payment)}
+}
+
+// Authorize approves the card unless it is one of the failure magic numbers
+func (f *FakeProvider) Authorize(ctx context.Context, req AuthorizeRequest) (*Payment, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidAmount, req.Amount)
+	}
+
+	number := strings.ReplaceAll(req.Card.Number, " ", "")
+	if !luhnValid(number) {
+		return nil, fmt.Errorf("%w: card number fails the checksum", ErrInvalidCard)
+	}
+
+	switch number {
+	case CardDeclined:
+		return nil, ErrDeclined
+	case CardAuthenticationRequired:
+		return nil, ErrAuthenticationRequired
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := fakePaymentID(req.Reference)
+	if existing, ok := f.payments[id]; ok {
+		p := *existing
+		return &p, nil
+	}
+
+	payment := &Payment{
+		ID:        id,
+		Reference: req.Reference,
+		Status:    StatusAuthorized,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+		CardLast4: number[len(number)-4:],
+	}
+	f.payments[id] = payment
+	if number == CardTimeout {
+		return nil, ErrTimeout
+	}
+
+	p := *payment
+	return &p, nil
+}
+
+// Capture charges the full authorised amount
+func (f *FakeProvider) Capture(ctx context.Context, paymentID string) (*Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[paymentID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayment, paymentID)
+	}
+
+	switch payment.Status {
+	case StatusAuthorized:
+		payment.Status = StatusCaptured
+		payment.Captured = payment.Amount
+	case StatusRefunded:
+		return nil, fmt.Errorf("payment %s was already refunded", paymentID)
+	}
+
+	p := *payment
+	return &p, nil
+}
+
+// Refund refunds the captured amount or voids an uncaptured authorisation
+func (f *FakeProvider) Refund(ctx context.Context, paymentID string) (*Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[paymentID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayment, paymentID)
+	}
+
+	if payment.Status != StatusRefunded {
+		payment.Status = StatusRefunded
+		payment.Refunded = payment.Captured
+	}
+
+	p := *payment
+	return &p, nil
+}
+
+// Lookup returns the payment authorised for reference
+func (f *FakeProvider) Lookup(ctx context.Context, reference string) (*Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[fakePaymentID(reference)]
+	if !ok {
+		return nil, fmt.Errorf("%w: no payment for %s", ErrUnknownPayment, reference)
+	}
+
+	p := *payment
+	return &p, nil
+}
+
+// fakePaymentID derives a stable payment ID from the order reference
+func fakePaymentID(reference string) string {
+	sum := sha256.Sum256([]byte(reference))
+	return "pay_fake_" + hex.EncodeToString(sum[:8])
+}
+
+// luhnValid reports whether number is 12-19 digits with a valid Luhn check digit
+func luhnValid(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	return sum%10 == 0
+}
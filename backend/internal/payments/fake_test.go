@@ -0,0 +1,103 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func authorizeRequest(card string) AuthorizeRequest {
+	return AuthorizeRequest{
+		Reference: "ORD-1",
+		Amount:    44173,
+		Currency:  "TRY",
+		Card:      Card{Number: card, ExpiryMonth: 12, ExpiryYear: 2030, CVC: "123"},
+	}
+}
+
+func TestFakeProviderMagicCards(t *testing.T) {
+	tests := []struct {
+		card     string
+		expected error
+	}{
+		{CardApproved, nil},
+		{"5555555555554444", nil},
+		{"4242 4242 4242 4242", nil},
+		{CardDeclined, ErrDeclined},
+		{CardAuthenticationRequired, ErrAuthenticationRequired},
+		{CardTimeout, ErrTimeout},
+		{"4242424242424241", ErrInvalidCard},
+		{"1234", ErrInvalidCard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.card, func(t *testing.T) {
+			_, err := NewFakeProvider().Authorize(context.Background(), authorizeRequest(tt.card))
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestFakeProviderLifecycle(t *testing.T) {
+	provider := NewFakeProvider()
+	ctx := context.Background()
+
+	payment, err := provider.Authorize(ctx, authorizeRequest(CardApproved))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if payment.Status != StatusAuthorized || payment.CardLast4 != "4242" {
+		t.Errorf("Unexpected payment: %+v", payment)
+	}
+
+	// Retrying the same reference returns the same payment
+	again, _ := provider.Authorize(ctx, authorizeRequest(CardApproved))
+	if again.ID != payment.ID {
+		t.Errorf("Expected deterministic payment ID, got %s and %s", payment.ID, again.ID)
+	}
+
+	captured, err := provider.Capture(ctx, payment.ID)
+	if err != nil || captured.Status != StatusCaptured || captured.Captured != 44173 {
+		t.Fatalf("Unexpected capture: %+v, %v", captured, err)
+	}
+
+	refunded, err := provider.Refund(ctx, payment.ID)
+	if err != nil || refunded.Status != StatusRefunded || refunded.Refunded != 44173 {
+		t.Fatalf("Unexpected refund: %+v, %v", refunded, err)
+	}
+
+	if _, err := provider.Capture(ctx, payment.ID); err == nil {
+		t.Error("Expected capturing a refunded payment to fail")
+	}
+	if _, err := provider.Refund(ctx, "pay_unknown"); !errors.Is(err, ErrUnknownPayment) {
+		t.Errorf("Expected ErrUnknownPayment, got %v", err)
+	}
+}
+
+func TestFakeProviderRejectsZeroAmount(t *testing.T) {
+	req := authorizeRequest(CardApproved)
+	req.Amount = 0
+	if _, err := NewFakeProvider().Authorize(context.Background(), req); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount, got %v", err)
+	}
+}
+
+func TestFakeProviderLookupAfterTimeout(t *testing.T) {
+	provider := NewFakeProvider()
+	ctx := context.Background()
+
+	if _, err := provider.Lookup(ctx, "ORD-1"); !errors.Is(err, ErrUnknownPayment) {
+		t.Errorf("Expected ErrUnknownPayment before authorising, got %v", err)
+	}
+
+	// The timed out authorisation went through and is found by its reference
+	if _, err := provider.Authorize(ctx, authorizeRequest(CardTimeout)); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+	payment, err := provider.Lookup(ctx, "ORD-1")
+	if err != nil || payment.Status != StatusAuthorized {
+		t.Fatalf("Unexpected lookup: %+v, %v", payment, err)
+	}
+}
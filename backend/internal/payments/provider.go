@@ -0,0 +1,70 @@
+package payments
+
+import (
+	"context"
+	"errors"
+)
+
+// Payment errors returned by providers
+var (
+	ErrInvalidCard            = errors.New("invalid card details")
+	ErrDeclined               = errors.New("payment was declined")
+	ErrAuthenticationRequired = errors.New("payment requires 3-D Secure authentication")
+	ErrTimeout                = errors.New("payment provider timed out")
+	ErrUnknownPayment         = errors.New("unknown payment")
+	ErrInvalidAmount          = errors.New("invalid payment amount")
+)
+
+// Payment statuses stored on orders. An order's payment is unknown until its
+// authorisation answers, and failed when no payment was taken.
+const (
+	StatusUnknown    = "unknown"
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusRefunded   = "refunded"
+	StatusFailed     = "failed"
+)
+
+// Card holds the card details for an authorisation. Card data is only passed through to
+// the provider and never stored.
+type Card struct {
+	Number      string
+	ExpiryMonth int
+	ExpiryYear  int
+	CVC         string
+	HolderName  string
+}
+
+// AuthorizeRequest describes an amount to reserve on a card. Amounts are in minor units
+// (kuruş for TRY). Reference ties the payment to an order and makes retries idempotent.
+type AuthorizeRequest struct {
+	Reference string
+	Amount    int64
+	Currency  string
+	Card      Card
+}
+
+// Payment is the provider's view of a payment
+type Payment struct {
+	ID        string
+	Reference string
+	Status    string
+	Amount    int64
+	Captured  int64
+	Refunded  int64
+	Currency  string
+	CardLast4 string
+}
+
+// PaymentProvider authorises, captures and refunds card payments
+type PaymentProvider interface {
+	// Authorize reserves the amount on the card without charging it
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Payment, error)
+	// Capture charges a previously authorised payment
+	Capture(ctx context.Context, paymentID string) (*Payment, error)
+	// Refund returns the captured amount, or releases the authorisation if nothing was captured
+	Refund(ctx context.Context, paymentID string) (*Payment, error)
+	// Lookup finds the payment authorised for reference, e.g. after an authorisation timed
+	// out, and returns ErrUnknownPayment when there is none
+	Lookup(ctx context.Context, reference string) (*Payment, error)
+}
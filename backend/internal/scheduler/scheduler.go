@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"app/internal/logging"
+)
+
+// Job is a task run periodically by the scheduler
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Elector decides which replica runs the scheduled jobs. TryLead is called before every
+// job run and reports whether this process currently holds leadership; Resign gives it
+// up on shutdown.
+type Elector interface {
+	TryLead(ctx context.Context) (bool, error)
+	Resign(ctx context.Context) error
+}
+
+// AlwaysLeader is an Elector for single-replica deployments that always leads
+type AlwaysLeader struct{}
+
+// TryLead always reports leadership
+func (AlwaysLeader) TryLead(ctx context.Context) (bool, error) { return true, nil }
+
+// Resign is a no-op
+func (AlwaysLeader) Resign(ctx context.Context) error { return nil }
+
+// Scheduler runs jobs at fixed intervals on the elected leader replica. Each job runs in
+// its own goroutine, once at start and then every Interval; a run never overlaps with
+// the previous run of the same job.
+type Scheduler struct {
+	elector Elector
+	jobs    []Job
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex // serialises elector calls between job goroutines
+}
+
+// New creates a scheduler using elector for leader election
+func New(elector Elector) *Scheduler {
+	return &Scheduler{elector: elector}
+}
+
+// Add registers a job. Jobs must be added before Start.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start launches all registered jobs. They stop when ctx is cancelled or Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+
+	logging.For(ctx, "scheduler").Info("Scheduler started", "jobs", len(s.jobs))
+}
+
+// Stop cancels running jobs, waits for them to return and resigns leadership. If ctx
+// expires first, Stop returns its error without waiting any longer.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler jobs did not stop in time: %w", ctx.Err())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.elector.Resign(ctx); err != nil {
+		return fmt.Errorf("failed to resign scheduler leadership: %w", err)
+	}
+
+	logging.For(ctx, "scheduler").Info("Scheduler stopped")
+	return nil
+}
+
+// loop runs job immediately and then on every tick until ctx is cancelled
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.runOnce(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce runs job if this replica is the leader. A run may take at most one interval.
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Records logged by the job carry its name
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("job", job.Name))
+
+	s.mu.Lock()
+	leader, err := s.elector.TryLead(ctx)
+	s.mu.Unlock()
+	if err != nil {
+		logging.For(ctx, "scheduler").Warn("Leader election failed, skipping job", "error", err)
+		return
+	}
+	if !leader {
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Interval)
+	defer cancel()
+
+	start := time.Now()
+	if err := job.Run(runCtx); err != nil {
+		logging.For(ctx, "scheduler").Error("Job failed", "duration_ms", time.Since(start).Milliseconds(), "error", err)
+	}
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeElector grants leadership according to leader and counts resignations
+type fakeElector struct {
+	leader   atomic.Bool
+	err      error
+	resigned atomic.Int32
+}
+
+func (f *fakeElector) TryLead(ctx context.Context) (bool, error) {
+	return f.leader.Load(), f.err
+}
+
+func (f *fakeElector) Resign(ctx context.Context) error {
+	f.resigned.Add(1)
+	return nil
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsJobsPeriodically(t *testing.T) {
+	var runs atomic.Int32
+	s := New(AlwaysLeader{})
+	s.Add(Job{Name: "count", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	s.Start(context.Background())
+	waitFor(t, func() bool { return runs.Load() >= 3 })
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Errorf("Expected no runs after Stop, got %d more", runs.Load()-stopped)
+	}
+}
+
+func TestSchedulerRunsImmediately(t *testing.T) {
+	ran := make(chan struct{}, 1)
+	s := New(AlwaysLeader{})
+	s.Add(Job{Name: "once", Interval: time.Hour, Run: func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}})
+
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected job to run at start")
+	}
+}
+
+func TestSchedulerSkipsWhenNotLeader(t *testing.T) {
+	elector := &fakeElector{}
+	var runs atomic.Int32
+	s := New(elector)
+	s.Add(Job{Name: "count", Interval: 2 * time.Millisecond, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	s.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != 0 {
+		t.Fatalf("Expected no runs while not leader, got %d", runs.Load())
+	}
+
+	// Taking over leadership, e.g. after the previous leader died, starts running jobs
+	elector.leader.Store(true)
+	waitFor(t, func() bool { return runs.Load() > 0 })
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elector.resigned.Load() != 1 {
+		t.Errorf("Expected leadership to be resigned once, got %d", elector.resigned.Load())
+	}
+}
+
+func TestSchedulerSkipsOnElectionError(t *testing.T) {
+	elector := &fakeElector{err: errors.New("connection refused")}
+	elector.leader.Store(true)
+	var runs atomic.Int32
+	s := New(elector)
+	s.Add(Job{Name: "count", Interval: 2 * time.Millisecond, Run: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	s.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	s.Stop(context.Background())
+
+	if runs.Load() != 0 {
+		t.Errorf("Expected no runs when election fails, got %d", runs.Load())
+	}
+}
+
+func TestSchedulerStopCancelsRunningJob(t *testing.T) {
+	started := make(chan struct{})
+	var once sync.Once
+	s := New(AlwaysLeader{})
+	s.Add(Job{Name: "slow", Interval: time.Hour, Run: func(ctx context.Context) error {
+		once.Do(func() { close(started) })
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	s.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Expected running job to be cancelled, got %v", err)
+	}
+}
+
+func TestSchedulerStopTimeout(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	s := New(AlwaysLeader{})
+	s.Add(Job{Name: "stuck", Interval: time.Hour, Run: func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}})
+
+	s.Start(context.Background())
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+// AnalyticsService provides aggregated business reporting over coverage and the catalog
+type AnalyticsService struct {
+	db db.DatabaseInterface
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(database db.DatabaseInterface) *AnalyticsService {
+	return &AnalyticsService{
+		db: database,
+	}
+}
+
+// CoverageAnalytics returns per city/district technology availability together with the
+// cheapest mobile + home bundle that can be sold somewhere in each district
+func (s *AnalyticsService) CoverageAnalytics(ctx context.Context) (*CoverageAnalyticsReport, error) {
+	districts, err := s.db.GetDistrictCoverage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get district coverage: %w", err)
+	}
+
+	catalog, err := s.db.GetCatalog(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+
+	report := &CoverageAnalyticsReport{
+		Districts: make([]DistrictAnalytics, 0, len(districts)),
+	}
+
+	for _, d := range districts {
+		report.Districts = append(report.Districts, DistrictAnalytics{
+			DistrictCoverage: d,
+			CheapestBundle:   CheapestDistrictBundle(d, catalog),
+		})
+	}
+
+	return report, nil
+}
+
+// CheapestDistrictBundle prices the cheapest mobile + home bundle available in a district.
+// A technology counts as available when at least one address in the district has it.
+// Returns nil when no home technology is available or the catalog lacks plans.
+func CheapestDistrictBundle(district models.DistrictCoverage, catalog *models.Catalog) *DistrictBundlePrice {
+	techAvailable := map[string]bool{
+		"fiber": district.FiberAddresses > 0,
+		"vdsl":  district.VDSLAddresses > 0,
+		"fwa":   district.FWAAddresses > 0,
+	}
+
+	if len(catalog.MobilePlans) == 0 {
+		return nil
+	}
+
+	cheapestMobile := catalog.MobilePlans[0]
+	for _, plan := range catalog.MobilePlans[1:] {
+		if plan.MonthlyPrice < cheapestMobile.MonthlyPrice {
+			cheapestMobile = plan
+		}
+	}
+
+	bundleDiscountRate := utils.CalcBundleDiscount(true, true, false)
+
+	var best *DistrictBundlePrice
+	for _, home := range catalog.HomePlans {
+		if !techAvailable[home.Tech] {
+			continue
+		}
+
+		breakdown := utils.CalcGrandTotal(cheapestMobile.MonthlyPrice, home.MonthlyPrice, 0, bundleDiscountRate)
+		if best == nil || breakdown.GrandTotal < best.MonthlyTotal {
+			best = &DistrictBundlePrice{
+				MonthlyTotal:   breakdown.GrandTotal,
+				Tech:           home.Tech,
+				HomePlanID:     home.HomeID,
+				HomePlanName:   home.Name,
+				MobilePlanID:   cheapestMobile.PlanID,
+				MobilePlanName: cheapestMobile.PlanName,
+			}
+		}
+	}
+
+	return best
+}
+
+// CoverageAnalyticsReport represents coverage and pricing analytics for all districts
+type CoverageAnalyticsReport struct {
+	Districts []DistrictAnalytics `json:"districts"`
+}
+
+// DistrictAnalytics represents coverage counts and the cheapest bundle for one district
+type DistrictAnalytics struct {
+	models.DistrictCoverage
+	CheapestBundle *DistrictBundlePrice `json:"cheapest_bundle"`
+}
+
+// DistrictBundlePrice represents the cheapest single-line mobile + home bundle in a district
+type DistrictBundlePrice struct {
+	MonthlyTotal   float64 `json:"monthly_total"`
+	Tech           string  `json:"tech"`
+	HomePlanID     int     `json:"home_plan_id"`
+	HomePlanName   string  `json:"home_plan_name"`
+	MobilePlanID   int     `json:"mobile_plan_id"`
+	MobilePlanName string  `json:"mobile_plan_name"`
+}
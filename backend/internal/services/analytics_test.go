@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"app/internal/models"
+)
+
+func analyticsTestCatalog() *models.Catalog {
+	return &models.Catalog{
+		MobilePlans: []models.MobilePlan{
+			{PlanID: 2, PlanName: "Standard 10GB", MonthlyPrice: 149.90},
+			{PlanID: 1, PlanName: "Basic 5GB", MonthlyPrice: 99.90},
+		},
+		HomePlans: []models.HomePlan{
+			{HomeID: 1, Name: "Fiber 50Mbps", Tech: "fiber", DownMbps: 50, MonthlyPrice: 89.90},
+			{HomeID: 4, Name: "VDSL 25Mbps", Tech: "vdsl", DownMbps: 25, MonthlyPrice: 69.90},
+			{HomeID: 6, Name: "FWA 30Mbps", Tech: "fwa", DownMbps: 30, MonthlyPrice: 79.90},
+		},
+	}
+}
+
+func TestCheapestDistrictBundle(t *testing.T) {
+	catalog := analyticsTestCatalog()
+
+	tests := []struct {
+		name          string
+		district      models.DistrictCoverage
+		expectedHome  int
+		expectedTotal float64
+		expectNil     bool
+	}{
+		{
+			name:          "VDSL is cheapest when available",
+			district:      models.DistrictCoverage{City: "Istanbul", District: "Kadikoy", Addresses: 2, FiberAddresses: 2, VDSLAddresses: 1},
+			expectedHome:  4,
+			expectedTotal: (99.90 + 69.90) * 0.90,
+		},
+		{
+			name:          "Fiber only district",
+			district:      models.DistrictCoverage{City: "Izmir", District: "Bornova", Addresses: 1, FiberAddresses: 1},
+			expectedHome:  1,
+			expectedTotal: (99.90 + 89.90) * 0.90,
+		},
+		{
+			name:      "No home technology",
+			district:  models.DistrictCoverage{City: "Rural", District: "Remote", Addresses: 3},
+			expectNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := CheapestDistrictBundle(tt.district, catalog)
+
+			if tt.expectNil {
+				if bundle != nil {
+					t.Errorf("Expected no bundle, got %+v", bundle)
+				}
+				return
+			}
+
+			if bundle == nil {
+				t.Fatal("Expected a bundle, got nil")
+			}
+
+			if bundle.HomePlanID != tt.expectedHome {
+				t.Errorf("Expected home plan %d, got %d", tt.expectedHome, bundle.HomePlanID)
+			}
+
+			if bundle.MobilePlanID != 1 {
+				t.Errorf("Expected cheapest mobile plan 1, got %d", bundle.MobilePlanID)
+			}
+
+			if math.Abs(bundle.MonthlyTotal-tt.expectedTotal) > 0.01 {
+				t.Errorf("Expected total %.2f, got %.2f", tt.expectedTotal, bundle.MonthlyTotal)
+			}
+		})
+	}
+}
+
+func TestCoverageAnalytics(t *testing.T) {
+	mock := &mockDB{
+		districts: []models.DistrictCoverage{
+			{City: "Ankara", District: "Cankaya", Addresses: 1, FiberAddresses: 1, VDSLAddresses: 1},
+			{City: "Izmir", District: "Konak", Addresses: 1, FWAAddresses: 1},
+		},
+		catalog: analyticsTestCatalog(),
+	}
+
+	report, err := NewAnalyticsService(mock).CoverageAnalytics(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(report.Districts) != 2 {
+		t.Fatalf("Expected 2 districts, got %d", len(report.Districts))
+	}
+
+	konak := report.Districts[1]
+	if konak.District != "Konak" || konak.FWAAddresses != 1 {
+		t.Errorf("Unexpected district stats: %+v", konak.DistrictCoverage)
+	}
+
+	if konak.CheapestBundle == nil || konak.CheapestBundle.Tech != "fwa" {
+		t.Errorf("Expected an FWA bundle for Konak, got %+v", konak.CheapestBundle)
+	}
+}
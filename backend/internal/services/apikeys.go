@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to recognize
+const apiKeyPrefix = "pk_"
+
+// apiKeyTouchInterval is how stale last_used_at may get before a request updates it, so
+// busy keys do not write to the database on every request
+const apiKeyTouchInterval = time.Minute
+
+var (
+	// ErrInvalidAPIKey is returned for keys that are malformed, unknown, revoked or
+	// expired
+	ErrInvalidAPIKey = db.NewError(ErrUnauthorized, "invalid API key")
+	// ErrInvalidAPIKeyRequest is returned for API keys that cannot be created as requested
+	ErrInvalidAPIKeyRequest = db.NewError(ErrInvalidInput, "invalid API key request")
+	// ErrAPIKeyRevoked is returned when rotating a revoked key
+	ErrAPIKeyRevoked = db.ErrAPIKeyRevoked
+)
+
+// APIKeyService issues the keys partners call the API with and authenticates requests
+// carrying them. A key reads "pk_<prefix>_<secret>"; the database keeps the prefix, to
+// find the key, and the SHA-256 of the whole key, so a database leak does not leak keys.
+type APIKeyService struct {
+	db            db.DatabaseInterface
+	rotationGrace time.Duration
+	now           func() time.Time
+}
+
+// NewAPIKeyService creates an API key service. Rotated keys keep working for
+// rotationGrace, unless the rotation asks for another grace period.
+func NewAPIKeyService(database db.DatabaseInterface, rotationGrace time.Duration) *APIKeyService {
+	return &APIKeyService{
+		db:            database,
+		rotationGrace: rotationGrace,
+		now:           time.Now,
+	}
+}
+
+// Create issues a key named name granting scopes. The key is limited to perMinute
+// requests per minute with bursts of burst in every route group when perMinute is set,
+// and expires at expiresAt when set. The returned key carries the key itself in Key; it
+// is not shown again.
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []string, perMinute, burst *int, expiresAt *time.Time) (*models.APIKey, error) {
+	if perMinute != nil && burst == nil {
+		burst = perMinute
+	}
+	if burst != nil && perMinute == nil {
+		return nil, fmt.Errorf("%w: rate_limit_burst requires rate_limit_per_minute", ErrInvalidAPIKeyRequest)
+	}
+	if expiresAt != nil && !expiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKeyRequest)
+	}
+
+	return s.issue(ctx, models.APIKey{
+		Name:               name,
+		Scopes:             scopes,
+		RateLimitPerMinute: perMinute,
+		RateLimitBurst:     burst,
+		ExpiresAt:          expiresAt,
+	})
+}
+
+// issue generates the key of template and stores it
+func (s *APIKeyService) issue(ctx context.Context, template models.APIKey) (*models.APIKey, error) {
+	raw, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	template.Prefix = prefix
+	template.KeyHash = hashAPIKey(raw)
+	created, err := s.db.CreateAPIKey(ctx, &template)
+	if err != nil {
+		return nil, err
+	}
+
+	created.KeyHash = ""
+	created.Key = raw
+	return created, nil
+}
+
+// generateAPIKey returns a new random key and its prefix
+func generateAPIKey() (raw, prefix string, err error) {
+	prefix, err = randomHex(6)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	return apiKeyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+// Keys returns every key without its hash
+func (s *APIKeyService) Keys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.db.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		keys[i].KeyHash = ""
+	}
+	return keys, nil
+}
+
+// Revoke stops a key from working at once
+func (s *APIKeyService) Revoke(ctx context.Context, id int64) (*models.APIKey, error) {
+	key, err := s.db.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	key.KeyHash = ""
+	return key, nil
+}
+
+// Rotate issues a replacement for a key with the same name, scopes, limits and expiry,
+// and lets the old key expire after grace, or the configured grace period when grace is
+// nil, so the partner can switch over without downtime. Both happen in one transaction,
+// so a failed rotation changes nothing. The returned key carries the new key in Key.
+func (s *APIKeyService) Rotate(ctx context.Context, id int64, grace *time.Duration) (*models.APIKey, error) {
+	raw, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	period := s.rotationGrace
+	if grace != nil {
+		period = *grace
+	}
+	replacement, err := s.db.RotateAPIKey(ctx, id, prefix, hashAPIKey(raw), s.now().Add(period))
+	if err != nil {
+		return nil, err
+	}
+
+	replacement.KeyHash = ""
+	replacement.Key = raw
+	return replacement, nil
+}
+
+// Authenticate returns the key raw belongs to, without its hash. It returns an error
+// matching ErrInvalidAPIKey when the key is malformed, unknown, revoked or expired.
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*models.APIKey, error) {
+	prefix, ok := parseAPIKey(raw)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed key", ErrInvalidAPIKey)
+	}
+
+	key, err := s.db.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, db.ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown key", ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(raw)), []byte(key.KeyHash)) != 1 {
+		return nil, fmt.Errorf("%w: unknown key", ErrInvalidAPIKey)
+	}
+	now := s.now()
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: key was revoked", ErrInvalidAPIKey)
+	}
+	if key.Expired(now) {
+		return nil, fmt.Errorf("%w: key expired at %s", ErrInvalidAPIKey, key.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.db.TouchAPIKey(ctx, key.ID); err != nil {
+			logger(ctx).Warn("Failed to record API key use", "api_key_id", key.ID, "error", err)
+		}
+	}
+
+	key.KeyHash = ""
+	return key, nil
+}
+
+// parseAPIKey returns the prefix of a key shaped "pk_<prefix>_<secret>"
+func parseAPIKey(raw string) (string, bool) {
+	rest, ok := strings.CutPrefix(raw, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// hashAPIKey returns the hex SHA-256 of a key. Keys carry 256 random bits, so a plain
+// hash cannot be brute-forced and a slow password hash is not needed.
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes in hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
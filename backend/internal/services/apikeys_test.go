@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+func TestAPIKeyCreateAndAuthenticate(t *testing.T) {
+	database := &mockDB{}
+	service := NewAPIKeyService(database, time.Hour)
+	ctx := context.Background()
+
+	perMinute := 120
+	created, err := service.Create(ctx, "Dealer A", []string{models.ScopeRecommendationRead}, &perMinute, nil, nil)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, "pk_"+created.Prefix+"_") || created.KeyHash != "" {
+		t.Errorf("Expected the key without its hash, got key %q hash %q", created.Key, created.KeyHash)
+	}
+	if stored := database.apiKeys[0]; stored.KeyHash == "" || strings.Contains(stored.KeyHash, created.Key) || stored.Key != "" {
+		t.Errorf("Expected only the hash of the key to be stored, got %+v", stored)
+	}
+	if created.RateLimitBurst == nil || *created.RateLimitBurst != perMinute {
+		t.Errorf("Expected the burst to default to the per-minute limit, got %v", created.RateLimitBurst)
+	}
+
+	key, err := service.Authenticate(ctx, created.Key)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if key.ID != created.ID || !key.HasScope(models.ScopeRecommendationRead) || key.HasScope(models.ScopeAdmin) {
+		t.Errorf("Authenticated the wrong key: %+v", key)
+	}
+
+	// last_used_at is recorded at most once a minute
+	if _, err := service.Authenticate(ctx, created.Key); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if database.apiKeyTouches != 1 {
+		t.Errorf("Expected 1 last_used_at update for 2 requests, got %d", database.apiKeyTouches)
+	}
+
+	for _, raw := range []string{"", "not-a-key", "pk_" + created.Prefix + "_wrong", "pk_unknown_" + strings.Repeat("0", 64)} {
+		if _, err := service.Authenticate(ctx, raw); !errors.Is(err, ErrInvalidAPIKey) || !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Authenticate(%q) = %v, want ErrInvalidAPIKey", raw, err)
+		}
+	}
+}
+
+func TestAPIKeyCreateRejectsPastExpiry(t *testing.T) {
+	service := NewAPIKeyService(&mockDB{}, time.Hour)
+
+	past := time.Now().Add(-time.Minute)
+	_, err := service.Create(context.Background(), "Dealer A", []string{models.ScopeAdmin}, nil, nil, &past)
+	if !errors.Is(err, ErrInvalidAPIKeyRequest) || !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidAPIKeyRequest, got %v", err)
+	}
+}
+
+func TestAPIKeyRevokedAndExpiredKeysAreInvalid(t *testing.T) {
+	database := &mockDB{}
+	service := NewAPIKeyService(database, time.Hour)
+	ctx := context.Background()
+
+	revoked, _ := service.Create(ctx, "Revoked", []string{models.ScopeCheckoutWrite}, nil, nil, nil)
+	if _, err := service.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := service.Authenticate(ctx, revoked.Key); !errors.Is(err, ErrInvalidAPIKey) || !strings.Contains(err.Error(), "revoked") {
+		t.Errorf("Expected a revoked key to be rejected, got %v", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiring, _ := service.Create(ctx, "Expiring", []string{models.ScopeCheckoutWrite}, nil, nil, &expiresAt)
+	service.now = func() time.Time { return expiresAt }
+	if _, err := service.Authenticate(ctx, expiring.Key); !errors.Is(err, ErrInvalidAPIKey) || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Expected an expired key to be rejected, got %v", err)
+	}
+}
+
+func TestAPIKeyRotateKeepsOldKeyForGracePeriod(t *testing.T) {
+	database := &mockDB{}
+	service := NewAPIKeyService(database, 24*time.Hour)
+	ctx := context.Background()
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	old, _ := service.Create(ctx, "Comparison site", []string{models.ScopeRecommendationRead, models.ScopeCheckoutWrite}, nil, nil, nil)
+	replacement, err := service.Rotate(ctx, old.ID, nil)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if replacement.Key == old.Key || replacement.RotatedFrom == nil || *replacement.RotatedFrom != old.ID || len(replacement.Scopes) != 2 {
+		t.Errorf("Expected a new key replacing %d with the same scopes, got %+v", old.ID, replacement)
+	}
+
+	// Both keys work during the grace period; the old one stops after it
+	for _, raw := range []string{old.Key, replacement.Key} {
+		if _, err := service.Authenticate(ctx, raw); err != nil {
+			t.Errorf("Expected both keys to work during the grace period, got %v", err)
+		}
+	}
+	service.now = func() time.Time { return now.Add(24 * time.Hour) }
+	if _, err := service.Authenticate(ctx, old.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected the old key to expire after the grace period, got %v", err)
+	}
+	if _, err := service.Authenticate(ctx, replacement.Key); err != nil {
+		t.Errorf("Expected the new key to keep working, got %v", err)
+	}
+
+	// A revoked key cannot be rotated back to life
+	if _, err := service.Revoke(ctx, replacement.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := service.Rotate(ctx, replacement.ID, nil); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("Expected ErrAPIKeyRevoked, got %v", err)
+	}
+}
+
+func TestAPIKeyFailedRotationChangesNothing(t *testing.T) {
+	database := &mockDB{}
+	service := NewAPIKeyService(database, time.Hour)
+	ctx := context.Background()
+
+	old, _ := service.Create(ctx, "Dealer", []string{models.ScopeCheckoutWrite}, nil, nil, nil)
+	database.rotateErr = fmt.Errorf("%w: connection reset", db.ErrUnavailable)
+	if _, err := service.Rotate(ctx, old.ID, nil); !errors.Is(err, db.ErrUnavailable) {
+		t.Fatalf("Expected the rotation to fail, got %v", err)
+	}
+
+	if len(database.apiKeys) != 1 || database.apiKeys[0].ExpiresAt != nil {
+		t.Errorf("Expected only the old key, still without expiry, got %d keys", len(database.apiKeys))
+	}
+}
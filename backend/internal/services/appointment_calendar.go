@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+// ErrNoAppointment is returned when an order has no install slot to export
+var ErrNoAppointment = db.NewError(ErrNotFound, "order has no installation appointment")
+
+const (
+	calendarProdID    = "-//Turkcell//Recommendation Engine//EN"
+	calendarUIDDomain = "install.turkcell.com.tr"
+	calendarOrganizer = "mailto:installations@turkcell.com.tr"
+	icsTimeLayout     = "20060102T150405"
+	icsMaxLineOctets  = 75
+)
+
+// istanbulVTimezone describes Europe/Istanbul, which has stayed on UTC+3 without
+// daylight saving since 2016
+var istanbulVTimezone = []string{
+	"BEGIN:VTIMEZONE",
+	"TZID:" + utils.IstanbulTimezone,
+	"BEGIN:STANDARD",
+	"DTSTART:19700101T000000",
+	"TZOFFSETFROM:+0300",
+	"TZOFFSETTO:+0300",
+	"TZNAME:+03",
+	"END:STANDARD",
+	"END:VTIMEZONE",
+}
+
+// AppointmentEvent holds the details rendered into an installation calendar event
+type AppointmentEvent struct {
+	OrderID     string
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Tech        string
+	ComboLabel  string
+	Location    string
+	Cancelled   bool
+	LastChanged time.Time
+}
+
+// AppointmentCalendar renders the order's installation appointment as an iCalendar
+// (RFC 5545) document. Cancelled orders produce a METHOD:CANCEL update for the same
+// event so calendar clients remove the previously imported appointment.
+func (s *OrderService) AppointmentCalendar(ctx context.Context, orderID string) ([]byte, error) {
+	order, err := s.db.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order %s: %w", orderID, err)
+	}
+
+	if order.SlotID == nil {
+		return nil, fmt.Errorf("order %s: %w", orderID, ErrNoAppointment)
+	}
+
+	slot, err := s.db.GetInstallSlot(ctx, *order.SlotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get install slot for order %s: %w", orderID, err)
+	}
+
+	// Every reschedule or cancellation after the initial booking bumps the sequence
+	history, err := s.db.GetAppointmentHistory(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appointment history for order %s: %w", orderID, err)
+	}
+
+	event := AppointmentEvent{
+		OrderID:     order.OrderID,
+		Sequence:    max(len(history)-1, 0),
+		Start:       slot.SlotStart,
+		End:         slot.SlotEnd,
+		Tech:        slot.Tech,
+		ComboLabel:  order.ComboLabel,
+		Location:    s.appointmentLocation(ctx, order.AddressID),
+		Cancelled:   order.Status == models.OrderStatusCancelled,
+		LastChanged: order.UpdatedAt,
+	}
+
+	return []byte(BuildAppointmentICS(event, time.Now())), nil
+}
+
+// appointmentLocation describes the installation address. Coverage data only adds
+// the district and city, so a failed lookup falls back to the bare address ID.
+func (s *OrderService) appointmentLocation(ctx context.Context, addressID string) string {
+	location := "Address " + addressID
+	coverage, err := s.db.GetCoverage(ctx, addressID)
+	if err != nil || coverage == nil {
+		return location
+	}
+	return fmt.Sprintf("%s, %s, %s", location, coverage.District, coverage.City)
+}
+
+// BuildAppointmentICS renders a VCALENDAR with a single VEVENT for the appointment.
+// The UID is derived from the order ID so reschedules and cancellations update the
+// same event in the customer's calendar.
+func BuildAppointmentICS(event AppointmentEvent, stamp time.Time) string {
+	method, status := "PUBLISH", "CONFIRMED"
+	if event.Cancelled {
+		method, status = "CANCEL", "CANCELLED"
+	}
+
+	location := utils.IstanbulLocation()
+	summary := strings.ToUpper(event.Tech) + " installation"
+	if event.Cancelled {
+		summary = "Cancelled: " + summary
+	}
+
+	description := fmt.Sprintf("Order %s\nPackage: %s\nTechnology: %s", event.OrderID, event.ComboLabel, event.Tech)
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + calendarProdID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:" + method,
+	}
+	lines = append(lines, istanbulVTimezone...)
+	lines = append(lines,
+		"BEGIN:VEVENT",
+		"UID:"+strings.ToLower(event.OrderID)+"@"+calendarUIDDomain,
+		"SEQUENCE:"+fmt.Sprint(event.Sequence),
+		"DTSTAMP:"+stamp.UTC().Format(icsTimeLayout)+"Z",
+		"DTSTART;TZID="+utils.IstanbulTimezone+":"+event.Start.In(location).Format(icsTimeLayout),
+		"DTEND;TZID="+utils.IstanbulTimezone+":"+event.End.In(location).Format(icsTimeLayout),
+		"SUMMARY:"+escapeICSText(summary),
+		"DESCRIPTION:"+escapeICSText(description),
+		"LOCATION:"+escapeICSText(event.Location),
+		"ORGANIZER;CN=Turkcell Installations:"+calendarOrganizer,
+		"STATUS:"+status,
+	)
+	if !event.LastChanged.IsZero() {
+		lines = append(lines, "LAST-MODIFIED:"+event.LastChanged.UTC().Format(icsTimeLayout)+"Z")
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICSLine(line))
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+// escapeICSText escapes a TEXT property value as described in RFC 5545 section 3.3.11
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// foldICSLine splits lines longer than 75 octets into CRLF + space continuations
+// without breaking multi-byte UTF-8 characters
+func foldICSLine(line string) string {
+	if len(line) <= icsMaxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > icsMaxLineOctets {
+			b.WriteString("\r\n ")
+			width = 1 // the leading space counts towards the next line
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/models"
+)
+
+func calendarMock(status string) *mockDB {
+	slotID := "C1-fiber-202603020600"
+	tech := "fiber"
+	return &mockDB{
+		coverage: map[string]*models.Coverage{
+			"A1001": {AddressID: "A1001", City: "Istanbul", District: "Kadikoy", Fiber: true},
+		},
+		slots: []models.InstallSlot{{
+			SlotID:    slotID,
+			SlotStart: time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC),
+			SlotEnd:   time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+			Tech:      "fiber",
+		}},
+		orders: map[string]*models.Order{
+			"ORD-ABC123": {
+				OrderID:    "ORD-ABC123",
+				AddressID:  "A1001",
+				SlotID:     &slotID,
+				Tech:       &tech,
+				Status:     status,
+				ComboLabel: "Mobile + Fiber 100Mbps",
+			},
+		},
+		history: map[string][]models.AppointmentChange{
+			"ORD-ABC123": {
+				{Action: models.AppointmentBooked},
+				{Action: models.AppointmentRescheduled},
+			},
+		},
+	}
+}
+
+func TestAppointmentCalendar(t *testing.T) {
+	service := NewOrderService(calendarMock(models.OrderStatusConfirmed), nil, nil, nil, DefaultRescheduleCutoff)
+
+	ics, err := service.AppointmentCalendar(context.Background(), "ORD-ABC123")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	body := string(ics)
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"METHOD:PUBLISH\r\n",
+		"TZID:Europe/Istanbul\r\n",
+		"UID:ord-abc123@install.turkcell.com.tr\r\n",
+		"SEQUENCE:1\r\n",
+		"DTSTART;TZID=Europe/Istanbul:20260302T090000\r\n",
+		"DTEND;TZID=Europe/Istanbul:20260302T120000\r\n",
+		"LOCATION:Address A1001\\, Kadikoy\\, Istanbul\r\n",
+		"STATUS:CONFIRMED\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected calendar to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestAppointmentCalendarCancelled(t *testing.T) {
+	service := NewOrderService(calendarMock(models.OrderStatusCancelled), nil, nil, nil, DefaultRescheduleCutoff)
+
+	ics, err := service.AppointmentCalendar(context.Background(), "ORD-ABC123")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	body := string(ics)
+	for _, want := range []string{"METHOD:CANCEL\r\n", "STATUS:CANCELLED\r\n", "UID:ord-abc123@install.turkcell.com.tr\r\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected calendar to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestAppointmentCalendarWithoutSlot(t *testing.T) {
+	mock := calendarMock(models.OrderStatusConfirmed)
+	mock.orders["ORD-ABC123"].SlotID = nil
+	service := NewOrderService(mock, nil, nil, nil, DefaultRescheduleCutoff)
+
+	_, err := service.AppointmentCalendar(context.Background(), "ORD-ABC123")
+	if !errors.Is(err, ErrNoAppointment) {
+		t.Errorf("Expected ErrNoAppointment, got %v", err)
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("ş", 60)
+	folded := foldICSLine(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > icsMaxLineOctets {
+			t.Errorf("Expected folded lines of at most %d octets, got %d", icsMaxLineOctets, len(part))
+		}
+	}
+
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("Expected unfolding to restore the line, got %q", unfolded)
+	}
+}
+
+func TestEscapeICSText(t *testing.T) {
+	got := escapeICSText("a,b;c\\d\ne")
+	want := `a\,b\;c\\d\ne`
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
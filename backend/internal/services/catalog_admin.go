@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// Catalog entities managed by the admin API, named after their tables
+const (
+	CatalogMobilePlans   = "mobile_plans"
+	CatalogHomePlans     = "home_plans"
+	CatalogTVPlans       = "tv_plans"
+	CatalogBundlingRules = "bundling_rules"
+	CatalogCoverage      = "coverage"
+	CatalogCampaigns     = "campaigns"
+)
+
+// catalogEntities are the catalog entities, with whether they are part of the cached
+// plan catalog
+var catalogEntities = map[string]bool{
+	CatalogMobilePlans:   true,
+	CatalogHomePlans:     true,
+	CatalogTVPlans:       true,
+	CatalogBundlingRules: true,
+	CatalogCoverage:      false,
+	CatalogCampaigns:     false,
+}
+
+// CatalogAdminService changes plans, bundling rules, coverage and campaigns for
+// administrators. Every change is recorded in the catalog audit log together with the
+// change itself, and drops the cached catalog so recommendations on this replica see it
+// at once.
+type CatalogAdminService struct {
+	db    db.DatabaseInterface
+	cache *CatalogCache
+}
+
+// NewCatalogAdminService creates a catalog admin service invalidating cache on changes
+func NewCatalogAdminService(database db.DatabaseInterface, cache *CatalogCache) *CatalogAdminService {
+	return &CatalogAdminService{
+		db:    database,
+		cache: cache,
+	}
+}
+
+// Catalog returns the plan catalog in effect at a time as stored, bypassing the cache
+func (s *CatalogAdminService) Catalog(ctx context.Context, at time.Time) (*models.Catalog, error) {
+	return s.db.GetCatalog(ctx, at)
+}
+
+// Create adds row, a whole row of entity keyed by column, on behalf of actor. Plans and
+// rules get a new ID and are sold from effectiveFrom, or now when it is nil; campaigns
+// get a new ID too, and coverage rows keep their address_id.
+func (s *CatalogAdminService) Create(ctx context.Context, entity, actor string, row interface{}, effectiveFrom *time.Time) (*models.CatalogAuditEntry, error) {
+	return s.apply(ctx, entity, models.CatalogActionCreate, "", actor, row, effectiveFrom)
+}
+
+// Update replaces every column but the key of the entity row with key. Plans and rules
+// get a new version from effectiveFrom, or now when it is nil, and keep the version in
+// effect before it for the orders that bought it.
+func (s *CatalogAdminService) Update(ctx context.Context, entity, key, actor string, row interface{}, effectiveFrom *time.Time) (*models.CatalogAuditEntry, error) {
+	return s.apply(ctx, entity, models.CatalogActionUpdate, key, actor, row, effectiveFrom)
+}
+
+// Delete removes the entity row with key. Plans and rules stop being sold from
+// effectiveFrom, or now when it is nil, and keep their past versions.
+func (s *CatalogAdminService) Delete(ctx context.Context, entity, key, actor string, effectiveFrom *time.Time) (*models.CatalogAuditEntry, error) {
+	return s.apply(ctx, entity, models.CatalogActionDelete, key, actor, nil, effectiveFrom)
+}
+
+// Campaigns returns every campaign, ended ones included
+func (s *CatalogAdminService) Campaigns(ctx context.Context) ([]models.Campaign, error) {
+	return s.db.ListCampaigns(ctx, time.Time{})
+}
+
+// Audit returns up to limit changes of entity, or of every entity when entity is empty,
+// newest first
+func (s *CatalogAdminService) Audit(ctx context.Context, entity string, limit int) ([]models.CatalogAuditEntry, error) {
+	if _, ok := catalogEntities[entity]; entity != "" && !ok {
+		return nil, fmt.Errorf("%w: unknown entity %q", db.ErrInvalidCatalogEntry, entity)
+	}
+	return s.db.ListCatalogAudit(ctx, entity, limit)
+}
+
+// apply makes a change and drops the cached catalog when it changed. Only plans and rules,
+// the cached entities, are versioned, and changes are scheduled in the future only: the
+// past stays as the orders placed then saw it.
+func (s *CatalogAdminService) apply(ctx context.Context, entity, action, key, actor string, row interface{}, effectiveFrom *time.Time) (*models.CatalogAuditEntry, error) {
+	cached, ok := catalogEntities[entity]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown entity %q", db.ErrInvalidCatalogEntry, entity)
+	}
+	if effectiveFrom != nil {
+		if !cached {
+			return nil, fmt.Errorf("%w: %s changes cannot be scheduled", db.ErrInvalidCatalogEntry, entity)
+		}
+		if !effectiveFrom.After(time.Now()) {
+			return nil, fmt.Errorf("%w: effective_from %s is not in the future", db.ErrInvalidCatalogEntry, effectiveFrom.Format(time.RFC3339))
+		}
+	}
+
+	change := models.CatalogChange{Entity: entity, Action: action, Key: key, Actor: actor, EffectiveFrom: effectiveFrom}
+	if row != nil {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s entry: %w", entity, err)
+		}
+		change.Data = data
+	}
+
+	entry, err := s.db.ApplyCatalogChange(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached {
+		s.cache.Invalidate()
+	}
+	return entry, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+func TestCatalogAdminChangesInvalidateCache(t *testing.T) {
+	mock := &mockDB{catalog: &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1, MonthlyPrice: 100}}}}
+	cache := NewCatalogCache(mock, time.Hour)
+	service := NewCatalogAdminService(mock, cache)
+	ctx := context.Background()
+
+	if _, err := cache.Get(ctx); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	// A price change is seen by the next recommendation, not after the TTL
+	mock.catalog = &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1, MonthlyPrice: 120}}}
+	row := map[string]interface{}{"plan_name": "Basic", "monthly_price": 120}
+	entry, err := service.Update(ctx, CatalogMobilePlans, "1", "admin_token (unverified X-Admin-User: ayse)", row, nil)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if entry.Action != models.CatalogActionUpdate || entry.Actor != "admin_token (unverified X-Admin-User: ayse)" {
+		t.Errorf("Unexpected audit entry %+v", entry)
+	}
+	if catalog, _ := cache.Get(ctx); catalog.MobilePlans[0].MonthlyPrice != 120 {
+		t.Errorf("Expected the cache to be invalidated, got price %v", catalog.MobilePlans[0].MonthlyPrice)
+	}
+
+	var sent map[string]interface{}
+	if err := json.Unmarshal(mock.catalogChanges[0].Data, &sent); err != nil || sent["plan_name"] != "Basic" {
+		t.Errorf("Expected the row to be sent as JSON, got %s", mock.catalogChanges[0].Data)
+	}
+
+	// Coverage is not part of the cached catalog
+	mock.catalog = &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1, MonthlyPrice: 150}}}
+	if _, err := service.Delete(ctx, CatalogCoverage, "A1001", "admin_token", nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if mock.catalogChanges[1].Data != nil {
+		t.Errorf("Expected no row for a delete, got %s", mock.catalogChanges[1].Data)
+	}
+	if catalog, _ := cache.Get(ctx); catalog.MobilePlans[0].MonthlyPrice != 120 {
+		t.Error("Expected a coverage change to keep the cached catalog")
+	}
+}
+
+func TestCatalogAdminFailedChangeKeepsCache(t *testing.T) {
+	mock := &mockDB{catalog: &models.Catalog{}, catalogErr: db.ErrCatalogEntryNotFound}
+	cache := NewCatalogCache(mock, time.Hour)
+	service := NewCatalogAdminService(mock, cache)
+	ctx := context.Background()
+
+	first, _ := cache.Get(ctx)
+	if _, err := service.Delete(ctx, CatalogTVPlans, "9", "admin_token", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+	if cached, _ := cache.Get(ctx); cached != first {
+		t.Error("Expected a failed change to keep the cached catalog")
+	}
+
+	if _, err := service.Create(ctx, "users", "admin_token", map[string]string{}, nil); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected an unknown entity to be invalid input, got %v", err)
+	}
+}
+
+func TestCatalogAdminScheduledChange(t *testing.T) {
+	mock := &mockDB{catalog: &models.Catalog{}}
+	service := NewCatalogAdminService(mock, NewCatalogCache(mock, time.Hour))
+	ctx := context.Background()
+	row := map[string]interface{}{"name": "Sports", "hd_hours_included": 10, "monthly_price": 109}
+
+	priceChange := time.Now().Add(14 * 24 * time.Hour)
+	if _, err := service.Update(ctx, CatalogTVPlans, "3", "admin_token", row, &priceChange); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if at := mock.catalogChanges[0].EffectiveFrom; at == nil || !at.Equal(priceChange) {
+		t.Errorf("Expected the change to be scheduled, got %v", at)
+	}
+
+	// The past stays as the orders placed then saw it
+	past := time.Now().Add(-time.Hour)
+	if _, err := service.Update(ctx, CatalogTVPlans, "3", "admin_token", row, &past); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected a change in the past to be invalid input, got %v", err)
+	}
+	if _, err := service.Delete(ctx, CatalogCoverage, "A1001", "admin_token", &priceChange); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected a scheduled coverage change to be invalid input, got %v", err)
+	}
+	if len(mock.catalogChanges) != 1 {
+		t.Errorf("Expected invalid changes not to reach the database, got %d changes", len(mock.catalogChanges))
+	}
+}
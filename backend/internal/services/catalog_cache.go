@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"app/internal/db"
+	"app/internal/metrics"
+	"app/internal/models"
+)
+
+// CatalogCache keeps the plan catalog in memory for ttl, so recommendations do not load
+// it from the database on every request. Checkout still validates quotes against the
+// database, so a price change is never missed, only seen by recommendations up to ttl
+// late. A scheduled change is seen on time: the catalog is dropped when it takes effect.
+// A zero ttl disables caching.
+type CatalogCache struct {
+	db  db.DatabaseInterface
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	catalog   *models.Catalog
+	loadedAt  time.Time
+	scheduled map[time.Time]*models.Catalog
+}
+
+// NewCatalogCache creates a catalog cache reading from database
+func NewCatalogCache(database db.DatabaseInterface, ttl time.Duration) *CatalogCache {
+	return &CatalogCache{
+		db:  database,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// Get returns the cached catalog in effect now, loading it when it is missing, older
+// than ttl or superseded by a scheduled change. The returned catalog is shared and must
+// not be modified.
+func (c *CatalogCache) Get(ctx context.Context) (*models.Catalog, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.catalog != nil && now.Sub(c.loadedAt) < c.ttl && (c.catalog.ValidUntil == nil || now.Before(*c.catalog.ValidUntil)) {
+		metrics.CatalogCacheRequests.WithLabelValues("hit").Inc()
+		return c.catalog, nil
+	}
+	metrics.CatalogCacheRequests.WithLabelValues("miss").Inc()
+
+	catalog, err := c.db.GetCatalog(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	c.catalog, c.loadedAt, c.scheduled = catalog, now, nil
+
+	return catalog, nil
+}
+
+// Scheduled returns the catalog taking effect at a scheduled change, one of the
+// ValidUntil times of the catalogs Get and Scheduled return. It is cached with, and
+// dropped together with, the catalog in effect now.
+func (c *CatalogCache) Scheduled(ctx context.Context, at time.Time) (*models.Catalog, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if catalog, ok := c.scheduled[at.UTC()]; ok && c.catalog != nil && c.ttl > 0 {
+		metrics.CatalogCacheRequests.WithLabelValues("hit").Inc()
+		return catalog, nil
+	}
+	metrics.CatalogCacheRequests.WithLabelValues("miss").Inc()
+
+	catalog, err := c.db.GetCatalog(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	if c.catalog != nil {
+		if c.scheduled == nil {
+			c.scheduled = make(map[time.Time]*models.Catalog)
+		}
+		c.scheduled[at.UTC()] = catalog
+	}
+
+	return catalog, nil
+}
+
+// Invalidate drops the cached catalogs, so the next Get loads them from the database.
+// Other replicas still serve their cached catalog for up to ttl.
+func (c *CatalogCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.catalog, c.scheduled = nil, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"app/internal/metrics"
+	"app/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCatalogCache(t *testing.T) {
+	mock := &mockDB{catalog: &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1}}}}
+	cache := NewCatalogCache(mock, time.Minute)
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	hits := testutil.ToFloat64(metrics.CatalogCacheRequests.WithLabelValues("hit"))
+	misses := testutil.ToFloat64(metrics.CatalogCacheRequests.WithLabelValues("miss"))
+
+	first, err := cache.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A price change is picked up once the cached catalog is older than the TTL
+	mock.catalog = &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 2}}}
+	now = now.Add(30 * time.Second)
+	if cached, _ := cache.Get(context.Background()); cached != first {
+		t.Error("Expected the cached catalog within the TTL")
+	}
+	now = now.Add(time.Minute)
+	if reloaded, _ := cache.Get(context.Background()); reloaded.MobilePlans[0].PlanID != 2 {
+		t.Error("Expected the catalog to be reloaded after the TTL")
+	}
+
+	if n := testutil.ToFloat64(metrics.CatalogCacheRequests.WithLabelValues("hit")) - hits; n != 1 {
+		t.Errorf("Expected 1 cache hit, got %v", n)
+	}
+	if n := testutil.ToFloat64(metrics.CatalogCacheRequests.WithLabelValues("miss")) - misses; n != 2 {
+		t.Errorf("Expected 2 cache misses, got %v", n)
+	}
+}
+
+func TestCatalogCacheScheduledChange(t *testing.T) {
+	now := time.Date(2026, 10, 31, 23, 59, 0, 0, time.UTC)
+	priceChange := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	after := &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1, Version: 2}}}
+	mock := &mockDB{
+		catalog:   &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1, Version: 1}}, ValidUntil: &priceChange},
+		scheduled: map[time.Time]*models.Catalog{priceChange: after},
+	}
+	cache := NewCatalogCache(mock, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	if current, _ := cache.Get(context.Background()); current.MobilePlans[0].Version != 1 {
+		t.Error("Expected the version in effect now")
+	}
+	if scheduled, _ := cache.Scheduled(context.Background(), priceChange); scheduled != after {
+		t.Error("Expected the catalog of the scheduled change")
+	}
+
+	// The change takes effect well within the TTL
+	now = priceChange
+	if current, _ := cache.Get(context.Background()); current.MobilePlans[0].Version != 2 {
+		t.Error("Expected the cached catalog to be dropped when the scheduled change takes effect")
+	}
+}
+
+func TestCatalogCacheDisabled(t *testing.T) {
+	mock := &mockDB{catalog: &models.Catalog{}}
+	cache := NewCatalogCache(mock, 0)
+
+	first, _ := cache.Get(context.Background())
+	mock.catalog = &models.Catalog{}
+	if second, _ := cache.Get(context.Background()); second == first {
+		t.Error("Expected a zero TTL to reload the catalog every time")
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"fmt"
 
+	"app/internal/api"
 	"app/internal/db"
+	"app/internal/models"
 )
 
+// MaxCoverageBatchSize is the maximum number of addresses accepted by a single batch lookup
+const MaxCoverageBatchSize = 100
+
 // CoverageService handles coverage-related operations
 type CoverageService struct {
 	db db.DatabaseInterface
@@ -27,6 +32,66 @@ func (s *CoverageService) ComputeCoverage(ctx context.Context, addressID string)
 		return nil, fmt.Errorf("failed to get coverage for address %s: %w", addressID, err)
 	}
 
+	return availableTechnologies(coverage), nil
+}
+
+// GetCoverageInfo returns detailed coverage information for an address
+func (s *CoverageService) GetCoverageInfo(ctx context.Context, addressID string) (*CoverageInfo, error) {
+	coverage, err := s.db.GetCoverage(ctx, addressID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coverage info for address %s: %w", addressID, err)
+	}
+
+	return newCoverageInfo(coverage), nil
+}
+
+// GetCoverageInfoBatch returns coverage information for several addresses using a single
+// database query. Addresses without coverage are reported per address in the result's
+// Errors map instead of failing the whole batch.
+func (s *CoverageService) GetCoverageInfoBatch(ctx context.Context, addressIDs []string) (*CoverageBatchResult, error) {
+	// Deduplicate while preserving the caller's order
+	seen := make(map[string]bool, len(addressIDs))
+	var uniqueIDs []string
+	for _, id := range addressIDs {
+		if !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	if len(uniqueIDs) > MaxCoverageBatchSize {
+		return nil, fmt.Errorf("%w: batch of %d addresses exceeds the limit of %d", ErrInvalidInput, len(uniqueIDs), MaxCoverageBatchSize)
+	}
+
+	coverage, err := s.db.GetCoverageBatch(ctx, uniqueIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coverage batch: %w", err)
+	}
+
+	result := &CoverageBatchResult{
+		Coverage: make(map[string]*CoverageInfo, len(coverage)),
+	}
+
+	for _, id := range uniqueIDs {
+		c, ok := coverage[id]
+		if !ok {
+			if result.Errors == nil {
+				result.Errors = make(map[string]api.ErrorDetail)
+			}
+			result.Errors[id] = api.ErrorDetail{
+				Code:    "COVERAGE_NOT_FOUND",
+				Message: "Coverage information not found for the specified address",
+			}
+			continue
+		}
+		result.Coverage[id] = newCoverageInfo(c)
+	}
+
+	return result, nil
+}
+
+// availableTechnologies lists the technologies offered at an address in preference order
+func availableTechnologies(coverage *models.Coverage) []string {
 	var availableTech []string
 
 	// Add technologies in preference order
@@ -42,18 +107,11 @@ func (s *CoverageService) ComputeCoverage(ctx context.Context, addressID string)
 		availableTech = append(availableTech, "fwa")
 	}
 
-	return availableTech, nil
+	return availableTech
 }
 
-// GetCoverageInfo returns detailed coverage information for an address
-func (s *CoverageService) GetCoverageInfo(ctx context.Context, addressID string) (*CoverageInfo, error) {
-	coverage, err := s.db.GetCoverage(ctx, addressID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get coverage info for address %s: %w", addressID, err)
-	}
-
-	availableTech, _ := s.ComputeCoverage(ctx, addressID)
-
+// newCoverageInfo converts a coverage row into its API representation
+func newCoverageInfo(coverage *models.Coverage) *CoverageInfo {
 	return &CoverageInfo{
 		AddressID:     coverage.AddressID,
 		City:          coverage.City,
@@ -61,8 +119,8 @@ func (s *CoverageService) GetCoverageInfo(ctx context.Context, addressID string)
 		Fiber:         coverage.Fiber,
 		VDSL:          coverage.VDSL,
 		FWA:           coverage.FWA,
-		AvailableTech: availableTech,
-	}, nil
+		AvailableTech: availableTechnologies(coverage),
+	}
 }
 
 // CoverageInfo represents coverage information with available technologies
@@ -75,3 +133,9 @@ type CoverageInfo struct {
 	FWA           bool     `json:"fwa"`
 	AvailableTech []string `json:"available_tech"`
 }
+
+// CoverageBatchResult represents the outcome of a batch coverage lookup
+type CoverageBatchResult struct {
+	Coverage map[string]*CoverageInfo   `json:"coverage"`
+	Errors   map[string]api.ErrorDetail `json:"errors,omitempty"`
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"app/internal/models"
@@ -146,3 +148,55 @@ func TestCoveragePreferenceOrder(t *testing.T) {
 
 	t.Logf("✓ Technologies returned in correct preference order: %v", availableTech)
 }
+
+func TestGetCoverageInfoBatch(t *testing.T) {
+	mock := &mockDB{
+		coverage: map[string]*models.Coverage{
+			"A1001": {AddressID: "A1001", City: "Istanbul", District: "Kadikoy", Fiber: true, VDSL: true},
+			"A1004": {AddressID: "A1004", City: "Izmir", District: "Konak", FWA: true},
+		},
+	}
+	service := NewCoverageService(mock)
+
+	result, err := service.GetCoverageInfoBatch(context.Background(), []string{"A1001", "A9999", "A1004", "A1001"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mock.batchCalls != 1 {
+		t.Errorf("Expected a single batch query, got %d", mock.batchCalls)
+	}
+
+	if len(result.Coverage) != 2 {
+		t.Fatalf("Expected 2 coverage entries, got %d", len(result.Coverage))
+	}
+
+	if got := result.Coverage["A1001"].AvailableTech; len(got) != 2 || got[0] != "fiber" || got[1] != "vdsl" {
+		t.Errorf("Expected A1001 tech [fiber vdsl], got %v", got)
+	}
+
+	if got := result.Coverage["A1004"].AvailableTech; len(got) != 1 || got[0] != "fwa" {
+		t.Errorf("Expected A1004 tech [fwa], got %v", got)
+	}
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 not-found error, got %d", len(result.Errors))
+	}
+
+	if result.Errors["A9999"].Code != "COVERAGE_NOT_FOUND" {
+		t.Errorf("Expected COVERAGE_NOT_FOUND for A9999, got %q", result.Errors["A9999"].Code)
+	}
+}
+
+func TestGetCoverageInfoBatchTooLarge(t *testing.T) {
+	service := NewCoverageService(&mockDB{})
+
+	ids := make([]string, MaxCoverageBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("A%d", i)
+	}
+
+	if _, err := service.GetCoverageInfoBatch(context.Background(), ids); err == nil {
+		t.Error("Expected an error for a batch over the size limit")
+	}
+}
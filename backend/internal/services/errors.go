@@ -0,0 +1,14 @@
+package services
+
+import "app/internal/db"
+
+// Error kinds of the service layer. They are the database's kinds, so a service error and
+// the database error it wraps are classified the same way; every sentinel error of this
+// package matches one of them with errors.Is.
+var (
+	ErrNotFound     = db.ErrNotFound
+	ErrConflict     = db.ErrConflict
+	ErrInvalidInput = db.ErrInvalidInput
+	ErrUnauthorized = db.ErrUnauthorized
+	ErrUnavailable  = db.ErrUnavailable
+)
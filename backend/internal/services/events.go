@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// Event dispatch settings. A claimed event is leased for eventLease so a crashed
+// dispatcher does not lose it; failed events are retried with exponential backoff
+// starting at eventRetryBase and moved to the dead-letter state after maxAttempts.
+const (
+	DefaultEventMaxAttempts = 8
+	eventBatchSize          = 100
+	eventLease              = 5 * time.Minute
+	eventRetryBase          = 10 * time.Second
+	eventRetryMax           = 30 * time.Minute
+)
+
+// EventHandler processes one domain event. Events are delivered at least once, so
+// handlers must be idempotent.
+type EventHandler func(ctx context.Context, event models.Event) error
+
+// subscription is a named handler for one event type
+type subscription struct {
+	name    string
+	handler EventHandler
+}
+
+// EventDispatcher delivers domain events from the transactional outbox to the handlers
+// subscribed to their type. An event is delivered once every handler succeeded; if any
+// handler fails the whole event is retried, so handlers that already succeeded see it
+// again.
+type EventDispatcher struct {
+	db            db.DatabaseInterface
+	subscriptions map[string][]subscription
+	maxAttempts   int
+	now           func() time.Time
+}
+
+// NewEventDispatcher creates a dispatcher that gives up on an event after maxAttempts
+func NewEventDispatcher(database db.DatabaseInterface, maxAttempts int) *EventDispatcher {
+	return &EventDispatcher{
+		db:            database,
+		subscriptions: make(map[string][]subscription),
+		maxAttempts:   maxAttempts,
+		now:           time.Now,
+	}
+}
+
+// Subscribe registers handler for events of eventType. name identifies the handler in
+// logs and in the error recorded on failed events.
+func (d *EventDispatcher) Subscribe(eventType, name string, handler EventHandler) {
+	d.subscriptions[eventType] = append(d.subscriptions[eventType], subscription{name: name, handler: handler})
+}
+
+// Dispatch delivers due events until none are left
+func (d *EventDispatcher) Dispatch(ctx context.Context) error {
+	for {
+		claimed, err := d.db.ClaimEvents(ctx, eventBatchSize, int(eventLease.Seconds()))
+		if err != nil {
+			return err
+		}
+
+		for _, event := range claimed {
+			d.dispatch(ctx, event)
+		}
+
+		if len(claimed) < eventBatchSize || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// DeadLetters returns up to limit events that exhausted their attempts, newest first
+func (d *EventDispatcher) DeadLetters(ctx context.Context, limit int) ([]models.Event, error) {
+	return d.db.ListEvents(ctx, models.EventDead, limit)
+}
+
+// Requeue gives a dead event a fresh set of attempts
+func (d *EventDispatcher) Requeue(ctx context.Context, id int64) (*models.Event, error) {
+	return d.db.RequeueEvent(ctx, id)
+}
+
+// dispatch runs every handler subscribed to the event and records the outcome
+func (d *EventDispatcher) dispatch(ctx context.Context, event models.Event) {
+	var failures []error
+	for _, sub := range d.subscriptions[event.EventType] {
+		if err := sub.handler(ctx, event); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", sub.name, err))
+		}
+	}
+
+	if len(failures) == 0 {
+		if err := d.db.MarkEventDelivered(ctx, event.ID); err != nil {
+			logger(ctx).Error("Failed to record event delivery", "event_type", event.EventType, "event_id", event.ID, "error", err)
+		}
+		return
+	}
+
+	err := errors.Join(failures...)
+	var retryAt *time.Time
+	if event.Attempts < d.maxAttempts {
+		next := d.now().Add(retryBackoff(event.Attempts, eventRetryBase, eventRetryMax))
+		retryAt = &next
+		logger(ctx).Warn("Failed to dispatch event, retrying", "event_type", event.EventType, "event_id", event.ID,
+			"aggregate_id", event.AggregateID, "attempt", event.Attempts, "retry_at", next, "error", err)
+	} else {
+		logger(ctx).Error("Moved event to dead letters", "event_type", event.EventType, "event_id", event.ID,
+			"aggregate_id", event.AggregateID, "attempts", event.Attempts, "error", err)
+	}
+
+	if err := d.db.MarkEventFailed(ctx, event.ID, err.Error(), retryAt); err != nil {
+		logger(ctx).Error("Failed to record failed event dispatch", "event_id", event.ID, "error", err)
+	}
+}
+
+// retryBackoff returns the delay before retrying after the given attempt: base, 2*base,
+// 4*base, ... capped at max
+func retryBackoff(attempts int, base, max time.Duration) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// eventMock returns a database with one pending event of eventType
+func eventMock(eventType string) *mockDB {
+	return &mockDB{
+		events: []*models.Event{
+			{ID: 1, EventType: eventType, AggregateID: "ORD-1", Payload: []byte(`{"order_id":"ORD-1"}`), Status: models.EventPending},
+		},
+	}
+}
+
+func TestDispatchDeliversToSubscribers(t *testing.T) {
+	mock := eventMock(models.EventOrderPlaced)
+	dispatcher := NewEventDispatcher(mock, DefaultEventMaxAttempts)
+
+	var handled []string
+	record := func(name string) EventHandler {
+		return func(ctx context.Context, event models.Event) error {
+			handled = append(handled, name+":"+event.AggregateID)
+			return nil
+		}
+	}
+	dispatcher.Subscribe(models.EventOrderPlaced, "first", record("first"))
+	dispatcher.Subscribe(models.EventOrderPlaced, "second", record("second"))
+	dispatcher.Subscribe(models.EventOrderCancelled, "other", record("other"))
+
+	if err := dispatcher.Dispatch(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Join(handled, ",") != "first:ORD-1,second:ORD-1" {
+		t.Errorf("Expected both OrderPlaced handlers in order, got %v", handled)
+	}
+	if mock.events[0].Status != models.EventDelivered {
+		t.Errorf("Expected the event to be delivered, got %s", mock.events[0].Status)
+	}
+
+	// Delivered events are not dispatched again
+	if err := dispatcher.Dispatch(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(handled) != 2 {
+		t.Errorf("Expected no further deliveries, got %v", handled)
+	}
+}
+
+func TestDispatchWithoutSubscribers(t *testing.T) {
+	mock := eventMock(models.EventSlotBooked)
+	if err := NewEventDispatcher(mock, DefaultEventMaxAttempts).Dispatch(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mock.events[0].Status != models.EventDelivered {
+		t.Errorf("Expected an event nobody subscribed to to be delivered, got %s", mock.events[0].Status)
+	}
+}
+
+func TestDispatchFailures(t *testing.T) {
+	tests := []struct {
+		name           string
+		attempts       int
+		expectedStatus string
+		expectRetryIn  time.Duration
+	}{
+		{"First failure is retried", 0, models.EventPending, 10 * time.Second},
+		{"Backoff grows", 3, models.EventPending, 80 * time.Second},
+		{"Dead after max attempts", 4, models.EventDead, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := eventMock(models.EventOrderCancelled)
+			mock.events[0].Attempts = tt.attempts
+			dispatcher := NewEventDispatcher(mock, 5)
+			now := time.Now()
+			dispatcher.now = func() time.Time { return now }
+
+			dispatcher.Subscribe(models.EventOrderCancelled, "ok", func(ctx context.Context, event models.Event) error {
+				return nil
+			})
+			dispatcher.Subscribe(models.EventOrderCancelled, "broken", func(ctx context.Context, event models.Event) error {
+				return errors.New("connection refused")
+			})
+
+			if err := dispatcher.Dispatch(context.Background()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			event := mock.events[0]
+			if event.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, event.Status)
+			}
+			if event.LastError == nil || *event.LastError != "broken: connection refused" {
+				t.Errorf("Expected the failing handler in the error, got %v", event.LastError)
+			}
+			if tt.expectRetryIn > 0 && !event.NextAttemptAt.Equal(now.Add(tt.expectRetryIn)) {
+				t.Errorf("Expected retry in %v, got %v", tt.expectRetryIn, event.NextAttemptAt.Sub(now))
+			}
+		})
+	}
+}
+
+func TestDeadLettersAndRequeue(t *testing.T) {
+	mock := eventMock(models.EventOrderPlaced)
+	mock.events[0].Status = models.EventDead
+	mock.events[0].Attempts = DefaultEventMaxAttempts
+	mock.events = append(mock.events, &models.Event{ID: 2, EventType: models.EventSlotBooked, AggregateID: "ORD-2", Status: models.EventDelivered})
+	dispatcher := NewEventDispatcher(mock, DefaultEventMaxAttempts)
+
+	dead, err := dispatcher.DeadLetters(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != 1 {
+		t.Fatalf("Expected only event 1 in dead letters, got %+v", dead)
+	}
+
+	requeued, err := dispatcher.Requeue(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if requeued.Status != models.EventPending || requeued.Attempts != 0 {
+		t.Errorf("Expected a pending event with fresh attempts, got %+v", requeued)
+	}
+
+	// Only dead events can be requeued
+	for _, id := range []int64{1, 2, 99} {
+		if _, err := dispatcher.Requeue(context.Background(), id); !errors.Is(err, db.ErrEventNotFound) {
+			t.Errorf("Requeue(%d): expected ErrEventNotFound, got %v", id, err)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	expected := map[int]time.Duration{
+		0:  eventRetryBase,
+		1:  eventRetryBase,
+		2:  2 * eventRetryBase,
+		4:  8 * eventRetryBase,
+		9:  eventRetryMax,
+		64: eventRetryMax,
+	}
+	for attempts, delay := range expected {
+		if got := retryBackoff(attempts, eventRetryBase, eventRetryMax); got != delay {
+			t.Errorf("retryBackoff(%d) = %v, expected %v", attempts, got, delay)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"app/internal/db"
+)
+
+// Dependency checks run for readiness
+const (
+	CheckDatabase   = "database"
+	CheckCatalog    = "catalog"
+	CheckMigrations = "migrations"
+)
+
+// healthCheckTimeout bounds each dependency check
+const healthCheckTimeout = 3 * time.Second
+
+// DependencyHealth is the outcome of the latest check of one dependency, with the time
+// of the last error even if the dependency has recovered since. The error text is only
+// logged, not served, since database errors carry hosts, ports and SQL.
+type DependencyHealth struct {
+	Healthy     bool       `json:"healthy"`
+	LatencyMS   float64    `json:"latency_ms"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	Error       string     `json:"-"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// HealthReport is the health of every dependency, keyed by check name
+type HealthReport struct {
+	Ready        bool                        `json:"ready"`
+	Dependencies map[string]DependencyHealth `json:"dependencies"`
+}
+
+// HealthService checks whether the database is reachable, the plan catalog loads and
+// the schema is at least db.ExpectedSchemaVersion. Checks run at most once per interval;
+// probes in between get the previous report, so frequent probes do not load the
+// database.
+type HealthService struct {
+	db       db.DatabaseInterface
+	catalog  *CatalogCache
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	report  *HealthReport
+	checked time.Time
+}
+
+// NewHealthService creates a health service checking database and the catalog of catalog
+func NewHealthService(database db.DatabaseInterface, catalog *CatalogCache, interval time.Duration) *HealthService {
+	return &HealthService{
+		db:       database,
+		catalog:  catalog,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Check returns the health of every dependency, checking them again when the previous
+// report is older than the interval. The returned report must not be modified.
+func (s *HealthService) Check(ctx context.Context) *HealthReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.report != nil && s.now().Sub(s.checked) < s.interval {
+		return s.report
+	}
+
+	checks := map[string]func(context.Context) error{
+		CheckDatabase:   s.db.Health,
+		CheckCatalog:    s.checkCatalog,
+		CheckMigrations: s.checkMigrations,
+	}
+
+	report := &HealthReport{Ready: true, Dependencies: make(map[string]DependencyHealth, len(checks))}
+	for name, check := range checks {
+		dependency := s.run(ctx, check)
+		if previous, ok := s.previous(name); ok && dependency.Healthy {
+			dependency.LastErrorAt = previous.LastErrorAt
+		}
+		if !dependency.Healthy {
+			logger(ctx).Warn("Health check failed", "check", name, "error", dependency.Error)
+			report.Ready = false
+		}
+		report.Dependencies[name] = dependency
+	}
+	s.report, s.checked = report, s.now()
+
+	return report
+}
+
+// previous returns the last result of the named check
+func (s *HealthService) previous(name string) (DependencyHealth, bool) {
+	if s.report == nil {
+		return DependencyHealth{}, false
+	}
+	dependency, ok := s.report.Dependencies[name]
+	return dependency, ok
+}
+
+// run runs check with a timeout and records its latency and error. The check is not
+// cut short when the probe that triggered it disconnects, since its result is shared.
+func (s *HealthService) run(ctx context.Context, check func(context.Context) error) DependencyHealth {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), healthCheckTimeout)
+	defer cancel()
+
+	start := s.now()
+	err := check(ctx)
+	dependency := DependencyHealth{
+		Healthy:   err == nil,
+		LatencyMS: float64(s.now().Sub(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+	if err != nil {
+		dependency.Error, dependency.LastErrorAt = err.Error(), &start
+	}
+	return dependency
+}
+
+// checkCatalog checks the catalog loads and has plans to recommend
+func (s *HealthService) checkCatalog(ctx context.Context) error {
+	catalog, err := s.catalog.Get(ctx)
+	if err != nil {
+		return err
+	}
+	if len(catalog.MobilePlans) == 0 {
+		return errors.New("catalog has no mobile plans")
+	}
+	return nil
+}
+
+// checkMigrations checks the schema has every migration this build relies on. A newer
+// schema is accepted, since migrations are applied before the code that needs them.
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	version, err := s.db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version < db.ExpectedSchemaVersion {
+		return fmt.Errorf("schema version %d is older than the expected %d", version, db.ExpectedSchemaVersion)
+	}
+	return nil
+}
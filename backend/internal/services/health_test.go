@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+func TestHealthServiceReady(t *testing.T) {
+	mock := &mockDB{
+		catalog:       &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1}}},
+		schemaVersion: db.ExpectedSchemaVersion,
+	}
+	health := NewHealthService(mock, NewCatalogCache(mock, time.Minute), 5*time.Second)
+
+	report := health.Check(context.Background())
+	if !report.Ready {
+		t.Fatalf("Expected ready, got %+v", report.Dependencies)
+	}
+	for _, name := range []string{CheckDatabase, CheckCatalog, CheckMigrations} {
+		if !report.Dependencies[name].Healthy {
+			t.Errorf("Expected %s to be healthy", name)
+		}
+	}
+}
+
+func TestHealthServiceNotReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		mock   *mockDB
+		failed string
+	}{
+		{
+			name:   "database unreachable",
+			mock:   &mockDB{healthErr: errors.New("connection refused"), catalog: &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1}}}, schemaVersion: db.ExpectedSchemaVersion},
+			failed: CheckDatabase,
+		},
+		{
+			name:   "empty catalog",
+			mock:   &mockDB{catalog: &models.Catalog{}, schemaVersion: db.ExpectedSchemaVersion},
+			failed: CheckCatalog,
+		},
+		{
+			name:   "schema behind",
+			mock:   &mockDB{catalog: &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1}}}, schemaVersion: db.ExpectedSchemaVersion - 1},
+			failed: CheckMigrations,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := NewHealthService(tt.mock, NewCatalogCache(tt.mock, time.Minute), 5*time.Second)
+
+			report := health.Check(context.Background())
+			if report.Ready {
+				t.Fatal("Expected not ready")
+			}
+			for name, dependency := range report.Dependencies {
+				if dependency.Healthy == (name == tt.failed) {
+					t.Errorf("%s: healthy = %v, error %q", name, dependency.Healthy, dependency.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthServiceReusesReportAndKeepsLastError(t *testing.T) {
+	mock := &mockDB{
+		healthErr:     errors.New("connection refused"),
+		catalog:       &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1}}},
+		schemaVersion: db.ExpectedSchemaVersion,
+	}
+	health := NewHealthService(mock, NewCatalogCache(mock, time.Minute), 5*time.Second)
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	health.now = func() time.Time { return now }
+
+	health.Check(context.Background())
+	mock.healthErr = nil
+	now = now.Add(time.Second)
+	if report := health.Check(context.Background()); report.Ready || mock.healthChecks != 1 {
+		t.Fatalf("Expected the previous report within the interval, got ready=%v after %d checks", report.Ready, mock.healthChecks)
+	}
+
+	// Once recovered, the dependency still shows the error it last had
+	now = now.Add(5 * time.Second)
+	report := health.Check(context.Background())
+	database := report.Dependencies[CheckDatabase]
+	if !report.Ready || mock.healthChecks != 2 {
+		t.Fatalf("Expected a new, ready report, got ready=%v after %d checks", report.Ready, mock.healthChecks)
+	}
+	if database.LastErrorAt == nil || !database.LastErrorAt.Equal(now.Add(-6*time.Second)) {
+		t.Errorf("Expected the time of the last error to be kept, got %v", database.LastErrorAt)
+	}
+}
+
+func TestHealthReportOmitsErrorText(t *testing.T) {
+	mock := &mockDB{
+		healthErr:     errors.New("failed to connect to host=db.internal port=5432"),
+		catalog:       &models.Catalog{MobilePlans: []models.MobilePlan{{PlanID: 1}}},
+		schemaVersion: db.ExpectedSchemaVersion,
+	}
+	report := NewHealthService(mock, NewCatalogCache(mock, time.Minute), 5*time.Second).Check(context.Background())
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(body), "db.internal") || !strings.Contains(string(body), "last_error_at") {
+		t.Errorf("Expected the failure time without the error text, got %s", body)
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// DefaultIdempotencyTTL is how long a stored Idempotency-Key and its response are kept
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// MaxIdempotencyKeyLength is the longest accepted Idempotency-Key header value
+const MaxIdempotencyKeyLength = 255
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key arrives with a different request body
+	ErrIdempotencyKeyReused = db.NewError(ErrConflict, "idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInProgress is returned while the request that claimed a key is still running
+	ErrIdempotencyKeyInProgress = db.NewError(ErrConflict, "a request with this idempotency key is still in progress")
+)
+
+// IdempotencyService stores Idempotency-Key headers and the responses they produced so
+// retried requests are answered without being processed twice
+type IdempotencyService struct {
+	db  db.DatabaseInterface
+	ttl time.Duration
+}
+
+// NewIdempotencyService creates an idempotency service keeping keys for ttl
+func NewIdempotencyService(database db.DatabaseInterface, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		db:  database,
+		ttl: ttl,
+	}
+}
+
+// HashRequest fingerprints a request so a key reused for another request can be detected
+func HashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims key for the request identified by requestHash. It returns nil when the
+// caller should process the request and later call Complete or Abandon, or the stored
+// record whose response should be replayed. A key still being processed, or used with
+// another request, yields ErrIdempotencyKeyInProgress or ErrIdempotencyKeyReused.
+func (s *IdempotencyService) Begin(ctx context.Context, key, requestHash string) (*models.IdempotencyRecord, error) {
+	record, claimed, err := s.db.ClaimIdempotencyKey(ctx, key, requestHash, int(s.ttl.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case claimed:
+		return nil, nil
+	case record.RequestHash != requestHash:
+		return nil, ErrIdempotencyKeyReused
+	case record.StatusCode == nil:
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return record, nil
+}
+
+// Complete stores the response for replay
+func (s *IdempotencyService) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	return s.db.SaveIdempotentResponse(ctx, key, statusCode, string(body))
+}
+
+// Abandon releases a claimed key without a response so the request can be retried
+func (s *IdempotencyService) Abandon(ctx context.Context, key string) error {
+	return s.db.DeleteIdempotencyKey(ctx, key)
+}
+
+// PurgeExpired deletes keys older than the TTL
+func (s *IdempotencyService) PurgeExpired(ctx context.Context) error {
+	purged, err := s.db.PurgeIdempotencyKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		logger(ctx).Info("Purged expired idempotency keys", "count", purged)
+	}
+	return nil
+}
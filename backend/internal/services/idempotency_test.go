@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestIdempotencyReplay(t *testing.T) {
+	service := NewIdempotencyService(&mockDB{}, DefaultIdempotencyTTL)
+	ctx := context.Background()
+	hash := HashRequest(http.MethodPost, "/api/checkout", []byte(`{"user_id":1}`))
+
+	record, err := service.Begin(ctx, "key-1", hash)
+	if err != nil || record != nil {
+		t.Fatalf("Expected first request to claim the key, got %v, %v", record, err)
+	}
+
+	// A retry while the first request is still running must not be processed
+	if _, err := service.Begin(ctx, "key-1", hash); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Errorf("Expected ErrIdempotencyKeyInProgress, got %v", err)
+	}
+
+	if err := service.Complete(ctx, "key-1", http.StatusOK, []byte(`{"order_id":"ORD-1"}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	record, err = service.Begin(ctx, "key-1", hash)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if record == nil || *record.StatusCode != http.StatusOK || *record.ResponseBody != `{"order_id":"ORD-1"}` {
+		t.Errorf("Expected stored response to be replayed, got %+v", record)
+	}
+}
+
+func TestIdempotencyKeyReusedWithDifferentBody(t *testing.T) {
+	service := NewIdempotencyService(&mockDB{}, DefaultIdempotencyTTL)
+	ctx := context.Background()
+
+	if _, err := service.Begin(ctx, "key-1", HashRequest(http.MethodPost, "/api/checkout", []byte(`{"user_id":1}`))); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err := service.Begin(ctx, "key-1", HashRequest(http.MethodPost, "/api/checkout", []byte(`{"user_id":2}`)))
+	if !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("Expected ErrIdempotencyKeyReused, got %v", err)
+	}
+}
+
+func TestIdempotencyAbandon(t *testing.T) {
+	service := NewIdempotencyService(&mockDB{}, DefaultIdempotencyTTL)
+	ctx := context.Background()
+	hash := HashRequest(http.MethodPost, "/api/checkout", nil)
+
+	if _, err := service.Begin(ctx, "key-1", hash); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := service.Abandon(ctx, "key-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	record, err := service.Begin(ctx, "key-1", hash)
+	if err != nil || record != nil {
+		t.Errorf("Expected abandoned key to be claimable again, got %v, %v", record, err)
+	}
+}
+
+func TestHashRequest(t *testing.T) {
+	body := []byte(`{"user_id":1}`)
+	if HashRequest(http.MethodPost, "/api/checkout", body) != HashRequest(http.MethodPost, "/api/checkout", body) {
+		t.Error("Expected identical requests to hash equally")
+	}
+	if HashRequest(http.MethodPost, "/api/checkout", body) == HashRequest(http.MethodPost, "/api/orders", body) {
+		t.Error("Expected the route to be part of the hash")
+	}
+}
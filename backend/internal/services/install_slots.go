@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// Pagination bounds for install slot searches
+const (
+	DefaultSlotSearchLimit = 20
+	MaxSlotSearchLimit     = 100
+)
+
+// ErrInvalidSlotSearch is returned when slot search parameters are invalid
+var ErrInvalidSlotSearch = db.NewError(ErrInvalidInput, "invalid slot search")
+
+// validTechs lists the installation technologies in preference order
+var validTechs = []string{"fiber", "vdsl", "fwa"}
+
+// InstallSlotService handles install slot searches
+type InstallSlotService struct {
+	db              db.DatabaseInterface
+	coverageService *CoverageService
+	now             func() time.Time
+}
+
+// NewInstallSlotService creates a new install slot service
+func NewInstallSlotService(database db.DatabaseInterface, coverageService *CoverageService) *InstallSlotService {
+	return &InstallSlotService{
+		db:              database,
+		coverageService: coverageService,
+		now:             time.Now,
+	}
+}
+
+// SlotSearchParams represents an install slot search request
+type SlotSearchParams struct {
+	AddressID  string
+	Techs      []string  // empty means every technology available at the address
+	From       time.Time // zero means now
+	To         time.Time // zero means no upper bound
+	TimesOfDay []string
+	Limit      int // zero means DefaultSlotSearchLimit
+	Offset     int
+	Earliest   bool // return only the earliest slot per technology
+}
+
+// SlotSearchResult represents a page of install slots
+type SlotSearchResult struct {
+	AddressID string               `json:"address_id"`
+	Techs     []string             `json:"techs"`
+	Slots     []models.InstallSlot `json:"slots"`
+	Limit     int                  `json:"limit,omitempty"`
+	Offset    int                  `json:"offset,omitempty"`
+	HasMore   bool                 `json:"has_more"`
+}
+
+// Search finds install slots with remaining capacity for an address. Errors wrapping
+// ErrInvalidSlotSearch indicate invalid parameters rather than a lookup failure.
+func (s *InstallSlotService) Search(ctx context.Context, params SlotSearchParams) (*SlotSearchResult, error) {
+	if err := s.normalize(&params); err != nil {
+		return nil, err
+	}
+
+	if len(params.Techs) == 0 {
+		techs, err := s.coverageService.ComputeCoverage(ctx, params.AddressID)
+		if err != nil {
+			return nil, err
+		}
+		params.Techs = techs
+	}
+
+	result := &SlotSearchResult{
+		AddressID: params.AddressID,
+		Techs:     params.Techs,
+		Slots:     []models.InstallSlot{},
+	}
+
+	if len(params.Techs) == 0 {
+		return result, nil
+	}
+
+	query := models.SlotQuery{
+		AddressID:  params.AddressID,
+		Techs:      params.Techs,
+		From:       params.From,
+		To:         params.To,
+		TimesOfDay: params.TimesOfDay,
+	}
+
+	if params.Earliest {
+		slots, err := s.earliestPerTech(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		result.Slots = slots
+		return result, nil
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	query.Limit = params.Limit + 1
+	query.Offset = params.Offset
+
+	slots, err := s.db.SearchInstallSlots(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search install slots: %w", err)
+	}
+
+	if len(slots) > params.Limit {
+		slots = slots[:params.Limit]
+		result.HasMore = true
+	}
+	if slots != nil {
+		result.Slots = slots
+	}
+	result.Limit = params.Limit
+	result.Offset = params.Offset
+
+	return result, nil
+}
+
+// earliestPerTech returns the first matching slot of every technology, ordered by start
+func (s *InstallSlotService) earliestPerTech(ctx context.Context, query models.SlotQuery) ([]models.InstallSlot, error) {
+	slots := []models.InstallSlot{}
+	for _, tech := range query.Techs {
+		techQuery := query
+		techQuery.Techs = []string{tech}
+		techQuery.Limit = 1
+
+		found, err := s.db.SearchInstallSlots(ctx, techQuery)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search earliest %s slot: %w", tech, err)
+		}
+		slots = append(slots, found...)
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].SlotStart.Before(slots[j].SlotStart)
+	})
+
+	return slots, nil
+}
+
+// normalize validates the search parameters and fills in defaults
+func (s *InstallSlotService) normalize(params *SlotSearchParams) error {
+	if params.AddressID == "" {
+		return fmt.Errorf("%w: address ID is required", ErrInvalidSlotSearch)
+	}
+
+	techs, err := normalizeTechs(params.Techs)
+	if err != nil {
+		return err
+	}
+	params.Techs = techs
+
+	for _, tod := range params.TimesOfDay {
+		if _, ok := models.TimeOfDayHours[tod]; !ok {
+			return fmt.Errorf("%w: unknown time of day %q (expected morning, afternoon or evening)", ErrInvalidSlotSearch, tod)
+		}
+	}
+
+	if params.From.IsZero() {
+		params.From = s.now()
+	}
+	if !params.To.IsZero() && !params.To.After(params.From) {
+		return fmt.Errorf("%w: 'to' must be after 'from'", ErrInvalidSlotSearch)
+	}
+
+	switch {
+	case params.Limit == 0:
+		params.Limit = DefaultSlotSearchLimit
+	case params.Limit < 0 || params.Limit > MaxSlotSearchLimit:
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidSlotSearch, MaxSlotSearchLimit)
+	}
+
+	if params.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidSlotSearch)
+	}
+
+	return nil
+}
+
+// normalizeTechs rejects unknown technologies and returns the rest deduplicated in
+// preference order
+func normalizeTechs(techs []string) ([]string, error) {
+	requested := make(map[string]bool, len(techs))
+	for _, tech := range techs {
+		if !isValidTech(tech) {
+			return nil, fmt.Errorf("%w: unknown technology %q (expected fiber, vdsl or fwa)", ErrInvalidSlotSearch, tech)
+		}
+		requested[tech] = true
+	}
+
+	var normalized []string
+	for _, tech := range validTechs {
+		if requested[tech] {
+			normalized = append(normalized, tech)
+		}
+	}
+
+	return normalized, nil
+}
+
+// isValidTech reports whether tech is a known installation technology
+func isValidTech(tech string) bool {
+	for _, valid := range validTechs {
+		if tech == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"app/internal/models"
+)
+
+func slotSearchFixture() (*InstallSlotService, *mockDB, time.Time) {
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	slot := func(id, tech string, hoursFromNow int) models.InstallSlot {
+		start := now.Add(time.Duration(hoursFromNow) * time.Hour)
+		return models.InstallSlot{
+			SlotID: id, AddressID: "A1001", Tech: tech,
+			SlotStart: start, SlotEnd: start.Add(3 * time.Hour),
+			Capacity: 1, RemainingCapacity: 1,
+		}
+	}
+
+	mock := &mockDB{
+		coverage: map[string]*models.Coverage{
+			"A1001": {AddressID: "A1001", Fiber: true, VDSL: true},
+		},
+		slots: []models.InstallSlot{
+			slot("past", "fiber", -5),
+			slot("f1", "fiber", 2),
+			slot("v1", "vdsl", 3),
+			slot("f2", "fiber", 26),
+			slot("v2", "vdsl", 27),
+			slot("f3", "fiber", 50),
+		},
+	}
+
+	service := NewInstallSlotService(mock, NewCoverageService(mock))
+	service.now = func() time.Time { return now }
+
+	return service, mock, now
+}
+
+func TestSearchInstallSlotsDefaultsToCoveredTechs(t *testing.T) {
+	service, mock, now := slotSearchFixture()
+
+	result, err := service.Search(context.Background(), SlotSearchParams{AddressID: "A1001"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.Techs) != 2 || result.Techs[0] != "fiber" || result.Techs[1] != "vdsl" {
+		t.Errorf("Expected techs from coverage [fiber vdsl], got %v", result.Techs)
+	}
+
+	if len(result.Slots) != 5 {
+		t.Errorf("Expected 5 upcoming slots, got %d", len(result.Slots))
+	}
+
+	if !mock.slotQueries[0].From.Equal(now) {
+		t.Errorf("Expected search to start now, got %v", mock.slotQueries[0].From)
+	}
+}
+
+func TestSearchInstallSlotsPagination(t *testing.T) {
+	service, _, _ := slotSearchFixture()
+
+	first, err := service.Search(context.Background(), SlotSearchParams{AddressID: "A1001", Techs: []string{"fiber"}, Limit: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(first.Slots) != 2 || !first.HasMore {
+		t.Fatalf("Expected a full first page with more results, got %d slots, has_more=%v", len(first.Slots), first.HasMore)
+	}
+
+	second, err := service.Search(context.Background(), SlotSearchParams{AddressID: "A1001", Techs: []string{"fiber"}, Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(second.Slots) != 1 || second.HasMore || second.Slots[0].SlotID != "f3" {
+		t.Errorf("Expected last page with f3 only, got %+v has_more=%v", second.Slots, second.HasMore)
+	}
+}
+
+func TestSearchInstallSlotsEarliest(t *testing.T) {
+	service, _, now := slotSearchFixture()
+
+	result, err := service.Search(context.Background(), SlotSearchParams{
+		AddressID: "A1001",
+		Techs:     []string{"vdsl", "fiber"},
+		From:      now.Add(24 * time.Hour),
+		Earliest:  true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.Slots) != 2 || result.Slots[0].SlotID != "f2" || result.Slots[1].SlotID != "v2" {
+		t.Errorf("Expected earliest slots [f2 v2], got %+v", result.Slots)
+	}
+}
+
+func TestSearchInstallSlotsTimeOfDay(t *testing.T) {
+	service, mock, now := slotSearchFixture()
+	// The fixture's slots start in the afternoon in Istanbul; this one at 20:00
+	mock.slots = append(mock.slots, models.InstallSlot{
+		SlotID: "e1", AddressID: "A1001", Tech: "fiber",
+		SlotStart: now.Add(9 * time.Hour), SlotEnd: now.Add(12 * time.Hour),
+		Capacity: 1, RemainingCapacity: 1,
+	})
+
+	tests := []struct {
+		timesOfDay []string
+		expected   int
+	}{
+		{[]string{"morning"}, 0},
+		{[]string{"evening"}, 1},
+		{[]string{"afternoon"}, 5},
+		{[]string{"afternoon", "evening"}, 6},
+	}
+	for _, tt := range tests {
+		result, err := service.Search(context.Background(), SlotSearchParams{AddressID: "A1001", TimesOfDay: tt.timesOfDay})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(result.Slots) != tt.expected {
+			t.Errorf("%v: expected %d slots, got %d", tt.timesOfDay, tt.expected, len(result.Slots))
+		}
+	}
+}
+
+func TestSearchInstallSlotsValidation(t *testing.T) {
+	service, _, now := slotSearchFixture()
+
+	tests := []struct {
+		name   string
+		params SlotSearchParams
+	}{
+		{"Unknown tech", SlotSearchParams{AddressID: "A1001", Techs: []string{"cable"}}},
+		{"Unknown time of day", SlotSearchParams{AddressID: "A1001", TimesOfDay: []string{"night"}}},
+		{"Reversed range", SlotSearchParams{AddressID: "A1001", From: now.Add(time.Hour), To: now}},
+		{"Limit too large", SlotSearchParams{AddressID: "A1001", Limit: MaxSlotSearchLimit + 1}},
+		{"Negative offset", SlotSearchParams{AddressID: "A1001", Offset: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Search(context.Background(), tt.params)
+			if !errors.Is(err, ErrInvalidSlotSearch) {
+				t.Errorf("Expected ErrInvalidSlotSearch, got %v", err)
+			}
+		})
+	}
+}
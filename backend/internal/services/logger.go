@@ -0,0 +1,13 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"app/internal/logging"
+)
+
+// logger returns the logger of ctx for the services package
+func logger(ctx context.Context) *slog.Logger {
+	return logging.For(ctx, "services")
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+)
+
+// DefaultPendingOrderTTL is how long a pending order may hold its install slot
+const DefaultPendingOrderTTL = 15 * time.Minute
+
+// MaintenanceService holds the periodic clean-up tasks run by the background scheduler
+type MaintenanceService struct {
+	db              db.DatabaseInterface
+	generator       *SlotGenerator
+	pendingOrderTTL time.Duration
+	slotHorizonDays int
+}
+
+// NewMaintenanceService creates a maintenance service. Pending orders older than
+// pendingOrderTTL are expired and slots are kept generated slotHorizonDays ahead.
+func NewMaintenanceService(database db.DatabaseInterface, pendingOrderTTL time.Duration, slotHorizonDays int) *MaintenanceService {
+	return &MaintenanceService{
+		db:              database,
+		generator:       NewSlotGenerator(database),
+		pendingOrderTTL: pendingOrderTTL,
+		slotHorizonDays: slotHorizonDays,
+	}
+}
+
+// ExpireHolds cancels abandoned pending orders and releases the slots they hold
+func (s *MaintenanceService) ExpireHolds(ctx context.Context) error {
+	expired, err := s.db.ExpirePendingOrders(ctx, int(s.pendingOrderTTL.Seconds()))
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		logger(ctx).Info("Expired pending orders", "count", expired, "older_than", s.pendingOrderTTL.String())
+	}
+	return nil
+}
+
+// RegenerateSlots extends the generated install slots so they always cover the horizon
+func (s *MaintenanceService) RegenerateSlots(ctx context.Context) error {
+	created, err := s.generator.Materialize(ctx, s.slotHorizonDays)
+	if err != nil {
+		return fmt.Errorf("install slot generation failed: %w", err)
+	}
+
+	if created > 0 {
+		logger(ctx).Info("Generated install slots", "count", created, "horizon_days", s.slotHorizonDays)
+	}
+	return nil
+}
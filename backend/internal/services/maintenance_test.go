@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"app/internal/models"
+)
+
+func TestExpireHolds(t *testing.T) {
+	mock := &mockDB{expired: 2}
+	service := NewMaintenanceService(mock, 15*time.Minute, 14)
+
+	if err := service.ExpireHolds(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(mock.expireCalls) != 1 || mock.expireCalls[0] != 900 {
+		t.Errorf("Expected pending orders older than 900s to expire, got calls %v", mock.expireCalls)
+	}
+}
+
+func TestMaintenanceErrors(t *testing.T) {
+	mock := &mockDB{orderErr: errors.New("connection refused")}
+	service := NewMaintenanceService(mock, DefaultPendingOrderTTL, 14)
+
+	if err := service.ExpireHolds(context.Background()); err == nil {
+		t.Error("Expected ExpireHolds to return the database error")
+	}
+}
+
+func TestRegenerateSlots(t *testing.T) {
+	mock := &mockDB{crews: []models.Crew{testCrew()}}
+	service := NewMaintenanceService(mock, DefaultPendingOrderTTL, 7)
+
+	if err := service.RegenerateSlots(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(mock.upsertedSlots) == 0 {
+		t.Error("Expected generated slots to be stored")
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+func (m *mockDB) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	created := *key
+	created.ID = int64(len(m.apiKeys) + 1)
+	created.CreatedAt = time.Now()
+	m.apiKeys = append(m.apiKeys, &created)
+	copied := created
+	return &copied, nil
+}
+
+func (m *mockDB) GetAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	for _, key := range m.apiKeys {
+		if key.ID == id {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("API key %d: %w", id, db.ErrAPIKeyNotFound)
+}
+
+func (m *mockDB) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	for _, key := range m.apiKeys {
+		if key.Prefix == prefix {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("API key %s: %w", prefix, db.ErrAPIKeyNotFound)
+}
+
+func (m *mockDB) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	for _, key := range m.apiKeys {
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (m *mockDB) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	for _, key := range m.apiKeys {
+		if key.ID == id {
+			if key.RevokedAt == nil {
+				now := time.Now()
+				key.RevokedAt = &now
+			}
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("API key %d: %w", id, db.ErrAPIKeyNotFound)
+}
+
+func (m *mockDB) RotateAPIKey(ctx context.Context, id int64, prefix, keyHash string, expireOldAt time.Time) (*models.APIKey, error) {
+	var old *models.APIKey
+	for _, key := range m.apiKeys {
+		if key.ID == id {
+			old = key
+		}
+	}
+	if old == nil {
+		return nil, fmt.Errorf("API key %d: %w", id, db.ErrAPIKeyNotFound)
+	}
+	if old.RevokedAt != nil {
+		return nil, fmt.Errorf("API key %d: %w", id, db.ErrAPIKeyRevoked)
+	}
+	if m.rotateErr != nil {
+		return nil, m.rotateErr
+	}
+
+	replacement, err := m.CreateAPIKey(ctx, &models.APIKey{
+		Name:               old.Name,
+		Prefix:             prefix,
+		KeyHash:            keyHash,
+		Scopes:             old.Scopes,
+		RateLimitPerMinute: old.RateLimitPerMinute,
+		RateLimitBurst:     old.RateLimitBurst,
+		ExpiresAt:          old.ExpiresAt,
+		RotatedFrom:        &old.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if old.ExpiresAt == nil || old.ExpiresAt.After(expireOldAt) {
+		old.ExpiresAt = &expireOldAt
+	}
+	return replacement, nil
+}
+
+func (m *mockDB) TouchAPIKey(ctx context.Context, id int64) error {
+	m.apiKeyTouches++
+	for _, key := range m.apiKeys {
+		if key.ID == id {
+			now := time.Now()
+			key.LastUsedAt = &now
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+func (m *mockDB) GetCoverage(ctx context.Context, addressID string) (*models.Coverage, error) {
+	c, ok := m.coverage[addressID]
+	if !ok {
+		return nil, fmt.Errorf("coverage for address %s not found", addressID)
+	}
+	return c, nil
+}
+
+func (m *mockDB) GetCoverageBatch(ctx context.Context, addressIDs []string) (map[string]*models.Coverage, error) {
+	m.batchCalls++
+	result := make(map[string]*models.Coverage)
+	for _, id := range addressIDs {
+		if c, ok := m.coverage[id]; ok {
+			result[id] = c
+		}
+	}
+	return result, nil
+}
+
+func (m *mockDB) GetDistrictCoverage(ctx context.Context) ([]models.DistrictCoverage, error) {
+	return m.districts, nil
+}
+
+func (m *mockDB) GetCatalog(ctx context.Context, at time.Time) (*models.Catalog, error) {
+	for from, catalog := range m.scheduled {
+		if !at.Before(from) && (catalog.ValidUntil == nil || at.Before(*catalog.ValidUntil)) {
+			return catalog, nil
+		}
+	}
+	if m.catalog == nil {
+		return nil, fmt.Errorf("catalog not loaded")
+	}
+	return m.catalog, nil
+}
+
+func (m *mockDB) ApplyCatalogChange(ctx context.Context, change models.CatalogChange) (*models.CatalogAuditEntry, error) {
+	if m.catalogErr != nil {
+		return nil, m.catalogErr
+	}
+	m.catalogChanges = append(m.catalogChanges, change)
+	return &models.CatalogAuditEntry{
+		ID:        int64(len(m.catalogChanges)),
+		Entity:    change.Entity,
+		EntityKey: change.Key,
+		Action:    change.Action,
+		Actor:     change.Actor,
+		After:     change.Data,
+	}, nil
+}
+
+func (m *mockDB) SaveQuotes(ctx context.Context, quotes []models.Quote) error {
+	if m.quotes == nil {
+		m.quotes = make(map[string]*models.Quote)
+	}
+	for i := range quotes {
+		q := quotes[i]
+		m.quotes[q.QuoteID] = &q
+	}
+	return nil
+}
+
+func (m *mockDB) GetQuote(ctx context.Context, quoteID string) (*models.Quote, error) {
+	q, ok := m.quotes[quoteID]
+	if !ok {
+		return nil, fmt.Errorf("quote %s: %w", quoteID, db.ErrQuoteNotFound)
+	}
+	return q, nil
+}
+
+func (m *mockDB) ListCampaigns(ctx context.Context, at time.Time) ([]models.Campaign, error) {
+	var campaigns []models.Campaign
+	for _, c := range m.campaigns {
+		if at.IsZero() || c.EndsAt == nil || c.EndsAt.After(at) {
+			campaigns = append(campaigns, c)
+		}
+	}
+	return campaigns, nil
+}
+
+func (m *mockDB) IsNewCustomer(ctx context.Context, userID int) (bool, error) {
+	return !m.customers[userID], nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// mockDB is an in-memory database for service tests. It embeds the interface so that
+// tests only need to provide the methods they exercise; anything else panics. Its
+// methods are grouped by concern in the mock_*_test.go files next to this one.
+type mockDB struct {
+	db.DatabaseInterface
+
+	// Coverage, catalog, quotes and promotions (mock_catalog_test.go)
+	coverage       map[string]*models.Coverage
+	districts      []models.DistrictCoverage
+	batchCalls     int
+	catalog        *models.Catalog
+	scheduled      map[time.Time]*models.Catalog // catalogs from scheduled changes, by the time they take effect
+	catalogChanges []models.CatalogChange
+	catalogErr     error
+	quotes         map[string]*models.Quote
+	campaigns      []models.Campaign
+	customers      map[int]bool // users with services or orders, who are not new customers
+
+	// Crews and install slots (mock_slots_test.go)
+	crews         []models.Crew
+	slots         []models.InstallSlot
+	upsertedSlots []models.InstallSlot
+	slotQueries   []models.SlotQuery
+
+	// Orders, payments and idempotency keys (mock_orders_test.go)
+	orders          map[string]*models.Order
+	placedOrders    []*models.Order
+	rescheduleCalls []rescheduleCall
+	orderErr        error
+	history         map[string][]models.AppointmentChange
+	expireCalls     []int
+	expired         int
+	idempotencyKeys map[string]*models.IdempotencyRecord
+
+	// Users, notifications, order events and partner webhooks (mock_outbox_test.go)
+	users       map[int]*models.User
+	upcoming    []models.Order
+	outbox      []*models.Notification
+	events      []*models.Event
+	webhookSubs []models.WebhookSubscription
+	deliveries  []*models.WebhookDelivery
+
+	// Partner API keys (mock_apikeys_test.go)
+	apiKeys       []*models.APIKey
+	apiKeyTouches int
+	rotateErr     error
+
+	// Health checks
+	healthErr     error
+	schemaVersion int
+	healthChecks  int
+}
+
+func (m *mockDB) Health(ctx context.Context) error {
+	m.healthChecks++
+	return m.healthErr
+}
+
+func (m *mockDB) SchemaVersion(ctx context.Context) (int, error) {
+	return m.schemaVersion, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/payments"
+)
+
+type rescheduleCall struct {
+	OrderID       string
+	NewSlotID     string
+	CutoffSeconds int
+	Reason        string
+}
+
+func (m *mockDB) PlaceOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	if m.orderErr != nil {
+		return nil, m.orderErr
+	}
+	placed := *order
+	placed.Status = models.OrderStatusPending
+	unknown := payments.StatusUnknown
+	placed.PaymentStatus = &unknown
+	m.placedOrders = append(m.placedOrders, &placed)
+	if m.orders == nil {
+		m.orders = make(map[string]*models.Order)
+	}
+	m.orders[placed.OrderID] = &placed
+	return &placed, nil
+}
+
+func (m *mockDB) ConfirmOrder(ctx context.Context, orderID, paymentID string) (*models.Order, error) {
+	order, err := m.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, fmt.Errorf("order %s is %s: %w", orderID, order.Status, db.ErrOrderNotModifiable)
+	}
+	status := "authorized"
+	order.Status = models.OrderStatusConfirmed
+	order.PaymentID = &paymentID
+	order.PaymentStatus = &status
+	return order, nil
+}
+
+func (m *mockDB) SetPaymentStatus(ctx context.Context, orderID, status string) error {
+	order, err := m.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	order.PaymentStatus = &status
+	return nil
+}
+
+func (m *mockDB) GetOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	order, ok := m.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("order %s: %w", orderID, db.ErrOrderNotFound)
+	}
+	return order, nil
+}
+
+func (m *mockDB) RescheduleOrder(ctx context.Context, orderID, newSlotID string, cutoffSeconds int, reason string) (*models.Order, error) {
+	m.rescheduleCalls = append(m.rescheduleCalls, rescheduleCall{orderID, newSlotID, cutoffSeconds, reason})
+	if m.orderErr != nil {
+		return nil, m.orderErr
+	}
+	order, err := m.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.SlotID = &newSlotID
+	return order, nil
+}
+
+func (m *mockDB) CancelOrder(ctx context.Context, orderID, reason string) (*models.Order, error) {
+	if m.orderErr != nil {
+		return nil, m.orderErr
+	}
+	order, err := m.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = models.OrderStatusCancelled
+	order.SlotReleased = true
+	return order, nil
+}
+
+func (m *mockDB) GetAppointmentHistory(ctx context.Context, orderID string) ([]models.AppointmentChange, error) {
+	return m.history[orderID], nil
+}
+
+func (m *mockDB) ExpirePendingOrders(ctx context.Context, maxAgeSeconds int) (int, error) {
+	m.expireCalls = append(m.expireCalls, maxAgeSeconds)
+	return m.expired, m.orderErr
+}
+
+func (m *mockDB) ListUnreconciledOrders(ctx context.Context, minAgeSeconds, limit int) ([]models.Order, error) {
+	var unreconciled []models.Order
+	for _, order := range m.orders {
+		if order.PaymentStatus == nil {
+			continue
+		}
+		switch status := *order.PaymentStatus; {
+		case order.Status == models.OrderStatusCancelled && status != payments.StatusRefunded && status != payments.StatusFailed,
+			order.Status == models.OrderStatusConfirmed && status == payments.StatusAuthorized:
+			unreconciled = append(unreconciled, *order)
+		}
+	}
+	return unreconciled, nil
+}
+
+func (m *mockDB) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, ttlSeconds int) (*models.IdempotencyRecord, bool, error) {
+	if m.idempotencyKeys == nil {
+		m.idempotencyKeys = make(map[string]*models.IdempotencyRecord)
+	}
+	if existing, ok := m.idempotencyKeys[key]; ok {
+		return existing, false, nil
+	}
+	record := &models.IdempotencyRecord{Key: key, RequestHash: requestHash}
+	m.idempotencyKeys[key] = record
+	return record, true, nil
+}
+
+func (m *mockDB) SaveIdempotentResponse(ctx context.Context, key string, statusCode int, body string) error {
+	record, ok := m.idempotencyKeys[key]
+	if !ok {
+		return fmt.Errorf("idempotency key %s not claimed", key)
+	}
+	record.StatusCode = &statusCode
+	record.ResponseBody = &body
+	return nil
+}
+
+func (m *mockDB) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	delete(m.idempotencyKeys, key)
+	return nil
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+)
+
+func (m *mockDB) GetUser(ctx context.Context, userID int) (*models.User, error) {
+	user, ok := m.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+	return user, nil
+}
+
+func (m *mockDB) GetUpcomingAppointments(ctx context.Context, leadSeconds int) ([]models.Order, error) {
+	return m.upcoming, m.orderErr
+}
+
+func (m *mockDB) EnqueueNotifications(ctx context.Context, notifications []models.Notification) error {
+	for i := range notifications {
+		n := notifications[i]
+		if m.notification(n.DedupeKey) != nil {
+			continue
+		}
+		n.ID = int64(len(m.outbox) + 1)
+		n.Status = models.NotificationPending
+		m.outbox = append(m.outbox, &n)
+	}
+	return nil
+}
+
+func (m *mockDB) ClaimNotifications(ctx context.Context, limit, leaseSeconds int) ([]models.Notification, error) {
+	var claimed []models.Notification
+	for _, n := range m.outbox {
+		if len(claimed) == limit {
+			break
+		}
+		if n.Status != models.NotificationPending || n.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		n.Attempts++
+		n.NextAttemptAt = time.Now().Add(time.Duration(leaseSeconds) * time.Second)
+		claimed = append(claimed, *n)
+	}
+	return claimed, nil
+}
+
+func (m *mockDB) MarkNotificationSent(ctx context.Context, id int64) error {
+	m.outbox[id-1].Status = models.NotificationSent
+	return nil
+}
+
+func (m *mockDB) MarkNotificationFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error {
+	n := m.outbox[id-1]
+	n.LastError = &lastError
+	n.Status = models.NotificationFailed
+	if retryAt != nil {
+		n.Status = models.NotificationPending
+		n.NextAttemptAt = *retryAt
+	}
+	return nil
+}
+
+// notification returns the queued notification with the dedupe key, or nil
+func (m *mockDB) notification(dedupeKey string) *models.Notification {
+	for _, n := range m.outbox {
+		if n.DedupeKey == dedupeKey {
+			return n
+		}
+	}
+	return nil
+}
+
+func (m *mockDB) ClaimEvents(ctx context.Context, limit, leaseSeconds int) ([]models.Event, error) {
+	var claimed []models.Event
+	for _, e := range m.events {
+		if len(claimed) == limit {
+			break
+		}
+		if e.Status != models.EventPending || e.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		e.Attempts++
+		e.NextAttemptAt = time.Now().Add(time.Duration(leaseSeconds) * time.Second)
+		claimed = append(claimed, *e)
+	}
+	return claimed, nil
+}
+
+func (m *mockDB) MarkEventDelivered(ctx context.Context, id int64) error {
+	e := m.event(id)
+	e.Status = models.EventDelivered
+	e.LastError = nil
+	return nil
+}
+
+func (m *mockDB) MarkEventFailed(ctx context.Context, id int64, lastError string, retryAt *time.Time) error {
+	e := m.event(id)
+	e.LastError = &lastError
+	e.Status = models.EventDead
+	if retryAt != nil {
+		e.Status = models.EventPending
+		e.NextAttemptAt = *retryAt
+	}
+	return nil
+}
+
+func (m *mockDB) ListEvents(ctx context.Context, status string, limit int) ([]models.Event, error) {
+	var listed []models.Event
+	for i := len(m.events) - 1; i >= 0 && len(listed) < limit; i-- {
+		if m.events[i].Status == status {
+			listed = append(listed, *m.events[i])
+		}
+	}
+	return listed, nil
+}
+
+func (m *mockDB) RequeueEvent(ctx context.Context, id int64) (*models.Event, error) {
+	e := m.event(id)
+	if e == nil || e.Status != models.EventDead {
+		return nil, fmt.Errorf("event %d: %w", id, db.ErrEventNotFound)
+	}
+	e.Status = models.EventPending
+	e.Attempts = 0
+	e.NextAttemptAt = time.Time{}
+	requeued := *e
+	return &requeued, nil
+}
+
+// event returns the outbox event with the ID, or nil
+func (m *mockDB) event(id int64) *models.Event {
+	for _, e := range m.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (m *mockDB) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	created := *sub
+	created.ID = 1
+	if n := len(m.webhookSubs); n > 0 {
+		created.ID = m.webhookSubs[n-1].ID + 1
+	}
+	m.webhookSubs = append(m.webhookSubs, created)
+	return &created, nil
+}
+
+func (m *mockDB) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return append([]models.WebhookSubscription(nil), m.webhookSubs...), nil
+}
+
+func (m *mockDB) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	for i, sub := range m.webhookSubs {
+		if sub.ID == id {
+			m.webhookSubs = append(m.webhookSubs[:i], m.webhookSubs[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook subscription %d: %w", id, db.ErrWebhookNotFound)
+}
+
+func (m *mockDB) EnqueueWebhookDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	for i := range deliveries {
+		d := deliveries[i]
+		if m.delivery(d.SubscriptionID, d.EventID) != nil {
+			continue
+		}
+		d.ID = int64(len(m.deliveries) + 1)
+		d.Status = models.WebhookDeliveryPending
+		m.deliveries = append(m.deliveries, &d)
+	}
+	return nil
+}
+
+func (m *mockDB) ClaimWebhookDeliveries(ctx context.Context, limit, leaseSeconds int) ([]models.WebhookDelivery, error) {
+	var claimed []models.WebhookDelivery
+	for _, d := range m.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status != models.WebhookDeliveryPending || d.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		d.Attempts++
+		d.NextAttemptAt = time.Now().Add(time.Duration(leaseSeconds) * time.Second)
+		claimed = append(claimed, *d)
+	}
+	return claimed, nil
+}
+
+func (m *mockDB) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) error {
+	d := m.deliveries[id-1]
+	d.Status = models.WebhookDeliveryDelivered
+	d.LastStatusCode = &statusCode
+	d.LastError = nil
+	return nil
+}
+
+func (m *mockDB) MarkWebhookFailed(ctx context.Context, id int64, statusCode *int, lastError string, retryAt *time.Time) error {
+	d := m.deliveries[id-1]
+	d.LastStatusCode = statusCode
+	d.LastError = &lastError
+	d.Status = models.WebhookDeliveryFailed
+	if retryAt != nil {
+		d.Status = models.WebhookDeliveryPending
+		d.NextAttemptAt = *retryAt
+	}
+	return nil
+}
+
+func (m *mockDB) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	var listed []models.WebhookDelivery
+	for i := len(m.deliveries) - 1; i >= 0 && len(listed) < limit; i-- {
+		if m.deliveries[i].SubscriptionID == subscriptionID {
+			listed = append(listed, *m.deliveries[i])
+		}
+	}
+	return listed, nil
+}
+
+// delivery returns the queued delivery of the event to the subscription, or nil
+func (m *mockDB) delivery(subscriptionID, eventID int64) *models.WebhookDelivery {
+	for _, d := range m.deliveries {
+		if d.SubscriptionID == subscriptionID && d.EventID == eventID {
+			return d
+		}
+	}
+	return nil
+}
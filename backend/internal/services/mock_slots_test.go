@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+func (m *mockDB) GetCrews(ctx context.Context) ([]models.Crew, error) {
+	return m.crews, nil
+}
+
+func (m *mockDB) UpsertInstallSlots(ctx context.Context, slots []models.InstallSlot) (int, error) {
+	m.upsertedSlots = append(m.upsertedSlots, slots...)
+	return len(slots), nil
+}
+
+func (m *mockDB) SearchInstallSlots(ctx context.Context, q models.SlotQuery) ([]models.InstallSlot, error) {
+	m.slotQueries = append(m.slotQueries, q)
+
+	var matched []models.InstallSlot
+	for _, slot := range m.slots {
+		if slot.AddressID != q.AddressID || slot.RemainingCapacity <= 0 {
+			continue
+		}
+		if len(q.Techs) > 0 && !containsString(q.Techs, slot.Tech) {
+			continue
+		}
+		if !q.From.IsZero() && slot.SlotStart.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && !slot.SlotStart.Before(q.To) {
+			continue
+		}
+		if len(q.TimesOfDay) > 0 && !startsInTimesOfDay(slot, q.TimesOfDay) {
+			continue
+		}
+		matched = append(matched, slot)
+	}
+
+	if q.Offset >= len(matched) {
+		return nil, nil
+	}
+	matched = matched[q.Offset:]
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, nil
+}
+
+// startsInTimesOfDay reports whether slot starts in one of the time-of-day buckets, by
+// its Istanbul start hour as address_install_slots.local_start_hour
+func startsInTimesOfDay(slot models.InstallSlot, timesOfDay []string) bool {
+	hour := slot.SlotStart.In(utils.IstanbulLocation()).Hour()
+	for _, tod := range timesOfDay {
+		if hours := models.TimeOfDayHours[tod]; hour >= hours.From && hour < hours.To {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockDB) GetInstallSlot(ctx context.Context, slotID string) (*models.InstallSlot, error) {
+	for i := range m.slots {
+		if m.slots[i].SlotID == slotID {
+			return &m.slots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("install slot %s: %w", slotID, db.ErrSlotNotFound)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
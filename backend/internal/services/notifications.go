@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/notify"
+	"app/internal/utils"
+)
+
+// DefaultReminderLead is how long before the installation the reminder is sent
+const DefaultReminderLead = 24 * time.Hour
+
+// Outbox delivery settings. A claimed notification is leased for notificationLease so a
+// crashed sender does not lose it; failed sends are retried with exponential backoff
+// starting at notificationRetryBase until maxNotificationAttempts.
+const (
+	notificationBatchSize   = 50
+	notificationLease       = 5 * time.Minute
+	maxNotificationAttempts = 5
+	notificationRetryBase   = time.Minute
+	notificationRetryMax    = time.Hour
+)
+
+// NotificationService renders customer notifications into the outbox and delivers them
+// through the configured notifiers. Only channels with a notifier are queued, and only
+// when the customer has a contact for that channel.
+type NotificationService struct {
+	db           db.DatabaseInterface
+	notifiers    map[string]notify.Notifier
+	reminderLead time.Duration
+	now          func() time.Time
+}
+
+// NewNotificationService creates a notification service delivering through notifiers.
+// Installation reminders are sent reminderLead before the appointment.
+func NewNotificationService(database db.DatabaseInterface, reminderLead time.Duration, notifiers ...notify.Notifier) *NotificationService {
+	byChannel := make(map[string]notify.Notifier, len(notifiers))
+	for _, n := range notifiers {
+		byChannel[n.Channel()] = n
+	}
+
+	return &NotificationService{
+		db:           database,
+		notifiers:    byChannel,
+		reminderLead: reminderLead,
+		now:          time.Now,
+	}
+}
+
+// NotifiersFromConfig creates the email and SMS notifiers that are configured. Email is
+// enabled by SMTP_HOST and SMS by SMS_GATEWAY_URL.
+func NotifiersFromConfig(config *utils.Config) ([]notify.Notifier, error) {
+	var notifiers []notify.Notifier
+
+	if config.SMTPHost != "" {
+		email, err := notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+		})
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, email)
+	}
+
+	if config.SMSGatewayURL != "" {
+		notifiers = append(notifiers, notify.NewSMSNotifier(notify.SMSConfig{
+			URL:    config.SMSGatewayURL,
+			Token:  config.SMSGatewayToken,
+			Sender: config.SMSSender,
+		}))
+	}
+
+	return notifiers, nil
+}
+
+// OrderConfirmed queues the order confirmation
+func (s *NotificationService) OrderConfirmed(ctx context.Context, order *models.Order) error {
+	return s.enqueue(ctx, models.NotificationOrderConfirmed, order, order.OrderID)
+}
+
+// AppointmentRescheduled queues the new appointment of order.SlotID. bookingID identifies
+// the reschedule, so every reschedule is a new message.
+func (s *NotificationService) AppointmentRescheduled(ctx context.Context, order *models.Order, bookingID string) error {
+	return s.enqueue(ctx, models.NotificationAppointmentRescheduled, order, order.OrderID+":"+bookingID)
+}
+
+// OrderCancelled queues the cancellation notice
+func (s *NotificationService) OrderCancelled(ctx context.Context, order *models.Order) error {
+	return s.enqueue(ctx, models.NotificationOrderCancelled, order, order.OrderID)
+}
+
+// HandleEvent queues the notifications for an order event. Customers hear about
+// confirmed orders, rescheduled appointments and the cancellation of confirmed orders;
+// the initial booking of a pending order and the cancellation of one that was never
+// confirmed (failed payment, expired hold) are not announced.
+func (s *NotificationService) HandleEvent(ctx context.Context, event models.Event) error {
+	if len(s.notifiers) == 0 {
+		return nil
+	}
+
+	switch event.EventType {
+	case models.EventOrderPlaced:
+		order, err := s.db.GetOrder(ctx, event.AggregateID)
+		if err != nil {
+			return err
+		}
+		return s.OrderConfirmed(ctx, order)
+
+	case models.EventSlotBooked:
+		var payload models.SlotBookedPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.EventType, err)
+		}
+		if payload.PreviousSlotID == nil {
+			return nil
+		}
+		order, err := s.db.GetOrder(ctx, event.AggregateID)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusConfirmed {
+			return nil
+		}
+		// Announce the slot of this event; the order may have moved again since
+		booked := *order
+		booked.SlotID = &payload.SlotID
+		return s.AppointmentRescheduled(ctx, &booked, strconv.FormatInt(event.ID, 10))
+
+	case models.EventOrderCancelled:
+		var payload models.OrderCancelledPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", event.EventType, err)
+		}
+		if payload.PreviousStatus != models.OrderStatusConfirmed {
+			return nil
+		}
+		order, err := s.db.GetOrder(ctx, event.AggregateID)
+		if err != nil {
+			return err
+		}
+		return s.OrderCancelled(ctx, order)
+	}
+
+	return nil
+}
+
+// QueueReminders queues a reminder for every confirmed appointment starting within the
+// reminder lead. Each appointment is reminded once per slot, however often this runs.
+func (s *NotificationService) QueueReminders(ctx context.Context) error {
+	if len(s.notifiers) == 0 {
+		return nil
+	}
+
+	orders, err := s.db.GetUpcomingAppointments(ctx, int(s.reminderLead.Seconds()))
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for i := range orders {
+		order := &orders[i]
+		if order.SlotID == nil {
+			continue
+		}
+		if err := s.enqueue(ctx, models.NotificationInstallationReminder, order, order.OrderID+":"+*order.SlotID); err != nil {
+			logger(ctx).Error("Failed to queue installation reminder", "order_id", order.OrderID, "error", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to queue %d of %d installation reminders", failed, len(orders))
+	}
+	return nil
+}
+
+// Deliver sends due notifications from the outbox until none are left
+func (s *NotificationService) Deliver(ctx context.Context) error {
+	for {
+		claimed, err := s.db.ClaimNotifications(ctx, notificationBatchSize, int(notificationLease.Seconds()))
+		if err != nil {
+			return err
+		}
+
+		for _, n := range claimed {
+			s.deliver(ctx, n)
+		}
+
+		if len(claimed) < notificationBatchSize || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// deliver sends one claimed notification and records the outcome
+func (s *NotificationService) deliver(ctx context.Context, n models.Notification) {
+	var err error
+	if notifier, ok := s.notifiers[n.Channel]; ok {
+		msg := notify.Message{To: n.Recipient, Body: n.Body}
+		if n.Subject != nil {
+			msg.Subject = *n.Subject
+		}
+		err = notifier.Send(ctx, msg)
+	} else {
+		err = fmt.Errorf("%w: no %s notifier configured", notify.ErrRejected, n.Channel)
+	}
+
+	if err == nil {
+		if err := s.db.MarkNotificationSent(ctx, n.ID); err != nil {
+			logger(ctx).Error("Failed to record notification delivery", "notification_id", n.ID, "error", err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if !errors.Is(err, notify.ErrRejected) && n.Attempts < maxNotificationAttempts {
+		next := s.now().Add(notificationBackoff(n.Attempts))
+		retryAt = &next
+		logger(ctx).Warn("Failed to send notification, retrying", "channel", n.Channel, "notification_id", n.ID,
+			"attempt", n.Attempts, "retry_at", next, "error", err)
+	} else {
+		logger(ctx).Error("Giving up on notification", "channel", n.Channel, "notification_id", n.ID, "attempts", n.Attempts, "error", err)
+	}
+
+	if err := s.db.MarkNotificationFailed(ctx, n.ID, err.Error(), retryAt); err != nil {
+		logger(ctx).Error("Failed to record failed notification delivery", "notification_id", n.ID, "error", err)
+	}
+}
+
+// notificationBackoff returns the delay before retrying after the given attempt:
+// 1m, 2m, 4m, ... capped at notificationRetryMax
+func notificationBackoff(attempts int) time.Duration {
+	return retryBackoff(attempts, notificationRetryBase, notificationRetryMax)
+}
+
+// enqueue renders kind for the order's customer in their locale and queues it on every
+// channel the customer can be reached on. key identifies the occurrence, so the same
+// event queued twice is sent once.
+func (s *NotificationService) enqueue(ctx context.Context, kind string, order *models.Order, key string) error {
+	if len(s.notifiers) == 0 {
+		return nil
+	}
+
+	user, err := s.db.GetUser(ctx, order.UserID)
+	if err != nil {
+		return err
+	}
+
+	data := notify.TemplateData{
+		CustomerName: user.Name,
+		OrderID:      order.OrderID,
+		ComboLabel:   order.ComboLabel,
+		MonthlyTotal: order.MonthlyTotal,
+	}
+	// Cancellations only mention the upfront amount when there is a payment to refund
+	if kind != models.NotificationOrderCancelled || order.PaymentID != nil {
+		data.UpfrontAmount = order.UpfrontAmount
+	}
+	if kind == models.NotificationOrderCancelled && order.CancelReason != nil {
+		data.Reason = *order.CancelReason
+	}
+	if order.SlotID != nil {
+		slot, err := s.db.GetInstallSlot(ctx, *order.SlotID)
+		if err != nil {
+			return fmt.Errorf("failed to load appointment of order %s: %w", order.OrderID, err)
+		}
+		data.SlotStart, data.SlotEnd = &slot.SlotStart, &slot.SlotEnd
+	}
+
+	rendered, err := notify.Render(kind, user.Locale, data)
+	if err != nil {
+		return err
+	}
+
+	var queued []models.Notification
+	add := func(channel string, recipient *string, subject *string, body string) {
+		if _, ok := s.notifiers[channel]; !ok || recipient == nil || *recipient == "" {
+			return
+		}
+		queued = append(queued, models.Notification{
+			DedupeKey: kind + ":" + key + ":" + channel,
+			OrderID:   &order.OrderID,
+			Kind:      kind,
+			Channel:   channel,
+			Recipient: *recipient,
+			Subject:   subject,
+			Body:      body,
+		})
+	}
+	add(notify.ChannelEmail, user.Email, &rendered.Subject, rendered.Email)
+	add(notify.ChannelSMS, user.Phone, nil, rendered.SMS)
+
+	return s.db.EnqueueNotifications(ctx, queued)
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/models"
+	"app/internal/notify"
+)
+
+// recordingNotifier records sent messages and fails with err when set
+type recordingNotifier struct {
+	channel string
+	err     error
+	sent    []notify.Message
+}
+
+func (n *recordingNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// notificationMock returns a database with one English-speaking customer reachable by
+// email and SMS, and a confirmed order booked into a slot
+func notificationMock() *mockDB {
+	slotStart := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	return &mockDB{
+		users: map[int]*models.User{
+			1: {UserID: 1, Name: "Can Celik", Email: stringPtr("can.celik@example.com"), Phone: stringPtr("+905551000005"), Locale: "en"},
+		},
+		slots: []models.InstallSlot{
+			{SlotID: "C1-fiber-202603020600", SlotStart: slotStart, SlotEnd: slotStart.Add(3 * time.Hour), Tech: "fiber"},
+		},
+		orders: map[string]*models.Order{
+			"ORD-1": {
+				OrderID:       "ORD-1",
+				UserID:        1,
+				SlotID:        stringPtr("C1-fiber-202603020600"),
+				Status:        models.OrderStatusConfirmed,
+				ComboLabel:    "Mobile + Fiber 100Mbps",
+				MonthlyTotal:  242.73,
+				UpfrontAmount: 441.73,
+				PaymentID:     stringPtr("pay_fake_1"),
+			},
+		},
+	}
+}
+
+func TestNotificationsQueuedPerChannelInCustomerLocale(t *testing.T) {
+	mock := notificationMock()
+	email := &recordingNotifier{channel: notify.ChannelEmail}
+	sms := &recordingNotifier{channel: notify.ChannelSMS}
+	service := NewNotificationService(mock, DefaultReminderLead, email, sms)
+
+	order := mock.orders["ORD-1"]
+	for i := 0; i < 2; i++ {
+		if err := service.OrderConfirmed(context.Background(), order); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// Queuing the same event twice is deduplicated
+	if len(mock.outbox) != 2 {
+		t.Fatalf("Expected one email and one SMS, got %d notifications", len(mock.outbox))
+	}
+
+	byChannel := map[string]*models.Notification{}
+	for _, n := range mock.outbox {
+		byChannel[n.Channel] = n
+	}
+
+	mail := byChannel[notify.ChannelEmail]
+	if mail == nil || mail.Recipient != "can.celik@example.com" || mail.Subject == nil || *mail.Subject != "Your order is confirmed (ORD-1)" {
+		t.Errorf("Unexpected email notification: %+v", mail)
+	}
+	if mail != nil && !strings.Contains(mail.Body, "Monday 2 March 2026, 09:00-12:00") {
+		t.Errorf("Expected the appointment in Istanbul time, got:\n%s", mail.Body)
+	}
+
+	text := byChannel[notify.ChannelSMS]
+	if text == nil || text.Recipient != "+905551000005" || text.Subject != nil {
+		t.Errorf("Unexpected SMS notification: %+v", text)
+	}
+}
+
+func TestNotificationsSkipUnreachableChannels(t *testing.T) {
+	mock := notificationMock()
+	mock.users[1].Phone = nil
+	mock.users[1].Locale = "tr"
+
+	// SMS is configured but the customer has no phone; email is configured
+	service := NewNotificationService(mock, DefaultReminderLead,
+		&recordingNotifier{channel: notify.ChannelEmail},
+		&recordingNotifier{channel: notify.ChannelSMS},
+	)
+	if err := service.OrderCancelled(context.Background(), mock.orders["ORD-1"]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(mock.outbox) != 1 || mock.outbox[0].Channel != notify.ChannelEmail {
+		t.Fatalf("Expected only an email, got %d notifications", len(mock.outbox))
+	}
+	if !strings.Contains(*mock.outbox[0].Subject, "iptal edildi") {
+		t.Errorf("Expected a Turkish subject, got %q", *mock.outbox[0].Subject)
+	}
+
+	// Without notifiers nothing is queued
+	disabled := NewNotificationService(mock, DefaultReminderLead)
+	if err := disabled.OrderConfirmed(context.Background(), mock.orders["ORD-1"]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(mock.outbox) != 1 {
+		t.Errorf("Expected nothing to be queued without notifiers, got %d notifications", len(mock.outbox))
+	}
+}
+
+func TestRescheduleNotificationsAreNotDeduplicated(t *testing.T) {
+	mock := notificationMock()
+	service := NewNotificationService(mock, DefaultReminderLead, &recordingNotifier{channel: notify.ChannelEmail})
+
+	order := mock.orders["ORD-1"]
+	for _, bookingID := range []string{"1", "2", "2"} {
+		if err := service.AppointmentRescheduled(context.Background(), order, bookingID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if len(mock.outbox) != 2 {
+		t.Errorf("Expected one notification per reschedule, got %d", len(mock.outbox))
+	}
+}
+
+func TestQueueReminders(t *testing.T) {
+	mock := notificationMock()
+	mock.upcoming = []models.Order{*mock.orders["ORD-1"]}
+	service := NewNotificationService(mock, DefaultReminderLead, &recordingNotifier{channel: notify.ChannelSMS})
+
+	for i := 0; i < 2; i++ {
+		if err := service.QueueReminders(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if len(mock.outbox) != 1 {
+		t.Fatalf("Expected one reminder however often the job runs, got %d", len(mock.outbox))
+	}
+	reminder := mock.outbox[0]
+	if reminder.Kind != models.NotificationInstallationReminder || !strings.Contains(reminder.Body, "reminder") {
+		t.Errorf("Unexpected reminder: %+v", reminder)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		attempts        int
+		expectedStatus  string
+		expectRetryIn   time.Duration
+		expectDelivered bool
+	}{
+		{"Sent", nil, 0, models.NotificationSent, 0, true},
+		{"Transient failure is retried", errors.New("connection refused"), 0, models.NotificationPending, time.Minute, false},
+		{"Backoff grows", errors.New("connection refused"), 2, models.NotificationPending, 4 * time.Minute, false},
+		{"Gives up after max attempts", errors.New("connection refused"), maxNotificationAttempts - 1, models.NotificationFailed, 0, false},
+		{"Rejected is not retried", fmt.Errorf("%w: invalid number", notify.ErrRejected), 0, models.NotificationFailed, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := notificationMock()
+			sms := &recordingNotifier{channel: notify.ChannelSMS}
+			service := NewNotificationService(mock, DefaultReminderLead, sms)
+			now := time.Now()
+			service.now = func() time.Time { return now }
+
+			if err := service.OrderConfirmed(context.Background(), mock.orders["ORD-1"]); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			mock.outbox[0].Attempts = tt.attempts
+			sms.err = tt.err
+
+			if err := service.Deliver(context.Background()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			n := mock.outbox[0]
+			if n.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, n.Status)
+			}
+			if tt.expectRetryIn > 0 && !n.NextAttemptAt.Equal(now.Add(tt.expectRetryIn)) {
+				t.Errorf("Expected retry in %v, got %v", tt.expectRetryIn, n.NextAttemptAt.Sub(now))
+			}
+			if delivered := len(sms.sent) == 1; delivered != tt.expectDelivered {
+				t.Errorf("Expected delivered=%v, got %d messages", tt.expectDelivered, len(sms.sent))
+			}
+		})
+	}
+}
+
+func TestDeliverWithoutNotifierForChannel(t *testing.T) {
+	mock := notificationMock()
+	queued := NewNotificationService(mock, DefaultReminderLead, &recordingNotifier{channel: notify.ChannelEmail})
+	if err := queued.OrderConfirmed(context.Background(), mock.orders["ORD-1"]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The email channel was switched off after the notification was queued
+	sender := NewNotificationService(mock, DefaultReminderLead, &recordingNotifier{channel: notify.ChannelSMS})
+	if err := sender.Deliver(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mock.outbox[0].Status != models.NotificationFailed {
+		t.Errorf("Expected the notification to fail, got %s", mock.outbox[0].Status)
+	}
+}
+
+func TestNotificationBackoff(t *testing.T) {
+	expected := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		7:  notificationRetryMax,
+		50: notificationRetryMax,
+	}
+	for attempts, delay := range expected {
+		if got := notificationBackoff(attempts); got != delay {
+			t.Errorf("notificationBackoff(%d) = %v, expected %v", attempts, got, delay)
+		}
+	}
+}
+
+// orderEvent returns an outbox event for ORD-1 with the JSON encoded payload
+func orderEvent(t *testing.T, id int64, eventType string, payload interface{}) models.Event {
+	t.Helper()
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to encode payload: %v", err)
+	}
+	return models.Event{ID: id, EventType: eventType, AggregateID: "ORD-1", Payload: raw, Status: models.EventPending}
+}
+
+func TestHandleEvent(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		event        func(t *testing.T) models.Event
+		expectedKind string
+	}{
+		{
+			name:   "Order placed",
+			status: models.OrderStatusConfirmed,
+			event: func(t *testing.T) models.Event {
+				return orderEvent(t, 1, models.EventOrderPlaced, models.OrderPlacedPayload{OrderID: "ORD-1", PaymentID: "pay_fake_1"})
+			},
+			expectedKind: models.NotificationOrderConfirmed,
+		},
+		{
+			name:   "Initial booking is not announced",
+			status: models.OrderStatusPending,
+			event: func(t *testing.T) models.Event {
+				return orderEvent(t, 1, models.EventSlotBooked, models.SlotBookedPayload{OrderID: "ORD-1", SlotID: "C1-fiber-202603020600"})
+			},
+		},
+		{
+			name:   "Rescheduled appointment",
+			status: models.OrderStatusConfirmed,
+			event: func(t *testing.T) models.Event {
+				return orderEvent(t, 2, models.EventSlotBooked, models.SlotBookedPayload{OrderID: "ORD-1", SlotID: "C1-fiber-202603020600", PreviousSlotID: stringPtr("C1-fiber-202603010600")})
+			},
+			expectedKind: models.NotificationAppointmentRescheduled,
+		},
+		{
+			name:   "Confirmed order cancelled",
+			status: models.OrderStatusCancelled,
+			event: func(t *testing.T) models.Event {
+				return orderEvent(t, 3, models.EventOrderCancelled, models.OrderCancelledPayload{OrderID: "ORD-1", PreviousStatus: models.OrderStatusConfirmed})
+			},
+			expectedKind: models.NotificationOrderCancelled,
+		},
+		{
+			name:   "Expired hold is not announced",
+			status: models.OrderStatusCancelled,
+			event: func(t *testing.T) models.Event {
+				return orderEvent(t, 3, models.EventOrderCancelled, models.OrderCancelledPayload{OrderID: "ORD-1", PreviousStatus: models.OrderStatusPending, Reason: stringPtr("hold expired")})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := notificationMock()
+			mock.orders["ORD-1"].Status = tt.status
+			service := NewNotificationService(mock, DefaultReminderLead, &recordingNotifier{channel: notify.ChannelEmail})
+
+			// Events are delivered at least once; handling one twice queues it once
+			event := tt.event(t)
+			for i := 0; i < 2; i++ {
+				if err := service.HandleEvent(context.Background(), event); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			if tt.expectedKind == "" {
+				if len(mock.outbox) != 0 {
+					t.Errorf("Expected no notifications, got %d", len(mock.outbox))
+				}
+				return
+			}
+			if len(mock.outbox) != 1 || mock.outbox[0].Kind != tt.expectedKind {
+				t.Fatalf("Expected one %s notification, got %d", tt.expectedKind, len(mock.outbox))
+			}
+		})
+	}
+}
+
+func TestHandleEventFailures(t *testing.T) {
+	mock := notificationMock()
+	delete(mock.users, 1)
+	service := NewNotificationService(mock, DefaultReminderLead, &recordingNotifier{channel: notify.ChannelEmail})
+
+	// The customer cannot be loaded, so the event is retried by the dispatcher
+	event := orderEvent(t, 1, models.EventOrderPlaced, models.OrderPlacedPayload{OrderID: "ORD-1"})
+	if err := service.HandleEvent(context.Background(), event); err == nil {
+		t.Error("Expected an error when the customer cannot be loaded")
+	}
+
+	event = models.Event{ID: 2, EventType: models.EventOrderCancelled, AggregateID: "ORD-1", Payload: []byte("not json")}
+	if err := service.HandleEvent(context.Background(), event); err == nil {
+		t.Error("Expected an error for an invalid payload")
+	}
+}
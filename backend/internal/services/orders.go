@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/payments"
+	"app/internal/utils"
+)
+
+// DefaultRescheduleCutoff is how long before the installation an appointment can last be changed
+const DefaultRescheduleCutoff = 24 * time.Hour
+
+// PaymentCurrency is the currency upfront payments are taken in
+const PaymentCurrency = "TRY"
+
+// OrderService handles order placement, upfront payments and installation appointment changes
+type OrderService struct {
+	db               db.DatabaseInterface
+	quoteService     *QuoteService
+	promotions       *PromotionService
+	payments         payments.PaymentProvider
+	rescheduleCutoff time.Duration
+}
+
+// NewOrderService creates a new order service. Orders are placed from quotes verified by
+// quoteService, with coupons entered at checkout applied by promotions, and paid through
+// provider. Appointments can be rescheduled until rescheduleCutoff before the booked
+// slot starts.
+func NewOrderService(database db.DatabaseInterface, quoteService *QuoteService, promotions *PromotionService, provider payments.PaymentProvider, rescheduleCutoff time.Duration) *OrderService {
+	return &OrderService{
+		db:               database,
+		quoteService:     quoteService,
+		promotions:       promotions,
+		payments:         provider,
+		rescheduleCutoff: rescheduleCutoff,
+	}
+}
+
+// PaymentProviderFromConfig creates the configured payment provider. LoadConfig only
+// allows the fake provider in development.
+func PaymentProviderFromConfig(config *utils.Config) (payments.PaymentProvider, error) {
+	switch config.PaymentProvider {
+	case utils.PaymentProviderFake:
+		return payments.NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", config.PaymentProvider)
+	}
+}
+
+// PlaceOrder places an order from a valid quote. The price, items, promotions and
+// commitment are taken from the stored quote, never from the client; a coupon entered at
+// checkout prices the quote again with it. When the quote includes home internet, the
+// slot must be for the home plan's technology.
+//
+// The order is first stored as pending, with its payment unknown, which holds the install
+// slot while the upfront amount (install fee plus the first month) is authorised. It is
+// confirmed and the payment captured once the authorisation succeeds. A declined payment
+// cancels the order and releases the slot; when the provider times out the outcome is
+// unknown, so the order stays pending until the hold sweeper expires it. The payments
+// left unknown, uncaptured or unrefunded are settled by PaymentReconciler.
+func (s *OrderService) PlaceOrder(ctx context.Context, req *api.CheckoutRequest) (*models.Order, error) {
+	quote, err := s.quoteService.Resolve(ctx, req.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CouponCode != "" {
+		if quote, err = s.promotions.ApplyCoupon(ctx, quote, req.CouponCode); err != nil {
+			return nil, err
+		}
+	}
+
+	upfront, err := UpfrontAmount(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, err := GenerateOrderID()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.db.PlaceOrder(ctx, &models.Order{
+		OrderID:       orderID,
+		UserID:        quote.UserID,
+		AddressID:     quote.AddressID,
+		SlotID:        &req.SlotID,
+		Tech:          quote.Tech,
+		ComboLabel:    quote.ComboLabel,
+		MonthlyTotal:  quote.MonthlyTotal,
+		Items:         quote.Items,
+		Promotions:    quote.Promotions,
+		UpfrontAmount: upfront,
+
+		CommitmentMonths: quote.CommitmentMonths,
+		TerminationFee:   quote.TerminationFee,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	// Clean-up after a failed payment must run even if the client went away
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	payment, err := s.payments.Authorize(ctx, payments.AuthorizeRequest{
+		Reference: order.OrderID,
+		Amount:    int64(math.Round(upfront * 100)),
+		Currency:  PaymentCurrency,
+		Card: payments.Card{
+			Number:      req.Payment.CardNumber,
+			ExpiryMonth: req.Payment.ExpiryMonth,
+			ExpiryYear:  req.Payment.ExpiryYear,
+			CVC:         req.Payment.CVC,
+			HolderName:  req.Payment.HolderName,
+		},
+	})
+	if err != nil {
+		if !errors.Is(err, payments.ErrTimeout) {
+			if _, cancelErr := s.db.CancelOrder(cleanupCtx, order.OrderID, "payment failed: "+err.Error()); cancelErr != nil {
+				logger(ctx).Error("Failed to cancel order after payment failure", "order_id", order.OrderID, "error", cancelErr)
+			}
+		}
+		return nil, fmt.Errorf("payment for order %s failed: %w", order.OrderID, err)
+	}
+
+	confirmed, err := s.db.ConfirmOrder(ctx, order.OrderID, payment.ID)
+	if err != nil {
+		// The order can no longer be confirmed, so release the money reserved for it
+		if _, refundErr := s.payments.Refund(cleanupCtx, payment.ID); refundErr != nil {
+			logger(ctx).Error("Failed to release payment of unconfirmed order", "payment_id", payment.ID, "order_id", order.OrderID, "error", refundErr)
+		}
+		return nil, fmt.Errorf("failed to confirm order %s: %w", order.OrderID, err)
+	}
+
+	// A failed capture leaves the payment authorised for PaymentReconciler; the order
+	// itself is confirmed
+	if _, err := s.payments.Capture(ctx, payment.ID); err != nil {
+		logger(ctx).Error("Failed to capture payment", "payment_id", payment.ID, "order_id", order.OrderID, "error", err)
+		return confirmed, nil
+	}
+	if err := s.db.SetPaymentStatus(cleanupCtx, order.OrderID, payments.StatusCaptured); err != nil {
+		logger(ctx).Error("Failed to record captured payment", "order_id", order.OrderID, "error", err)
+		return confirmed, nil
+	}
+
+	status := payments.StatusCaptured
+	confirmed.PaymentStatus = &status
+	return confirmed, nil
+}
+
+// UpfrontAmount returns what is charged at checkout for a quote: the home plan's install
+// fee, if any, plus the first month, less the promotions for the first months only
+func UpfrontAmount(quote *models.Quote) (float64, error) {
+	var items api.RecommendationItemsDTO
+	if err := json.Unmarshal(quote.Items, &items); err != nil {
+		return 0, fmt.Errorf("failed to decode quote items: %w", err)
+	}
+
+	var promotions []api.PromotionDTO
+	if len(quote.Promotions) > 0 {
+		if err := json.Unmarshal(quote.Promotions, &promotions); err != nil {
+			return 0, fmt.Errorf("failed to decode quote promotions: %w", err)
+		}
+	}
+
+	amount := quote.MonthlyTotal
+	for _, promotion := range promotions {
+		if promotion.Months > 0 {
+			amount -= promotion.Amount
+		}
+	}
+	if items.Home != nil {
+		amount += items.Home.InstallFee
+	}
+
+	return math.Round(amount*100) / 100, nil
+}
+
+// Reschedule moves an order's installation to another slot, releasing the old one
+func (s *OrderService) Reschedule(ctx context.Context, orderID string, req *api.RescheduleRequest) (*models.Order, error) {
+	order, err := s.db.RescheduleOrder(ctx, orderID, req.SlotID, int(s.rescheduleCutoff.Seconds()), req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reschedule order %s: %w", orderID, err)
+	}
+
+	return order, nil
+}
+
+// Cancel cancels an order, releasing its install slot if the installation has not started,
+// and refunds its upfront payment. A failed refund is logged and leaves the payment
+// status unchanged so it can be retried; the cancellation itself stands.
+func (s *OrderService) Cancel(ctx context.Context, orderID string, req *api.CancelOrderRequest) (*models.Order, error) {
+	order, err := s.db.CancelOrder(ctx, orderID, req.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel order %s: %w", orderID, err)
+	}
+
+	s.refund(ctx, order)
+	return order, nil
+}
+
+// refund refunds the upfront payment of a cancelled order and records the new status
+func (s *OrderService) refund(ctx context.Context, order *models.Order) {
+	if order.PaymentID == nil || order.PaymentStatus == nil || *order.PaymentStatus == payments.StatusRefunded {
+		return
+	}
+
+	refundCtx := context.WithoutCancel(ctx)
+	if _, err := s.payments.Refund(refundCtx, *order.PaymentID); err != nil {
+		logger(ctx).Error("Failed to refund payment of cancelled order", "payment_id", *order.PaymentID, "order_id", order.OrderID, "error", err)
+		return
+	}
+	if err := s.db.SetPaymentStatus(refundCtx, order.OrderID, payments.StatusRefunded); err != nil {
+		logger(ctx).Error("Failed to record refund", "order_id", order.OrderID, "error", err)
+		return
+	}
+
+	status := payments.StatusRefunded
+	order.PaymentStatus = &status
+}
+
+// RescheduleCutoff returns how long before the installation changes are still accepted
+func (s *OrderService) RescheduleCutoff() time.Duration {
+	return s.rescheduleCutoff
+}
+
+// GenerateOrderID returns a random order ID such as ORD-3F9A0C1B2D4E
+func GenerateOrderID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate order ID: %w", err)
+	}
+	return "ORD-" + strings.ToUpper(hex.EncodeToString(buf)), nil
+}
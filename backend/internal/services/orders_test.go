@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/payments"
+)
+
+func checkoutCandidate() api.RecommendationCandidateDTO {
+	return api.RecommendationCandidateDTO{
+		ComboLabel:   "Mobile + Fiber 100Mbps",
+		MonthlyTotal: 242.73,
+		Items: api.RecommendationItemsDTO{
+			Mobile: []api.MobilePlanAssignmentDTO{{LineID: "LINE001", Plan: api.MobilePlanDTO{PlanID: 2}}},
+			Home:   &api.HomePlanDTO{HomeID: 2, Name: "Fiber 100Mbps", Tech: "fiber", MonthlyPrice: 119.90},
+		},
+	}
+}
+
+// quotedCheckout issues a quote for candidate and returns an order service and a
+// checkout request redeeming it
+func quotedCheckout(t *testing.T, mock *mockDB, candidate api.RecommendationCandidateDTO) (*OrderService, *api.CheckoutRequest) {
+	t.Helper()
+	if mock.catalog == nil {
+		mock.catalog = analyticsTestCatalog()
+	}
+
+	quotes := NewQuoteService(mock, testQuoteSecret, DefaultQuoteTTL)
+	candidates := []api.RecommendationCandidateDTO{candidate}
+	if err := quotes.Issue(context.Background(), quoteTestRequest(), CatalogVersion(mock.catalog), candidates); err != nil {
+		t.Fatalf("Failed to issue quote: %v", err)
+	}
+
+	service := NewOrderService(mock, quotes, NewPromotionService(mock), payments.NewFakeProvider(), DefaultRescheduleCutoff)
+	return service, &api.CheckoutRequest{
+		QuoteID: candidates[0].QuoteID,
+		SlotID:  "C1-fiber-202603020600",
+		Payment: api.PaymentMethodDTO{CardNumber: payments.CardApproved, ExpiryMonth: 12, ExpiryYear: 2030, CVC: "123"},
+	}
+}
+
+func TestPlaceOrder(t *testing.T) {
+	mock := &mockDB{}
+	service, req := quotedCheckout(t, mock, checkoutCandidate())
+
+	order, err := service.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !regexp.MustCompile(`^ORD-[0-9A-F]{12}$`).MatchString(order.OrderID) {
+		t.Errorf("Unexpected order ID format: %s", order.OrderID)
+	}
+
+	placed := mock.placedOrders[0]
+	if placed.Tech == nil || *placed.Tech != "fiber" {
+		t.Errorf("Expected slot tech restricted to fiber, got %v", placed.Tech)
+	}
+
+	if placed.SlotID == nil || *placed.SlotID != "C1-fiber-202603020600" {
+		t.Errorf("Expected requested slot to be booked, got %v", placed.SlotID)
+	}
+
+	if placed.UserID != 1 || placed.AddressID != "A1001" || placed.MonthlyTotal != 242.73 {
+		t.Errorf("Expected customer, address and price from the quote, got %+v", placed)
+	}
+
+	var items api.RecommendationItemsDTO
+	if err := json.Unmarshal(placed.Items, &items); err != nil || items.Home == nil || items.Home.HomeID != 2 {
+		t.Errorf("Expected items to round-trip, got %s (%v)", placed.Items, err)
+	}
+}
+
+func TestPlaceOrderMobileOnlyHasNoTechRestriction(t *testing.T) {
+	mock := &mockDB{}
+	candidate := checkoutCandidate()
+	candidate.Items.Home = nil
+	service, req := quotedCheckout(t, mock, candidate)
+
+	if _, err := service.PlaceOrder(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mock.placedOrders[0].Tech != nil {
+		t.Errorf("Expected no tech restriction, got %s", *mock.placedOrders[0].Tech)
+	}
+}
+
+func TestPlaceOrderKeepsCommitment(t *testing.T) {
+	mock := &mockDB{}
+	candidate := checkoutCandidate()
+	candidate.Commitment = api.CommitmentDTO{Months: 24, ContractValue: 24 * 242.73, TerminationFee: 360}
+	service, req := quotedCheckout(t, mock, candidate)
+
+	if _, err := service.PlaceOrder(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if placed := mock.placedOrders[0]; placed.CommitmentMonths != 24 || placed.TerminationFee != 360 {
+		t.Errorf("Expected a 24-month commitment with a 360 fee, got %d months and %.2f", placed.CommitmentMonths, placed.TerminationFee)
+	}
+}
+
+func TestPlaceOrderSlotUnavailable(t *testing.T) {
+	mock := &mockDB{}
+	service, req := quotedCheckout(t, mock, checkoutCandidate())
+	mock.orderErr = fmt.Errorf("failed to place order: %w", db.ErrSlotUnavailable)
+
+	_, err := service.PlaceOrder(context.Background(), req)
+	if !errors.Is(err, db.ErrSlotUnavailable) {
+		t.Errorf("Expected ErrSlotUnavailable, got %v", err)
+	}
+}
+
+func TestPlaceOrderRejectsInvalidQuote(t *testing.T) {
+	mock := &mockDB{}
+	service, req := quotedCheckout(t, mock, checkoutCandidate())
+	req.QuoteID = "QT-0000000000000000-forged"
+
+	if _, err := service.PlaceOrder(context.Background(), req); !errors.Is(err, db.ErrQuoteNotFound) {
+		t.Errorf("Expected ErrQuoteNotFound, got %v", err)
+	}
+	if len(mock.placedOrders) != 0 {
+		t.Error("Expected no order to be placed")
+	}
+}
+
+func TestRescheduleOrder(t *testing.T) {
+	oldSlot := "S1"
+	mock := &mockDB{orders: map[string]*models.Order{
+		"ORD-1": {OrderID: "ORD-1", Status: models.OrderStatusConfirmed, SlotID: &oldSlot},
+	}}
+	service := NewOrderService(mock, nil, nil, nil, 36*time.Hour)
+
+	order, err := service.Reschedule(context.Background(), "ORD-1", &api.RescheduleRequest{SlotID: "S2", Reason: "Customer travelling"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if *order.SlotID != "S2" {
+		t.Errorf("Expected order moved to S2, got %s", *order.SlotID)
+	}
+
+	call := mock.rescheduleCalls[0]
+	if call.CutoffSeconds != 36*60*60 || call.Reason != "Customer travelling" {
+		t.Errorf("Unexpected reschedule call: %+v", call)
+	}
+}
+
+func TestRescheduleOrderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		orderErr error
+		expected error
+	}{
+		{"Not found", db.ErrOrderNotFound, db.ErrOrderNotFound},
+		{"Window closed", db.ErrChangeWindowClosed, db.ErrChangeWindowClosed},
+		{"Cancelled order", db.ErrOrderNotModifiable, db.ErrOrderNotModifiable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewOrderService(&mockDB{orderErr: tt.orderErr}, nil, nil, nil, DefaultRescheduleCutoff)
+
+			_, err := service.Reschedule(context.Background(), "ORD-1", &api.RescheduleRequest{SlotID: "S2"})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	mock := &mockDB{orders: map[string]*models.Order{
+		"ORD-1": {OrderID: "ORD-1", Status: models.OrderStatusConfirmed},
+	}}
+
+	order, err := NewOrderService(mock, nil, nil, nil, DefaultRescheduleCutoff).Cancel(context.Background(), "ORD-1", &api.CancelOrderRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if order.Status != models.OrderStatusCancelled || !order.SlotReleased {
+		t.Errorf("Expected cancelled order with released slot, got %+v", order)
+	}
+}
+
+func TestPlaceOrderChargesUpfrontAmount(t *testing.T) {
+	mock := &mockDB{}
+	candidate := checkoutCandidate()
+	candidate.Items.Home.InstallFee = 199.00
+	service, req := quotedCheckout(t, mock, candidate)
+
+	order, err := service.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if order.Status != models.OrderStatusConfirmed {
+		t.Errorf("Expected confirmed order, got %s", order.Status)
+	}
+	if order.UpfrontAmount != 441.73 {
+		t.Errorf("Expected install fee plus first month (441.73), got %.2f", order.UpfrontAmount)
+	}
+	if order.PaymentID == nil || order.PaymentStatus == nil || *order.PaymentStatus != payments.StatusCaptured {
+		t.Errorf("Expected captured payment, got %v / %v", order.PaymentID, order.PaymentStatus)
+	}
+}
+
+func TestPlaceOrderPaymentFailures(t *testing.T) {
+	tests := []struct {
+		name           string
+		card           string
+		expected       error
+		expectedStatus string
+	}{
+		{"Declined", payments.CardDeclined, payments.ErrDeclined, models.OrderStatusCancelled},
+		{"3DS required", payments.CardAuthenticationRequired, payments.ErrAuthenticationRequired, models.OrderStatusCancelled},
+		{"Invalid card", "4242424242424241", payments.ErrInvalidCard, models.OrderStatusCancelled},
+		// The outcome of a timed out authorisation is unknown; the hold sweeper expires the order
+		{"Timeout", payments.CardTimeout, payments.ErrTimeout, models.OrderStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockDB{}
+			service, req := quotedCheckout(t, mock, checkoutCandidate())
+			req.Payment.CardNumber = tt.card
+
+			_, err := service.PlaceOrder(context.Background(), req)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, err)
+			}
+
+			if status := mock.placedOrders[0].Status; status != tt.expectedStatus {
+				t.Errorf("Expected order to be %s, got %s", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestCancelOrderRefundsPayment(t *testing.T) {
+	mock := &mockDB{}
+	service, req := quotedCheckout(t, mock, checkoutCandidate())
+
+	placed, err := service.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	order, err := service.Cancel(context.Background(), placed.OrderID, &api.CancelOrderRequest{Reason: "Moved house"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if order.PaymentStatus == nil || *order.PaymentStatus != payments.StatusRefunded {
+		t.Errorf("Expected refunded payment, got %v", order.PaymentStatus)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/payments"
+)
+
+// ReconcileMinAge is how long an order's payment must have been left unchanged before it
+// is reconciled, so checkouts and cancellations still in progress are left alone
+const ReconcileMinAge = time.Minute
+
+// reconcileBatchSize is the most orders reconciled per run
+const reconcileBatchSize = 100
+
+// PaymentReconciler settles the payments that checkout and cancellation left unfinished:
+// authorisations that timed out, captures and refunds that failed
+type PaymentReconciler struct {
+	db       db.DatabaseInterface
+	payments payments.PaymentProvider
+}
+
+// NewPaymentReconciler creates a payment reconciler settling payments with provider
+func NewPaymentReconciler(database db.DatabaseInterface, provider payments.PaymentProvider) *PaymentReconciler {
+	return &PaymentReconciler{
+		db:       database,
+		payments: provider,
+	}
+}
+
+// Reconcile settles the payments of unreconciled orders. A confirmed order's authorised
+// payment is captured. A cancelled order's payment is refunded, or its authorisation
+// released; when its authorisation never answered it is first looked up by the order ID,
+// and recorded as failed if the provider has none. Orders that fail are retried on the
+// next run, after the others.
+func (r *PaymentReconciler) Reconcile(ctx context.Context) error {
+	orders, err := r.db.ListUnreconciledOrders(ctx, int(ReconcileMinAge.Seconds()), reconcileBatchSize)
+	if err != nil {
+		return err
+	}
+
+	settled := 0
+	for i := range orders {
+		order := &orders[i]
+		status, err := r.settle(ctx, order)
+		if err != nil {
+			logger(ctx).Warn("Failed to reconcile payment", "order_id", order.OrderID, "status", order.Status, "error", err)
+			// Recording the unchanged status moves the order behind the others
+			status = *order.PaymentStatus
+		} else {
+			settled++
+		}
+		if err := r.db.SetPaymentStatus(ctx, order.OrderID, status); err != nil {
+			return err
+		}
+	}
+
+	if settled > 0 {
+		logger(ctx).Info("Reconciled payments", "count", settled)
+	}
+	return nil
+}
+
+// settle captures or refunds the payment of order and returns its new payment status
+func (r *PaymentReconciler) settle(ctx context.Context, order *models.Order) (string, error) {
+	var paymentID string
+	if order.PaymentID != nil {
+		paymentID = *order.PaymentID
+	} else {
+		payment, err := r.payments.Lookup(ctx, order.OrderID)
+		if errors.Is(err, payments.ErrUnknownPayment) {
+			return payments.StatusFailed, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		paymentID = payment.ID
+	}
+
+	if order.Status == models.OrderStatusConfirmed {
+		if _, err := r.payments.Capture(ctx, paymentID); err != nil {
+			return "", err
+		}
+		return payments.StatusCaptured, nil
+	}
+
+	if _, err := r.payments.Refund(ctx, paymentID); err != nil {
+		return "", err
+	}
+	return payments.StatusRefunded, nil
+}
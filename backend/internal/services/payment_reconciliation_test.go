@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"app/internal/models"
+	"app/internal/payments"
+)
+
+// captureTimeoutProvider is a fake provider whose captures time out
+type captureTimeoutProvider struct {
+	*payments.FakeProvider
+}
+
+func (p captureTimeoutProvider) Capture(ctx context.Context, paymentID string) (*payments.Payment, error) {
+	return nil, payments.ErrTimeout
+}
+
+func TestReconcileCancelledOrders(t *testing.T) {
+	tests := []struct {
+		name           string
+		card           string
+		expectedStatus string
+	}{
+		// The timed out authorisation went through and is released
+		{"Timeout", payments.CardTimeout, payments.StatusRefunded},
+		{"Declined", payments.CardDeclined, payments.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mock := &mockDB{}
+			service, req := quotedCheckout(t, mock, checkoutCandidate())
+			req.Payment.CardNumber = tt.card
+
+			if _, err := service.PlaceOrder(ctx, req); err == nil {
+				t.Fatal("Expected the payment to fail")
+			}
+			// The hold sweeper expires the pending order of a timeout
+			order := mock.placedOrders[0]
+			if _, err := mock.CancelOrder(ctx, order.OrderID, "hold expired"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if err := NewPaymentReconciler(mock, service.payments).Reconcile(ctx); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if *order.PaymentStatus != tt.expectedStatus {
+				t.Errorf("Expected payment %s, got %s", tt.expectedStatus, *order.PaymentStatus)
+			}
+			if payment, err := service.payments.Lookup(ctx, order.OrderID); err == nil && payment.Status != payments.StatusRefunded {
+				t.Errorf("Expected the provider's payment released, got %s", payment.Status)
+			}
+		})
+	}
+}
+
+func TestReconcileRetriesCapture(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockDB{}
+	service, req := quotedCheckout(t, mock, checkoutCandidate())
+	provider := captureTimeoutProvider{payments.NewFakeProvider()}
+	service.payments = provider
+
+	order, err := service.PlaceOrder(ctx, req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if order.Status != models.OrderStatusConfirmed || *order.PaymentStatus != payments.StatusAuthorized {
+		t.Fatalf("Expected a confirmed order with an uncaptured payment, got %s / %s", order.Status, *order.PaymentStatus)
+	}
+
+	// A capture that keeps failing leaves the order for the next run
+	if err := NewPaymentReconciler(mock, provider).Reconcile(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *order.PaymentStatus != payments.StatusAuthorized {
+		t.Errorf("Expected the payment still authorised, got %s", *order.PaymentStatus)
+	}
+
+	if err := NewPaymentReconciler(mock, provider.FakeProvider).Reconcile(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *order.PaymentStatus != payments.StatusCaptured {
+		t.Errorf("Expected the payment captured, got %s", *order.PaymentStatus)
+	}
+}
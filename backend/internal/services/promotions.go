@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+var (
+	// ErrInvalidCoupon is returned for coupon codes of no running or upcoming campaign
+	ErrInvalidCoupon = db.NewError(ErrInvalidInput, "invalid coupon code")
+	// ErrCouponNotApplicable is returned when a coupon entered at checkout does not apply
+	// to the quote, or saves less than the promotions it cannot be combined with
+	ErrCouponNotApplicable = db.NewError(ErrInvalidInput, "coupon does not apply to this offer")
+)
+
+// PromotionService finds the promotional campaigns a customer gets
+type PromotionService struct {
+	db  db.DatabaseInterface
+	now func() time.Time
+}
+
+// NewPromotionService creates a promotion service
+func NewPromotionService(database db.DatabaseInterface) *PromotionService {
+	return &PromotionService{
+		db:  database,
+		now: time.Now,
+	}
+}
+
+// Promotions are the campaigns open to a customer at an address, with what their
+// eligibility rules need to know about them
+type Promotions struct {
+	campaigns   []models.Campaign
+	city        string
+	newCustomer bool
+	coupon      string
+}
+
+// ForCustomer loads the campaigns that have not ended for a user at an address, with the
+// coupon they entered, if any. The address's city and whether the user is a new customer
+// are only looked up when a campaign depends on them.
+func (s *PromotionService) ForCustomer(ctx context.Context, userID int, addressID, coupon string) (*Promotions, error) {
+	campaigns, err := s.db.ListCampaigns(ctx, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load campaigns: %w", err)
+	}
+
+	promotions := &Promotions{campaigns: campaigns, coupon: strings.ToUpper(strings.TrimSpace(coupon))}
+	if promotions.coupon != "" && !promotions.hasCode(promotions.coupon) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCoupon, promotions.coupon)
+	}
+
+	var needsCity, needsCustomer bool
+	for _, campaign := range campaigns {
+		needsCity = needsCity || len(campaign.Cities) > 0
+		needsCustomer = needsCustomer || campaign.NewCustomers
+	}
+	if needsCity {
+		coverage, err := s.db.GetCoverage(ctx, addressID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get coverage for address %s: %w", addressID, err)
+		}
+		promotions.city = coverage.City
+	}
+	if needsCustomer {
+		if promotions.newCustomer, err = s.db.IsNewCustomer(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return promotions, nil
+}
+
+// hasCode reports whether one of the campaigns has code
+func (p *Promotions) hasCode(code string) bool {
+	for _, campaign := range p.campaigns {
+		if campaign.Code != nil && *campaign.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply applies the best promotions to a priced candidate signed at a time. Nil
+// promotions apply none.
+func (p *Promotions) Apply(candidate PricedCandidate, at time.Time) utils.PromotionResult {
+	if p == nil || len(p.campaigns) == 0 {
+		return utils.PromotionResult{}
+	}
+
+	offer := p.offer(candidate.Breakdown, at)
+	for _, assignment := range candidate.LineAssignments {
+		offer.MobilePlanIDs = append(offer.MobilePlanIDs, assignment.Plan.PlanID)
+	}
+	if home := candidate.Candidate.HomePlan; home != nil {
+		offer.HomePlanID = home.HomeID
+		offer.Tech = home.Tech
+	}
+	if tv := candidate.Candidate.TVPlan; tv != nil {
+		offer.TVPlanID = tv.TVID
+	}
+
+	return utils.ApplyPromotions(p.campaigns, offer)
+}
+
+// offer returns the customer's side of a promotion offer
+func (p *Promotions) offer(breakdown utils.GrandTotalBreakdown, at time.Time) utils.PromotionOffer {
+	return utils.PromotionOffer{
+		Breakdown:   breakdown,
+		City:        p.city,
+		NewCustomer: p.newCustomer,
+		CouponCode:  p.coupon,
+		At:          at,
+	}
+}
+
+// ApplyCoupon returns quote priced again with a coupon entered at checkout, together with
+// the promotions it got at recommendation. The price before promotions is rebuilt from
+// the quote's items, which Resolve has checked against the current catalog. A coupon the
+// quote was already priced with leaves it unchanged.
+func (s *PromotionService) ApplyCoupon(ctx context.Context, quote *models.Quote, coupon string) (*models.Quote, error) {
+	promotions, err := s.ForCustomer(ctx, quote.UserID, quote.AddressID, coupon)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []api.PromotionDTO
+	if len(quote.Promotions) > 0 {
+		if err := json.Unmarshal(quote.Promotions, &applied); err != nil {
+			return nil, fmt.Errorf("failed to decode quote promotions: %w", err)
+		}
+	}
+	for _, promotion := range applied {
+		if promotion.Code == promotions.coupon {
+			return quote, nil
+		}
+	}
+
+	var items api.RecommendationItemsDTO
+	if err := json.Unmarshal(quote.Items, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode quote items: %w", err)
+	}
+
+	mobileTotal := 0.0
+	for _, line := range items.Mobile {
+		mobileTotal += line.LineCost
+	}
+	mobileAfterDiscount, _ := utils.ApplyExtraLineDiscount(mobileTotal, len(items.Mobile))
+
+	homeCost, tvCost := 0.0, 0.0
+	if items.Home != nil {
+		homeCost = items.Home.MonthlyPrice
+	}
+	if items.TV != nil {
+		tvCost = items.TV.MonthlyPrice
+	}
+	rate := utils.CalcBundleDiscount(len(items.Mobile) > 0, items.Home != nil, items.TV != nil)
+	breakdown := utils.CalcGrandTotal(mobileAfterDiscount, homeCost, tvCost, rate)
+
+	offer := promotions.offer(breakdown, s.now())
+	for _, line := range items.Mobile {
+		offer.MobilePlanIDs = append(offer.MobilePlanIDs, line.Plan.PlanID)
+	}
+	if items.Home != nil {
+		offer.HomePlanID = items.Home.HomeID
+		offer.Tech = items.Home.Tech
+	}
+	if items.TV != nil {
+		offer.TVPlanID = items.TV.TVID
+	}
+	result := utils.ApplyPromotions(promotions.campaigns, offer)
+
+	dtos := promotionDTOs(result)
+	couponApplied := false
+	for _, promotion := range dtos {
+		couponApplied = couponApplied || promotion.Code == promotions.coupon
+	}
+	if !couponApplied {
+		return nil, fmt.Errorf("%w: %s", ErrCouponNotApplicable, promotions.coupon)
+	}
+
+	encoded, err := json.Marshal(dtos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode quote promotions: %w", err)
+	}
+
+	priced := *quote
+	priced.MonthlyTotal = math.Round((breakdown.GrandTotal-result.MonthlyDiscount)*100) / 100
+	priced.Promotions = encoded
+	return &priced, nil
+}
+
+// promotionDTOs lists the promotions of a result for the API, an empty list when none
+// applied
+func promotionDTOs(result utils.PromotionResult) []api.PromotionDTO {
+	dtos := make([]api.PromotionDTO, 0, len(result.Applied))
+	for _, applied := range result.Applied {
+		dto := api.PromotionDTO{
+			CampaignID:   applied.Campaign.CampaignID,
+			Name:         applied.Campaign.Name,
+			DiscountType: applied.Campaign.DiscountType,
+			AppliesTo:    applied.Campaign.AppliesTo,
+			Amount:       applied.Amount,
+			Months:       applied.Months,
+		}
+		if applied.Campaign.Code != nil {
+			dto.Code = *applied.Campaign.Code
+		}
+		dtos = append(dtos, dto)
+	}
+	return dtos
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"app/internal/api"
+	"app/internal/models"
+)
+
+// testCampaigns are an automatic 10% off mobile in Istanbul and a coupon making fiber
+// free for new customers for 3 months
+func testCampaigns() []models.Campaign {
+	code := "FIBER3"
+	started := time.Now().Add(-24 * time.Hour)
+	return []models.Campaign{
+		{CampaignID: 1, Name: "Istanbul mobile", DiscountType: models.DiscountPercent, DiscountValue: 10, AppliesTo: "mobile",
+			Cities: []string{"Istanbul"}, Stackable: true, StartsAt: started},
+		{CampaignID: 2, Name: "Fiber welcome", Code: &code, DiscountType: models.DiscountFreeMonths, DiscountValue: 3, AppliesTo: "home",
+			Techs: []string{"fiber"}, NewCustomers: true, Stackable: true, StartsAt: started},
+	}
+}
+
+func promotionTestService(mock *mockDB) *RecommendationService {
+	mock.catalog = analyticsTestCatalog()
+	mock.campaigns = testCampaigns()
+	mock.coverage = map[string]*models.Coverage{
+		"A1001": {AddressID: "A1001", City: "Istanbul", Fiber: true},
+	}
+	return NewRecommendationService(mock, NewCoverageService(mock), NewQuoteService(mock, testQuoteSecret, DefaultQuoteTTL), NewCatalogCache(mock, time.Minute), NewPromotionService(mock))
+}
+
+func TestRecommendationPromotions(t *testing.T) {
+	mock := &mockDB{}
+	service := promotionTestService(mock)
+
+	req := quoteTestRequest()
+	req.CouponCode = " fiber3 "
+	response, err := service.ProcessRecommendationRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	byLabel := make(map[string]api.RecommendationCandidateDTO)
+	for _, candidate := range response.Top3 {
+		byLabel[candidate.ComboLabel] = candidate
+	}
+
+	// The coupon needs home internet
+	mobile := byLabel["Mobile Only"]
+	if promotions := mobile.Discounts.Promotions; len(promotions) != 1 || promotions[0].CampaignID != 1 || promotions[0].Amount != 9.99 {
+		t.Errorf("Expected 10%% off mobile only, got %+v", promotions)
+	}
+
+	// 10% of the mobile plan's bundle price every month, and 3 free months of fiber
+	fiber := byLabel["Mobile + Fiber 50Mbps"]
+	if len(fiber.Discounts.Promotions) != 2 || fiber.Discounts.PromotionDiscount != 8.99 {
+		t.Fatalf("Expected both promotions, got %+v", fiber.Discounts)
+	}
+	if free := fiber.Discounts.Promotions[1]; free.Code != "FIBER3" || free.Months != 3 || math.Abs(free.Amount-80.91) > 0.001 {
+		t.Errorf("Expected 3 free months of fiber, got %+v", free)
+	}
+	if math.Abs(fiber.MonthlyTotal-161.83) > 0.001 || math.Abs(fiber.FirstMonthTotal-80.92) > 0.001 {
+		t.Errorf("Expected 161.83 a month and 80.92 in the first, got %.2f and %.2f", fiber.MonthlyTotal, fiber.FirstMonthTotal)
+	}
+	if math.Abs(fiber.Discounts.TotalDiscount-fiber.Savings) > 0.001 || math.Abs(fiber.Savings-(18.98+8.99)) > 0.001 {
+		t.Errorf("Expected the savings to include the promotion, got %+v", fiber.Discounts)
+	}
+
+	var stored []api.PromotionDTO
+	if err := json.Unmarshal(mock.quotes[fiber.QuoteID].Promotions, &stored); err != nil || len(stored) != 2 {
+		t.Errorf("Expected the quote to keep the promotions, got %s", mock.quotes[fiber.QuoteID].Promotions)
+	}
+	if string(mock.quotes[mobile.QuoteID].Promotions) == "" {
+		t.Error("Expected every quote to list its promotions")
+	}
+}
+
+func TestRecommendationPromotionEligibility(t *testing.T) {
+	// A returning customer does not get the welcome coupon
+	mock := &mockDB{customers: map[int]bool{1: true}}
+	service := promotionTestService(mock)
+
+	req := quoteTestRequest()
+	req.CouponCode = "FIBER3"
+	response, err := service.ProcessRecommendationRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, candidate := range response.Top3 {
+		if len(candidate.Discounts.Promotions) != 1 || candidate.FirstMonthTotal != candidate.MonthlyTotal {
+			t.Errorf("%s: expected the automatic promotion only, got %+v", candidate.ComboLabel, candidate.Discounts.Promotions)
+		}
+	}
+
+	req.CouponCode = "NOPE"
+	if _, err := service.ProcessRecommendationRequest(context.Background(), req); !errors.Is(err, ErrInvalidCoupon) {
+		t.Errorf("Expected an unknown coupon to be invalid, got %v", err)
+	}
+}
+
+func TestCheckoutCoupon(t *testing.T) {
+	code, tvCode, months := "HOME50", "TV20", 6
+	mock := &mockDB{campaigns: []models.Campaign{
+		{CampaignID: 3, Name: "Half year home", Code: &code, DiscountType: models.DiscountFixed, DiscountValue: 50,
+			DurationMonths: &months, AppliesTo: "home", Stackable: true, StartsAt: time.Now().Add(-time.Hour)},
+		{CampaignID: 4, Name: "TV", Code: &tvCode, DiscountType: models.DiscountPercent, DiscountValue: 20,
+			AppliesTo: "tv", Stackable: true, StartsAt: time.Now().Add(-time.Hour)},
+	}}
+
+	// 150 for mobile and 119.90 for fiber, less the 10% bundle discount
+	candidate := checkoutCandidate()
+	candidate.MonthlyTotal = 242.91
+	candidate.Items.Mobile[0].LineCost = 150
+	service, req := quotedCheckout(t, mock, candidate)
+
+	req.CouponCode = "TV20"
+	if _, err := service.PlaceOrder(context.Background(), req); !errors.Is(err, ErrCouponNotApplicable) {
+		t.Errorf("Expected a TV coupon not to apply without TV, got %v", err)
+	}
+
+	req.CouponCode = "home50"
+	order, err := service.PlaceOrder(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The monthly price stays; the first month, charged upfront, is 50 less
+	if order.MonthlyTotal != 242.91 || order.UpfrontAmount != 192.91 {
+		t.Errorf("Expected 242.91 a month and 192.91 upfront, got %.2f and %.2f", order.MonthlyTotal, order.UpfrontAmount)
+	}
+	var promotions []api.PromotionDTO
+	if err := json.Unmarshal(mock.placedOrders[0].Promotions, &promotions); err != nil || len(promotions) != 1 || promotions[0].Code != "HOME50" {
+		t.Errorf("Expected the order to record the coupon, got %s", mock.placedOrders[0].Promotions)
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/models"
+)
+
+// DefaultQuoteTTL is how long a recommendation price can be checked out
+const DefaultQuoteTTL = 30 * time.Minute
+
+// quoteIDPrefix starts every quote ID, followed by a random nonce and the signature
+const quoteIDPrefix = "QT"
+
+var (
+	// ErrInvalidQuote is returned for malformed quote IDs or quotes whose signature does not match
+	ErrInvalidQuote = db.NewError(ErrInvalidInput, "invalid quote")
+	// ErrQuoteExpired is returned when a quote is past its expiry
+	ErrQuoteExpired = db.NewError(ErrConflict, "quote has expired")
+	// ErrQuoteStale is returned when the catalog changed since the quote was priced
+	ErrQuoteStale = db.NewError(ErrConflict, "quote was priced against an older catalog")
+)
+
+// QuoteService issues and verifies signed quotes for recommendation candidates. A quote
+// ID is an HMAC over the catalog version, household hash, price and commitment, so a
+// quote cannot be forged or re-priced, and it is only accepted while the catalog is
+// unchanged.
+type QuoteService struct {
+	db     db.DatabaseInterface
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewQuoteService creates a quote service signing with secret. Quotes expire after ttl.
+func NewQuoteService(database db.DatabaseInterface, secret string, ttl time.Duration) *QuoteService {
+	return &QuoteService{
+		db:     database,
+		secret: []byte(secret),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Issue creates and stores a quote for every candidate and sets its QuoteID and expiry
+func (s *QuoteService) Issue(ctx context.Context, req *api.RecommendationRequest, catalogVersion string, candidates []api.RecommendationCandidateDTO) error {
+	householdHash, err := HouseholdHash(req)
+	if err != nil {
+		return err
+	}
+
+	// Stored expiry is truncated to seconds so the signature survives the database round trip
+	expiresAt := s.now().Add(s.ttl).UTC().Truncate(time.Second)
+
+	quotes := make([]models.Quote, 0, len(candidates))
+	for i := range candidates {
+		candidate := &candidates[i]
+
+		items, err := json.Marshal(candidate.Items)
+		if err != nil {
+			return fmt.Errorf("failed to encode quote items: %w", err)
+		}
+
+		// Always a list, so orders record that no promotion applied
+		promotions, err := json.Marshal(append([]api.PromotionDTO{}, candidate.Discounts.Promotions...))
+		if err != nil {
+			return fmt.Errorf("failed to encode quote promotions: %w", err)
+		}
+
+		var tech *string
+		if candidate.Items.Home != nil {
+			tech = &candidate.Items.Home.Tech
+		}
+
+		nonce := make([]byte, 8)
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("failed to generate quote ID: %w", err)
+		}
+
+		quote := models.Quote{
+			UserID:           req.UserID,
+			AddressID:        req.AddressID,
+			CatalogVersion:   catalogVersion,
+			HouseholdHash:    householdHash,
+			ComboLabel:       candidate.ComboLabel,
+			MonthlyTotal:     math.Round(candidate.MonthlyTotal*100) / 100, // stored as NUMERIC(10,2)
+			Tech:             tech,
+			Items:            items,
+			Promotions:       promotions,
+			CommitmentMonths: candidate.Commitment.Months,
+			TerminationFee:   candidate.Commitment.TerminationFee,
+			ExpiresAt:        expiresAt,
+		}
+		quote.QuoteID = quoteIDPrefix + "-" + hex.EncodeToString(nonce) + "-" + s.sign(hex.EncodeToString(nonce), &quote)
+
+		candidate.QuoteID = quote.QuoteID
+		candidate.QuoteExpiresAt = &quote.ExpiresAt
+		quotes = append(quotes, quote)
+	}
+
+	if err := s.db.SaveQuotes(ctx, quotes); err != nil {
+		return fmt.Errorf("failed to store quotes: %w", err)
+	}
+
+	return nil
+}
+
+// Resolve returns the stored quote for quoteID after checking its signature, expiry and
+// that the catalog has not changed since it was priced
+func (s *QuoteService) Resolve(ctx context.Context, quoteID string) (*models.Quote, error) {
+	parts := strings.Split(quoteID, "-")
+	if len(parts) != 3 || parts[0] != quoteIDPrefix {
+		return nil, fmt.Errorf("%w: malformed quote ID", ErrInvalidQuote)
+	}
+
+	quote, err := s.db.GetQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[1], quote))) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidQuote)
+	}
+
+	if !s.now().Before(quote.ExpiresAt) {
+		return nil, fmt.Errorf("quote %s expired at %s: %w", quoteID, quote.ExpiresAt.Format(time.RFC3339), ErrQuoteExpired)
+	}
+
+	catalog, err := s.db.GetCatalog(ctx, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog: %w", err)
+	}
+	if version := CatalogVersion(catalog); version != quote.CatalogVersion {
+		return nil, fmt.Errorf("quote %s: %w", quoteID, ErrQuoteStale)
+	}
+
+	return quote, nil
+}
+
+// PurgeExpired deletes quotes past their expiry
+func (s *QuoteService) PurgeExpired(ctx context.Context) error {
+	purged, err := s.db.PurgeExpiredQuotes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		logger(ctx).Info("Purged expired quotes", "count", purged)
+	}
+	return nil
+}
+
+// sign computes the hex HMAC-SHA256 binding a quote's nonce, owner, catalog version,
+// household hash, price (in kuruş), commitment and expiry
+func (s *QuoteService) sign(nonce string, q *models.Quote) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s|%d|%s|%s|%s|%d|%d|%d",
+		nonce,
+		q.UserID,
+		q.AddressID,
+		q.CatalogVersion,
+		q.HouseholdHash,
+		int64(math.Round(q.MonthlyTotal*100)),
+		q.CommitmentMonths,
+		q.ExpiresAt.Unix(),
+	)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HouseholdHash fingerprints the recommendation input a quote was priced for
+func HouseholdHash(req *api.RecommendationRequest) (string, error) {
+	payload, err := json.Marshal(struct {
+		UserID     int                    `json:"user_id"`
+		AddressID  string                 `json:"address_id"`
+		Household  []api.HouseholdLineDTO `json:"household"`
+		PreferTech []string               `json:"prefer_tech"`
+	}{req.UserID, req.AddressID, req.Household, req.PreferTech})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode household: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CatalogVersion returns a short content hash of the catalog. Plans and rules are sorted
+// by ID first so the version does not depend on the order the database returned them in.
+// The end dates of the versions are left out, like ValidUntil: scheduling a change does
+// not change the prices in effect before it.
+func CatalogVersion(catalog *models.Catalog) string {
+	sorted := models.Catalog{
+		MobilePlans:   append([]models.MobilePlan(nil), catalog.MobilePlans...),
+		HomePlans:     append([]models.HomePlan(nil), catalog.HomePlans...),
+		TVPlans:       append([]models.TVPlan(nil), catalog.TVPlans...),
+		BundlingRules: append([]models.BundlingRule(nil), catalog.BundlingRules...),
+	}
+	for i := range sorted.MobilePlans {
+		sorted.MobilePlans[i].EffectiveTo = nil
+	}
+	for i := range sorted.HomePlans {
+		sorted.HomePlans[i].EffectiveTo = nil
+	}
+	for i := range sorted.TVPlans {
+		sorted.TVPlans[i].EffectiveTo = nil
+	}
+	for i := range sorted.BundlingRules {
+		sorted.BundlingRules[i].EffectiveTo = nil
+	}
+	sort.Slice(sorted.MobilePlans, func(i, j int) bool { return sorted.MobilePlans[i].PlanID < sorted.MobilePlans[j].PlanID })
+	sort.Slice(sorted.HomePlans, func(i, j int) bool { return sorted.HomePlans[i].HomeID < sorted.HomePlans[j].HomeID })
+	sort.Slice(sorted.TVPlans, func(i, j int) bool { return sorted.TVPlans[i].TVID < sorted.TVPlans[j].TVID })
+	sort.Slice(sorted.BundlingRules, func(i, j int) bool { return sorted.BundlingRules[i].RuleID < sorted.BundlingRules[j].RuleID })
+
+	// Marshalling plain structs of numbers and strings cannot fail
+	payload, _ := json.Marshal(sorted)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"app/internal/api"
+	"app/internal/db"
+	"app/internal/models"
+)
+
+const testQuoteSecret = "test-quote-signing-secret-0123456789"
+
+func quoteTestRequest() *api.RecommendationRequest {
+	return &api.RecommendationRequest{
+		UserID:    1,
+		AddressID: "A1001",
+		Household: []api.HouseholdLineDTO{{LineID: "LINE001", ExpectedGB: 12, ExpectedMin: 300}},
+	}
+}
+
+// issueTestQuote issues a single quote and returns the service and the quote ID
+func issueTestQuote(t *testing.T, mock *mockDB) (*QuoteService, string) {
+	t.Helper()
+	mock.catalog = analyticsTestCatalog()
+	service := NewQuoteService(mock, testQuoteSecret, DefaultQuoteTTL)
+
+	candidates := []api.RecommendationCandidateDTO{checkoutCandidate()}
+	if err := service.Issue(context.Background(), quoteTestRequest(), CatalogVersion(mock.catalog), candidates); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if candidates[0].QuoteID == "" || candidates[0].QuoteExpiresAt == nil {
+		t.Fatalf("Expected candidate to get a quote, got %+v", candidates[0])
+	}
+	return service, candidates[0].QuoteID
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	mock := &mockDB{}
+	service, quoteID := issueTestQuote(t, mock)
+
+	quote, err := service.Resolve(context.Background(), quoteID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if quote.MonthlyTotal != 242.73 || quote.UserID != 1 || quote.AddressID != "A1001" {
+		t.Errorf("Unexpected quote: %+v", quote)
+	}
+}
+
+func TestQuoteRejectsTamperedPrice(t *testing.T) {
+	mock := &mockDB{}
+	service, quoteID := issueTestQuote(t, mock)
+	mock.quotes[quoteID].MonthlyTotal = 1.00
+
+	if _, err := service.Resolve(context.Background(), quoteID); !errors.Is(err, ErrInvalidQuote) {
+		t.Errorf("Expected ErrInvalidQuote, got %v", err)
+	}
+}
+
+func TestQuoteRejectsOtherSecret(t *testing.T) {
+	mock := &mockDB{}
+	_, quoteID := issueTestQuote(t, mock)
+
+	other := NewQuoteService(mock, "another-secret-another-secret-0000", DefaultQuoteTTL)
+	if _, err := other.Resolve(context.Background(), quoteID); !errors.Is(err, ErrInvalidQuote) {
+		t.Errorf("Expected ErrInvalidQuote, got %v", err)
+	}
+}
+
+func TestQuoteExpired(t *testing.T) {
+	mock := &mockDB{}
+	service, quoteID := issueTestQuote(t, mock)
+	service.now = func() time.Time { return time.Now().Add(DefaultQuoteTTL + time.Minute) }
+
+	if _, err := service.Resolve(context.Background(), quoteID); !errors.Is(err, ErrQuoteExpired) {
+		t.Errorf("Expected ErrQuoteExpired, got %v", err)
+	}
+}
+
+func TestQuoteStaleAfterCatalogChange(t *testing.T) {
+	mock := &mockDB{}
+	service, quoteID := issueTestQuote(t, mock)
+	mock.catalog.HomePlans[0].MonthlyPrice = 99.90
+
+	if _, err := service.Resolve(context.Background(), quoteID); !errors.Is(err, ErrQuoteStale) {
+		t.Errorf("Expected ErrQuoteStale, got %v", err)
+	}
+}
+
+func TestQuoteMalformedAndUnknown(t *testing.T) {
+	mock := &mockDB{}
+	service, _ := issueTestQuote(t, mock)
+
+	if _, err := service.Resolve(context.Background(), "not-a-quote-id"); !errors.Is(err, ErrInvalidQuote) {
+		t.Errorf("Expected ErrInvalidQuote, got %v", err)
+	}
+	if _, err := service.Resolve(context.Background(), "QT-0000000000000000-00"); !errors.Is(err, db.ErrQuoteNotFound) {
+		t.Errorf("Expected ErrQuoteNotFound, got %v", err)
+	}
+}
+
+func TestCatalogVersionIgnoresOrder(t *testing.T) {
+	catalog := analyticsTestCatalog()
+	reordered := analyticsTestCatalog()
+	reordered.HomePlans = []models.HomePlan{reordered.HomePlans[2], reordered.HomePlans[0], reordered.HomePlans[1]}
+
+	if CatalogVersion(catalog) != CatalogVersion(reordered) {
+		t.Error("Expected catalog version to ignore plan order")
+	}
+
+	reordered.MobilePlans[0].MonthlyPrice += 10
+	if CatalogVersion(catalog) == CatalogVersion(reordered) {
+		t.Error("Expected a price change to change the catalog version")
+	}
+}
+
+func TestCatalogVersionIgnoresScheduledChanges(t *testing.T) {
+	catalog := analyticsTestCatalog()
+	scheduled := analyticsTestCatalog()
+	priceChange := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	scheduled.HomePlans[0].EffectiveTo = &priceChange
+	scheduled.ValidUntil = &priceChange
+
+	// Quotes issued before a price change is scheduled stay valid until it takes effect
+	if CatalogVersion(catalog) != CatalogVersion(scheduled) {
+		t.Error("Expected catalog version to ignore when versions end")
+	}
+
+	scheduled.HomePlans[0].Version = 2
+	if CatalogVersion(catalog) == CatalogVersion(scheduled) {
+		t.Error("Expected a new plan version to change the catalog version")
+	}
+}
+
+func TestHouseholdHash(t *testing.T) {
+	a, _ := HouseholdHash(quoteTestRequest())
+	b := quoteTestRequest()
+	b.Household[0].ExpectedGB = 20
+	hb, _ := HouseholdHash(b)
+
+	if a == hb {
+		t.Error("Expected different households to hash differently")
+	}
+}
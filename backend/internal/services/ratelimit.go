@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"app/internal/db"
+	"app/internal/metrics"
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+// Route groups with their own rate limits
+const (
+	RateLimitRecommendation     = "recommendation"      // per client
+	RateLimitRecommendationUser = "recommendation_user" // per user_id in the request
+	RateLimitCheckout           = "checkout"            // checkout and order changes
+	RateLimitAPI                = "api"                 // every other public route
+)
+
+// Rate limit stores selectable with RATE_LIMIT_STORE
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+// rateLimitIdleTTL is how long an unused in-memory bucket is kept, matching
+// purge_rate_limit_buckets. A forgotten bucket starts full again.
+const rateLimitIdleTTL = time.Hour
+
+// RateLimit is a token bucket allowing Burst requests at once, refilled at PerMinute
+// requests per minute. A zero PerMinute disables the limit.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+// ratePerSecond returns the refill rate of the bucket
+func (l RateLimit) ratePerSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// RateLimitStore takes request tokens from the bucket of a key
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (*models.RateLimitDecision, error)
+}
+
+// MemoryRateLimitStore keeps buckets in process memory, so every replica applies the
+// limits on its own
+type MemoryRateLimitStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*rateLimitBucket
+	lastSweep time.Time
+}
+
+// rateLimitBucket holds the tokens of a bucket as of updated
+type rateLimitBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		now:     time.Now,
+		buckets: make(map[string]*rateLimitBucket),
+	}
+}
+
+// Take refills the bucket of key for the time since it was last used and takes a token
+// if there is one
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (*models.RateLimitDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &rateLimitBucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = bucket
+	} else {
+		refill := now.Sub(bucket.updated).Seconds() * limit.ratePerSecond()
+		bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+refill)
+		bucket.updated = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return &models.RateLimitDecision{Allowed: true, Remaining: int(bucket.tokens)}, nil
+	}
+	wait := (1 - bucket.tokens) / limit.ratePerSecond()
+	return &models.RateLimitDecision{RetryAfterMS: int(math.Ceil(wait * 1000))}, nil
+}
+
+// sweep forgets the buckets unused for rateLimitIdleTTL, at most once a minute
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= rateLimitIdleTTL {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// DBRateLimitStore keeps buckets in the database, shared by every replica
+type DBRateLimitStore struct {
+	db db.DatabaseInterface
+}
+
+// NewDBRateLimitStore creates a store keeping buckets in database
+func NewDBRateLimitStore(database db.DatabaseInterface) *DBRateLimitStore {
+	return &DBRateLimitStore{db: database}
+}
+
+// Take takes a token from the shared bucket of key
+func (s *DBRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (*models.RateLimitDecision, error) {
+	return s.db.TakeRateLimitToken(ctx, key, limit.ratePerSecond(), limit.Burst)
+}
+
+// PurgeExpired deletes buckets unused for an hour
+func (s *DBRateLimitStore) PurgeExpired(ctx context.Context) error {
+	purged, err := s.db.PurgeRateLimitBuckets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		logger(ctx).Info("Purged idle rate limit buckets", "count", purged)
+	}
+	return nil
+}
+
+// RateLimiter applies the limit of each route group to the clients calling it
+type RateLimiter struct {
+	store  RateLimitStore
+	limits map[string]RateLimit
+}
+
+// NewRateLimiter creates a rate limiter taking tokens from store, with the limits of
+// each route group
+func NewRateLimiter(store RateLimitStore, limits map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		limits: limits,
+	}
+}
+
+// RateLimiterFromConfig creates the rate limiter with the configured store and limits
+func RateLimiterFromConfig(database db.DatabaseInterface, config *utils.Config) *RateLimiter {
+	var store RateLimitStore = NewMemoryRateLimitStore()
+	if config.RateLimitStore == RateLimitStorePostgres {
+		store = NewDBRateLimitStore(database)
+	}
+
+	return NewRateLimiter(store, map[string]RateLimit{
+		RateLimitRecommendation:     {PerMinute: config.RateLimitRecommendation, Burst: config.RateLimitRecommendationBurst},
+		RateLimitRecommendationUser: {PerMinute: config.RateLimitRecommendationUser, Burst: config.RateLimitRecommendationUserBurst},
+		RateLimitCheckout:           {PerMinute: config.RateLimitCheckout, Burst: config.RateLimitCheckoutBurst},
+		RateLimitAPI:                {PerMinute: config.RateLimitAPI, Burst: config.RateLimitAPIBurst},
+	})
+}
+
+// Limit returns the limit of group
+func (l *RateLimiter) Limit(group string) RateLimit {
+	return l.limits[group]
+}
+
+// Allow takes a token for client from its bucket in group. It returns nil when the
+// group has no limit. When the store fails the request is allowed, so a database outage
+// does not take the API down with it.
+func (l *RateLimiter) Allow(ctx context.Context, group, client string) *models.RateLimitDecision {
+	return l.AllowWithin(ctx, group, client, l.limits[group])
+}
+
+// AllowWithin is Allow with limit in place of the limit of group, for clients such as API
+// keys that have a limit of their own
+func (l *RateLimiter) AllowWithin(ctx context.Context, group, client string, limit RateLimit) *models.RateLimitDecision {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+
+	decision, err := l.store.Take(ctx, group+":"+client, limit)
+	if err != nil {
+		logger(ctx).Warn("Rate limit store failed, allowing request", "group", group, "error", err)
+		return nil
+	}
+
+	if !decision.Allowed {
+		metrics.RateLimitedRequests.WithLabelValues(group).Inc()
+	}
+	return decision
+}
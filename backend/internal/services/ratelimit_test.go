@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"app/internal/models"
+)
+
+func TestMemoryRateLimitStoreBurst(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	limit := RateLimit{PerMinute: 60, Burst: 3}
+
+	// A burst of 5 requests at once gets the 3 tokens of the bucket
+	var allowed int
+	var last *models.RateLimitDecision
+	for i := 0; i < 5; i++ {
+		decision, err := store.Take(context.Background(), "recommendation:ip:10.0.0.1", limit)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if decision.Allowed {
+			allowed++
+		}
+		last = decision
+	}
+	if allowed != 3 {
+		t.Errorf("Expected 3 requests of the burst to be allowed, got %d", allowed)
+	}
+	if last.Allowed || last.RetryAfterMS != 1000 {
+		t.Errorf("Expected a refusal with a 1s retry, got %+v", last)
+	}
+
+	// Other clients have their own bucket
+	if decision, _ := store.Take(context.Background(), "recommendation:ip:10.0.0.2", limit); !decision.Allowed {
+		t.Error("Expected another client to be allowed")
+	}
+
+	// One token is refilled per second, up to the burst
+	now = now.Add(1500 * time.Millisecond)
+	if decision, _ := store.Take(context.Background(), "recommendation:ip:10.0.0.1", limit); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("Expected a refilled token, got %+v", decision)
+	}
+	now = now.Add(time.Hour - time.Second)
+	if decision, _ := store.Take(context.Background(), "recommendation:ip:10.0.0.1", limit); !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("Expected a full bucket after an idle period, got %+v", decision)
+	}
+}
+
+func TestMemoryRateLimitStoreForgetsIdleBuckets(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	limit := RateLimit{PerMinute: 60, Burst: 3}
+
+	store.Take(context.Background(), "api:ip:10.0.0.1", limit)
+	now = now.Add(rateLimitIdleTTL)
+	store.Take(context.Background(), "api:ip:10.0.0.2", limit)
+
+	if _, ok := store.buckets["api:ip:10.0.0.1"]; ok || len(store.buckets) != 1 {
+		t.Errorf("Expected the idle bucket to be forgotten, got %d buckets", len(store.buckets))
+	}
+}
+
+// failingRateLimitStore fails every Take
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimit) (*models.RateLimitDecision, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), map[string]RateLimit{
+		RateLimitRecommendation: {PerMinute: 60, Burst: 1},
+		RateLimitCheckout:       {PerMinute: 0, Burst: 1},
+	})
+
+	// Groups count separately for the same client
+	if decision := limiter.Allow(context.Background(), RateLimitRecommendation, "ip:10.0.0.1"); decision == nil || !decision.Allowed {
+		t.Fatalf("Expected the first request to be allowed, got %+v", decision)
+	}
+	if decision := limiter.Allow(context.Background(), RateLimitRecommendation, "ip:10.0.0.1"); decision == nil || decision.Allowed {
+		t.Fatalf("Expected the second request to be refused, got %+v", decision)
+	}
+
+	// Disabled and unknown groups are not limited
+	for _, group := range []string{RateLimitCheckout, RateLimitAPI} {
+		if decision := limiter.Allow(context.Background(), group, "ip:10.0.0.1"); decision != nil {
+			t.Errorf("Expected no limit for %s, got %+v", group, decision)
+		}
+	}
+
+	// A failing store lets requests through
+	failing := NewRateLimiter(failingRateLimitStore{}, map[string]RateLimit{RateLimitAPI: {PerMinute: 60, Burst: 1}})
+	if decision := failing.Allow(context.Background(), RateLimitAPI, "ip:10.0.0.1"); decision != nil {
+		t.Errorf("Expected a failing store to allow the request, got %+v", decision)
+	}
+}
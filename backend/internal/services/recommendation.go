@@ -4,27 +4,76 @@ import (
 	"context"
 	"math"
 	"sort"
+	"time"
 
 	"app/internal/api"
 	"app/internal/db"
+	"app/internal/metrics"
 	"app/internal/models"
+	"app/internal/tracing"
 	"app/internal/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the tracer of service spans
+const tracerName = "app/internal/services"
+
+// scheduledPriceHorizon is how far ahead recommendations look for scheduled catalog
+// changes, and maxScheduledPrices how many of them they price
+const (
+	scheduledPriceHorizon = 180 * 24 * time.Hour
+	maxScheduledPrices    = 3
 )
 
 // RecommendationService handles recommendation calculations
 type RecommendationService struct {
 	db              db.DatabaseInterface
 	coverageService *CoverageService
+	quoteService    *QuoteService
+	catalogCache    *CatalogCache
+	promotions      *PromotionService
 }
 
-// NewRecommendationService creates a new recommendation service
-func NewRecommendationService(database db.DatabaseInterface, coverageService *CoverageService) *RecommendationService {
+// NewRecommendationService creates a new recommendation service. Every returned
+// candidate gets a quote from quoteService that checkout redeems. Plans are read through
+// catalogCache, and candidates get the campaigns promotions finds for the customer.
+func NewRecommendationService(database db.DatabaseInterface, coverageService *CoverageService, quoteService *QuoteService, catalogCache *CatalogCache, promotions *PromotionService) *RecommendationService {
 	return &RecommendationService{
 		db:              database,
 		coverageService: coverageService,
+		quoteService:    quoteService,
+		catalogCache:    catalogCache,
+		promotions:      promotions,
 	}
 }
 
+// customerPromotions returns the promotions open to the customer of a request, none
+// when the service has no promotion service
+func (s *RecommendationService) customerPromotions(ctx context.Context, req *api.RecommendationRequest) (*Promotions, error) {
+	if s.promotions == nil {
+		return nil, nil
+	}
+	return s.promotions.ForCustomer(ctx, req.UserID, req.AddressID, req.CouponCode)
+}
+
+// catalog returns the plan catalog, from the cache when the service has one
+func (s *RecommendationService) catalog(ctx context.Context) (*models.Catalog, error) {
+	if s.catalogCache != nil {
+		return s.catalogCache.Get(ctx)
+	}
+	return s.db.GetCatalog(ctx, time.Now())
+}
+
+// scheduledCatalog returns the catalog taking effect at a scheduled change
+func (s *RecommendationService) scheduledCatalog(ctx context.Context, at time.Time) (*models.Catalog, error) {
+	if s.catalogCache != nil {
+		return s.catalogCache.Scheduled(ctx, at)
+	}
+	return s.db.GetCatalog(ctx, at)
+}
+
 // GetDB returns the database instance (for handler access)
 func (s *RecommendationService) GetDB() db.DatabaseInterface {
 	return s.db
@@ -122,8 +171,8 @@ type MbpsCalculation struct {
 
 // GenerateCandidates creates all valid combinations of home and TV plans
 func (s *RecommendationService) GenerateCandidates(ctx context.Context, availableTech []string, neededMbps float64, maxTVHours float64) ([]BundleCandidate, error) {
-	// Get all plans from database
-	catalog, err := s.db.GetCatalog(ctx)
+	// Get all plans from the catalog
+	catalog, err := s.catalog(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -369,17 +418,55 @@ type PricedCandidate struct {
 	TotalSavings         float64                   `json:"total_savings"`
 	Reasoning            string                    `json:"reasoning"`
 	Breakdown            utils.GrandTotalBreakdown `json:"breakdown"`
+	Promotions           utils.PromotionResult     `json:"promotions"`
+	CommitmentMonths     int                       `json:"commitment_months"` // 0 without commitment
+}
+
+// MonthlyTotal is the price every month: the grand total less the promotions that do
+// not end
+func (c PricedCandidate) MonthlyTotal() float64 {
+	return c.GrandTotal - c.Promotions.MonthlyDiscount
+}
+
+// Savings is what the candidate saves every month, promotions included
+func (c PricedCandidate) Savings() float64 {
+	return c.TotalSavings + c.Promotions.MonthlyDiscount
 }
 
-// ProcessRecommendationRequest processes a full recommendation request
-func (s *RecommendationService) ProcessRecommendationRequest(ctx context.Context, req *api.RecommendationRequest) (*api.RecommendationResponse, error) {
+// TerminationFee is the fee for terminating the candidate's contract before its
+// commitment ends
+func (c PricedCandidate) TerminationFee() float64 {
+	mobile := make([]models.MobilePlan, len(c.LineAssignments))
+	for i, assignment := range c.LineAssignments {
+		mobile[i] = assignment.Plan
+	}
+	return utils.CalcTerminationFee(mobile, c.Candidate.HomePlan, c.Candidate.TVPlan, c.CommitmentMonths)
+}
+
+// ProcessRecommendationRequest processes a full recommendation request. It is traced as
+// one span with a child span per step, and the steps' durations are recorded as metrics.
+func (s *RecommendationService) ProcessRecommendationRequest(ctx context.Context, req *api.RecommendationRequest) (_ *api.RecommendationResponse, err error) {
+	ctx, span := tracing.Tracer(tracerName).Start(ctx, "ProcessRecommendationRequest", trace.WithAttributes(
+		attribute.Int("user_id", req.UserID),
+		attribute.String("address_id", req.AddressID),
+		attribute.Int("household.lines", len(req.Household)),
+	))
+	defer func(start time.Time) {
+		tracing.RecordError(span, err)
+		span.End()
+		metrics.RecommendationDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
 	// Step 1: Check coverage for the address
-	availableTech, err := s.coverageService.ComputeCoverage(ctx, req.AddressID)
+	stepCtx, endStep := startStep(ctx, "coverage")
+	availableTech, err := s.coverageService.ComputeCoverage(stepCtx, req.AddressID)
+	endStep()
 	if err != nil {
 		return nil, err
 	}
 
 	// Step 2: Compute needed home Mbps
+	stepCtx, endStep = startStep(ctx, "candidates")
 	neededMbps := s.ComputeHomeMbps(req.Household)
 
 	// Step 3: Compute max TV hours needed
@@ -391,41 +478,212 @@ func (s *RecommendationService) ProcessRecommendationRequest(ctx context.Context
 	}
 
 	// Step 4: Generate candidate combinations
-	candidates, err := s.GenerateCandidates(ctx, availableTech, neededMbps, maxTVHours)
+	candidates, err := s.GenerateCandidates(stepCtx, availableTech, neededMbps, maxTVHours)
+	endStep()
 	if err != nil {
 		return nil, err
 	}
+	metrics.RecommendationCandidates.Observe(float64(len(candidates)))
+	span.SetAttributes(attribute.Int("candidates", len(candidates)))
 
 	// Get mobile plans catalog for line matching
-	catalog, err := s.db.GetCatalog(ctx)
+	stepCtx, endStep = startStep(ctx, "pricing")
+	catalog, err := s.catalog(stepCtx)
 	if err != nil {
+		endStep()
+		return nil, err
+	}
+
+	promotions, err := s.customerPromotions(stepCtx, req)
+	if err != nil {
+		endStep()
 		return nil, err
 	}
 
 	// Step 5: Match lines to optimal mobile plans
 	lineAssignments := s.MatchLinesToPlans(req.Household, catalog.MobilePlans)
 
-	// Step 6: Price each candidate
+	// Step 6: Price each candidate, apply the promotions it gets, and commit it for the
+	// accepted commitment that costs least every month
+	now := time.Now()
+	committed := make(map[int]*models.Catalog)
+	for _, months := range utils.CommitmentLengths(catalog, req.MaxCommitmentMonths)[1:] {
+		committed[months] = utils.CatalogAtCommitment(catalog, months)
+	}
 	var pricedCandidates []PricedCandidate
 	for _, candidate := range candidates {
 		priced := s.PriceBundleCandidate(candidate, lineAssignments)
-		pricedCandidates = append(pricedCandidates, priced)
+		priced.Promotions = promotions.Apply(priced, now)
+		pricedCandidates = append(pricedCandidates, s.CommitCandidate(priced, req.Household, committed, promotions, now))
 	}
+	endStep()
 
-	// Step 7: Sort by best value (lowest grand total) and return top 3
+	// Step 7: Sort by best value (lowest monthly total) and return top 3
+	_, endStep = startStep(ctx, "selection")
 	top3 := s.SelectTop3Candidates(pricedCandidates)
 
 	// Convert to response DTOs
 	response := s.ConvertToResponse(top3)
+	endStep()
+
+	// Step 8: Price the same plans after upcoming scheduled catalog changes
+	stepCtx, endStep = startStep(ctx, "scheduled_prices")
+	err = s.AddScheduledPrices(stepCtx, catalog, req.Household, promotions, top3, response.Top3)
+	endStep()
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 9: Issue a signed quote per candidate, bound to the catalog it was priced with
+	stepCtx, endStep = startStep(ctx, "quotes")
+	err = s.quoteService.Issue(stepCtx, req, CatalogVersion(catalog), response.Top3)
+	endStep()
+	if err != nil {
+		return nil, err
+	}
 
 	return response, nil
 }
 
-// SelectTop3Candidates sorts candidates by grand total and returns the best 3
+// CommitCandidate prices the plans of candidate, priced without commitment, for every
+// commitment length in committed, the catalog priced for it, and returns the candidate
+// committed for the length that costs least every month. A commitment only wins when it
+// is cheaper, so without commitment terms for its plans a candidate is not committed.
+func (s *RecommendationService) CommitCandidate(candidate PricedCandidate, household []api.HouseholdLineDTO, committed map[int]*models.Catalog, promotions *Promotions, at time.Time) PricedCandidate {
+	lengths := make([]int, 0, len(committed))
+	for months := range committed {
+		lengths = append(lengths, months)
+	}
+	sort.Ints(lengths)
+
+	best := candidate
+	for _, months := range lengths {
+		repriced, ok := s.RepriceCandidate(candidate, household, committed[months])
+		if !ok {
+			continue
+		}
+		repriced.Promotions = promotions.Apply(repriced, at)
+		repriced.CommitmentMonths = months
+		if math.Round(repriced.MonthlyTotal()*100) < math.Round(best.MonthlyTotal()*100) {
+			best = repriced
+		}
+	}
+
+	return best
+}
+
+// AddScheduledPrices prices the plans of each candidate again with the catalog of every
+// scheduled change after catalog, up to maxScheduledPrices within scheduledPriceHorizon,
+// and lists the prices that differ from the one before on the candidate: what signing
+// after a price change would cost instead of signing today, for the same commitment and
+// with the promotions running then. priced and candidates are the same candidates,
+// before and after ConvertToResponse.
+func (s *RecommendationService) AddScheduledPrices(ctx context.Context, catalog *models.Catalog, household []api.HouseholdLineDTO, promotions *Promotions, priced []PricedCandidate, candidates []api.RecommendationCandidateDTO) error {
+	last := make([]api.ScheduledPriceDTO, len(priced))
+	for i, candidate := range priced {
+		last[i] = api.ScheduledPriceDTO{Available: true, MonthlyTotal: candidate.MonthlyTotal(), Savings: candidate.Savings()}
+	}
+
+	horizon := time.Now().Add(scheduledPriceHorizon)
+	at := catalog.ValidUntil
+	for n := 0; n < maxScheduledPrices && at != nil && at.Before(horizon); n++ {
+		next, err := s.scheduledCatalog(ctx, *at)
+		if err != nil {
+			return err
+		}
+
+		for i, candidate := range priced {
+			scheduled := api.ScheduledPriceDTO{EffectiveFrom: *at}
+			if repriced, ok := s.RepriceCandidate(candidate, household, utils.CatalogAtCommitment(next, candidate.CommitmentMonths)); ok {
+				repriced.Promotions = promotions.Apply(repriced, *at)
+				scheduled.Available = true
+				scheduled.MonthlyTotal = repriced.MonthlyTotal()
+				scheduled.Savings = repriced.Savings()
+			}
+
+			if scheduled.Available != last[i].Available || math.Round(scheduled.MonthlyTotal*100) != math.Round(last[i].MonthlyTotal*100) {
+				candidates[i].ScheduledPrices = append(candidates[i].ScheduledPrices, scheduled)
+			}
+			last[i] = scheduled
+		}
+		at = next.ValidUntil
+	}
+
+	return nil
+}
+
+// RepriceCandidate prices the plans of candidate, for the same household, with their
+// versions in catalog. It reports false when one of them is not sold in catalog.
+func (s *RecommendationService) RepriceCandidate(candidate PricedCandidate, household []api.HouseholdLineDTO, catalog *models.Catalog) (PricedCandidate, bool) {
+	bundle := BundleCandidate{Label: candidate.Candidate.Label}
+	if home := candidate.Candidate.HomePlan; home != nil {
+		plan, ok := findPlan(catalog.HomePlans, func(p models.HomePlan) bool { return p.HomeID == home.HomeID })
+		if !ok {
+			return PricedCandidate{}, false
+		}
+		bundle.HomePlan = &plan
+	}
+	if tv := candidate.Candidate.TVPlan; tv != nil {
+		plan, ok := findPlan(catalog.TVPlans, func(p models.TVPlan) bool { return p.TVID == tv.TVID })
+		if !ok {
+			return PricedCandidate{}, false
+		}
+		bundle.TVPlan = &plan
+	}
+
+	usage := make(map[string]api.HouseholdLineDTO, len(household))
+	for _, line := range household {
+		usage[line.LineID] = line
+	}
+
+	var assignments []LineAssignment
+	for _, assignment := range candidate.LineAssignments {
+		plan, ok := findPlan(catalog.MobilePlans, func(p models.MobilePlan) bool { return p.PlanID == assignment.Plan.PlanID })
+		if !ok {
+			return PricedCandidate{}, false
+		}
+
+		line := usage[assignment.LineID]
+		overageGB, overageMin := s.calculateOverages(line, plan)
+		assignments = append(assignments, LineAssignment{
+			LineID:     assignment.LineID,
+			Plan:       plan,
+			LineCost:   s.calculateLineCost(line, plan),
+			OverageGB:  overageGB,
+			OverageMin: overageMin,
+		})
+	}
+
+	return s.PriceBundleCandidate(bundle, assignments), true
+}
+
+// findPlan returns the first of plans that matches
+func findPlan[T any](plans []T, match func(T) bool) (T, bool) {
+	for _, plan := range plans {
+		if match(plan) {
+			return plan, true
+		}
+	}
+	var none T
+	return none, false
+}
+
+// startStep starts the span of a recommendation step. The returned function ends it and
+// records the step's duration.
+func startStep(ctx context.Context, step string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Tracer(tracerName).Start(ctx, "recommendation."+step)
+	return ctx, func() {
+		span.End()
+		metrics.ObserveRecommendationStep(step, start)
+	}
+}
+
+// SelectTop3Candidates sorts candidates by monthly total and returns the best 3
 func (s *RecommendationService) SelectTop3Candidates(candidates []PricedCandidate) []PricedCandidate {
-	// Sort by grand total (ascending - cheapest first)
+	// Sort by monthly total (ascending - cheapest first)
 	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].GrandTotal < candidates[j].GrandTotal
+		return candidates[i].MonthlyTotal() < candidates[j].MonthlyTotal()
 	})
 
 	// Return top 3 (or fewer if less than 3 candidates)
@@ -448,6 +706,7 @@ func (s *RecommendationService) ConvertToResponse(pricedCandidates []PricedCandi
 				LineID: assignment.LineID,
 				Plan: api.MobilePlanDTO{
 					PlanID:       assignment.Plan.PlanID,
+					Version:      assignment.Plan.Version,
 					PlanName:     assignment.Plan.PlanName,
 					QuotaGB:      assignment.Plan.QuotaGB,
 					QuotaMin:     assignment.Plan.QuotaMin,
@@ -466,6 +725,7 @@ func (s *RecommendationService) ConvertToResponse(pricedCandidates []PricedCandi
 		if candidate.Candidate.HomePlan != nil {
 			homePlan = &api.HomePlanDTO{
 				HomeID:       candidate.Candidate.HomePlan.HomeID,
+				Version:      candidate.Candidate.HomePlan.Version,
 				Name:         candidate.Candidate.HomePlan.Name,
 				Tech:         candidate.Candidate.HomePlan.Tech,
 				DownMbps:     candidate.Candidate.HomePlan.DownMbps,
@@ -479,6 +739,7 @@ func (s *RecommendationService) ConvertToResponse(pricedCandidates []PricedCandi
 		if candidate.Candidate.TVPlan != nil {
 			tvPlan = &api.TVPlanDTO{
 				TVID:            candidate.Candidate.TVPlan.TVID,
+				Version:         candidate.Candidate.TVPlan.Version,
 				Name:            candidate.Candidate.TVPlan.Name,
 				HDHoursIncluded: candidate.Candidate.TVPlan.HDHoursIncluded,
 				MonthlyPrice:    candidate.Candidate.TVPlan.MonthlyPrice,
@@ -487,21 +748,31 @@ func (s *RecommendationService) ConvertToResponse(pricedCandidates []PricedCandi
 
 		// Create the recommendation candidate
 		recommendationCandidate := api.RecommendationCandidateDTO{
-			ComboLabel:   candidate.Candidate.Label,
-			MonthlyTotal: candidate.GrandTotal,
-			Savings:      candidate.TotalSavings,
-			Reasoning:    candidate.Reasoning,
+			ComboLabel:      candidate.Candidate.Label,
+			MonthlyTotal:    candidate.MonthlyTotal(),
+			FirstMonthTotal: candidate.GrandTotal - candidate.Promotions.FirstMonthDiscount,
+			Savings:         candidate.Savings(),
+			Reasoning:       candidate.Reasoning,
 			Items: api.RecommendationItemsDTO{
 				Mobile: mobileAssignments,
 				Home:   homePlan,
 				TV:     tvPlan,
 			},
 			Discounts: api.RecommendationDiscountsDTO{
-				LineDiscount:   candidate.LineDiscountAmount,
-				BundleDiscount: candidate.BundleDiscountAmount,
-				TotalDiscount:  candidate.TotalSavings,
+				LineDiscount:      candidate.LineDiscountAmount,
+				BundleDiscount:    candidate.BundleDiscountAmount,
+				PromotionDiscount: candidate.Promotions.MonthlyDiscount,
+				TotalDiscount:     candidate.Savings(),
 			},
 		}
+		recommendationCandidate.Commitment = api.CommitmentDTO{
+			Months:         candidate.CommitmentMonths,
+			ContractValue:  utils.CalcContractValue(candidate.MonthlyTotal(), candidate.Promotions, candidate.CommitmentMonths),
+			TerminationFee: candidate.TerminationFee(),
+		}
+		if len(candidate.Promotions.Applied) > 0 {
+			recommendationCandidate.Discounts.Promotions = promotionDTOs(candidate.Promotions)
+		}
 
 		top3 = append(top3, recommendationCandidate)
 	}
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"math"
 	"testing"
+	"time"
 
 	"app/internal/api"
 	"app/internal/models"
@@ -765,3 +767,114 @@ func TestSelectTop3Candidates(t *testing.T) {
 
 	t.Logf("✓ SelectTop3Candidates: Correctly sorted and returned top 3 cheapest options")
 }
+
+func TestScheduledPrices(t *testing.T) {
+	priceChange := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	current := analyticsTestCatalog()
+	current.HomePlans[0].Version = 1
+	current.ValidUntil = &priceChange
+
+	// On the price change, fiber costs more and VDSL is no longer sold
+	after := analyticsTestCatalog()
+	after.HomePlans = []models.HomePlan{
+		{HomeID: 1, Name: "Fiber 50Mbps", Tech: "fiber", DownMbps: 50, MonthlyPrice: 109.90, Version: 2, EffectiveFrom: priceChange},
+		after.HomePlans[2],
+	}
+
+	mock := &mockDB{
+		catalog:   current,
+		scheduled: map[time.Time]*models.Catalog{priceChange: after},
+		coverage: map[string]*models.Coverage{
+			"A1001": {AddressID: "A1001", Fiber: true, VDSL: true},
+		},
+	}
+	service := NewRecommendationService(mock, NewCoverageService(mock), NewQuoteService(mock, testQuoteSecret, DefaultQuoteTTL), NewCatalogCache(mock, time.Minute), NewPromotionService(mock))
+
+	response, err := service.ProcessRecommendationRequest(context.Background(), quoteTestRequest())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	byLabel := make(map[string]api.RecommendationCandidateDTO)
+	for _, candidate := range response.Top3 {
+		byLabel[candidate.ComboLabel] = candidate
+	}
+
+	if prices := byLabel["Mobile Only"].ScheduledPrices; len(prices) != 0 {
+		t.Errorf("Expected no scheduled prices for unchanged plans, got %+v", prices)
+	}
+
+	fiber := byLabel["Mobile + Fiber 50Mbps"]
+	if fiber.Items.Home == nil || fiber.Items.Home.Version != 1 {
+		t.Errorf("Expected the items to name the version in effect now, got %+v", fiber.Items.Home)
+	}
+	if len(fiber.ScheduledPrices) != 1 {
+		t.Fatalf("Expected 1 scheduled fiber price, got %+v", fiber.ScheduledPrices)
+	}
+	if scheduled := fiber.ScheduledPrices[0]; !scheduled.EffectiveFrom.Equal(priceChange) || !scheduled.Available ||
+		math.Abs(scheduled.MonthlyTotal-fiber.MonthlyTotal-20*0.90) > 0.01 {
+		t.Errorf("Expected fiber to cost 18 more from the price change on, got %+v from %.2f", scheduled, fiber.MonthlyTotal)
+	}
+
+	vdsl := byLabel["Mobile + VDSL 25Mbps"]
+	if len(vdsl.ScheduledPrices) != 1 || vdsl.ScheduledPrices[0].Available {
+		t.Errorf("Expected VDSL to be unavailable after the price change, got %+v", vdsl.ScheduledPrices)
+	}
+}
+
+func TestRecommendationCommitment(t *testing.T) {
+	catalog := analyticsTestCatalog()
+	catalog.HomePlans[0].Terms = []models.PlanTerm{
+		{Months: 12, MonthlyPrice: 79.90, TerminationFee: 150},
+		{Months: 24, MonthlyPrice: 69.90, TerminationFee: 300},
+	}
+	mock := &mockDB{
+		catalog: catalog,
+		coverage: map[string]*models.Coverage{
+			"A1001": {AddressID: "A1001", Fiber: true},
+		},
+	}
+	quotes := NewQuoteService(mock, testQuoteSecret, DefaultQuoteTTL)
+	service := NewRecommendationService(mock, NewCoverageService(mock), quotes, NewCatalogCache(mock, time.Minute), NewPromotionService(mock))
+
+	tests := []struct {
+		name          string
+		maxMonths     int
+		months        int
+		monthlyTotal  float64
+		contractValue float64
+		fee           float64
+	}{
+		{"no commitment by default", 0, 0, (99.90 + 89.90) * 0.90, (99.90 + 89.90) * 0.90, 0},
+		{"12 months accepted", 18, 12, (99.90 + 79.90) * 0.90, 12 * (99.90 + 79.90) * 0.90, 150},
+		{"24 months accepted", 24, 24, (99.90 + 69.90) * 0.90, 24 * (99.90 + 69.90) * 0.90, 300},
+	}
+
+	for _, tt := range tests {
+		req := quoteTestRequest()
+		req.MaxCommitmentMonths = tt.maxMonths
+		response, err := service.ProcessRecommendationRequest(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		for _, candidate := range response.Top3 {
+			if candidate.ComboLabel == "Mobile Only" {
+				if candidate.Commitment.Months != 0 || candidate.Commitment.TerminationFee != 0 || candidate.Commitment.ContractValue != candidate.MonthlyTotal {
+					t.Errorf("%s: expected mobile only without commitment, got %+v", tt.name, candidate.Commitment)
+				}
+				continue
+			}
+
+			commitment := candidate.Commitment
+			if commitment.Months != tt.months || commitment.TerminationFee != tt.fee ||
+				math.Abs(candidate.MonthlyTotal-tt.monthlyTotal) > 0.001 || math.Abs(commitment.ContractValue-tt.contractValue) > 0.01 {
+				t.Errorf("%s: expected %d months at %.2f, %.2f in total and a %.2f fee, got %+v at %.2f",
+					tt.name, tt.months, tt.monthlyTotal, tt.contractValue, tt.fee, commitment, candidate.MonthlyTotal)
+			}
+			if quote := mock.quotes[candidate.QuoteID]; quote.CommitmentMonths != tt.months || quote.TerminationFee != tt.fee {
+				t.Errorf("%s: expected the quote to keep the commitment, got %d months and a %.2f fee", tt.name, quote.CommitmentMonths, quote.TerminationFee)
+			}
+		}
+	}
+}
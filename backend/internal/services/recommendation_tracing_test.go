@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"app/internal/models"
+	"app/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRecommendationSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mock := &mockDB{
+		catalog: analyticsTestCatalog(),
+		coverage: map[string]*models.Coverage{
+			"A1001": {AddressID: "A1001", Fiber: true, VDSL: true},
+		},
+	}
+	coverage := NewCoverageService(mock)
+	service := NewRecommendationService(mock, coverage, NewQuoteService(mock, testQuoteSecret, DefaultQuoteTTL), NewCatalogCache(mock, 0), NewPromotionService(mock))
+
+	if _, err := service.ProcessRecommendationRequest(context.Background(), quoteTestRequest()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	root, ok := spans["ProcessRecommendationRequest"]
+	if !ok {
+		t.Fatal("Expected a ProcessRecommendationRequest span")
+	}
+	for _, step := range []string{"coverage", "candidates", "pricing", "selection", "scheduled_prices", "quotes"} {
+		span, ok := spans["recommendation."+step]
+		if !ok {
+			t.Errorf("Expected a span for the %s step", step)
+			continue
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("Expected the %s step to be a child of the request span", step)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+// DefaultSlotDuration is the length of a generated installation window
+const DefaultSlotDuration = 3 * time.Hour
+
+// SlotGenerator materialises bookable installation windows from crew capacity calendars
+type SlotGenerator struct {
+	db           db.DatabaseInterface
+	slotDuration time.Duration
+	location     *time.Location
+	now          func() time.Time
+}
+
+// NewSlotGenerator creates a slot generator producing 3-hour windows in Istanbul time
+func NewSlotGenerator(database db.DatabaseInterface) *SlotGenerator {
+	return &SlotGenerator{
+		db:           database,
+		slotDuration: DefaultSlotDuration,
+		location:     utils.IstanbulLocation(),
+		now:          time.Now,
+	}
+}
+
+// Materialize generates slots for the next days and stores the ones that do not exist yet.
+// Existing slots keep their remaining capacity. Returns the number of slots created.
+func (g *SlotGenerator) Materialize(ctx context.Context, days int) (int, error) {
+	crews, err := g.db.GetCrews(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load crews: %w", err)
+	}
+
+	slots, err := g.GenerateSlots(crews, g.now(), days)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := g.db.UpsertInstallSlots(ctx, slots)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store generated slots: %w", err)
+	}
+
+	return created, nil
+}
+
+// GenerateSlots builds one slot per crew, technology and window for every working-hours
+// block between from and from+days. Windows that already started are skipped, as are
+// partial windows at the end of a block. Capacity is the number of the crew's active
+// technicians qualified for the technology. A technician qualified for several
+// technologies counts towards each of them, but can only be booked once per window:
+// claiming a slot lowers the remaining capacity of the window's other slots as
+// WindowCapacity does.
+func (g *SlotGenerator) GenerateSlots(crews []models.Crew, from time.Time, days int) ([]models.InstallSlot, error) {
+	if days <= 0 {
+		return nil, nil
+	}
+
+	from = from.In(g.location)
+	firstDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, g.location)
+
+	var slots []models.InstallSlot
+	for _, crew := range crews {
+		capacity := WindowCapacity(crew, nil)
+		if len(capacity) == 0 || len(crew.ServiceAreas) == 0 {
+			continue
+		}
+
+		// Iterate technologies in a stable order so output is deterministic
+		techs := make([]string, 0, len(capacity))
+		for tech := range capacity {
+			techs = append(techs, tech)
+		}
+		sort.Strings(techs)
+
+		for d := 0; d < days; d++ {
+			day := firstDay.AddDate(0, 0, d)
+
+			for _, hours := range crew.WorkingHours {
+				if time.Weekday(hours.Weekday) != day.Weekday() {
+					continue
+				}
+
+				blockStart, err := clockOnDay(day, hours.StartTime)
+				if err != nil {
+					return nil, fmt.Errorf("crew %d: invalid start time: %w", crew.CrewID, err)
+				}
+				blockEnd, err := clockOnDay(day, hours.EndTime)
+				if err != nil {
+					return nil, fmt.Errorf("crew %d: invalid end time: %w", crew.CrewID, err)
+				}
+
+				for start := blockStart; !start.Add(g.slotDuration).After(blockEnd); start = start.Add(g.slotDuration) {
+					if start.Before(from) {
+						continue
+					}
+
+					for _, tech := range techs {
+						crewID := crew.CrewID
+						slots = append(slots, models.InstallSlot{
+							SlotID:            GeneratedSlotID(crewID, tech, start),
+							CrewID:            &crewID,
+							SlotStart:         start,
+							SlotEnd:           start.Add(g.slotDuration),
+							Tech:              tech,
+							Capacity:          capacity[tech],
+							RemainingCapacity: capacity[tech],
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return slots, nil
+}
+
+// WindowCapacity returns how many more installations of each technology a crew can take
+// in one window, with booked installations of each technology already taken there. Every
+// installation needs one of the crew's active technicians qualified for it, and each
+// technician does one per window, so the bookings of any set of technologies can be at
+// most the technicians qualified for one of them. A technology can take as many more as
+// the tightest of the sets containing it leaves. claim_install_slot and
+// release_install_slot keep the remaining capacity of crew slots to the same rule.
+func WindowCapacity(crew models.Crew, booked map[string]int) map[string]int {
+	var active []models.Technician
+	qualified := make(map[string]bool)
+	for _, technician := range crew.Technicians {
+		if !technician.Active || len(technician.Techs) == 0 {
+			continue
+		}
+		active = append(active, technician)
+		for _, tech := range technician.Techs {
+			qualified[tech] = true
+		}
+	}
+
+	techs := make([]string, 0, len(qualified))
+	for tech := range qualified {
+		techs = append(techs, tech)
+	}
+	sort.Strings(techs)
+
+	capacity := make(map[string]int, len(techs))
+	for i, tech := range techs {
+		capacity[tech] = len(active)
+		for set := 1; set < 1<<len(techs); set++ {
+			if set&(1<<i) == 0 {
+				continue
+			}
+
+			// Technicians qualified for one of the set's technologies, less its bookings
+			left := 0
+			for _, technician := range active {
+				for j, other := range techs {
+					if set&(1<<j) != 0 && slices.Contains(technician.Techs, other) {
+						left++
+						break
+					}
+				}
+			}
+			for j, other := range techs {
+				if set&(1<<j) != 0 {
+					left -= booked[other]
+				}
+			}
+			capacity[tech] = max(0, min(capacity[tech], left))
+		}
+	}
+
+	return capacity
+}
+
+// GeneratedSlotID returns the deterministic ID of a generated slot, so regenerating the
+// same window never creates a duplicate
+func GeneratedSlotID(crewID int, tech string, start time.Time) string {
+	return fmt.Sprintf("C%d-%s-%s", crewID, tech, start.UTC().Format("200601021504"))
+}
+
+// clockOnDay parses an HH:MM or HH:MM:SS time of day, up to 24:00, and places it on the
+// given day. Seconds are validated but dropped, as windows start on the minute.
+func clockOnDay(day time.Time, clock string) (time.Time, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return time.Time{}, fmt.Errorf("malformed time of day %q", clock)
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return time.Time{}, fmt.Errorf("malformed hour in %q", clock)
+	}
+
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return time.Time{}, fmt.Errorf("malformed minute in %q", clock)
+	}
+
+	second := 0
+	if len(parts) == 3 {
+		if second, err = strconv.Atoi(parts[2]); err != nil || second < 0 || second > 59 {
+			return time.Time{}, fmt.Errorf("malformed second in %q", clock)
+		}
+	}
+
+	// 24:00 ends a block at midnight; no other time of day is past it
+	if hour == 24 && (minute != 0 || second != 0) {
+		return time.Time{}, fmt.Errorf("time of day %q is after 24:00", clock)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location()), nil
+}
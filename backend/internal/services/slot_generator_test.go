@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"app/internal/models"
+	"app/internal/utils"
+)
+
+func testCrew() models.Crew {
+	return models.Crew{
+		CrewID: 7,
+		Name:   "Test Crew",
+		Active: true,
+		Technicians: []models.Technician{
+			{TechnicianID: 1, CrewID: 7, Name: "A", Techs: []string{"fiber", "vdsl"}, Active: true},
+			{TechnicianID: 2, CrewID: 7, Name: "B", Techs: []string{"fiber"}, Active: true},
+			{TechnicianID: 3, CrewID: 7, Name: "C", Techs: []string{"fwa"}, Active: false},
+		},
+		ServiceAreas: []models.ServiceArea{{CrewID: 7, City: "Istanbul", District: "Kadikoy"}},
+		WorkingHours: []models.WorkingHours{
+			{CrewID: 7, Weekday: int(time.Monday), StartTime: "09:00", EndTime: "18:00"},
+			{CrewID: 7, Weekday: int(time.Saturday), StartTime: "09:00:00", EndTime: "13:00:00"},
+		},
+	}
+}
+
+func TestGenerateSlots(t *testing.T) {
+	generator := NewSlotGenerator(nil)
+	loc := utils.IstanbulLocation()
+
+	// Monday 2026-01-05 07:00 Istanbul time, generating Monday through Sunday
+	from := time.Date(2026, 1, 5, 7, 0, 0, 0, loc)
+
+	slots, err := generator.GenerateSlots([]models.Crew{testCrew()}, from, 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Monday: 09-12, 12-15, 15-18 (3 windows); Saturday: 09-12 (13:00 end leaves no full window)
+	// Each window has fiber and vdsl slots; the inactive fwa technician adds nothing
+	if len(slots) != 8 {
+		t.Fatalf("Expected 8 slots, got %d", len(slots))
+	}
+
+	capacity := map[string]int{}
+	for _, slot := range slots {
+		capacity[slot.Tech] = slot.Capacity
+
+		if slot.RemainingCapacity != slot.Capacity {
+			t.Errorf("Expected full remaining capacity for %s, got %d/%d", slot.SlotID, slot.RemainingCapacity, slot.Capacity)
+		}
+
+		if slot.CrewID == nil || *slot.CrewID != 7 {
+			t.Errorf("Expected crew 7 on slot %s", slot.SlotID)
+		}
+
+		if slot.SlotEnd.Sub(slot.SlotStart) != DefaultSlotDuration {
+			t.Errorf("Expected %v window, got %v", DefaultSlotDuration, slot.SlotEnd.Sub(slot.SlotStart))
+		}
+	}
+
+	if capacity["fiber"] != 2 || capacity["vdsl"] != 1 {
+		t.Errorf("Expected fiber capacity 2 and vdsl capacity 1, got %v", capacity)
+	}
+
+	if _, ok := capacity["fwa"]; ok {
+		t.Error("Inactive technicians should not create capacity")
+	}
+
+	first := slots[0]
+	if first.SlotStart.In(loc).Hour() != 9 || first.SlotStart.In(loc).Weekday() != time.Monday {
+		t.Errorf("Expected first slot Monday 09:00, got %v", first.SlotStart.In(loc))
+	}
+
+	if first.SlotID != GeneratedSlotID(7, first.Tech, first.SlotStart) {
+		t.Errorf("Unexpected slot ID %s", first.SlotID)
+	}
+}
+
+func TestGenerateSlotsSkipsStartedWindows(t *testing.T) {
+	generator := NewSlotGenerator(nil)
+	from := time.Date(2026, 1, 5, 12, 30, 0, 0, utils.IstanbulLocation())
+
+	slots, err := generator.GenerateSlots([]models.Crew{testCrew()}, from, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Only the 15:00-18:00 window is still ahead
+	if len(slots) != 2 {
+		t.Fatalf("Expected 2 slots, got %d", len(slots))
+	}
+
+	for _, slot := range slots {
+		if slot.SlotStart.Before(from) {
+			t.Errorf("Slot %s starts before generation time", slot.SlotID)
+		}
+	}
+}
+
+func TestGenerateSlotsInvalidHours(t *testing.T) {
+	crew := testCrew()
+	crew.WorkingHours = []models.WorkingHours{{CrewID: 7, Weekday: int(time.Monday), StartTime: "nine", EndTime: "18:00"}}
+
+	from := time.Date(2026, 1, 5, 7, 0, 0, 0, utils.IstanbulLocation())
+	if _, err := NewSlotGenerator(nil).GenerateSlots([]models.Crew{crew}, from, 1); err == nil {
+		t.Error("Expected an error for malformed working hours")
+	}
+}
+
+func TestGenerateSlotsWorkingHoursUntilMidnight(t *testing.T) {
+	from := time.Date(2026, 1, 5, 7, 0, 0, 0, utils.IstanbulLocation())
+
+	tests := []struct {
+		end   string
+		valid bool
+	}{
+		{"24:00", true},
+		{"24:00:00", true},
+		{"24:59", false},
+		{"24:00:30", false},
+		{"18:00:60", false},
+	}
+
+	for _, tt := range tests {
+		crew := testCrew()
+		crew.WorkingHours = []models.WorkingHours{{CrewID: 7, Weekday: int(time.Monday), StartTime: "18:00", EndTime: tt.end}}
+		slots, err := NewSlotGenerator(nil).GenerateSlots([]models.Crew{crew}, from, 1)
+		if tt.valid && (err != nil || len(slots) != 4) {
+			t.Errorf("%s: expected the 18-21 and 21-24 windows, got %d slots (%v)", tt.end, len(slots), err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected an error", tt.end)
+		}
+	}
+}
+
+func TestWindowCapacity(t *testing.T) {
+	technician := func(techs ...string) models.Technician {
+		return models.Technician{Techs: techs, Active: true}
+	}
+
+	tests := []struct {
+		name        string
+		technicians []models.Technician
+		booked      map[string]int
+		expected    map[string]int
+	}{
+		{"one technician for two techs", []models.Technician{technician("fiber", "vdsl")},
+			nil, map[string]int{"fiber": 1, "vdsl": 1}},
+		// Booked for fiber, the only technician cannot take a VDSL installation too
+		{"one technician booked", []models.Technician{technician("fiber", "vdsl")},
+			map[string]int{"fiber": 1}, map[string]int{"fiber": 0, "vdsl": 0}},
+		// The fiber booking goes to the fiber-only technician, leaving VDSL open
+		{"fiber booking leaves vdsl", []models.Technician{technician("fiber", "vdsl"), technician("fiber")},
+			map[string]int{"fiber": 1}, map[string]int{"fiber": 1, "vdsl": 1}},
+		{"vdsl booking leaves one fiber", []models.Technician{technician("fiber", "vdsl"), technician("fiber")},
+			map[string]int{"vdsl": 1}, map[string]int{"fiber": 1, "vdsl": 0}},
+		// VDSL and FWA share one technician, though each has its own slot
+		{"two techs sharing a technician", []models.Technician{technician("fiber"), technician("fiber"), technician("vdsl", "fwa")},
+			map[string]int{"vdsl": 1}, map[string]int{"fiber": 2, "vdsl": 0, "fwa": 0}},
+		{"inactive technicians", []models.Technician{{Techs: []string{"fiber"}}},
+			nil, map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		capacity := WindowCapacity(models.Crew{Technicians: tt.technicians}, tt.booked)
+		if len(capacity) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, capacity)
+			continue
+		}
+		for tech, expected := range tt.expected {
+			if capacity[tech] != expected {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, capacity)
+				break
+			}
+		}
+	}
+}
+
+func TestMaterializeSlots(t *testing.T) {
+	mock := &mockDB{crews: []models.Crew{testCrew()}}
+	generator := NewSlotGenerator(mock)
+	generator.now = func() time.Time { return time.Date(2026, 1, 5, 7, 0, 0, 0, utils.IstanbulLocation()) }
+
+	created, err := generator.Materialize(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if created != 6 || len(mock.upsertedSlots) != 6 {
+		t.Errorf("Expected 6 slots stored, got created=%d stored=%d", created, len(mock.upsertedSlots))
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/webhook"
+)
+
+// Webhook delivery settings. A claimed delivery is leased for webhookLease so a crashed
+// sender does not lose it; failed deliveries are retried with exponential backoff
+// starting at webhookRetryBase until maxWebhookAttempts.
+const (
+	webhookBatchSize   = 50
+	webhookLease       = 5 * time.Minute
+	maxWebhookAttempts = 8
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = time.Hour
+)
+
+// WebhookService manages partner webhook subscriptions and delivers order events to them.
+// Events are queued per subscription by HandleEvent and sent by Deliver, so a slow or
+// failing partner never holds up the event dispatcher or other partners.
+type WebhookService struct {
+	db     db.DatabaseInterface
+	sender *webhook.Sender
+	now    func() time.Time
+}
+
+// NewWebhookService creates a webhook service sending through sender
+func NewWebhookService(database db.DatabaseInterface, sender *webhook.Sender) *WebhookService {
+	return &WebhookService{
+		db:     database,
+		sender: sender,
+		now:    time.Now,
+	}
+}
+
+// Subscribe registers url for eventTypes, or for every event type when eventTypes is
+// empty. The returned subscription carries the generated signing secret; it is not
+// shown again.
+func (s *WebhookService) Subscribe(ctx context.Context, url string, eventTypes []string) (*models.WebhookSubscription, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.CreateWebhookSubscription(ctx, &models.WebhookSubscription{
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+}
+
+// Subscriptions returns every subscription without its secret
+func (s *WebhookService) Subscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subs, err := s.db.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// Unsubscribe deletes a subscription and its delivery log
+func (s *WebhookService) Unsubscribe(ctx context.Context, id int64) error {
+	return s.db.DeleteWebhookSubscription(ctx, id)
+}
+
+// Deliveries returns up to limit deliveries of the subscription, newest first
+func (s *WebhookService) Deliveries(ctx context.Context, subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	return s.db.ListWebhookDeliveries(ctx, subscriptionID, limit)
+}
+
+// HandleEvent queues a delivery of the event for every subscription to its type. An
+// event handed over again is not queued twice for the same subscription.
+func (s *WebhookService) HandleEvent(ctx context.Context, event models.Event) error {
+	subs, err := s.db.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	var queued []models.WebhookDelivery
+	for i := range subs {
+		if !subs[i].Matches(event.EventType) {
+			continue
+		}
+
+		if body == nil {
+			body, err = json.Marshal(webhook.Envelope{
+				ID:        event.ID,
+				Type:      event.EventType,
+				CreatedAt: event.CreatedAt,
+				Data:      event.Payload,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+
+		queued = append(queued, models.WebhookDelivery{
+			SubscriptionID: subs[i].ID,
+			EventID:        event.ID,
+			EventType:      event.EventType,
+			Payload:        body,
+		})
+	}
+
+	return s.db.EnqueueWebhookDeliveries(ctx, queued)
+}
+
+// Deliver sends due webhook deliveries until none are left
+func (s *WebhookService) Deliver(ctx context.Context) error {
+	for {
+		claimed, err := s.db.ClaimWebhookDeliveries(ctx, webhookBatchSize, int(webhookLease.Seconds()))
+		if err != nil {
+			return err
+		}
+
+		if len(claimed) > 0 {
+			subs, err := s.db.ListWebhookSubscriptions(ctx)
+			if err != nil {
+				return err
+			}
+			byID := make(map[int64]*models.WebhookSubscription, len(subs))
+			for i := range subs {
+				byID[subs[i].ID] = &subs[i]
+			}
+
+			for _, d := range claimed {
+				s.deliver(ctx, d, byID[d.SubscriptionID])
+			}
+		}
+
+		if len(claimed) < webhookBatchSize || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// deliver sends one claimed delivery to its subscription and records the outcome. sub
+// is nil when the subscription was deleted after the delivery was claimed.
+func (s *WebhookService) deliver(ctx context.Context, d models.WebhookDelivery, sub *models.WebhookSubscription) {
+	if sub == nil {
+		if err := s.db.MarkWebhookFailed(ctx, d.ID, nil, "subscription deleted", nil); err != nil {
+			logger(ctx).Error("Failed to record failed webhook delivery", "delivery_id", d.ID, "error", err)
+		}
+		return
+	}
+
+	status, err := s.sender.Send(ctx, webhook.Request{
+		URL:       sub.URL,
+		Secret:    sub.Secret,
+		EventID:   d.EventID,
+		EventType: d.EventType,
+		Body:      d.Payload,
+	})
+	if err == nil {
+		if err := s.db.MarkWebhookDelivered(ctx, d.ID, status); err != nil {
+			logger(ctx).Error("Failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+		}
+		return
+	}
+
+	var statusCode *int
+	if status != 0 {
+		statusCode = &status
+	}
+
+	var retryAt *time.Time
+	if d.Attempts < maxWebhookAttempts {
+		next := s.now().Add(retryBackoff(d.Attempts, webhookRetryBase, webhookRetryMax))
+		retryAt = &next
+		logger(ctx).Warn("Failed to deliver webhook, retrying", "event_type", d.EventType, "event_id", d.EventID,
+			"subscription_id", d.SubscriptionID, "attempt", d.Attempts, "retry_at", next, "error", err)
+	} else {
+		logger(ctx).Error("Giving up on webhook delivery", "event_type", d.EventType, "event_id", d.EventID,
+			"subscription_id", d.SubscriptionID, "attempts", d.Attempts, "error", err)
+	}
+
+	if err := s.db.MarkWebhookFailed(ctx, d.ID, statusCode, err.Error(), retryAt); err != nil {
+		logger(ctx).Error("Failed to record failed webhook delivery", "delivery_id", d.ID, "error", err)
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"app/internal/db"
+	"app/internal/models"
+	"app/internal/webhook"
+)
+
+// receiver is an httptest partner endpoint that verifies signatures and answers with
+// the queued statuses, then 200
+type receiver struct {
+	t      *testing.T
+	server *httptest.Server
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	received []webhook.Envelope
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{t: t, statuses: statuses}
+	r.server = httptest.NewServer(http.HandlerFunc(r.handle))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *receiver) handle(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	err := webhook.Verify(r.secret, req.Header.Get(webhook.HeaderTimestamp), req.Header.Get(webhook.HeaderSignature), body, webhook.DefaultTolerance, time.Now())
+	if err != nil {
+		r.t.Errorf("Receiver rejected signature: %v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var envelope webhook.Envelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		r.t.Errorf("Receiver got invalid JSON: %v", err)
+	}
+	r.received = append(r.received, envelope)
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// webhookFixture returns a service with one pending order event
+func webhookFixture(t *testing.T) (*WebhookService, *mockDB) {
+	t.Helper()
+	mock := &mockDB{
+		events: []*models.Event{
+			{
+				ID:          42,
+				EventType:   models.EventOrderPlaced,
+				AggregateID: "ORD-1",
+				Payload:     []byte(`{"order_id":"ORD-1","monthly_total":599.9}`),
+				Status:      models.EventPending,
+				CreatedAt:   time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	return NewWebhookService(mock, webhook.NewSender()), mock
+}
+
+// subscribe registers r for eventTypes and lets it verify with the generated secret
+func subscribe(t *testing.T, s *WebhookService, r *receiver, eventTypes ...string) *models.WebhookSubscription {
+	t.Helper()
+	sub, err := s.Subscribe(context.Background(), r.server.URL, eventTypes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r.secret = sub.Secret
+	return sub
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	service, mock := webhookFixture(t)
+	crm := newReceiver(t)
+	fieldOps := newReceiver(t)
+	other := newReceiver(t)
+	subscribe(t, service, crm)
+	subscribe(t, service, fieldOps, models.EventOrderPlaced, models.EventOrderCancelled)
+	subscribe(t, service, other, models.EventSlotBooked)
+
+	if err := service.HandleEvent(context.Background(), *mock.events[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The dispatcher may hand the same event over again
+	if err := service.HandleEvent(context.Background(), *mock.events[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(mock.deliveries) != 2 {
+		t.Fatalf("Expected one delivery per matching subscription, got %d", len(mock.deliveries))
+	}
+
+	if err := service.Deliver(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for name, r := range map[string]*receiver{"crm": crm, "field ops": fieldOps} {
+		if len(r.received) != 1 {
+			t.Fatalf("Expected %s to receive one delivery, got %d", name, len(r.received))
+		}
+		got := r.received[0]
+		if got.ID != 42 || got.Type != models.EventOrderPlaced || !strings.Contains(string(got.Data), `"order_id":"ORD-1"`) {
+			t.Errorf("Unexpected envelope for %s: %+v", name, got)
+		}
+	}
+	if len(other.received) != 0 {
+		t.Errorf("Expected the SlotBooked subscriber to receive nothing, got %d", len(other.received))
+	}
+
+	for _, d := range mock.deliveries {
+		if d.Status != models.WebhookDeliveryDelivered || d.LastStatusCode == nil || *d.LastStatusCode != http.StatusOK {
+			t.Errorf("Expected delivery %d to be delivered with 200, got %s %v", d.ID, d.Status, d.LastStatusCode)
+		}
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	service, mock := webhookFixture(t)
+	crm := newReceiver(t, http.StatusServiceUnavailable, http.StatusInternalServerError)
+	sub := subscribe(t, service, crm)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	if err := service.HandleEvent(context.Background(), *mock.events[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	delivery := mock.deliveries[0]
+
+	// First attempt fails and is retried after webhookRetryBase, second after twice that
+	for attempt, expected := range []struct {
+		status int
+		retry  time.Duration
+	}{
+		{http.StatusServiceUnavailable, webhookRetryBase},
+		{http.StatusInternalServerError, 2 * webhookRetryBase},
+	} {
+		if err := service.Deliver(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if delivery.Status != models.WebhookDeliveryPending {
+			t.Fatalf("Attempt %d: expected a pending retry, got %s", attempt+1, delivery.Status)
+		}
+		if delivery.LastStatusCode == nil || *delivery.LastStatusCode != expected.status {
+			t.Errorf("Attempt %d: expected status %d in the log, got %v", attempt+1, expected.status, delivery.LastStatusCode)
+		}
+		if !delivery.NextAttemptAt.Equal(now.Add(expected.retry)) {
+			t.Errorf("Attempt %d: expected retry in %v, got %v", attempt+1, expected.retry, delivery.NextAttemptAt.Sub(now))
+		}
+
+		// Not due yet
+		if err := service.Deliver(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(crm.received) != attempt+1 {
+			t.Fatalf("Expected no delivery before the retry is due, got %d", len(crm.received))
+		}
+		delivery.NextAttemptAt = time.Time{}
+	}
+
+	if err := service.Deliver(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if delivery.Status != models.WebhookDeliveryDelivered || delivery.Attempts != 3 || delivery.LastError != nil {
+		t.Errorf("Expected delivery on the third attempt, got %+v", delivery)
+	}
+	for _, got := range crm.received {
+		if got.ID != 42 {
+			t.Errorf("Expected every retry to carry event 42, got %d", got.ID)
+		}
+	}
+
+	log, err := service.Deliveries(context.Background(), sub.ID, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(log) != 1 || log[0].Attempts != 3 {
+		t.Errorf("Expected the delivery log to show 3 attempts, got %+v", log)
+	}
+}
+
+func TestWebhookDeliveryGivesUp(t *testing.T) {
+	service, mock := webhookFixture(t)
+	crm := newReceiver(t, http.StatusBadGateway)
+	subscribe(t, service, crm)
+
+	if err := service.HandleEvent(context.Background(), *mock.events[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	mock.deliveries[0].Attempts = maxWebhookAttempts - 1
+
+	if err := service.Deliver(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	d := mock.deliveries[0]
+	if d.Status != models.WebhookDeliveryFailed {
+		t.Errorf("Expected the delivery to fail for good, got %s", d.Status)
+	}
+	if d.LastError == nil || !strings.Contains(*d.LastError, "status 502") {
+		t.Errorf("Expected the receiver's status in the error, got %v", d.LastError)
+	}
+}
+
+func TestWebhookUnreachableReceiver(t *testing.T) {
+	service, mock := webhookFixture(t)
+	crm := newReceiver(t)
+	subscribe(t, service, crm)
+	crm.server.Close()
+
+	if err := service.HandleEvent(context.Background(), *mock.events[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := service.Deliver(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	d := mock.deliveries[0]
+	if d.Status != models.WebhookDeliveryPending || d.LastStatusCode != nil || d.LastError == nil {
+		t.Errorf("Expected a retry without status code, got %+v", d)
+	}
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	service, mock := webhookFixture(t)
+	crm := newReceiver(t)
+	sub := subscribe(t, service, crm, models.EventOrderCancelled)
+
+	if !strings.HasPrefix(sub.Secret, "whsec_") {
+		t.Errorf("Expected the created subscription to carry its secret, got %q", sub.Secret)
+	}
+
+	subs, err := service.Subscriptions(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Secret != "" || subs[0].URL != crm.server.URL {
+		t.Errorf("Expected the subscription listed without its secret, got %+v", subs)
+	}
+	if mock.webhookSubs[0].Secret != sub.Secret {
+		t.Error("Expected listing not to clear the stored secret")
+	}
+
+	if err := service.Unsubscribe(context.Background(), sub.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := service.Unsubscribe(context.Background(), sub.ID); !errors.Is(err, db.ErrWebhookNotFound) {
+		t.Errorf("Expected ErrWebhookNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// record installs a global provider recording every span in memory
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	if _, err := Setup(context.Background(), Config{Exporter: ExporterNone}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(NewProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+	recorder := record(t)
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/orders/:id", func(c echo.Context) error {
+		_, span := Tracer("test").Start(c.Request().Context(), "load order")
+		span.End()
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "database unavailable")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/orders/ORD-1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected a server and a handler span, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+
+	if server.Name() != "GET /api/orders/:id" {
+		t.Errorf("Expected the span named after the route, got %q", server.Name())
+	}
+	if server.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the incoming traceparent as parent, got trace %s parent %s", server.SpanContext().TraceID(), server.Parent().SpanID())
+	}
+	if child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("Expected the handler span to be a child of the server span")
+	}
+	if status := attr(server, "http.response.status_code").AsInt64(); status != http.StatusServiceUnavailable || rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 on the span and response, got %d and %d", status, rec.Code)
+	}
+	if server.Status().Code != codes.Error {
+		t.Errorf("Expected a 5xx to mark the span failed, got %v", server.Status())
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), Config{Exporter: "jaeger"}); err == nil {
+		t.Error("Expected an unknown exporter to be rejected")
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"math"
+	"sort"
+
+	"app/internal/models"
+)
+
+// FindTerm returns the commitment term of a plan for months, if it is offered with one
+func FindTerm(terms []models.PlanTerm, months int) (models.PlanTerm, bool) {
+	for _, term := range terms {
+		if term.Months == months {
+			return term, true
+		}
+	}
+	return models.PlanTerm{}, false
+}
+
+// CommitmentLengths returns 0, for no commitment, followed by the commitment lengths up
+// to maxMonths that any plan of catalog is offered with, shortest first
+func CommitmentLengths(catalog *models.Catalog, maxMonths int) []int {
+	seen := make(map[int]bool)
+	add := func(terms []models.PlanTerm) {
+		for _, term := range terms {
+			if term.Months > 0 && term.Months <= maxMonths {
+				seen[term.Months] = true
+			}
+		}
+	}
+	for _, plan := range catalog.MobilePlans {
+		add(plan.Terms)
+	}
+	for _, plan := range catalog.HomePlans {
+		add(plan.Terms)
+	}
+	for _, plan := range catalog.TVPlans {
+		add(plan.Terms)
+	}
+
+	lengths := make([]int, 0, len(seen))
+	for months := range seen {
+		lengths = append(lengths, months)
+	}
+	sort.Ints(lengths)
+	return append([]int{0}, lengths...)
+}
+
+// CatalogAtCommitment returns a copy of catalog with its plans priced for a commitment of
+// months: the plans offered with that commitment at its monthly price, the others at
+// their price without commitment. It returns catalog itself for 0.
+func CatalogAtCommitment(catalog *models.Catalog, months int) *models.Catalog {
+	if months == 0 {
+		return catalog
+	}
+
+	committed := *catalog
+	committed.MobilePlans = append([]models.MobilePlan(nil), catalog.MobilePlans...)
+	for i := range committed.MobilePlans {
+		if term, ok := FindTerm(committed.MobilePlans[i].Terms, months); ok {
+			committed.MobilePlans[i].MonthlyPrice = term.MonthlyPrice
+		}
+	}
+	committed.HomePlans = append([]models.HomePlan(nil), catalog.HomePlans...)
+	for i := range committed.HomePlans {
+		if term, ok := FindTerm(committed.HomePlans[i].Terms, months); ok {
+			committed.HomePlans[i].MonthlyPrice = term.MonthlyPrice
+		}
+	}
+	committed.TVPlans = append([]models.TVPlan(nil), catalog.TVPlans...)
+	for i := range committed.TVPlans {
+		if term, ok := FindTerm(committed.TVPlans[i].Terms, months); ok {
+			committed.TVPlans[i].MonthlyPrice = term.MonthlyPrice
+		}
+	}
+
+	return &committed
+}
+
+// CalcTerminationFee returns the fee for terminating an offer committed for months before
+// the commitment ends: the sum of the termination fees of its plans offered with that
+// commitment. Plans without it carry no fee.
+func CalcTerminationFee(mobile []models.MobilePlan, home *models.HomePlan, tv *models.TVPlan, months int) float64 {
+	if months == 0 {
+		return 0
+	}
+
+	fee := 0.0
+	for _, plan := range mobile {
+		if term, ok := FindTerm(plan.Terms, months); ok {
+			fee += term.TerminationFee
+		}
+	}
+	if home != nil {
+		if term, ok := FindTerm(home.Terms, months); ok {
+			fee += term.TerminationFee
+		}
+	}
+	if tv != nil {
+		if term, ok := FindTerm(tv.Terms, months); ok {
+			fee += term.TerminationFee
+		}
+	}
+
+	return fee
+}
+
+// CalcContractValue returns what an offer costs over a commitment of months: monthlyTotal,
+// after the promotions that do not end, every month, less the time-limited promotions
+// for the months of the commitment they run. Without commitment the contract is the
+// first month.
+func CalcContractValue(monthlyTotal float64, promotions PromotionResult, months int) float64 {
+	if months == 0 {
+		months = 1
+	}
+
+	value := monthlyTotal * float64(months)
+	for _, applied := range promotions.Applied {
+		if applied.Months != 0 {
+			value -= applied.Amount * float64(min(applied.Months, months))
+		}
+	}
+
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"app/internal/models"
+)
+
+// commitmentCatalog offers the fiber plan for 12 and 24 months and the TV plan for 12
+func commitmentCatalog() *models.Catalog {
+	return &models.Catalog{
+		MobilePlans: []models.MobilePlan{{PlanID: 1, MonthlyPrice: 100}},
+		HomePlans: []models.HomePlan{{HomeID: 1, Tech: "fiber", MonthlyPrice: 120, Terms: []models.PlanTerm{
+			{Months: 24, MonthlyPrice: 90, TerminationFee: 300},
+			{Months: 12, MonthlyPrice: 100, TerminationFee: 150},
+		}}},
+		TVPlans: []models.TVPlan{{TVID: 1, MonthlyPrice: 50, Terms: []models.PlanTerm{
+			{Months: 12, MonthlyPrice: 40, TerminationFee: 60},
+		}}},
+	}
+}
+
+func TestCommitmentLengths(t *testing.T) {
+	tests := []struct {
+		maxMonths int
+		expected  []int
+	}{
+		{0, []int{0}},
+		{12, []int{0, 12}},
+		{18, []int{0, 12}},
+		{24, []int{0, 12, 24}},
+	}
+
+	for _, tt := range tests {
+		lengths := CommitmentLengths(commitmentCatalog(), tt.maxMonths)
+		if len(lengths) != len(tt.expected) {
+			t.Errorf("max %d: expected %v, got %v", tt.maxMonths, tt.expected, lengths)
+			continue
+		}
+		for i := range lengths {
+			if lengths[i] != tt.expected[i] {
+				t.Errorf("max %d: expected %v, got %v", tt.maxMonths, tt.expected, lengths)
+				break
+			}
+		}
+	}
+}
+
+func TestCatalogAtCommitment(t *testing.T) {
+	catalog := commitmentCatalog()
+
+	committed := CatalogAtCommitment(catalog, 24)
+	if committed.HomePlans[0].MonthlyPrice != 90 {
+		t.Errorf("Expected the 24-month fiber price, got %.2f", committed.HomePlans[0].MonthlyPrice)
+	}
+	// Plans without the commitment keep their price without commitment
+	if committed.TVPlans[0].MonthlyPrice != 50 || committed.MobilePlans[0].MonthlyPrice != 100 {
+		t.Errorf("Expected plans without a 24-month term unchanged, got TV %.2f and mobile %.2f",
+			committed.TVPlans[0].MonthlyPrice, committed.MobilePlans[0].MonthlyPrice)
+	}
+	if catalog.HomePlans[0].MonthlyPrice != 120 {
+		t.Errorf("Expected the catalog itself unchanged, got %.2f", catalog.HomePlans[0].MonthlyPrice)
+	}
+	if CatalogAtCommitment(catalog, 0) != catalog {
+		t.Error("Expected no commitment to return the catalog")
+	}
+}
+
+func TestCalcTerminationFee(t *testing.T) {
+	catalog := commitmentCatalog()
+	home, tv := &catalog.HomePlans[0], &catalog.TVPlans[0]
+
+	tests := []struct {
+		name     string
+		months   int
+		tv       *models.TVPlan
+		expected float64
+	}{
+		{"no commitment", 0, tv, 0},
+		{"12 months", 12, tv, 210},
+		{"24 months, TV not offered for it", 24, tv, 300},
+		{"12 months without TV", 12, nil, 150},
+	}
+
+	for _, tt := range tests {
+		if fee := CalcTerminationFee(catalog.MobilePlans, home, tt.tv, tt.months); fee != tt.expected {
+			t.Errorf("%s: expected %.2f, got %.2f", tt.name, tt.expected, fee)
+		}
+	}
+}
+
+func TestCalcContractValue(t *testing.T) {
+	// 20 off every month is already in the monthly total; 50 off the first 3 months is not
+	promotions := PromotionResult{Applied: []AppliedPromotion{
+		{Amount: 20},
+		{Amount: 50, Months: 3},
+	}}
+
+	tests := []struct {
+		name     string
+		months   int
+		expected float64
+	}{
+		{"no commitment is the first month", 0, 150},
+		{"12 months", 12, 12*200 - 3*50},
+		{"promotion longer than the commitment", 2, 2*200 - 2*50},
+	}
+
+	for _, tt := range tests {
+		if value := CalcContractValue(200, promotions, tt.months); math.Abs(value-tt.expected) > 0.001 {
+			t.Errorf("%s: expected %.2f, got %.2f", tt.name, tt.expected, value)
+		}
+	}
+}
@@ -1,67 +1,329 @@
 package utils
 
 import (
+	"flag"
 	"fmt"
+	"net/mail"
+	"net/url"
 	"os"
-	"strconv"
+	"time"
+
+	"app/internal/logging"
+)
+
+// Deployment environments
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
 )
 
-// Config holds all configuration for our application
+// PaymentProviderFake is the in-memory payment provider for local development
+const PaymentProviderFake = "fake"
+
+// Config holds all configuration for our application. Every setting has a default, and
+// can be set in the YAML config file under its yaml key, by its environment variable and
+// by its command-line flag (the yaml key with dashes, e.g. --shutdown-timeout), each
+// overriding the one before. Secrets are redacted by Redacted.
 type Config struct {
-	Port               string
-	DatabaseURL        string
-	SupabaseURL        string
-	SupabaseAnonKey    string
-	SupabaseServiceKey string
+	// HTTP server
+	Port                int           `yaml:"port" env:"PORT" default:"8000" usage:"HTTP port"`
+	CORSOrigins         []string      `yaml:"cors_origins" env:"CORS_ORIGINS" default:"http://localhost:3000,https://localhost:3000" usage:"origins allowed to call the API from a browser, comma-separated"`
+	ShutdownTimeout     time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"10s" usage:"how long in-flight requests and jobs get to finish on shutdown"`
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" env:"HEALTH_CHECK_INTERVAL" default:"5s" usage:"how often readiness probes check dependencies; probes in between reuse the last result"`
+	Environment         string        `yaml:"environment" env:"APP_ENV" default:"production" usage:"deployment environment: development or production"`
+
+	// Supabase REST API
+	SupabaseURL          string        `yaml:"supabase_url" env:"SUPABASE_URL" usage:"Supabase project URL"`
+	SupabaseAnonKey      string        `yaml:"supabase_anon_key" env:"SUPABASE_ANON_KEY" secret:"true" usage:"Supabase anon key"`
+	SupabaseServiceKey   string        `yaml:"supabase_service_role_key" env:"SUPABASE_SERVICE_ROLE_KEY" secret:"true" usage:"Supabase service role key"`
+	SupabaseTimeout      time.Duration `yaml:"supabase_timeout" env:"SUPABASE_TIMEOUT" default:"30s" usage:"timeout of Supabase API requests"`
+	SupabaseMaxIdleConns int           `yaml:"supabase_max_idle_conns" env:"SUPABASE_MAX_IDLE_CONNS" default:"10" usage:"idle connections kept open to the Supabase API"`
+
+	// Direct database connection, used for scheduler leader election
+	DatabaseURL             string        `yaml:"database_url" env:"DATABASE_URL" secret:"true" usage:"PostgreSQL connection string"`
+	DatabaseMaxConns        int           `yaml:"database_max_conns" env:"DATABASE_MAX_CONNS" default:"10" usage:"maximum database connections"`
+	DatabaseMinConns        int           `yaml:"database_min_conns" env:"DATABASE_MIN_CONNS" default:"2" usage:"database connections kept open"`
+	DatabaseMaxConnLifetime time.Duration `yaml:"database_max_conn_lifetime" env:"DATABASE_MAX_CONN_LIFETIME" default:"1h" usage:"age after which a database connection is replaced"`
+	DatabaseMaxConnIdleTime time.Duration `yaml:"database_max_conn_idle_time" env:"DATABASE_MAX_CONN_IDLE_TIME" default:"30m" usage:"idle time after which a database connection is closed"`
+
+	// Logging
+	LogLevel         string   `yaml:"log_level" env:"LOG_LEVEL" default:"info" usage:"minimum level logged: debug, info, warn or error"`
+	LogPackageLevels []string `yaml:"log_package_levels" env:"LOG_PACKAGE_LEVELS" usage:"per-package log levels, comma-separated, e.g. db=debug,scheduler=warn"`
+
+	// Rate limits: requests per minute and burst per client (API key or IP), or per user
+	// for recommendation_user; 0 requests per minute disables a limit
+	RateLimitStore                   string `yaml:"rate_limit_store" env:"RATE_LIMIT_STORE" default:"memory" usage:"where rate limit buckets are kept: memory (per replica) or postgres (shared)"`
+	RateLimitRecommendation          int    `yaml:"rate_limit_recommendation" env:"RATE_LIMIT_RECOMMENDATION" default:"30" usage:"recommendation requests per minute per client"`
+	RateLimitRecommendationBurst     int    `yaml:"rate_limit_recommendation_burst" env:"RATE_LIMIT_RECOMMENDATION_BURST" default:"10" usage:"recommendation requests a client can make at once"`
+	RateLimitRecommendationUser      int    `yaml:"rate_limit_recommendation_user" env:"RATE_LIMIT_RECOMMENDATION_USER" default:"10" usage:"recommendation requests per minute per user_id"`
+	RateLimitRecommendationUserBurst int    `yaml:"rate_limit_recommendation_user_burst" env:"RATE_LIMIT_RECOMMENDATION_USER_BURST" default:"5" usage:"recommendation requests for one user_id at once"`
+	RateLimitCheckout                int    `yaml:"rate_limit_checkout" env:"RATE_LIMIT_CHECKOUT" default:"20" usage:"checkout and order requests per minute per client"`
+	RateLimitCheckoutBurst           int    `yaml:"rate_limit_checkout_burst" env:"RATE_LIMIT_CHECKOUT_BURST" default:"5" usage:"checkout and order requests a client can make at once"`
+	RateLimitAPI                     int    `yaml:"rate_limit_api" env:"RATE_LIMIT_API" default:"300" usage:"requests per minute per client to the other API routes"`
+	RateLimitAPIBurst                int    `yaml:"rate_limit_api_burst" env:"RATE_LIMIT_API_BURST" default:"60" usage:"requests a client can make at once to the other API routes"`
+
+	// Tracing
+	TracingExporter     string  `yaml:"tracing_exporter" env:"TRACING_EXPORTER" default:"none" usage:"where spans are sent: none, stdout or otlp"`
+	TracingOTLPEndpoint string  `yaml:"tracing_otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" default:"localhost:4318" usage:"host:port of the OTLP/HTTP collector"`
+	TracingSampleRatio  float64 `yaml:"tracing_sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1" usage:"share of new traces recorded, 0 to 1"`
+
+	// Install slots, orders and quotes
+	SlotHorizonDays    int           `yaml:"slot_horizon_days" env:"SLOT_HORIZON_DAYS" default:"14" usage:"days ahead install slots are generated"`
+	RescheduleCutoff   time.Duration `yaml:"reschedule_cutoff" env:"RESCHEDULE_CUTOFF" default:"24h" usage:"latest time before the installation an appointment can change"`
+	PendingOrderTTL    time.Duration `yaml:"pending_order_ttl" env:"PENDING_ORDER_TTL" default:"15m" usage:"how long a pending order holds its install slot"`
+	SweepInterval      time.Duration `yaml:"sweep_interval" env:"SWEEP_INTERVAL" default:"1m" usage:"how often expired holds are swept"`
+	SlotRegenInterval  time.Duration `yaml:"slot_regeneration_interval" env:"SLOT_REGENERATION_INTERVAL" default:"1h" usage:"how often future install slots are regenerated"`
+	PurgeInterval      time.Duration `yaml:"purge_interval" env:"PURGE_INTERVAL" default:"1h" usage:"how often expired Idempotency-Key responses, quotes and rate limit buckets are deleted"`
+	IdempotencyKeyTTL  time.Duration `yaml:"idempotency_key_ttl" env:"IDEMPOTENCY_KEY_TTL" default:"24h" usage:"how long checkout Idempotency-Key responses are replayed"`
+	QuoteSigningSecret string        `yaml:"quote_signing_secret" env:"QUOTE_SIGNING_SECRET" secret:"true" usage:"HMAC key for recommendation quote IDs, at least 32 characters"`
+	QuoteTTL           time.Duration `yaml:"quote_ttl" env:"QUOTE_TTL" default:"30m" usage:"how long a recommendation quote can be checked out"`
+	CatalogCacheTTL    time.Duration `yaml:"catalog_cache_ttl" env:"CATALOG_CACHE_TTL" default:"30s" usage:"how long recommendations reuse the plan catalog, 0 to always reload it"`
+
+	// Payments
+	PaymentProvider          string        `yaml:"payment_provider" env:"PAYMENT_PROVIDER" default:"fake" usage:"payment gateway taking upfront payments: fake (in memory, development only)"`
+	PaymentReconcileInterval time.Duration `yaml:"payment_reconcile_interval" env:"PAYMENT_RECONCILE_INTERVAL" default:"5m" usage:"how often timed out, uncaptured and unrefunded payments are settled with the provider"`
+
+	// Notifications; email is enabled by SMTPHost and SMS by SMSGatewayURL
+	SMTPHost             string        `yaml:"smtp_host" env:"SMTP_HOST" usage:"SMTP server, enables email notifications"`
+	SMTPPort             int           `yaml:"smtp_port" env:"SMTP_PORT" default:"587" usage:"SMTP port"`
+	SMTPUsername         string        `yaml:"smtp_username" env:"SMTP_USERNAME" usage:"SMTP user"`
+	SMTPPassword         string        `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true" usage:"SMTP password"`
+	SMTPFrom             string        `yaml:"smtp_from" env:"SMTP_FROM" default:"Turkcell <no-reply@turkcell.com.tr>" usage:"sender address of emails"`
+	SMSGatewayURL        string        `yaml:"sms_gateway_url" env:"SMS_GATEWAY_URL" usage:"SMS gateway endpoint, enables SMS notifications"`
+	SMSGatewayToken      string        `yaml:"sms_gateway_token" env:"SMS_GATEWAY_TOKEN" secret:"true" usage:"bearer token for the SMS gateway"`
+	SMSSender            string        `yaml:"sms_sender" env:"SMS_SENDER" default:"TURKCELL" usage:"SMS sender ID"`
+	NotificationInterval time.Duration `yaml:"notification_interval" env:"NOTIFICATION_INTERVAL" default:"30s" usage:"how often queued notifications are sent"`
+	ReminderLead         time.Duration `yaml:"reminder_lead" env:"REMINDER_LEAD" default:"24h" usage:"how long before the installation the reminder is sent"`
+	ReminderInterval     time.Duration `yaml:"reminder_interval" env:"REMINDER_INTERVAL" default:"5m" usage:"how often reminders are queued for appointments entering the reminder lead"`
+
+	// Domain events; the admin endpoints are enabled by AdminToken
+	EventDispatchInterval time.Duration `yaml:"event_dispatch_interval" env:"EVENT_DISPATCH_INTERVAL" default:"5s" usage:"how often pending order events are dispatched"`
+	EventMaxAttempts      int           `yaml:"event_max_attempts" env:"EVENT_MAX_ATTEMPTS" default:"8" usage:"attempts before an event is dead-lettered"`
+	AdminToken            string        `yaml:"admin_token" env:"ADMIN_TOKEN" secret:"true" usage:"bearer token enabling the admin endpoints, at least 32 characters"`
+
+	// Partner webhooks
+	WebhookDeliveryInterval time.Duration `yaml:"webhook_delivery_interval" env:"WEBHOOK_DELIVERY_INTERVAL" default:"10s" usage:"how often queued partner webhooks are sent"`
+
+	// Partner API keys
+	APIKeyRotationGrace time.Duration `yaml:"api_key_rotation_grace" env:"API_KEY_ROTATION_GRACE" default:"24h" usage:"how long a rotated API key keeps working next to its replacement"`
+	APIKeyRequired      bool          `yaml:"api_key_required" env:"API_KEY_REQUIRED" default:"false" usage:"reject /api requests without an X-API-Key header instead of serving them anonymously"`
 }
 
-// LoadConfig loads configuration from environment variables
-func LoadConfig() (*Config, error) {
-	config := &Config{
-		Port:               getEnvWithDefault("PORT", "8000"),
-		DatabaseURL:        os.Getenv("DATABASE_URL"),
-		SupabaseURL:        os.Getenv("SUPABASE_URL"),
-		SupabaseAnonKey:    os.Getenv("SUPABASE_ANON_KEY"),
-		SupabaseServiceKey: os.Getenv("SUPABASE_SERVICE_ROLE_KEY"),
+// LoadConfig loads the configuration from its defaults, the YAML file given by --config
+// or CONFIG_FILE, environment variables and the flags in args, in that order. The
+// setting flags and --config are registered on fs, so callers can add flags of their
+// own before. Every invalid or missing setting is reported in one *ConfigError.
+func LoadConfig(fs *flag.FlagSet, args []string) (*Config, error) {
+	config := &Config{}
+	settings := config.settings()
+
+	configFile := fs.String("config", "", "YAML config file (env CONFIG_FILE)")
+	flags := registerFlags(fs, settings)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	problems = append(problems, applyDefaults(settings)...)
+
+	path := *configFile
+	if path == "" {
+		path = getEnvWithDefault("CONFIG_FILE", "")
+	}
+	if path != "" {
+		fileProblems, err := loadFile(config, path)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, fileProblems...)
 	}
 
+	problems = append(problems, applyEnv(settings)...)
+	problems = append(problems, flags.apply()...)
+
 	// Validate required configuration
-	if err := config.validate(); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
 	}
 
 	return config, nil
 }
 
-// validate checks that all required configuration is present
-func (c *Config) validate() error {
-	var missingVars []string
+// validate checks that all required configuration is present and every setting is in
+// range, returning one problem per invalid setting
+func (c *Config) validate() []string {
+	var problems []string
+	invalid := func(key, reason string) {
+		problems = append(problems, c.describe(key)+": "+reason)
+	}
 
 	if c.SupabaseURL == "" {
-		missingVars = append(missingVars, "SUPABASE_URL")
+		invalid("supabase_url", "is required")
 	}
 	if c.SupabaseAnonKey == "" {
-		missingVars = append(missingVars, "SUPABASE_ANON_KEY")
+		invalid("supabase_anon_key", "is required")
 	}
 	if c.SupabaseServiceKey == "" {
-		missingVars = append(missingVars, "SUPABASE_SERVICE_ROLE_KEY")
+		invalid("supabase_service_role_key", "is required")
+	}
+	if len(c.QuoteSigningSecret) < 32 {
+		invalid("quote_signing_secret", "must be at least 32 characters")
+	}
+
+	// Validate the server settings
+	if c.Port <= 0 || c.Port > 65535 {
+		invalid("port", "must be a valid port number")
+	}
+	for _, origin := range c.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			invalid("cors_origins", fmt.Sprintf("%q must be * or an origin such as https://example.com", origin))
+		}
 	}
 
-	// Validate port is a valid number
-	if _, err := strconv.Atoi(c.Port); err != nil {
-		missingVars = append(missingVars, "PORT (must be a valid number)")
+	// Validate the environment
+	switch c.Environment {
+	case EnvironmentDevelopment, EnvironmentProduction:
+	default:
+		invalid("environment", "must be development or production")
+	}
+
+	// Validate the payment provider; the fake keeps payments in the memory of one replica
+	// and loses them on restart, so it cannot take real payments
+	switch c.PaymentProvider {
+	case PaymentProviderFake:
+		if c.Environment != EnvironmentDevelopment {
+			invalid("payment_provider", "fake is only allowed with environment development")
+		}
+	default:
+		invalid("payment_provider", "must be fake")
+	}
+
+	// Validate connection pools
+	if c.SupabaseMaxIdleConns < 1 {
+		invalid("supabase_max_idle_conns", "must be a positive number")
+	}
+	if c.DatabaseMaxConns < 1 {
+		invalid("database_max_conns", "must be a positive number")
+	}
+	if c.DatabaseMinConns < 0 || c.DatabaseMinConns > c.DatabaseMaxConns {
+		invalid("database_min_conns", "must be between 0 and database_max_conns")
+	}
+
+	// Validate log levels
+	if err := (logging.Config{Level: c.LogLevel}).Validate(); err != nil {
+		invalid("log_level", "must be debug, info, warn or error")
+	}
+	for _, entry := range c.LogPackageLevels {
+		if err := (logging.Config{Level: "info", PackageLevels: []string{entry}}).Validate(); err != nil {
+			invalid("log_package_levels", fmt.Sprintf("%q must be package=level such as db=debug", entry))
+		}
+	}
+
+	// Validate rate limits
+	switch c.RateLimitStore {
+	case "memory", "postgres":
+	default:
+		invalid("rate_limit_store", "must be memory or postgres")
+	}
+	for _, limit := range []struct {
+		key, burstKey    string
+		perMinute, burst int
+	}{
+		{"rate_limit_recommendation", "rate_limit_recommendation_burst", c.RateLimitRecommendation, c.RateLimitRecommendationBurst},
+		{"rate_limit_recommendation_user", "rate_limit_recommendation_user_burst", c.RateLimitRecommendationUser, c.RateLimitRecommendationUserBurst},
+		{"rate_limit_checkout", "rate_limit_checkout_burst", c.RateLimitCheckout, c.RateLimitCheckoutBurst},
+		{"rate_limit_api", "rate_limit_api_burst", c.RateLimitAPI, c.RateLimitAPIBurst},
+	} {
+		if limit.perMinute < 0 {
+			invalid(limit.key, "must be a non-negative number")
+		}
+		if limit.perMinute > 0 && limit.burst < 1 {
+			invalid(limit.burstKey, "must be a positive number")
+		}
+	}
+
+	// Validate tracing settings
+	switch c.TracingExporter {
+	case "none", "stdout", "otlp":
+	default:
+		invalid("tracing_exporter", "must be none, stdout or otlp")
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		invalid("tracing_sample_ratio", "must be between 0 and 1")
+	}
+
+	// Validate SMTP settings when email is enabled
+	if c.SMTPHost != "" {
+		if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+			invalid("smtp_port", "must be a valid port number")
+		}
+		if _, err := mail.ParseAddress(c.SMTPFrom); err != nil {
+			invalid("smtp_from", "must be an email address such as Turkcell <no-reply@turkcell.com.tr>")
+		}
+	}
+
+	// Validate the admin token is hard to guess when the admin endpoints are enabled
+	if c.AdminToken != "" && len(c.AdminToken) < 32 {
+		invalid("admin_token", "must be at least 32 characters")
+	}
+
+	// Validate events are attempted at least once
+	if c.EventMaxAttempts < 1 {
+		invalid("event_max_attempts", "must be a positive number")
+	}
+
+	// Validate slot horizon is a non-negative number of days
+	if c.SlotHorizonDays < 0 {
+		invalid("slot_horizon_days", "must be a non-negative number")
+	}
+
+	// Validate reschedule cutoff and catalog cache TTL are non-negative durations
+	if c.RescheduleCutoff < 0 {
+		invalid("reschedule_cutoff", "must be a non-negative duration such as 24h")
+	}
+	if c.CatalogCacheTTL < 0 {
+		invalid("catalog_cache_ttl", "must be a non-negative duration such as 30s")
 	}
 
-	if len(missingVars) > 0 {
-		return fmt.Errorf("missing or invalid environment variables: %v", missingVars)
+	// Validate timeouts and background job timings are positive durations
+	for _, v := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"shutdown_timeout", c.ShutdownTimeout},
+		{"health_check_interval", c.HealthCheckInterval},
+		{"supabase_timeout", c.SupabaseTimeout},
+		{"database_max_conn_lifetime", c.DatabaseMaxConnLifetime},
+		{"database_max_conn_idle_time", c.DatabaseMaxConnIdleTime},
+		{"pending_order_ttl", c.PendingOrderTTL},
+		{"sweep_interval", c.SweepInterval},
+		{"slot_regeneration_interval", c.SlotRegenInterval},
+		{"purge_interval", c.PurgeInterval},
+		{"idempotency_key_ttl", c.IdempotencyKeyTTL},
+		{"quote_ttl", c.QuoteTTL},
+		{"payment_reconcile_interval", c.PaymentReconcileInterval},
+		{"notification_interval", c.NotificationInterval},
+		{"reminder_lead", c.ReminderLead},
+		{"reminder_interval", c.ReminderInterval},
+		{"event_dispatch_interval", c.EventDispatchInterval},
+		{"webhook_delivery_interval", c.WebhookDeliveryInterval},
+		{"api_key_rotation_grace", c.APIKeyRotationGrace},
+	} {
+		if v.value <= 0 {
+			invalid(v.key, "must be a positive duration such as 15m")
+		}
 	}
 
-	return nil
+	return problems
 }
 
 // GetAddr returns the server address with port
 func (c *Config) GetAddr() string {
-	return ":" + c.Port
+	return fmt.Sprintf(":%d", c.Port)
 }
 
 // getEnvWithDefault returns environment variable value or default if not set
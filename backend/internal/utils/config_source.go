@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in Redacted
+const redacted = "[REDACTED]"
+
+// ConfigError lists every problem found while loading the configuration
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// setting is one configuration field with the names it is set by
+type setting struct {
+	key    string // YAML key
+	env    string
+	flag   string
+	def    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// name identifies the setting in error messages, e.g. port (PORT, --port)
+func (s setting) name() string {
+	return fmt.Sprintf("%s (%s, --%s)", s.key, s.env, s.flag)
+}
+
+// settings returns the settings of c, in field order
+func (c *Config) settings() []setting {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	settings := make([]setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("yaml")
+		settings = append(settings, setting{
+			key:    key,
+			env:    field.Tag.Get("env"),
+			flag:   strings.ReplaceAll(key, "_", "-"),
+			def:    field.Tag.Get("default"),
+			usage:  field.Tag.Get("usage"),
+			secret: field.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return settings
+}
+
+// describe returns the name of the setting with the YAML key for error messages
+func (c *Config) describe(key string) string {
+	for _, s := range c.settings() {
+		if s.key == key {
+			return s.name()
+		}
+	}
+	return key
+}
+
+// set parses raw into the setting. Lists are comma-separated.
+func (s setting) set(raw string) error {
+	switch s.value.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 15m", raw)
+		}
+		s.value.SetInt(int64(d))
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		s.value.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		s.value.SetFloat(f)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		s.value.SetBool(b)
+	case string:
+		s.value.SetString(raw)
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// applyDefaults sets every setting to its default
+func applyDefaults(settings []setting) []string {
+	var problems []string
+	for _, s := range settings {
+		if s.def == "" {
+			continue
+		}
+		if err := s.set(s.def); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid default: %v", s.name(), err))
+		}
+	}
+	return problems
+}
+
+// loadFile overrides settings with those in the YAML file at path. Unknown keys and
+// values of the wrong type are returned as problems.
+func loadFile(config *Config, path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(config)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		problems := make([]string, len(typeErr.Errors))
+		for i, e := range typeErr.Errors {
+			problems[i] = path + ": " + e
+		}
+		return problems, nil
+	}
+	return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+}
+
+// applyEnv overrides settings with the environment variables that are set
+func applyEnv(settings []setting) []string {
+	var problems []string
+	for _, s := range settings {
+		raw := os.Getenv(s.env)
+		if raw == "" {
+			continue
+		}
+		if err := s.set(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.name(), err))
+		}
+	}
+	return problems
+}
+
+// flagValues holds the setting flags given on the command line until they are applied
+// over the file and environment
+type flagValues struct {
+	settings []setting
+	raw      map[string]string
+}
+
+// flagValue records a setting flag
+type flagValue struct {
+	values  *flagValues
+	key     string
+	boolean bool // set without a value, as --api-key-required
+}
+
+func (f *flagValue) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return f.values.raw[f.key]
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.values.raw[f.key] = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.boolean
+}
+
+// registerFlags registers a flag for every setting on fs
+func registerFlags(fs *flag.FlagSet, settings []setting) *flagValues {
+	values := &flagValues{settings: settings, raw: make(map[string]string)}
+	for _, s := range settings {
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		if s.def != "" {
+			usage += fmt.Sprintf(" (default %s)", s.def)
+		}
+		boolean := s.value.Kind() == reflect.Bool
+		fs.Var(&flagValue{values: values, key: s.key, boolean: boolean}, s.flag, usage)
+	}
+	return values
+}
+
+// apply overrides settings with the flags given on the command line
+func (f *flagValues) apply() []string {
+	var problems []string
+	for _, s := range f.settings {
+		raw, ok := f.raw[s.key]
+		if !ok {
+			continue
+		}
+		if err := s.set(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.name(), err))
+		}
+	}
+	return problems
+}
+
+// Redacted returns the configuration as YAML, in the format of the config file, with
+// every secret that is set replaced by [REDACTED]
+func (c *Config) Redacted() ([]byte, error) {
+	copied := *c
+	for _, s := range copied.settings() {
+		if s.secret && s.value.String() != "" {
+			s.value.SetString(redacted)
+		}
+	}
+
+	// Durations are written as strings such as 15m0s so the output can be loaded again
+	var doc yaml.Node
+	if err := doc.Encode(&copied); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	for i := 1; i < len(doc.Content); i += 2 {
+		key, value := doc.Content[i-1], doc.Content[i]
+		for _, s := range copied.settings() {
+			if s.key == key.Value {
+				if d, ok := s.value.Interface().(time.Duration); ok {
+					value.Tag, value.Value = "!!str", d.String()
+				}
+				if s.usage != "" {
+					key.HeadComment = s.usage
+				}
+			}
+		}
+	}
+
+	return yaml.Marshal(&doc)
+}
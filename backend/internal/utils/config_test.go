@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// requiredEnv sets the settings without defaults that must be present
+func requiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("SUPABASE_URL", "https://example.supabase.co")
+	t.Setenv("SUPABASE_ANON_KEY", "anon-key")
+	t.Setenv("SUPABASE_SERVICE_ROLE_KEY", "service-key")
+	t.Setenv("QUOTE_SIGNING_SECRET", strings.Repeat("q", 32))
+	t.Setenv("APP_ENV", "development")
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func loadConfig(args ...string) (*Config, error) {
+	return LoadConfig(flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	requiredEnv(t)
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Port != 8000 || config.GetAddr() != ":8000" {
+		t.Errorf("Expected port 8000, got %d", config.Port)
+	}
+	if !reflect.DeepEqual(config.CORSOrigins, []string{"http://localhost:3000", "https://localhost:3000"}) {
+		t.Errorf("Expected the local frontend origins, got %v", config.CORSOrigins)
+	}
+	if config.ShutdownTimeout != 10*time.Second || config.SupabaseTimeout != 30*time.Second {
+		t.Errorf("Expected 10s shutdown and 30s Supabase timeouts, got %v and %v", config.ShutdownTimeout, config.SupabaseTimeout)
+	}
+	if config.DatabaseMaxConns != 10 || config.DatabaseMinConns != 2 {
+		t.Errorf("Expected a pool of 2 to 10 connections, got %d to %d", config.DatabaseMinConns, config.DatabaseMaxConns)
+	}
+	if config.SlotRegenInterval != time.Hour || config.PurgeInterval != time.Hour {
+		t.Errorf("Expected hourly slot regeneration and purges, got %v and %v", config.SlotRegenInterval, config.PurgeInterval)
+	}
+	if config.QuoteTTL != 30*time.Minute || config.EventMaxAttempts != 8 || config.SMTPPort != 587 {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	requiredEnv(t)
+	path := writeConfigFile(t, `
+port: 9000
+shutdown_timeout: 20s
+quote_ttl: 45m
+cors_origins:
+  - https://shop.example.com
+database_max_conns: 20
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("SHUTDOWN_TIMEOUT", "30s")
+	t.Setenv("QUOTE_TTL", "1h")
+
+	config, err := loadConfig("--quote-ttl", "2h", "--cors-origins", "https://a.example.com, https://b.example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// File over defaults, environment over file, flags over environment
+	if config.Port != 9000 || config.DatabaseMaxConns != 20 {
+		t.Errorf("Expected the file to override defaults, got port %d and %d connections", config.Port, config.DatabaseMaxConns)
+	}
+	if config.ShutdownTimeout != 30*time.Second {
+		t.Errorf("Expected the environment to override the file, got %v", config.ShutdownTimeout)
+	}
+	if config.QuoteTTL != 2*time.Hour {
+		t.Errorf("Expected the flag to override the environment, got %v", config.QuoteTTL)
+	}
+	if !reflect.DeepEqual(config.CORSOrigins, []string{"https://a.example.com", "https://b.example.com"}) {
+		t.Errorf("Expected the flag's origins, got %v", config.CORSOrigins)
+	}
+	if config.SweepInterval != time.Minute {
+		t.Errorf("Expected settings set nowhere to keep their default, got %v", config.SweepInterval)
+	}
+}
+
+func TestLoadConfigBoolSettings(t *testing.T) {
+	requiredEnv(t)
+
+	config, err := loadConfig()
+	if err != nil || config.APIKeyRequired {
+		t.Fatalf("Expected API keys to be optional by default, got %v (%v)", config, err)
+	}
+
+	// A bool flag needs no value
+	if config, err = loadConfig("--api-key-required"); err != nil || !config.APIKeyRequired {
+		t.Errorf("Expected --api-key-required to require API keys, got %v", err)
+	}
+
+	t.Setenv("API_KEY_REQUIRED", "sometimes")
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), `"sometimes" is not true or false`) {
+		t.Errorf("Expected an invalid bool to be rejected, got %v", err)
+	}
+}
+
+func TestLoadConfigAggregatesErrors(t *testing.T) {
+	t.Setenv("SUPABASE_ANON_KEY", "anon-key")
+	t.Setenv("SUPABASE_SERVICE_ROLE_KEY", "service-key")
+	t.Setenv("QUOTE_SIGNING_SECRET", "short")
+	t.Setenv("SWEEP_INTERVAL", "soon")
+	path := writeConfigFile(t, "port: high\ndatabase_min_conns: 50\n")
+
+	_, err := loadConfig("--config", path, "--cors-origins", "localhost:3000")
+
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a ConfigError, got %v", err)
+	}
+	for _, expected := range []string{
+		"cannot unmarshal !!str `high` into int",
+		`sweep_interval (SWEEP_INTERVAL, --sweep-interval): "soon" is not a duration`,
+		"supabase_url (SUPABASE_URL, --supabase-url): is required",
+		"quote_signing_secret (QUOTE_SIGNING_SECRET, --quote-signing-secret): must be at least 32 characters",
+		`cors_origins (CORS_ORIGINS, --cors-origins): "localhost:3000" must be * or an origin`,
+		"database_min_conns (DATABASE_MIN_CONNS, --database-min-conns): must be between 0 and database_max_conns",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadConfigRejectsFakePaymentsOutsideDevelopment(t *testing.T) {
+	requiredEnv(t)
+	t.Setenv("APP_ENV", "production")
+
+	_, err := loadConfig()
+	if err == nil || !strings.Contains(err.Error(), "payment_provider (PAYMENT_PROVIDER, --payment-provider): fake is only allowed with environment development") {
+		t.Errorf("Expected the fake payment provider to be rejected in production, got %v", err)
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	requiredEnv(t)
+	path := writeConfigFile(t, "prot: 9000\n")
+
+	if _, err := loadConfig("--config", path); err == nil || !strings.Contains(err.Error(), "field prot not found") {
+		t.Errorf("Expected the misspelt key to be rejected, got %v", err)
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	requiredEnv(t)
+	t.Setenv("ADMIN_TOKEN", strings.Repeat("a", 32))
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out, err := config.Redacted()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, secret := range []string{"anon-key", "service-key", config.QuoteSigningSecret, config.AdminToken} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("Expected %q to be redacted in:\n%s", secret, out)
+		}
+	}
+	if config.AdminToken == redacted {
+		t.Error("Expected redacting not to change the config")
+	}
+
+	// The output is a valid config file
+	var printed map[string]interface{}
+	if err := yaml.Unmarshal(out, &printed); err != nil {
+		t.Fatalf("Expected YAML, got %v:\n%s", err, out)
+	}
+	if printed["admin_token"] != redacted || printed["smtp_password"] != "" || printed["quote_ttl"] != "30m0s" {
+		t.Errorf("Unexpected printed values: admin_token=%v smtp_password=%v quote_ttl=%v",
+			printed["admin_token"], printed["smtp_password"], printed["quote_ttl"])
+	}
+}
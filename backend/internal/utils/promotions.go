@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"app/internal/models"
+)
+
+// promotionValueMonths is the period over which promotions that cannot be combined are
+// compared: a discount for every month is worth this many months of it
+const promotionValueMonths = 12
+
+// PromotionOffer is a priced offer as campaigns see it
+type PromotionOffer struct {
+	Breakdown     GrandTotalBreakdown // the price after line and bundle discounts
+	City          string              // the city of the installation address
+	Tech          string              // the home plan's technology, empty without home internet
+	MobilePlanIDs []int
+	HomePlanID    int // 0 without home internet
+	TVPlanID      int // 0 without TV
+	NewCustomer   bool
+	CouponCode    string    // the code the customer entered, if any
+	At            time.Time // when the offer is signed
+}
+
+// AppliedPromotion is a campaign applied to an offer: Amount off every month, or only
+// for the first Months months when Months is not 0
+type AppliedPromotion struct {
+	Campaign models.Campaign
+	Amount   float64
+	Months   int
+}
+
+// PromotionResult lists the promotions applied to an offer, with the discount every
+// month and the discount in the first month, which includes the time-limited ones
+type PromotionResult struct {
+	Applied            []AppliedPromotion
+	MonthlyDiscount    float64
+	FirstMonthDiscount float64
+}
+
+// CampaignEligible reports whether campaign applies to offer: it runs at offer.At, its
+// code was entered, the customer, address and plans match its eligibility lists, and the
+// offer has the component it discounts
+func CampaignEligible(campaign models.Campaign, offer PromotionOffer) bool {
+	if offer.At.Before(campaign.StartsAt) || (campaign.EndsAt != nil && !offer.At.Before(*campaign.EndsAt)) {
+		return false
+	}
+	if campaign.Code != nil && !strings.EqualFold(*campaign.Code, strings.TrimSpace(offer.CouponCode)) {
+		return false
+	}
+	if campaign.NewCustomers && !offer.NewCustomer {
+		return false
+	}
+	if len(campaign.Cities) > 0 && !slices.ContainsFunc(campaign.Cities, func(city string) bool { return strings.EqualFold(city, offer.City) }) {
+		return false
+	}
+	if len(campaign.Techs) > 0 && !slices.Contains(campaign.Techs, offer.Tech) {
+		return false
+	}
+	if len(campaign.MobilePlanIDs) > 0 && !slices.ContainsFunc(offer.MobilePlanIDs, func(id int) bool { return slices.Contains(campaign.MobilePlanIDs, id) }) {
+		return false
+	}
+	if len(campaign.HomePlanIDs) > 0 && !slices.Contains(campaign.HomePlanIDs, offer.HomePlanID) {
+		return false
+	}
+	if len(campaign.TVPlanIDs) > 0 && !slices.Contains(campaign.TVPlanIDs, offer.TVPlanID) {
+		return false
+	}
+
+	return promotionBase(offer.Breakdown)[campaign.AppliesTo] > 0
+}
+
+// ApplyPromotions applies the best combination of the campaigns eligible for offer. All
+// stackable campaigns combine; a campaign that is not stackable applies alone, when it
+// saves more over promotionValueMonths than the stackable ones together.
+func ApplyPromotions(campaigns []models.Campaign, offer PromotionOffer) PromotionResult {
+	var stackable []models.Campaign
+	var options [][]models.Campaign
+	for _, campaign := range campaigns {
+		if !CampaignEligible(campaign, offer) {
+			continue
+		}
+		if campaign.Stackable {
+			stackable = append(stackable, campaign)
+		} else {
+			options = append(options, []models.Campaign{campaign})
+		}
+	}
+	options = append([][]models.Campaign{stackable}, options...)
+
+	var best PromotionResult
+	bestValue := 0.0
+	for _, option := range options {
+		result := applyCampaigns(option, offer.Breakdown)
+		if value := result.value(); value > bestValue {
+			best, bestValue = result, value
+		}
+	}
+
+	return best
+}
+
+// applyCampaigns applies campaigns together, those discounting every month first. Each
+// discount is taken from what is left of its component after the ones before it, so
+// percentages compound and no component, nor the total, drops below zero in the first
+// month, when every promotion runs.
+func applyCampaigns(campaigns []models.Campaign, breakdown GrandTotalBreakdown) PromotionResult {
+	remaining := promotionBase(breakdown)
+	var result PromotionResult
+	for _, limited := range []bool{false, true} {
+		for _, campaign := range campaigns {
+			if timeLimited(campaign) != limited {
+				continue
+			}
+			applied, ok := applyCampaign(campaign, remaining)
+			if !ok {
+				continue
+			}
+
+			result.Applied = append(result.Applied, applied)
+			result.FirstMonthDiscount += applied.Amount
+			if applied.Months == 0 {
+				result.MonthlyDiscount += applied.Amount
+			}
+		}
+	}
+
+	return result
+}
+
+// applyCampaign takes a campaign's discount from remaining, what is left of each
+// component, and reports false when nothing is left to discount
+func applyCampaign(campaign models.Campaign, remaining map[string]float64) (AppliedPromotion, bool) {
+	left := math.Min(remaining[campaign.AppliesTo], remaining["total"])
+
+	applied := AppliedPromotion{Campaign: campaign}
+	if campaign.DurationMonths != nil {
+		applied.Months = *campaign.DurationMonths
+	}
+	switch campaign.DiscountType {
+	case models.DiscountPercent:
+		applied.Amount = remaining[campaign.AppliesTo] * campaign.DiscountValue / 100
+	case models.DiscountFixed:
+		applied.Amount = campaign.DiscountValue
+	case models.DiscountFreeMonths:
+		applied.Amount = left
+		applied.Months = int(campaign.DiscountValue)
+	}
+	applied.Amount = math.Round(math.Min(applied.Amount, left)*100) / 100
+	if applied.Amount <= 0 {
+		return AppliedPromotion{}, false
+	}
+
+	remaining[campaign.AppliesTo] -= applied.Amount
+	if campaign.AppliesTo != "total" {
+		remaining["total"] -= applied.Amount
+	}
+	return applied, true
+}
+
+// value is what the promotions save over promotionValueMonths
+func (r PromotionResult) value() float64 {
+	value := 0.0
+	for _, applied := range r.Applied {
+		months := promotionValueMonths
+		if applied.Months != 0 && applied.Months < months {
+			months = applied.Months
+		}
+		value += applied.Amount * float64(months)
+	}
+	return value
+}
+
+// promotionBase returns what each component of an offer costs after line and bundle
+// discounts, keyed like Campaign.AppliesTo
+func promotionBase(breakdown GrandTotalBreakdown) map[string]float64 {
+	rate := 1 - breakdown.BundleDiscountRate
+	return map[string]float64{
+		"mobile": breakdown.MobileTotal * rate,
+		"home":   breakdown.HomeTotal * rate,
+		"tv":     breakdown.TVTotal * rate,
+		"total":  breakdown.GrandTotal,
+	}
+}
+
+// timeLimited reports whether a campaign only discounts the first months
+func timeLimited(campaign models.Campaign) bool {
+	return campaign.DurationMonths != nil || campaign.DiscountType == models.DiscountFreeMonths
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"app/internal/models"
+)
+
+// promotionOffer is a mobile and home offer without a bundle discount: mobile 200, home
+// 100, total 300
+func promotionOffer() PromotionOffer {
+	return PromotionOffer{
+		Breakdown:     CalcGrandTotal(200, 100, 0, 0),
+		City:          "Istanbul",
+		Tech:          "fiber",
+		MobilePlanIDs: []int{1, 2},
+		HomePlanID:    4,
+		At:            time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func campaign(id int, discountType string, value float64, appliesTo string) models.Campaign {
+	return models.Campaign{
+		CampaignID:    id,
+		Name:          "Campaign",
+		DiscountType:  discountType,
+		DiscountValue: value,
+		AppliesTo:     appliesTo,
+		Stackable:     true,
+		StartsAt:      time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCampaignEligible(t *testing.T) {
+	code := "WELCOME"
+	ended := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		modify   func(c *models.Campaign, o *PromotionOffer)
+		eligible bool
+	}{
+		{"automatic campaign", func(c *models.Campaign, o *PromotionOffer) {}, true},
+		{"not started", func(c *models.Campaign, o *PromotionOffer) { c.StartsAt = o.At.Add(time.Hour) }, false},
+		{"ended", func(c *models.Campaign, o *PromotionOffer) { c.EndsAt = &ended }, false},
+		{"code not entered", func(c *models.Campaign, o *PromotionOffer) { c.Code = &code }, false},
+		{"code entered in lower case", func(c *models.Campaign, o *PromotionOffer) { c.Code = &code; o.CouponCode = "welcome" }, true},
+		{"returning customer", func(c *models.Campaign, o *PromotionOffer) { c.NewCustomers = true }, false},
+		{"new customer", func(c *models.Campaign, o *PromotionOffer) { c.NewCustomers = true; o.NewCustomer = true }, true},
+		{"other city", func(c *models.Campaign, o *PromotionOffer) { c.Cities = []string{"Ankara", "Izmir"} }, false},
+		{"city in other case", func(c *models.Campaign, o *PromotionOffer) { c.Cities = []string{"ISTANBUL"} }, true},
+		{"other tech", func(c *models.Campaign, o *PromotionOffer) { c.Techs = []string{"vdsl"} }, false},
+		{"one of the mobile plans", func(c *models.Campaign, o *PromotionOffer) { c.MobilePlanIDs = []int{2, 3} }, true},
+		{"other home plan", func(c *models.Campaign, o *PromotionOffer) { c.HomePlanIDs = []int{5} }, false},
+		{"TV plan without TV", func(c *models.Campaign, o *PromotionOffer) { c.TVPlanIDs = []int{1} }, false},
+		{"TV discount without TV", func(c *models.Campaign, o *PromotionOffer) { c.AppliesTo = "tv" }, false},
+	}
+
+	for _, tt := range tests {
+		c := campaign(1, models.DiscountPercent, 10, "mobile")
+		o := promotionOffer()
+		tt.modify(&c, &o)
+		if got := CampaignEligible(c, o); got != tt.eligible {
+			t.Errorf("%s: expected eligible %v, got %v", tt.name, tt.eligible, got)
+		}
+	}
+}
+
+func TestApplyPromotions(t *testing.T) {
+	months := func(n int) *int { return &n }
+
+	percent := campaign(1, models.DiscountPercent, 10, "mobile")
+	fixed := campaign(2, models.DiscountFixed, 25, "total")
+	fixed.DurationMonths = months(6)
+	freeHome := campaign(3, models.DiscountFreeMonths, 3, "home")
+	exclusive := campaign(4, models.DiscountPercent, 30, "total")
+	exclusive.Stackable = false
+	tooLarge := campaign(5, models.DiscountFixed, 500, "home")
+
+	tests := []struct {
+		name       string
+		campaigns  []models.Campaign
+		monthly    float64
+		firstMonth float64
+		applied    []int
+	}{
+		{"no campaigns", nil, 0, 0, nil},
+		{"percent off mobile", []models.Campaign{percent}, 20, 20, []int{1}},
+		// The time-limited discount is taken after the ongoing one, whatever the order
+		{"stacked", []models.Campaign{fixed, percent}, 20, 45, []int{1, 2}},
+		// Three free months of home, then 25 off the 200 left of the total
+		{"free months", []models.Campaign{freeHome, fixed}, 0, 125, []int{3, 2}},
+		// 30% of 300 every month beats 20 every month and 25 for 6 months
+		{"exclusive wins", []models.Campaign{percent, fixed, exclusive}, 90, 90, []int{4}},
+		// A discount is capped at what the component costs
+		{"capped", []models.Campaign{tooLarge}, 100, 100, []int{5}},
+	}
+
+	for _, tt := range tests {
+		result := ApplyPromotions(tt.campaigns, promotionOffer())
+		if math.Abs(result.MonthlyDiscount-tt.monthly) > 0.001 || math.Abs(result.FirstMonthDiscount-tt.firstMonth) > 0.001 {
+			t.Errorf("%s: expected %.2f monthly and %.2f in the first month, got %.2f and %.2f",
+				tt.name, tt.monthly, tt.firstMonth, result.MonthlyDiscount, result.FirstMonthDiscount)
+		}
+
+		var applied []int
+		for _, a := range result.Applied {
+			applied = append(applied, a.Campaign.CampaignID)
+		}
+		if len(applied) != len(tt.applied) {
+			t.Errorf("%s: expected campaigns %v, got %v", tt.name, tt.applied, applied)
+			continue
+		}
+		for i := range applied {
+			if applied[i] != tt.applied[i] {
+				t.Errorf("%s: expected campaigns %v, got %v", tt.name, tt.applied, applied)
+				break
+			}
+		}
+	}
+}
+
+func TestApplyPromotionsAfterBundleDiscount(t *testing.T) {
+	offer := promotionOffer()
+	offer.Breakdown = CalcGrandTotal(200, 100, 0, 0.10)
+
+	// Half off home is taken from the home plan's share of the bundle price
+	result := ApplyPromotions([]models.Campaign{campaign(1, models.DiscountPercent, 50, "home")}, offer)
+	if math.Abs(result.MonthlyDiscount-45) > 0.001 {
+		t.Errorf("Expected 45 off the discounted home plan, got %.2f", result.MonthlyDiscount)
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+import "time"
+
+// IstanbulTimezone is the IANA name of the timezone installations are scheduled in
+const IstanbulTimezone = "Europe/Istanbul"
+
+// IstanbulLocation returns the Europe/Istanbul location. Turkey has stayed on UTC+3 all
+// year since 2016, so a fixed zone is used when the tz database is not available.
+func IstanbulLocation() *time.Location {
+	if loc, err := time.LoadLocation(IstanbulTimezone); err == nil {
+		return loc
+	}
+	return time.FixedZone(IstanbulTimezone, 3*60*60)
+}
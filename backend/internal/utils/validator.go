@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 
+	"app/internal/api"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -31,17 +33,47 @@ func NewValidator() *Validator {
 
 // ValidateStruct validates a struct and returns user-friendly error messages
 func (v *Validator) ValidateStruct(s interface{}) []string {
+	fields := v.ValidateFields(s)
+	if fields == nil {
+		return nil
+	}
+
+	errors := make([]string, len(fields))
+	for i, f := range fields {
+		errors[i] = f.Message
+	}
+
+	return errors
+}
+
+// ValidateFields validates a struct and returns one error per invalid field, or nil when
+// the struct is valid
+func (v *Validator) ValidateFields(s interface{}) []api.FieldError {
 	err := v.validate.Struct(s)
 	if err == nil {
 		return nil
 	}
 
-	var errors []string
-	for _, err := range err.(validator.ValidationErrors) {
-		errors = append(errors, v.formatError(err))
+	var fields []api.FieldError
+	for _, e := range err.(validator.ValidationErrors) {
+		fields = append(fields, api.FieldError{
+			Field:   fieldPath(e),
+			Rule:    e.Tag(),
+			Message: v.formatError(e),
+		})
 	}
 
-	return errors
+	return fields
+}
+
+// fieldPath returns the JSON path of the invalid field without the top-level struct
+// name, e.g. household[0].expected_gb
+func fieldPath(e validator.FieldError) string {
+	_, path, found := strings.Cut(e.Namespace(), ".")
+	if !found {
+		return e.Field()
+	}
+	return path
 }
 
 // formatError converts validation error to user-friendly message
@@ -60,8 +92,10 @@ func (v *Validator) formatError(e validator.FieldError) string {
 		return fmt.Sprintf("%s must be at most %s", field, e.Param())
 	case "email":
 		return fmt.Sprintf("%s must be a valid email address", field)
-	case "url":
+	case "url", "http_url":
 		return fmt.Sprintf("%s must be a valid URL", field)
+	case "numeric":
+		return fmt.Sprintf("%s must contain only digits", field)
 	case "oneof":
 		return fmt.Sprintf("%s must be one of: %s", field, e.Param())
 	default:
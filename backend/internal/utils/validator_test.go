@@ -71,14 +71,13 @@ func TestValidator(t *testing.T) {
 		{
 			name: "Valid checkout request",
 			input: api.CheckoutRequest{
-				UserID:    1,
-				SlotID:    "S123",
-				AddressID: "A1001",
-				SelectedCombo: api.RecommendationCandidateDTO{
-					ComboLabel:   "Triple Bundle",
-					MonthlyTotal: 299.90,
-					Savings:      50.0,
-					Reasoning:    "Best value triple bundle",
+				QuoteID: "QT-0123456789abcdef-0123",
+				SlotID:  "S123",
+				Payment: api.PaymentMethodDTO{
+					CardNumber:  "4242424242424242",
+					ExpiryMonth: 12,
+					ExpiryYear:  2030,
+					CVC:         "123",
 				},
 			},
 			expectedErrors: nil,
@@ -90,9 +89,12 @@ func TestValidator(t *testing.T) {
 				// Missing all required fields
 			},
 			expectedErrors: []string{
-				"user_id is required",
+				"quote_id is required",
 				"slot_id is required",
-				"address_id is required",
+				"card_number is required",
+				"expiry_month is required",
+				"expiry_year is required",
+				"cvc is required",
 			},
 			description: "Should catch missing checkout fields",
 		},
@@ -128,3 +130,28 @@ func TestValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateFields(t *testing.T) {
+	validator := NewValidator()
+
+	fields := validator.ValidateFields(api.RecommendationRequest{
+		UserID:    1,
+		AddressID: "A1001",
+		Household: []api.HouseholdLineDTO{
+			{LineID: "LINE001", ExpectedGB: 8, ExpectedMin: 450},
+			{LineID: "LINE002", ExpectedGB: -5, ExpectedMin: 100},
+		},
+	})
+
+	if len(fields) != 1 {
+		t.Fatalf("Expected one invalid field, got %+v", fields)
+	}
+	expected := api.FieldError{Field: "household[1].expected_gb", Rule: "min", Message: "expected_gb must be at least 0"}
+	if fields[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, fields[0])
+	}
+
+	if fields := validator.ValidateFields(api.CancelOrderRequest{}); fields != nil {
+		t.Errorf("Expected no errors for a valid struct, got %+v", fields)
+	}
+}
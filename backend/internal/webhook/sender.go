@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultTimeout is the HTTP timeout of a delivery; slow receivers are retried later
+const defaultTimeout = 10 * time.Second
+
+// Envelope is the JSON body of a delivery. ID is the order event's outbox ID and stays
+// the same across retries, so receivers can use it to drop duplicates.
+type Envelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Request is one delivery attempt to a subscriber
+type Request struct {
+	URL       string
+	Secret    string
+	EventID   int64
+	EventType string
+	Body      []byte
+}
+
+// Sender POSTs signed deliveries to subscriber endpoints
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender creates a webhook sender
+func NewSender() *Sender {
+	return &Sender{
+		client: &http.Client{Timeout: defaultTimeout},
+		now:    time.Now,
+	}
+}
+
+// Send POSTs the body to the subscriber, signed at the current time, and returns the
+// response status. Any 2xx response means the delivery was accepted; the status is 0 when
+// no response was received.
+func (s *Sender) Send(ctx context.Context, r Request) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Turkcell-Webhooks/1.0")
+	req.Header.Set(HeaderEventID, strconv.FormatInt(r.EventID, 10))
+	req.Header.Set(HeaderEventType, r.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(r.Secret, timestamp, r.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return resp.StatusCode, fmt.Errorf("webhook receiver returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSenderSignsDeliveries(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sender := NewSender()
+	now := time.Now()
+	sender.now = func() time.Time { return now }
+
+	payload := []byte(`{"id":7,"type":"OrderPlaced","data":{"order_id":"ORD-1"}}`)
+	status, err := sender.Send(context.Background(), Request{
+		URL:       receiver.URL,
+		Secret:    "whsec_test",
+		EventID:   7,
+		EventType: "OrderPlaced",
+		Body:      payload,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", status)
+	}
+
+	if string(body) != string(payload) {
+		t.Errorf("Expected the body unchanged, got %s", body)
+	}
+	if received.Header.Get(HeaderEventID) != "7" || received.Header.Get(HeaderEventType) != "OrderPlaced" {
+		t.Errorf("Unexpected event headers: %v", received.Header)
+	}
+	if err := Verify("whsec_test", received.Header.Get(HeaderTimestamp), received.Header.Get(HeaderSignature), body, DefaultTolerance, now); err != nil {
+		t.Errorf("Expected the receiver to verify the signature, got %v", err)
+	}
+	if err := Verify("whsec_other", received.Header.Get(HeaderTimestamp), received.Header.Get(HeaderSignature), body, DefaultTolerance, now); err == nil {
+		t.Error("Expected verification with another secret to fail")
+	}
+}
+
+func TestSenderErrors(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	status, err := NewSender().Send(context.Background(), Request{URL: receiver.URL, Secret: "s", Body: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", status)
+	}
+
+	// Unreachable receivers have no status
+	receiver.Close()
+	status, err = NewSender().Send(context.Background(), Request{URL: receiver.URL, Secret: "s", Body: []byte(`{}`)})
+	if err == nil || status != 0 {
+		t.Errorf("Expected a connection error without status, got %d, %v", status, err)
+	}
+}
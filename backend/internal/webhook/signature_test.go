@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignKnownValue(t *testing.T) {
+	// printf '1700000000.{"id":1}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11"
+	got := Sign("secret", 1700000000, []byte(`{"id":1}`))
+	if got != expected {
+		t.Fatalf("Expected %s, got %s", expected, got)
+	}
+	if got == Sign("other", 1700000000, []byte(`{"id":1}`)) {
+		t.Error("Expected the secret to change the signature")
+	}
+	if got == Sign("secret", 1700000001, []byte(`{"id":1}`)) {
+		t.Error("Expected the timestamp to change the signature")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":1,"type":"OrderPlaced"}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", now.Unix(), body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		expected  error
+	}{
+		{"Valid", "secret", ts, signature, body, now, nil},
+		{"Valid within tolerance", "secret", ts, signature, body, now.Add(4 * time.Minute), nil},
+		{"Wrong secret", "other", ts, signature, body, now, ErrInvalidSignature},
+		{"Tampered body", "secret", ts, signature, []byte(`{"id":2,"type":"OrderPlaced"}`), now, ErrInvalidSignature},
+		{"Tampered timestamp", "secret", strconv.FormatInt(now.Unix()+1, 10), signature, body, now, ErrInvalidSignature},
+		{"Malformed timestamp", "secret", "yesterday", signature, body, now, ErrInvalidSignature},
+		{"Unknown scheme", "secret", ts, strings.Replace(signature, "sha256=", "md5=", 1), body, now, ErrInvalidSignature},
+		{"Replayed", "secret", ts, signature, body, now.Add(DefaultTolerance + time.Second), ErrStaleTimestamp},
+		{"From the future", "secret", ts, signature, body, now.Add(-DefaultTolerance - time.Second), ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, DefaultTolerance, tt.now)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, _ := NewSecret()
+	if !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+64 {
+		t.Errorf("Unexpected secret format %q", a)
+	}
+	if a == b {
+		t.Error("Expected distinct secrets")
+	}
+}
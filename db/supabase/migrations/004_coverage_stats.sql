@@ -0,0 +1,17 @@
+-- District-level coverage statistics
+-- Aggregates coverage rows so analytics can be read with a single query from both
+-- the PostgREST API and direct PostgreSQL connections
+
+CREATE OR REPLACE VIEW coverage_district_stats AS
+SELECT
+    city,
+    district,
+    COUNT(*)::INTEGER AS addresses,
+    COUNT(*) FILTER (WHERE fiber)::INTEGER AS fiber_addresses,
+    COUNT(*) FILTER (WHERE vdsl)::INTEGER AS vdsl_addresses,
+    COUNT(*) FILTER (WHERE fwa)::INTEGER AS fwa_addresses
+FROM coverage
+GROUP BY city, district;
+
+-- Index to support the grouping above
+CREATE INDEX idx_coverage_city_district ON coverage(city, district);
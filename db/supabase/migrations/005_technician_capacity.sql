@@ -0,0 +1,107 @@
+-- Technician capacity calendars
+-- Models installation crews, their technicians, service areas and working hours, and
+-- turns install_slots into capacity-carrying windows that can be generated from them
+
+-- Installation crews
+CREATE TABLE crews (
+    crew_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    active BOOLEAN DEFAULT TRUE
+);
+
+-- Technicians belong to a crew and are qualified for one or more technologies
+CREATE TABLE technicians (
+    technician_id SERIAL PRIMARY KEY,
+    crew_id INTEGER NOT NULL REFERENCES crews(crew_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    techs TEXT[] NOT NULL DEFAULT '{}', -- subset of 'fiber', 'vdsl', 'fwa'
+    active BOOLEAN DEFAULT TRUE
+);
+
+-- Districts a crew is dispatched to
+CREATE TABLE crew_service_areas (
+    crew_id INTEGER NOT NULL REFERENCES crews(crew_id) ON DELETE CASCADE,
+    city VARCHAR(100) NOT NULL,
+    district VARCHAR(100) NOT NULL,
+    PRIMARY KEY (crew_id, city, district)
+);
+
+-- Weekly working hours per crew (weekday 0 = Sunday, matching Go's time.Weekday)
+CREATE TABLE crew_working_hours (
+    crew_id INTEGER NOT NULL REFERENCES crews(crew_id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    PRIMARY KEY (crew_id, weekday, start_time)
+);
+
+ALTER TABLE technicians ADD CONSTRAINT valid_technician_techs CHECK (techs <@ ARRAY['fiber', 'vdsl', 'fwa']::TEXT[]);
+ALTER TABLE crew_working_hours ADD CONSTRAINT valid_weekday CHECK (weekday BETWEEN 0 AND 6);
+ALTER TABLE crew_working_hours ADD CONSTRAINT valid_working_hours CHECK (end_time > start_time);
+
+CREATE INDEX idx_technicians_crew_id ON technicians(crew_id);
+CREATE INDEX idx_crew_service_areas_city_district ON crew_service_areas(city, district);
+
+-- Generated slots use deterministic text IDs, so slot_id becomes text
+ALTER TABLE install_slots ALTER COLUMN slot_id DROP DEFAULT;
+ALTER TABLE install_slots ALTER COLUMN slot_id TYPE VARCHAR(64) USING slot_id::TEXT;
+ALTER TABLE install_slots ALTER COLUMN slot_id SET DEFAULT nextval('install_slots_slot_id_seq')::TEXT;
+
+-- Crew-generated slots serve every address in the crew's service areas,
+-- so they carry a crew instead of an address
+ALTER TABLE install_slots ALTER COLUMN address_id DROP NOT NULL;
+ALTER TABLE install_slots ADD COLUMN crew_id INTEGER REFERENCES crews(crew_id) ON DELETE CASCADE;
+ALTER TABLE install_slots ADD CONSTRAINT slot_has_owner CHECK (address_id IS NOT NULL OR crew_id IS NOT NULL);
+
+-- Replace the available flag with a remaining capacity count
+ALTER TABLE install_slots ADD COLUMN capacity INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE install_slots ADD COLUMN remaining_capacity INTEGER NOT NULL DEFAULT 1;
+UPDATE install_slots SET remaining_capacity = CASE WHEN available THEN capacity ELSE 0 END;
+ALTER TABLE install_slots DROP COLUMN available;
+ALTER TABLE install_slots ADD CONSTRAINT valid_slot_capacity CHECK (remaining_capacity >= 0 AND remaining_capacity <= capacity);
+
+CREATE INDEX idx_install_slots_crew_tech_time ON install_slots(crew_id, tech, slot_start);
+CREATE INDEX idx_install_slots_addr_tech_remaining ON install_slots(address_id, tech, remaining_capacity);
+
+-- Crews serving each address, resolved through the address's city and district
+CREATE OR REPLACE VIEW address_crews AS
+SELECT c.address_id, a.crew_id
+FROM coverage c
+JOIN crew_service_areas a ON a.city = c.city AND a.district = c.district
+JOIN crews cr ON cr.crew_id = a.crew_id AND cr.active;
+
+-- Sample crews covering the seeded districts
+INSERT INTO crews (crew_id, name) VALUES
+(1, 'Istanbul Anadolu Crew'),
+(2, 'Istanbul Avrupa Crew'),
+(3, 'Ankara Crew'),
+(4, 'Izmir Crew');
+
+INSERT INTO technicians (crew_id, name, techs) VALUES
+(1, 'Emre Aydin', ARRAY['fiber', 'vdsl']),
+(1, 'Burak Sahin', ARRAY['fiber']),
+(2, 'Kemal Arslan', ARRAY['fiber', 'vdsl', 'fwa']),
+(2, 'Selin Koc', ARRAY['fwa']),
+(3, 'Deniz Yildiz', ARRAY['fiber', 'vdsl']),
+(4, 'Zeynep Acar', ARRAY['fiber', 'fwa']);
+
+INSERT INTO crew_service_areas (crew_id, city, district) VALUES
+(1, 'Istanbul', 'Kadikoy'),
+(2, 'Istanbul', 'Besiktas'),
+(2, 'Istanbul', 'Sisli'),
+(2, 'Istanbul', 'Bakirkoy'),
+(3, 'Ankara', 'Cankaya'),
+(3, 'Ankara', 'Kecioren'),
+(4, 'Izmir', 'Konak'),
+(4, 'Izmir', 'Bornova');
+
+-- Monday to Friday 09:00-18:00, Saturday 09:00-13:00 for every crew
+INSERT INTO crew_working_hours (crew_id, weekday, start_time, end_time)
+SELECT crew_id, weekday, '09:00', '18:00'
+FROM crews, generate_series(1, 5) AS weekday;
+
+INSERT INTO crew_working_hours (crew_id, weekday, start_time, end_time)
+SELECT crew_id, 6, '09:00', '13:00'
+FROM crews;
+
+SELECT setval('crews_crew_id_seq', (SELECT MAX(crew_id) FROM crews));
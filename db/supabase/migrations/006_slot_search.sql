@@ -0,0 +1,31 @@
+-- Install slot search
+-- Expands crew slots to every address the crew serves and exposes the local start hour,
+-- so slot searches (tech, date range, time of day, pagination) are plain filters on one view
+
+CREATE OR REPLACE VIEW address_install_slots AS
+SELECT
+    s.address_id,
+    s.slot_id,
+    s.crew_id,
+    s.slot_start,
+    s.slot_end,
+    s.tech,
+    s.capacity,
+    s.remaining_capacity,
+    EXTRACT(HOUR FROM s.slot_start AT TIME ZONE 'Europe/Istanbul')::INTEGER AS local_start_hour
+FROM install_slots s
+WHERE s.address_id IS NOT NULL
+UNION ALL
+SELECT
+    ac.address_id,
+    s.slot_id,
+    s.crew_id,
+    s.slot_start,
+    s.slot_end,
+    s.tech,
+    s.capacity,
+    s.remaining_capacity,
+    EXTRACT(HOUR FROM s.slot_start AT TIME ZONE 'Europe/Istanbul')::INTEGER AS local_start_hour
+FROM install_slots s
+JOIN address_crews ac ON ac.crew_id = s.crew_id
+WHERE s.address_id IS NULL;
@@ -0,0 +1,184 @@
+-- Orders and installation appointments
+-- Orders claim one unit of install slot capacity. Booking, rescheduling and cancelling are
+-- implemented as functions so the slot swap and the history entry happen in one transaction,
+-- whether called over PostgREST (/rpc) or a direct connection.
+--
+-- Application errors use custom SQLSTATEs mapped by the backend:
+--   AP001 slot not available, AP002 order not found,
+--   AP003 order cannot be modified, AP004 change window closed
+
+CREATE TABLE orders (
+    order_id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id),
+    address_id VARCHAR(50) NOT NULL,
+    slot_id VARCHAR(64) REFERENCES install_slots(slot_id),
+    tech VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'confirmed',
+    combo_label VARCHAR(255) NOT NULL,
+    monthly_total NUMERIC(10,2) NOT NULL,
+    items JSONB NOT NULL DEFAULT '{}',
+    slot_released BOOLEAN NOT NULL DEFAULT FALSE,
+    cancel_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Every booking change made to an order's installation appointment
+CREATE TABLE appointment_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id VARCHAR(64) NOT NULL REFERENCES orders(order_id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,
+    old_slot_id VARCHAR(64),
+    new_slot_id VARCHAR(64),
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE orders ADD CONSTRAINT valid_order_status CHECK (status IN ('pending', 'confirmed', 'cancelled'));
+ALTER TABLE orders ADD CONSTRAINT positive_order_total CHECK (monthly_total >= 0);
+ALTER TABLE appointment_history ADD CONSTRAINT valid_appointment_action CHECK (action IN ('booked', 'rescheduled', 'cancelled'));
+
+CREATE INDEX idx_orders_user_id ON orders(user_id);
+CREATE INDEX idx_orders_slot_id ON orders(slot_id);
+CREATE INDEX idx_appointment_history_order_id ON appointment_history(order_id, created_at);
+
+-- claim_install_slot takes one unit of capacity from a slot serving the address, or raises AP001
+CREATE OR REPLACE FUNCTION claim_install_slot(p_slot_id VARCHAR, p_address_id VARCHAR, p_tech VARCHAR, p_not_before TIMESTAMPTZ)
+RETURNS install_slots AS $$
+DECLARE
+    v_slot install_slots;
+BEGIN
+    UPDATE install_slots s
+    SET remaining_capacity = s.remaining_capacity - 1
+    WHERE s.slot_id = p_slot_id
+      AND s.remaining_capacity > 0
+      AND s.slot_start > p_not_before
+      AND (p_tech IS NULL OR s.tech = p_tech)
+      AND EXISTS (SELECT 1 FROM address_install_slots a WHERE a.slot_id = p_slot_id AND a.address_id = p_address_id)
+    RETURNING s.* INTO v_slot;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'install slot % is not available for address %', p_slot_id, p_address_id USING ERRCODE = 'AP001';
+    END IF;
+
+    RETURN v_slot;
+END;
+$$ LANGUAGE plpgsql;
+
+-- release_install_slot gives one unit of capacity back to a slot
+CREATE OR REPLACE FUNCTION release_install_slot(p_slot_id VARCHAR)
+RETURNS VOID AS $$
+    UPDATE install_slots
+    SET remaining_capacity = LEAST(remaining_capacity + 1, capacity)
+    WHERE slot_id = p_slot_id;
+$$ LANGUAGE sql;
+
+-- place_order claims the slot and creates a confirmed order
+CREATE OR REPLACE FUNCTION place_order(
+    p_order_id VARCHAR,
+    p_user_id INTEGER,
+    p_address_id VARCHAR,
+    p_slot_id VARCHAR,
+    p_tech VARCHAR,
+    p_combo_label VARCHAR,
+    p_monthly_total NUMERIC,
+    p_items JSONB
+) RETURNS orders AS $$
+DECLARE
+    v_slot install_slots;
+    v_order orders;
+BEGIN
+    v_slot := claim_install_slot(p_slot_id, p_address_id, p_tech, NOW());
+
+    INSERT INTO orders (order_id, user_id, address_id, slot_id, tech, status, combo_label, monthly_total, items)
+    VALUES (p_order_id, p_user_id, p_address_id, p_slot_id, v_slot.tech, 'confirmed', p_combo_label, p_monthly_total, p_items)
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, new_slot_id)
+    VALUES (p_order_id, 'booked', p_slot_id);
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+-- reschedule_order moves an order to a new slot of the same technology. Both the current
+-- and the new slot must start more than p_cutoff_seconds from now.
+CREATE OR REPLACE FUNCTION reschedule_order(
+    p_order_id VARCHAR,
+    p_new_slot_id VARCHAR,
+    p_cutoff_seconds INTEGER,
+    p_reason TEXT
+) RETURNS orders AS $$
+DECLARE
+    v_order orders;
+    v_old_slot_id VARCHAR;
+    v_old_start TIMESTAMPTZ;
+    v_cutoff TIMESTAMPTZ := NOW() + make_interval(secs => p_cutoff_seconds);
+BEGIN
+    SELECT * INTO v_order FROM orders WHERE order_id = p_order_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'order % not found', p_order_id USING ERRCODE = 'AP002';
+    END IF;
+
+    IF v_order.status <> 'confirmed' THEN
+        RAISE EXCEPTION 'order % is %', p_order_id, v_order.status USING ERRCODE = 'AP003';
+    END IF;
+
+    IF v_order.slot_id = p_new_slot_id THEN
+        RETURN v_order;
+    END IF;
+
+    v_old_slot_id := v_order.slot_id;
+    SELECT slot_start INTO v_old_start FROM install_slots WHERE slot_id = v_old_slot_id;
+    IF v_old_start IS NOT NULL AND v_old_start <= v_cutoff THEN
+        RAISE EXCEPTION 'appointment for order % can no longer be changed', p_order_id USING ERRCODE = 'AP004';
+    END IF;
+
+    PERFORM claim_install_slot(p_new_slot_id, v_order.address_id, v_order.tech, v_cutoff);
+    IF v_old_slot_id IS NOT NULL THEN
+        PERFORM release_install_slot(v_old_slot_id);
+    END IF;
+
+    UPDATE orders SET slot_id = p_new_slot_id, updated_at = NOW()
+    WHERE order_id = p_order_id
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, old_slot_id, new_slot_id, reason)
+    VALUES (p_order_id, 'rescheduled', v_old_slot_id, p_new_slot_id, p_reason);
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+-- cancel_order cancels an order and releases its slot if the installation has not started
+CREATE OR REPLACE FUNCTION cancel_order(p_order_id VARCHAR, p_reason TEXT)
+RETURNS orders AS $$
+DECLARE
+    v_order orders;
+    v_release BOOLEAN;
+BEGIN
+    SELECT * INTO v_order FROM orders WHERE order_id = p_order_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'order % not found', p_order_id USING ERRCODE = 'AP002';
+    END IF;
+
+    IF v_order.status = 'cancelled' THEN
+        RAISE EXCEPTION 'order % is already cancelled', p_order_id USING ERRCODE = 'AP003';
+    END IF;
+
+    SELECT slot_start > NOW() INTO v_release FROM install_slots WHERE slot_id = v_order.slot_id;
+    IF COALESCE(v_release, FALSE) THEN
+        PERFORM release_install_slot(v_order.slot_id);
+    END IF;
+
+    UPDATE orders
+    SET status = 'cancelled', cancel_reason = p_reason, slot_released = COALESCE(v_release, FALSE), updated_at = NOW()
+    WHERE order_id = p_order_id
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, old_slot_id, reason)
+    VALUES (p_order_id, 'cancelled', v_order.slot_id, p_reason);
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
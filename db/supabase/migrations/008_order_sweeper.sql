@@ -0,0 +1,71 @@
+-- Background clean-up of install slot capacity
+-- A pending order holds one unit of its slot's capacity until it is confirmed. Pending
+-- orders that are never confirmed, and cancelled orders whose slot was not given back,
+-- are swept periodically by the backend scheduler through these functions.
+
+CREATE INDEX idx_orders_pending_created_at ON orders(created_at) WHERE status = 'pending';
+CREATE INDEX idx_orders_unreleased_cancelled ON orders(slot_id) WHERE status = 'cancelled' AND NOT slot_released;
+
+-- expire_pending_orders cancels pending orders older than p_max_age_seconds and releases
+-- their slots. Rows locked by a concurrent checkout are skipped and picked up next run.
+-- Returns the number of orders expired.
+CREATE OR REPLACE FUNCTION expire_pending_orders(p_max_age_seconds INTEGER)
+RETURNS INTEGER AS $$
+DECLARE
+    v_order orders;
+    v_release BOOLEAN;
+    v_count INTEGER := 0;
+BEGIN
+    FOR v_order IN
+        SELECT * FROM orders
+        WHERE status = 'pending'
+          AND created_at <= NOW() - make_interval(secs => p_max_age_seconds)
+        FOR UPDATE SKIP LOCKED
+    LOOP
+        SELECT slot_start > NOW() INTO v_release FROM install_slots WHERE slot_id = v_order.slot_id;
+        IF COALESCE(v_release, FALSE) THEN
+            PERFORM release_install_slot(v_order.slot_id);
+        END IF;
+
+        UPDATE orders
+        SET status = 'cancelled', cancel_reason = 'hold expired', slot_released = COALESCE(v_release, FALSE), updated_at = NOW()
+        WHERE order_id = v_order.order_id;
+
+        INSERT INTO appointment_history (order_id, action, old_slot_id, reason)
+        VALUES (v_order.order_id, 'cancelled', v_order.slot_id, 'hold expired');
+
+        v_count := v_count + 1;
+    END LOOP;
+
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- release_cancelled_slots gives back the capacity of cancelled orders whose slot is still
+-- in the future but was not released when the order was cancelled. Returns the number of
+-- slots released.
+CREATE OR REPLACE FUNCTION release_cancelled_slots()
+RETURNS INTEGER AS $$
+DECLARE
+    v_order orders;
+    v_count INTEGER := 0;
+BEGIN
+    FOR v_order IN
+        SELECT o.* FROM orders o
+        JOIN install_slots s ON s.slot_id = o.slot_id
+        WHERE o.status = 'cancelled'
+          AND NOT o.slot_released
+          AND s.slot_start > NOW()
+        FOR UPDATE OF o SKIP LOCKED
+    LOOP
+        PERFORM release_install_slot(v_order.slot_id);
+
+        UPDATE orders SET slot_released = TRUE, updated_at = NOW()
+        WHERE order_id = v_order.order_id;
+
+        v_count := v_count + 1;
+    END LOOP;
+
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
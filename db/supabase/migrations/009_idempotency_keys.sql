@@ -0,0 +1,57 @@
+-- Idempotency keys for retried requests
+-- A client sends the same Idempotency-Key header when retrying a request. The first
+-- request claims the key; once it finishes its response is stored and replayed to every
+-- retry carrying the same request hash until the key expires.
+
+CREATE TABLE idempotency_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    response_body TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- claim_idempotency_key stores a new key, replacing an expired one. When the key is
+-- already in use the existing row is returned with claimed = false.
+CREATE OR REPLACE FUNCTION claim_idempotency_key(p_key VARCHAR, p_request_hash VARCHAR, p_ttl_seconds INTEGER)
+RETURNS TABLE (
+    idempotency_key VARCHAR,
+    request_hash VARCHAR,
+    status_code INTEGER,
+    response_body TEXT,
+    created_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    claimed BOOLEAN
+) AS $$
+BEGIN
+    DELETE FROM idempotency_keys k WHERE k.idempotency_key = p_key AND k.expires_at <= NOW();
+
+    RETURN QUERY
+    INSERT INTO idempotency_keys AS k (idempotency_key, request_hash, expires_at)
+    VALUES (p_key, p_request_hash, NOW() + make_interval(secs => p_ttl_seconds))
+    ON CONFLICT ON CONSTRAINT idempotency_keys_pkey DO NOTHING
+    RETURNING k.idempotency_key, k.request_hash, k.status_code, k.response_body, k.created_at, k.expires_at, TRUE;
+
+    IF NOT FOUND THEN
+        RETURN QUERY
+        SELECT k.idempotency_key, k.request_hash, k.status_code, k.response_body, k.created_at, k.expires_at, FALSE
+        FROM idempotency_keys k
+        WHERE k.idempotency_key = p_key;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- purge_idempotency_keys deletes expired keys and returns how many were removed
+CREATE OR REPLACE FUNCTION purge_idempotency_keys()
+RETURNS INTEGER AS $$
+DECLARE
+    v_count INTEGER;
+BEGIN
+    DELETE FROM idempotency_keys WHERE expires_at <= NOW();
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
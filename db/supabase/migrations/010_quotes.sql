@@ -0,0 +1,34 @@
+-- Recommendation quotes
+-- POST /api/recommendation stores every returned candidate as a quote. The quote ID is
+-- signed by the backend over the catalog version, household hash and price, and checkout
+-- places orders from the stored quote instead of trusting client-supplied prices.
+
+CREATE TABLE quotes (
+    quote_id VARCHAR(128) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id),
+    address_id VARCHAR(50) NOT NULL,
+    catalog_version VARCHAR(64) NOT NULL,
+    household_hash VARCHAR(64) NOT NULL,
+    combo_label VARCHAR(255) NOT NULL,
+    monthly_total NUMERIC(10,2) NOT NULL,
+    tech VARCHAR(50),
+    items JSONB NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE quotes ADD CONSTRAINT positive_quote_total CHECK (monthly_total >= 0);
+
+CREATE INDEX idx_quotes_expires_at ON quotes(expires_at);
+
+-- purge_expired_quotes deletes expired quotes and returns how many were removed
+CREATE OR REPLACE FUNCTION purge_expired_quotes()
+RETURNS INTEGER AS $$
+DECLARE
+    v_count INTEGER;
+BEGIN
+    DELETE FROM quotes WHERE expires_at <= NOW();
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
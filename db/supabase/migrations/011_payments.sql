@@ -0,0 +1,69 @@
+-- Upfront payments for orders
+-- Checkout now creates a pending order that holds its install slot while the upfront
+-- amount (install fee plus the first month) is authorised. The order is confirmed once
+-- the authorisation succeeds; pending orders whose payment never completes are expired
+-- by the hold sweeper (expire_pending_orders).
+
+ALTER TABLE orders ADD COLUMN upfront_amount NUMERIC(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN payment_id VARCHAR(64);
+ALTER TABLE orders ADD COLUMN payment_status VARCHAR(20);
+
+ALTER TABLE orders ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE orders ADD CONSTRAINT positive_upfront_amount CHECK (upfront_amount >= 0);
+ALTER TABLE orders ADD CONSTRAINT valid_payment_status CHECK (payment_status IN ('authorized', 'captured', 'refunded'));
+
+-- place_order now takes the upfront amount and creates the order as pending
+DROP FUNCTION place_order(VARCHAR, INTEGER, VARCHAR, VARCHAR, VARCHAR, VARCHAR, NUMERIC, JSONB);
+
+CREATE OR REPLACE FUNCTION place_order(
+    p_order_id VARCHAR,
+    p_user_id INTEGER,
+    p_address_id VARCHAR,
+    p_slot_id VARCHAR,
+    p_tech VARCHAR,
+    p_combo_label VARCHAR,
+    p_monthly_total NUMERIC,
+    p_items JSONB,
+    p_upfront_amount NUMERIC
+) RETURNS orders AS $$
+DECLARE
+    v_slot install_slots;
+    v_order orders;
+BEGIN
+    v_slot := claim_install_slot(p_slot_id, p_address_id, p_tech, NOW());
+
+    INSERT INTO orders (order_id, user_id, address_id, slot_id, tech, status, combo_label, monthly_total, items, upfront_amount)
+    VALUES (p_order_id, p_user_id, p_address_id, p_slot_id, v_slot.tech, 'pending', p_combo_label, p_monthly_total, p_items, p_upfront_amount)
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, new_slot_id)
+    VALUES (p_order_id, 'booked', p_slot_id);
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+-- confirm_order confirms a pending order once its payment is authorised. Raises AP003
+-- when the order is no longer pending, e.g. because its hold already expired.
+CREATE OR REPLACE FUNCTION confirm_order(p_order_id VARCHAR, p_payment_id VARCHAR)
+RETURNS orders AS $$
+DECLARE
+    v_order orders;
+BEGIN
+    SELECT * INTO v_order FROM orders WHERE order_id = p_order_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'order % not found', p_order_id USING ERRCODE = 'AP002';
+    END IF;
+
+    IF v_order.status <> 'pending' THEN
+        RAISE EXCEPTION 'order % is %', p_order_id, v_order.status USING ERRCODE = 'AP003';
+    END IF;
+
+    UPDATE orders
+    SET status = 'confirmed', payment_id = p_payment_id, payment_status = 'authorized', updated_at = NOW()
+    WHERE order_id = p_order_id
+    RETURNING * INTO v_order;
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,75 @@
+-- Customer notifications
+-- Order confirmations, appointment changes, cancellations and day-before installation
+-- reminders are rendered by the backend and queued in notification_outbox, one row per
+-- channel. A background job claims due rows, sends them by email or SMS and records the
+-- outcome; failed sends are retried with backoff until they are marked failed.
+
+ALTER TABLE users ADD COLUMN email VARCHAR(255);
+ALTER TABLE users ADD COLUMN phone VARCHAR(20);
+ALTER TABLE users ADD COLUMN locale VARCHAR(5) NOT NULL DEFAULT 'tr';
+
+ALTER TABLE users ADD CONSTRAINT valid_user_locale CHECK (locale IN ('tr', 'en'));
+
+-- Demo contact details for the seeded customers
+UPDATE users SET email = 'ahmet.yilmaz@example.com', phone = '+905551000001' WHERE user_id = 1;
+UPDATE users SET email = 'fatma.demir@example.com', phone = '+905551000002' WHERE user_id = 2;
+UPDATE users SET email = 'mehmet.ozkan@example.com', phone = '+905551000003' WHERE user_id = 3;
+UPDATE users SET email = 'ayse.kaya@example.com', phone = '+905551000004' WHERE user_id = 4;
+UPDATE users SET email = 'can.celik@example.com', phone = '+905551000005', locale = 'en' WHERE user_id = 5;
+
+CREATE TABLE notification_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    dedupe_key VARCHAR(255) NOT NULL UNIQUE,
+    order_id VARCHAR(64) REFERENCES orders(order_id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    channel VARCHAR(16) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT,
+    body TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE notification_outbox ADD CONSTRAINT valid_notification_channel CHECK (channel IN ('email', 'sms'));
+ALTER TABLE notification_outbox ADD CONSTRAINT valid_notification_status CHECK (status IN ('pending', 'sent', 'failed'));
+
+CREATE INDEX idx_notification_outbox_due ON notification_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_notification_outbox_order ON notification_outbox(order_id);
+
+-- claim_notifications leases up to p_limit due notifications for p_lease_seconds and
+-- counts the attempt. A sender that dies mid-send leaves the row pending, so it is picked
+-- up again once the lease runs out. Rows leased by a concurrent sender are skipped.
+CREATE OR REPLACE FUNCTION claim_notifications(p_limit INTEGER, p_lease_seconds INTEGER)
+RETURNS SETOF notification_outbox AS $$
+    UPDATE notification_outbox
+    SET attempts = attempts + 1,
+        next_attempt_at = NOW() + make_interval(secs => p_lease_seconds)
+    WHERE id IN (
+        SELECT id FROM notification_outbox
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at, id
+        LIMIT p_limit
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING *;
+$$ LANGUAGE sql;
+
+-- upcoming_appointments returns confirmed orders whose installation starts within the
+-- next p_lead_seconds. Appointments booked or changed inside that window are left out,
+-- since their confirmation was sent only moments ago.
+CREATE OR REPLACE FUNCTION upcoming_appointments(p_lead_seconds INTEGER)
+RETURNS SETOF orders AS $$
+    SELECT o.*
+    FROM orders o
+    JOIN install_slots s ON s.slot_id = o.slot_id
+    WHERE o.status = 'confirmed'
+      AND s.slot_start > NOW()
+      AND s.slot_start <= NOW() + make_interval(secs => p_lead_seconds)
+      AND (
+          SELECT MAX(h.created_at) FROM appointment_history h WHERE h.order_id = o.order_id
+      ) < s.slot_start - make_interval(secs => p_lead_seconds);
+$$ LANGUAGE sql STABLE;
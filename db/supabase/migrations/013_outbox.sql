@@ -0,0 +1,259 @@
+-- Transactional outbox for order domain events
+-- The order functions now record what happened as events in the outbox table, in the same
+-- transaction as the order change, so an event exists if and only if the change was
+-- committed. The backend dispatcher claims pending events and hands them to its handlers
+-- (e.g. customer notifications). Failed events are retried with backoff and end up in
+-- the 'dead' state after the last attempt, where an admin can inspect and requeue them.
+--
+-- Events:
+--   SlotBooked      an install slot was booked for an order (at checkout or on reschedule)
+--   OrderPlaced     a pending order was confirmed after its payment was authorised
+--   OrderCancelled  an order was cancelled, by the customer or because its hold expired
+
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE outbox ADD CONSTRAINT valid_outbox_status CHECK (status IN ('pending', 'delivered', 'dead'));
+
+CREATE INDEX idx_outbox_due ON outbox(next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX idx_outbox_dead ON outbox(created_at) WHERE status = 'dead';
+CREATE INDEX idx_outbox_aggregate ON outbox(aggregate_id, id);
+
+-- emit_event appends an event for an order to the outbox
+CREATE OR REPLACE FUNCTION emit_event(p_event_type VARCHAR, p_order_id VARCHAR, p_payload JSONB)
+RETURNS VOID AS $$
+    INSERT INTO outbox (event_type, aggregate_id, payload)
+    VALUES (p_event_type, p_order_id, p_payload || jsonb_build_object('order_id', p_order_id));
+$$ LANGUAGE sql;
+
+-- claim_events leases up to p_limit due events for p_lease_seconds and counts the
+-- attempt, oldest first. Events leased by a concurrent dispatcher are skipped.
+CREATE OR REPLACE FUNCTION claim_events(p_limit INTEGER, p_lease_seconds INTEGER)
+RETURNS SETOF outbox AS $$
+    UPDATE outbox
+    SET attempts = attempts + 1,
+        next_attempt_at = NOW() + make_interval(secs => p_lease_seconds)
+    WHERE id IN (
+        SELECT id FROM outbox
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY id
+        LIMIT p_limit
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING *;
+$$ LANGUAGE sql;
+
+-- The order functions below are unchanged apart from emitting their events
+
+CREATE OR REPLACE FUNCTION place_order(
+    p_order_id VARCHAR,
+    p_user_id INTEGER,
+    p_address_id VARCHAR,
+    p_slot_id VARCHAR,
+    p_tech VARCHAR,
+    p_combo_label VARCHAR,
+    p_monthly_total NUMERIC,
+    p_items JSONB,
+    p_upfront_amount NUMERIC
+) RETURNS orders AS $$
+DECLARE
+    v_slot install_slots;
+    v_order orders;
+BEGIN
+    v_slot := claim_install_slot(p_slot_id, p_address_id, p_tech, NOW());
+
+    INSERT INTO orders (order_id, user_id, address_id, slot_id, tech, status, combo_label, monthly_total, items, upfront_amount)
+    VALUES (p_order_id, p_user_id, p_address_id, p_slot_id, v_slot.tech, 'pending', p_combo_label, p_monthly_total, p_items, p_upfront_amount)
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, new_slot_id)
+    VALUES (p_order_id, 'booked', p_slot_id);
+
+    PERFORM emit_event('SlotBooked', p_order_id, jsonb_build_object(
+        'slot_id', p_slot_id,
+        'previous_slot_id', NULL,
+        'slot_start', v_slot.slot_start,
+        'slot_end', v_slot.slot_end
+    ));
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION confirm_order(p_order_id VARCHAR, p_payment_id VARCHAR)
+RETURNS orders AS $$
+DECLARE
+    v_order orders;
+BEGIN
+    SELECT * INTO v_order FROM orders WHERE order_id = p_order_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'order % not found', p_order_id USING ERRCODE = 'AP002';
+    END IF;
+
+    IF v_order.status <> 'pending' THEN
+        RAISE EXCEPTION 'order % is %', p_order_id, v_order.status USING ERRCODE = 'AP003';
+    END IF;
+
+    UPDATE orders
+    SET status = 'confirmed', payment_id = p_payment_id, payment_status = 'authorized', updated_at = NOW()
+    WHERE order_id = p_order_id
+    RETURNING * INTO v_order;
+
+    PERFORM emit_event('OrderPlaced', p_order_id, jsonb_build_object(
+        'user_id', v_order.user_id,
+        'slot_id', v_order.slot_id,
+        'combo_label', v_order.combo_label,
+        'monthly_total', v_order.monthly_total,
+        'upfront_amount', v_order.upfront_amount,
+        'payment_id', p_payment_id
+    ));
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION reschedule_order(
+    p_order_id VARCHAR,
+    p_new_slot_id VARCHAR,
+    p_cutoff_seconds INTEGER,
+    p_reason TEXT
+) RETURNS orders AS $$
+DECLARE
+    v_order orders;
+    v_old_slot_id VARCHAR;
+    v_old_start TIMESTAMPTZ;
+    v_new_slot install_slots;
+    v_cutoff TIMESTAMPTZ := NOW() + make_interval(secs => p_cutoff_seconds);
+BEGIN
+    SELECT * INTO v_order FROM orders WHERE order_id = p_order_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'order % not found', p_order_id USING ERRCODE = 'AP002';
+    END IF;
+
+    IF v_order.status <> 'confirmed' THEN
+        RAISE EXCEPTION 'order % is %', p_order_id, v_order.status USING ERRCODE = 'AP003';
+    END IF;
+
+    IF v_order.slot_id = p_new_slot_id THEN
+        RETURN v_order;
+    END IF;
+
+    v_old_slot_id := v_order.slot_id;
+    SELECT slot_start INTO v_old_start FROM install_slots WHERE slot_id = v_old_slot_id;
+    IF v_old_start IS NOT NULL AND v_old_start <= v_cutoff THEN
+        RAISE EXCEPTION 'appointment for order % can no longer be changed', p_order_id USING ERRCODE = 'AP004';
+    END IF;
+
+    v_new_slot := claim_install_slot(p_new_slot_id, v_order.address_id, v_order.tech, v_cutoff);
+    IF v_old_slot_id IS NOT NULL THEN
+        PERFORM release_install_slot(v_old_slot_id);
+    END IF;
+
+    UPDATE orders SET slot_id = p_new_slot_id, updated_at = NOW()
+    WHERE order_id = p_order_id
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, old_slot_id, new_slot_id, reason)
+    VALUES (p_order_id, 'rescheduled', v_old_slot_id, p_new_slot_id, p_reason);
+
+    PERFORM emit_event('SlotBooked', p_order_id, jsonb_build_object(
+        'slot_id', p_new_slot_id,
+        'previous_slot_id', v_old_slot_id,
+        'slot_start', v_new_slot.slot_start,
+        'slot_end', v_new_slot.slot_end,
+        'reason', p_reason
+    ));
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION cancel_order(p_order_id VARCHAR, p_reason TEXT)
+RETURNS orders AS $$
+DECLARE
+    v_order orders;
+    v_previous_status VARCHAR;
+    v_release BOOLEAN;
+BEGIN
+    SELECT * INTO v_order FROM orders WHERE order_id = p_order_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'order % not found', p_order_id USING ERRCODE = 'AP002';
+    END IF;
+
+    IF v_order.status = 'cancelled' THEN
+        RAISE EXCEPTION 'order % is already cancelled', p_order_id USING ERRCODE = 'AP003';
+    END IF;
+    v_previous_status := v_order.status;
+
+    SELECT slot_start > NOW() INTO v_release FROM install_slots WHERE slot_id = v_order.slot_id;
+    IF COALESCE(v_release, FALSE) THEN
+        PERFORM release_install_slot(v_order.slot_id);
+    END IF;
+
+    UPDATE orders
+    SET status = 'cancelled', cancel_reason = p_reason, slot_released = COALESCE(v_release, FALSE), updated_at = NOW()
+    WHERE order_id = p_order_id
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, old_slot_id, reason)
+    VALUES (p_order_id, 'cancelled', v_order.slot_id, p_reason);
+
+    PERFORM emit_event('OrderCancelled', p_order_id, jsonb_build_object(
+        'previous_status', v_previous_status,
+        'reason', p_reason,
+        'slot_id', v_order.slot_id,
+        'slot_released', v_order.slot_released
+    ));
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION expire_pending_orders(p_max_age_seconds INTEGER)
+RETURNS INTEGER AS $$
+DECLARE
+    v_order orders;
+    v_release BOOLEAN;
+    v_count INTEGER := 0;
+BEGIN
+    FOR v_order IN
+        SELECT * FROM orders
+        WHERE status = 'pending'
+          AND created_at <= NOW() - make_interval(secs => p_max_age_seconds)
+        FOR UPDATE SKIP LOCKED
+    LOOP
+        SELECT slot_start > NOW() INTO v_release FROM install_slots WHERE slot_id = v_order.slot_id;
+        IF COALESCE(v_release, FALSE) THEN
+            PERFORM release_install_slot(v_order.slot_id);
+        END IF;
+
+        UPDATE orders
+        SET status = 'cancelled', cancel_reason = 'hold expired', slot_released = COALESCE(v_release, FALSE), updated_at = NOW()
+        WHERE order_id = v_order.order_id;
+
+        INSERT INTO appointment_history (order_id, action, old_slot_id, reason)
+        VALUES (v_order.order_id, 'cancelled', v_order.slot_id, 'hold expired');
+
+        PERFORM emit_event('OrderCancelled', v_order.order_id, jsonb_build_object(
+            'previous_status', 'pending',
+            'reason', 'hold expired',
+            'slot_id', v_order.slot_id,
+            'slot_released', COALESCE(v_release, FALSE)
+        ));
+
+        v_count := v_count + 1;
+    END LOOP;
+
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
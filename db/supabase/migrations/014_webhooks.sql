@@ -0,0 +1,53 @@
+-- Outbound webhooks for order lifecycle events
+-- Partner systems (CRM, field ops) subscribe an HTTPS endpoint to some or all order
+-- events. The `webhooks` handler of the event dispatcher queues one delivery per matching
+-- subscription in webhook_deliveries; a background job claims due deliveries, POSTs them
+-- signed with the subscription's secret and records the outcome. Failed deliveries are
+-- retried with backoff until they are marked failed. The table doubles as the delivery
+-- log shown by the admin API.
+
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- empty for every event type
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES outbox(id) ON DELETE CASCADE,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (subscription_id, event_id)
+);
+
+ALTER TABLE webhook_deliveries ADD CONSTRAINT valid_webhook_delivery_status CHECK (status IN ('pending', 'delivered', 'failed'));
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, id);
+
+-- claim_webhook_deliveries leases up to p_limit due deliveries for p_lease_seconds and
+-- counts the attempt. Deliveries leased by a concurrent sender are skipped.
+CREATE OR REPLACE FUNCTION claim_webhook_deliveries(p_limit INTEGER, p_lease_seconds INTEGER)
+RETURNS SETOF webhook_deliveries AS $$
+    UPDATE webhook_deliveries
+    SET attempts = attempts + 1,
+        next_attempt_at = NOW() + make_interval(secs => p_lease_seconds)
+    WHERE id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at, id
+        LIMIT p_limit
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING *;
+$$ LANGUAGE sql;
@@ -0,0 +1,27 @@
+-- Schema version
+-- Records every applied migration, so the API can refuse traffic (GET /readyz) until the
+-- database has at least the schema it was built for (db.ExpectedSchemaVersion). Every
+-- later migration ends by inserting its own row.
+
+CREATE TABLE schema_version (
+    version INTEGER PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO schema_version (version, name) VALUES
+    (1, 'init'),
+    (2, 'indexes'),
+    (3, 'seed'),
+    (4, 'coverage_stats'),
+    (5, 'technician_capacity'),
+    (6, 'slot_search'),
+    (7, 'orders'),
+    (8, 'order_sweeper'),
+    (9, 'idempotency_keys'),
+    (10, 'quotes'),
+    (11, 'payments'),
+    (12, 'notifications'),
+    (13, 'outbox'),
+    (14, 'webhooks'),
+    (15, 'schema_version');
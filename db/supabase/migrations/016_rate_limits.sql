@@ -0,0 +1,58 @@
+-- Shared rate limit buckets
+-- With RATE_LIMIT_STORE=postgres every replica takes request tokens from the same
+-- token bucket per route group and client, so a client cannot multiply its limit by
+-- spreading requests over replicas. A bucket holds at most p_burst tokens and refills
+-- at p_rate tokens per second; it is stored with its token count at updated_at and
+-- refilled lazily when the next token is taken.
+
+CREATE TABLE rate_limit_buckets (
+    bucket_key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+-- take_rate_limit_token refills the bucket for the time since it was last used and takes
+-- one token if there is one. It returns whether the request is allowed, the whole tokens
+-- left and, when refused, how long until the next token in milliseconds. The upsert locks
+-- the row, so concurrent requests for the same bucket are serialized.
+CREATE OR REPLACE FUNCTION take_rate_limit_token(p_key VARCHAR, p_rate DOUBLE PRECISION, p_burst INTEGER)
+RETURNS TABLE (
+    allowed BOOLEAN,
+    remaining INTEGER,
+    retry_after_ms INTEGER
+) AS $$
+DECLARE
+    v_tokens DOUBLE PRECISION;
+BEGIN
+    INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, updated_at)
+    VALUES (p_key, p_burst, NOW())
+    ON CONFLICT ON CONSTRAINT rate_limit_buckets_pkey DO UPDATE
+    SET tokens = LEAST(p_burst, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at)) * p_rate),
+        updated_at = NOW()
+    RETURNING b.tokens INTO v_tokens;
+
+    IF v_tokens >= 1 THEN
+        UPDATE rate_limit_buckets b SET tokens = v_tokens - 1 WHERE b.bucket_key = p_key;
+        RETURN QUERY SELECT TRUE, FLOOR(v_tokens - 1)::INTEGER, 0;
+    ELSE
+        RETURN QUERY SELECT FALSE, 0, CEIL((1 - v_tokens) / p_rate * 1000)::INTEGER;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- purge_rate_limit_buckets deletes buckets unused for an hour and returns how many were
+-- removed. A deleted bucket starts full again on its next request.
+CREATE OR REPLACE FUNCTION purge_rate_limit_buckets()
+RETURNS INTEGER AS $$
+DECLARE
+    v_count INTEGER;
+BEGIN
+    DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - INTERVAL '1 hour';
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version, name) VALUES (16, 'rate_limits');
@@ -0,0 +1,25 @@
+-- Partner API keys
+-- Dealers and comparison sites call the API server-to-server with an X-API-Key header.
+-- A key reads "pk_<prefix>_<secret>"; only the prefix, which finds the row, and the
+-- SHA-256 of the whole key are stored, so the key cannot be recovered from the database.
+-- Scopes limit what a key may call, and a key may carry its own rate limit in place of
+-- the route group's. Rotating a key creates a new key pointing at the old one in
+-- rotated_from and lets the old one expire after a grace period.
+
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    rate_limit_per_minute INTEGER CHECK (rate_limit_per_minute > 0), -- NULL for the route group's limit
+    rate_limit_burst INTEGER CHECK (rate_limit_burst > 0),
+    expires_at TIMESTAMP WITH TIME ZONE, -- NULL for a key that does not expire
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    rotated_from BIGINT REFERENCES api_keys(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((rate_limit_per_minute IS NULL) = (rate_limit_burst IS NULL))
+);
+
+INSERT INTO schema_version (version, name) VALUES (17, 'api_keys');
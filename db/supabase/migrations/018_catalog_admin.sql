@@ -0,0 +1,93 @@
+-- Catalog management and its audit trail
+-- Administrators change plans, bundling rules and coverage through the admin API instead
+-- of SQL seeds. apply_catalog_change makes the change and records it in
+-- catalog_audit_log with who made it and the row before and after, in one transaction,
+-- whether called over PostgREST (/rpc) or a direct connection.
+--
+-- Application errors use custom SQLSTATEs mapped by the backend:
+--   AP005 catalog entry not found, AP006 catalog entry conflicts with existing data
+--   (duplicate key, or still referenced), AP007 catalog entry violates a constraint
+
+ALTER TABLE bundling_rules ADD CONSTRAINT valid_discount_percent CHECK (discount_percent >= 0 AND discount_percent <= 100);
+
+CREATE TABLE catalog_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    entity VARCHAR(32) NOT NULL, -- the table changed
+    entity_key VARCHAR(64) NOT NULL,
+    action VARCHAR(16) NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    actor VARCHAR(255) NOT NULL,
+    before JSONB, -- NULL for creates
+    after JSONB, -- NULL for deletes
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_catalog_audit_log_entity ON catalog_audit_log(entity, id DESC);
+
+-- apply_catalog_change creates, updates or deletes the row of p_entity with key p_key
+-- and returns its audit entry. p_data is the row as JSON, keyed by column: created rows
+-- get a new serial key (coverage keeps its address_id), and updates replace every column
+-- but the key. Missing columns are set to NULL, so callers send whole rows.
+CREATE OR REPLACE FUNCTION apply_catalog_change(p_entity VARCHAR, p_action VARCHAR, p_key VARCHAR, p_data JSONB, p_actor VARCHAR)
+RETURNS catalog_audit_log AS $$
+DECLARE
+    v_key_column TEXT;
+    v_columns TEXT;
+    v_before JSONB;
+    v_after JSONB;
+    v_entry catalog_audit_log;
+BEGIN
+    v_key_column := CASE p_entity
+        WHEN 'mobile_plans' THEN 'plan_id'
+        WHEN 'home_plans' THEN 'home_id'
+        WHEN 'tv_plans' THEN 'tv_id'
+        WHEN 'bundling_rules' THEN 'rule_id'
+        WHEN 'coverage' THEN 'address_id'
+    END;
+    IF v_key_column IS NULL THEN
+        RAISE EXCEPTION 'unknown catalog entity %', p_entity USING ERRCODE = 'AP007';
+    END IF;
+
+    IF p_action <> 'create' THEN
+        EXECUTE format('SELECT to_jsonb(t) FROM %I t WHERE t.%I::text = $1 FOR UPDATE', p_entity, v_key_column)
+        INTO v_before USING p_key;
+        IF v_before IS NULL THEN
+            RAISE EXCEPTION '% % not found', p_entity, p_key USING ERRCODE = 'AP005';
+        END IF;
+    END IF;
+
+    BEGIN
+        CASE p_action
+        WHEN 'create' THEN
+            IF p_entity <> 'coverage' THEN
+                p_data := p_data || jsonb_build_object(v_key_column, nextval(pg_get_serial_sequence(p_entity, v_key_column)));
+            END IF;
+            EXECUTE format('INSERT INTO %1$I SELECT * FROM jsonb_populate_record(NULL::%1$I, $1) RETURNING to_jsonb(%1$I.*)', p_entity)
+            INTO v_after USING p_data;
+        WHEN 'update' THEN
+            SELECT string_agg(quote_ident(k), ', ') INTO v_columns
+            FROM jsonb_object_keys(v_before) AS k
+            WHERE k <> v_key_column;
+            EXECUTE format('UPDATE %1$I t SET (%2$s) = (SELECT %2$s FROM jsonb_populate_record(NULL::%1$I, $1)) WHERE t.%3$I::text = $2 RETURNING to_jsonb(t)',
+                p_entity, v_columns, v_key_column)
+            INTO v_after USING p_data, p_key;
+        WHEN 'delete' THEN
+            EXECUTE format('DELETE FROM %I t WHERE t.%I::text = $1', p_entity, v_key_column) USING p_key;
+        ELSE
+            RAISE EXCEPTION 'unknown catalog action %', p_action USING ERRCODE = 'AP007';
+        END CASE;
+    EXCEPTION
+        WHEN unique_violation OR foreign_key_violation THEN
+            RAISE EXCEPTION '%', SQLERRM USING ERRCODE = 'AP006';
+        WHEN check_violation OR not_null_violation OR string_data_right_truncation OR numeric_value_out_of_range THEN
+            RAISE EXCEPTION '%', SQLERRM USING ERRCODE = 'AP007';
+    END;
+
+    INSERT INTO catalog_audit_log (entity, entity_key, action, actor, before, after)
+    VALUES (p_entity, COALESCE(v_after->>v_key_column, p_key), p_action, p_actor, v_before, v_after)
+    RETURNING * INTO v_entry;
+
+    RETURN v_entry;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version, name) VALUES (18, 'catalog_admin');
@@ -0,0 +1,197 @@
+-- Versioned catalog with effective dates
+-- Plans and bundling rules keep every price they ever had. Each row is one version of a
+-- plan or rule, in effect from effective_from until effective_to (exclusive, NULL while
+-- open ended); versions of the same plan never overlap. The plan IDs stay the same across
+-- versions, so orders name the exact plan they bought by ID and version.
+--
+-- apply_catalog_change no longer edits rows in place: an update closes the version in
+-- effect at p_effective_from (now when NULL) and adds the next one, and a delete retires
+-- the plan from then on. Changes can be scheduled ahead, e.g. a price change on a campaign
+-- date. Coverage is not versioned and still changes in place.
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Existing rows become version 1, in effect since before any order was taken
+ALTER TABLE mobile_plans
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT '1970-01-01 00:00:00+00',
+    ADD COLUMN effective_to TIMESTAMP WITH TIME ZONE;
+ALTER TABLE home_plans
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT '1970-01-01 00:00:00+00',
+    ADD COLUMN effective_to TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tv_plans
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT '1970-01-01 00:00:00+00',
+    ADD COLUMN effective_to TIMESTAMP WITH TIME ZONE;
+ALTER TABLE bundling_rules
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT '1970-01-01 00:00:00+00',
+    ADD COLUMN effective_to TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE mobile_plans ALTER COLUMN effective_from SET DEFAULT NOW();
+ALTER TABLE home_plans ALTER COLUMN effective_from SET DEFAULT NOW();
+ALTER TABLE tv_plans ALTER COLUMN effective_from SET DEFAULT NOW();
+ALTER TABLE bundling_rules ALTER COLUMN effective_from SET DEFAULT NOW();
+
+ALTER TABLE mobile_plans
+    DROP CONSTRAINT mobile_plans_pkey,
+    ADD PRIMARY KEY (plan_id, version),
+    ADD CONSTRAINT valid_mobile_plan_dates CHECK (effective_to IS NULL OR effective_to > effective_from),
+    ADD CONSTRAINT mobile_plan_versions_do_not_overlap
+        EXCLUDE USING gist (plan_id WITH =, tstzrange(effective_from, effective_to) WITH &&);
+ALTER TABLE home_plans
+    DROP CONSTRAINT home_plans_pkey,
+    ADD PRIMARY KEY (home_id, version),
+    ADD CONSTRAINT valid_home_plan_dates CHECK (effective_to IS NULL OR effective_to > effective_from),
+    ADD CONSTRAINT home_plan_versions_do_not_overlap
+        EXCLUDE USING gist (home_id WITH =, tstzrange(effective_from, effective_to) WITH &&);
+ALTER TABLE tv_plans
+    DROP CONSTRAINT tv_plans_pkey,
+    ADD PRIMARY KEY (tv_id, version),
+    ADD CONSTRAINT valid_tv_plan_dates CHECK (effective_to IS NULL OR effective_to > effective_from),
+    ADD CONSTRAINT tv_plan_versions_do_not_overlap
+        EXCLUDE USING gist (tv_id WITH =, tstzrange(effective_from, effective_to) WITH &&);
+ALTER TABLE bundling_rules
+    DROP CONSTRAINT bundling_rules_pkey,
+    ADD PRIMARY KEY (rule_id, version),
+    ADD CONSTRAINT valid_bundling_rule_dates CHECK (effective_to IS NULL OR effective_to > effective_from),
+    ADD CONSTRAINT bundling_rule_versions_do_not_overlap
+        EXCLUDE USING gist (rule_id WITH =, tstzrange(effective_from, effective_to) WITH &&);
+
+-- catalog_valid_until returns when the catalog in effect at p_at next changes: the first
+-- version starting or ending after it, NULL when no change is scheduled
+CREATE OR REPLACE FUNCTION catalog_valid_until(p_at TIMESTAMP WITH TIME ZONE)
+RETURNS TIMESTAMP WITH TIME ZONE AS $$
+    SELECT MIN(t) FROM (
+        SELECT effective_from FROM mobile_plans WHERE effective_from > p_at
+        UNION ALL SELECT effective_to FROM mobile_plans WHERE effective_to > p_at
+        UNION ALL SELECT effective_from FROM home_plans WHERE effective_from > p_at
+        UNION ALL SELECT effective_to FROM home_plans WHERE effective_to > p_at
+        UNION ALL SELECT effective_from FROM tv_plans WHERE effective_from > p_at
+        UNION ALL SELECT effective_to FROM tv_plans WHERE effective_to > p_at
+        UNION ALL SELECT effective_from FROM bundling_rules WHERE effective_from > p_at
+        UNION ALL SELECT effective_to FROM bundling_rules WHERE effective_to > p_at
+    ) AS changes(t);
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION apply_catalog_change(VARCHAR, VARCHAR, VARCHAR, JSONB, VARCHAR);
+
+-- apply_catalog_change creates, updates or deletes the entry of p_entity with key p_key
+-- and returns its audit entry. p_data is the row as JSON, keyed by column; missing
+-- columns are set to NULL, so callers send whole rows.
+--
+-- For plans and rules, changes take effect at p_effective_from, or now when it is NULL.
+-- A create adds version 1 of a new plan with a new serial key. An update adds the next
+-- version and ends the one in effect then, which keeps its price for the orders that
+-- bought it; a scheduled version starting exactly then is replaced instead. A delete ends
+-- the version in effect then and drops any scheduled after it. The audit entry holds the
+-- version ended (before) and the version added (after).
+CREATE OR REPLACE FUNCTION apply_catalog_change(p_entity VARCHAR, p_action VARCHAR, p_key VARCHAR, p_data JSONB, p_actor VARCHAR,
+    p_effective_from TIMESTAMP WITH TIME ZONE DEFAULT NULL)
+RETURNS catalog_audit_log AS $$
+DECLARE
+    v_key_column TEXT;
+    v_versioned BOOLEAN := p_entity <> 'coverage';
+    v_at TIMESTAMP WITH TIME ZONE := COALESCE(p_effective_from, NOW());
+    v_columns TEXT;
+    v_before JSONB;
+    v_after JSONB;
+    v_version INTEGER;
+    v_entry catalog_audit_log;
+BEGIN
+    v_key_column := CASE p_entity
+        WHEN 'mobile_plans' THEN 'plan_id'
+        WHEN 'home_plans' THEN 'home_id'
+        WHEN 'tv_plans' THEN 'tv_id'
+        WHEN 'bundling_rules' THEN 'rule_id'
+        WHEN 'coverage' THEN 'address_id'
+    END;
+    IF v_key_column IS NULL THEN
+        RAISE EXCEPTION 'unknown catalog entity %', p_entity USING ERRCODE = 'AP007';
+    END IF;
+    IF p_effective_from IS NOT NULL AND NOT v_versioned THEN
+        RAISE EXCEPTION 'coverage changes cannot be scheduled' USING ERRCODE = 'AP007';
+    END IF;
+
+    IF p_action <> 'create' THEN
+        IF v_versioned THEN
+            EXECUTE format('SELECT to_jsonb(t) FROM %I t WHERE t.%I::text = $1
+                AND t.effective_from <= $2 AND (t.effective_to IS NULL OR t.effective_to > $2) FOR UPDATE', p_entity, v_key_column)
+            INTO v_before USING p_key, v_at;
+        ELSE
+            EXECUTE format('SELECT to_jsonb(t) FROM %I t WHERE t.%I::text = $1 FOR UPDATE', p_entity, v_key_column)
+            INTO v_before USING p_key;
+        END IF;
+        IF v_before IS NULL THEN
+            RAISE EXCEPTION '% % not found', p_entity, p_key USING ERRCODE = 'AP005';
+        END IF;
+    END IF;
+
+    BEGIN
+        CASE p_action
+        WHEN 'create' THEN
+            IF v_versioned THEN
+                p_data := p_data || jsonb_build_object(
+                    v_key_column, nextval(pg_get_serial_sequence(p_entity, v_key_column)),
+                    'version', 1,
+                    'effective_from', v_at,
+                    'effective_to', NULL);
+            END IF;
+            EXECUTE format('INSERT INTO %1$I SELECT * FROM jsonb_populate_record(NULL::%1$I, $1) RETURNING to_jsonb(%1$I.*)', p_entity)
+            INTO v_after USING p_data;
+        WHEN 'update' THEN
+            IF NOT v_versioned THEN
+                SELECT string_agg(quote_ident(k), ', ') INTO v_columns
+                FROM jsonb_object_keys(v_before) AS k
+                WHERE k <> v_key_column;
+                EXECUTE format('UPDATE %1$I t SET (%2$s) = (SELECT %2$s FROM jsonb_populate_record(NULL::%1$I, $1)) WHERE t.%3$I::text = $2 RETURNING to_jsonb(t)',
+                    p_entity, v_columns, v_key_column)
+                INTO v_after USING p_data, p_key;
+            ELSE
+                EXECUTE format('SELECT MAX(version) + 1 FROM %I t WHERE t.%I::text = $1', p_entity, v_key_column)
+                INTO v_version USING p_key;
+                IF (v_before->>'effective_from')::timestamptz = v_at THEN
+                    EXECUTE format('DELETE FROM %I t WHERE t.%I::text = $1 AND t.version = $2', p_entity, v_key_column)
+                    USING p_key, (v_before->>'version')::int;
+                ELSE
+                    EXECUTE format('UPDATE %I t SET effective_to = $3 WHERE t.%I::text = $1 AND t.version = $2', p_entity, v_key_column)
+                    USING p_key, (v_before->>'version')::int, v_at;
+                END IF;
+                p_data := p_data || jsonb_build_object(
+                    v_key_column, v_before->v_key_column,
+                    'version', v_version,
+                    'effective_from', v_at,
+                    'effective_to', v_before->'effective_to');
+                EXECUTE format('INSERT INTO %1$I SELECT * FROM jsonb_populate_record(NULL::%1$I, $1) RETURNING to_jsonb(%1$I.*)', p_entity)
+                INTO v_after USING p_data;
+            END IF;
+        WHEN 'delete' THEN
+            IF NOT v_versioned THEN
+                EXECUTE format('DELETE FROM %I t WHERE t.%I::text = $1', p_entity, v_key_column) USING p_key;
+            ELSE
+                EXECUTE format('DELETE FROM %I t WHERE t.%I::text = $1 AND t.effective_from >= $2', p_entity, v_key_column)
+                USING p_key, v_at;
+                EXECUTE format('UPDATE %I t SET effective_to = $2 WHERE t.%I::text = $1 AND t.effective_from < $2 AND (t.effective_to IS NULL OR t.effective_to > $2)',
+                    p_entity, v_key_column)
+                USING p_key, v_at;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown catalog action %', p_action USING ERRCODE = 'AP007';
+        END CASE;
+    EXCEPTION
+        WHEN unique_violation OR foreign_key_violation OR exclusion_violation THEN
+            RAISE EXCEPTION '%', SQLERRM USING ERRCODE = 'AP006';
+        WHEN check_violation OR not_null_violation OR string_data_right_truncation OR numeric_value_out_of_range THEN
+            RAISE EXCEPTION '%', SQLERRM USING ERRCODE = 'AP007';
+    END;
+
+    INSERT INTO catalog_audit_log (entity, entity_key, action, actor, before, after)
+    VALUES (p_entity, COALESCE(v_after->>v_key_column, p_key), p_action, p_actor, v_before, v_after)
+    RETURNING * INTO v_entry;
+
+    RETURN v_entry;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version, name) VALUES (19, 'catalog_versions');
@@ -0,0 +1,201 @@
+-- Promotional campaigns and coupon codes
+-- A campaign takes a percentage or a fixed amount off a component of an offer (mobile,
+-- home, TV or the total) every month or for its first duration_months, or makes the
+-- component free for its first discount_value months. Campaigns without a code apply
+-- automatically to every eligible offer; those with a code only when the customer enters
+-- it, at recommendation or checkout. Eligibility can be limited to cities, home
+-- technologies, plans and new customers; empty lists do not limit. Stackable campaigns
+-- combine with each other; the others apply alone, when that saves more.
+--
+-- Quotes and orders keep the promotions they were priced with. Campaigns are managed
+-- through the admin catalog API, so apply_catalog_change learns them; they are not
+-- versioned.
+
+CREATE TABLE campaigns (
+    campaign_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    code VARCHAR(64) UNIQUE CHECK (code = UPPER(code)), -- NULL for automatic campaigns
+    discount_type VARCHAR(16) NOT NULL CHECK (discount_type IN ('percent', 'fixed', 'free_months')),
+    discount_value NUMERIC(10,2) NOT NULL CHECK (discount_value > 0),
+    duration_months INTEGER CHECK (duration_months > 0), -- NULL for every month
+    applies_to VARCHAR(16) NOT NULL CHECK (applies_to IN ('mobile', 'home', 'tv', 'total')),
+    cities TEXT[],
+    techs TEXT[],
+    mobile_plan_ids INTEGER[],
+    home_plan_ids INTEGER[],
+    tv_plan_ids INTEGER[],
+    new_customers_only BOOLEAN NOT NULL DEFAULT FALSE,
+    stackable BOOLEAN NOT NULL DEFAULT TRUE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT valid_percent_discount CHECK (discount_type <> 'percent' OR discount_value <= 100),
+    CONSTRAINT valid_free_months CHECK (discount_type <> 'free_months' OR (discount_value = TRUNC(discount_value) AND duration_months IS NULL)),
+    CONSTRAINT valid_campaign_dates CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_campaigns_ends_at ON campaigns(ends_at);
+
+ALTER TABLE quotes ADD COLUMN promotions JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE orders ADD COLUMN promotions JSONB NOT NULL DEFAULT '[]';
+
+-- is_new_customer reports whether a user has neither current services nor an order that
+-- was not cancelled
+CREATE OR REPLACE FUNCTION is_new_customer(p_user_id INTEGER)
+RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (SELECT 1 FROM current_services WHERE user_id = p_user_id)
+        AND NOT EXISTS (SELECT 1 FROM orders WHERE user_id = p_user_id AND status <> 'cancelled');
+$$ LANGUAGE sql STABLE;
+
+-- place_order now stores the order's promotions; it is otherwise unchanged
+DROP FUNCTION place_order(VARCHAR, INTEGER, VARCHAR, VARCHAR, VARCHAR, VARCHAR, NUMERIC, JSONB, NUMERIC);
+
+CREATE OR REPLACE FUNCTION place_order(
+    p_order_id VARCHAR,
+    p_user_id INTEGER,
+    p_address_id VARCHAR,
+    p_slot_id VARCHAR,
+    p_tech VARCHAR,
+    p_combo_label VARCHAR,
+    p_monthly_total NUMERIC,
+    p_items JSONB,
+    p_upfront_amount NUMERIC,
+    p_promotions JSONB DEFAULT NULL
+) RETURNS orders AS $$
+DECLARE
+    v_slot install_slots;
+    v_order orders;
+BEGIN
+    v_slot := claim_install_slot(p_slot_id, p_address_id, p_tech, NOW());
+
+    INSERT INTO orders (order_id, user_id, address_id, slot_id, tech, status, combo_label, monthly_total, items, upfront_amount, promotions)
+    VALUES (p_order_id, p_user_id, p_address_id, p_slot_id, v_slot.tech, 'pending', p_combo_label, p_monthly_total, p_items, p_upfront_amount,
+        COALESCE(p_promotions, '[]'))
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, new_slot_id)
+    VALUES (p_order_id, 'booked', p_slot_id);
+
+    PERFORM emit_event('SlotBooked', p_order_id, jsonb_build_object(
+        'slot_id', p_slot_id,
+        'previous_slot_id', NULL,
+        'slot_start', v_slot.slot_start,
+        'slot_end', v_slot.slot_end
+    ));
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+-- apply_catalog_change now manages campaigns too, like coverage but with serial keys; it
+-- is otherwise unchanged
+CREATE OR REPLACE FUNCTION apply_catalog_change(p_entity VARCHAR, p_action VARCHAR, p_key VARCHAR, p_data JSONB, p_actor VARCHAR,
+    p_effective_from TIMESTAMP WITH TIME ZONE DEFAULT NULL)
+RETURNS catalog_audit_log AS $$
+DECLARE
+    v_key_column TEXT;
+    v_versioned BOOLEAN := p_entity NOT IN ('coverage', 'campaigns');
+    v_at TIMESTAMP WITH TIME ZONE := COALESCE(p_effective_from, NOW());
+    v_columns TEXT;
+    v_before JSONB;
+    v_after JSONB;
+    v_version INTEGER;
+    v_entry catalog_audit_log;
+BEGIN
+    v_key_column := CASE p_entity
+        WHEN 'mobile_plans' THEN 'plan_id'
+        WHEN 'home_plans' THEN 'home_id'
+        WHEN 'tv_plans' THEN 'tv_id'
+        WHEN 'bundling_rules' THEN 'rule_id'
+        WHEN 'coverage' THEN 'address_id'
+        WHEN 'campaigns' THEN 'campaign_id'
+    END;
+    IF v_key_column IS NULL THEN
+        RAISE EXCEPTION 'unknown catalog entity %', p_entity USING ERRCODE = 'AP007';
+    END IF;
+    IF p_effective_from IS NOT NULL AND NOT v_versioned THEN
+        RAISE EXCEPTION '% changes cannot be scheduled', p_entity USING ERRCODE = 'AP007';
+    END IF;
+
+    IF p_action <> 'create' THEN
+        IF v_versioned THEN
+            EXECUTE format('SELECT to_jsonb(t) FROM %I t WHERE t.%I::text = $1
+                AND t.effective_from <= $2 AND (t.effective_to IS NULL OR t.effective_to > $2) FOR UPDATE', p_entity, v_key_column)
+            INTO v_before USING p_key, v_at;
+        ELSE
+            EXECUTE format('SELECT to_jsonb(t) FROM %I t WHERE t.%I::text = $1 FOR UPDATE', p_entity, v_key_column)
+            INTO v_before USING p_key;
+        END IF;
+        IF v_before IS NULL THEN
+            RAISE EXCEPTION '% % not found', p_entity, p_key USING ERRCODE = 'AP005';
+        END IF;
+    END IF;
+
+    BEGIN
+        CASE p_action
+        WHEN 'create' THEN
+            IF v_versioned THEN
+                p_data := p_data || jsonb_build_object(
+                    v_key_column, nextval(pg_get_serial_sequence(p_entity, v_key_column)),
+                    'version', 1,
+                    'effective_from', v_at,
+                    'effective_to', NULL);
+            ELSIF p_entity <> 'coverage' THEN
+                p_data := p_data || jsonb_build_object(v_key_column, nextval(pg_get_serial_sequence(p_entity, v_key_column)));
+            END IF;
+            EXECUTE format('INSERT INTO %1$I SELECT * FROM jsonb_populate_record(NULL::%1$I, $1) RETURNING to_jsonb(%1$I.*)', p_entity)
+            INTO v_after USING p_data;
+        WHEN 'update' THEN
+            IF NOT v_versioned THEN
+                SELECT string_agg(quote_ident(k), ', ') INTO v_columns
+                FROM jsonb_object_keys(v_before) AS k
+                WHERE k <> v_key_column;
+                EXECUTE format('UPDATE %1$I t SET (%2$s) = (SELECT %2$s FROM jsonb_populate_record(NULL::%1$I, $1)) WHERE t.%3$I::text = $2 RETURNING to_jsonb(t)',
+                    p_entity, v_columns, v_key_column)
+                INTO v_after USING p_data, p_key;
+            ELSE
+                EXECUTE format('SELECT MAX(version) + 1 FROM %I t WHERE t.%I::text = $1', p_entity, v_key_column)
+                INTO v_version USING p_key;
+                IF (v_before->>'effective_from')::timestamptz = v_at THEN
+                    EXECUTE format('DELETE FROM %I t WHERE t.%I::text = $1 AND t.version = $2', p_entity, v_key_column)
+                    USING p_key, (v_before->>'version')::int;
+                ELSE
+                    EXECUTE format('UPDATE %I t SET effective_to = $3 WHERE t.%I::text = $1 AND t.version = $2', p_entity, v_key_column)
+                    USING p_key, (v_before->>'version')::int, v_at;
+                END IF;
+                p_data := p_data || jsonb_build_object(
+                    v_key_column, v_before->v_key_column,
+                    'version', v_version,
+                    'effective_from', v_at,
+                    'effective_to', v_before->'effective_to');
+                EXECUTE format('INSERT INTO %1$I SELECT * FROM jsonb_populate_record(NULL::%1$I, $1) RETURNING to_jsonb(%1$I.*)', p_entity)
+                INTO v_after USING p_data;
+            END IF;
+        WHEN 'delete' THEN
+            IF NOT v_versioned THEN
+                EXECUTE format('DELETE FROM %I t WHERE t.%I::text = $1', p_entity, v_key_column) USING p_key;
+            ELSE
+                EXECUTE format('DELETE FROM %I t WHERE t.%I::text = $1 AND t.effective_from >= $2', p_entity, v_key_column)
+                USING p_key, v_at;
+                EXECUTE format('UPDATE %I t SET effective_to = $2 WHERE t.%I::text = $1 AND t.effective_from < $2 AND (t.effective_to IS NULL OR t.effective_to > $2)',
+                    p_entity, v_key_column)
+                USING p_key, v_at;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown catalog action %', p_action USING ERRCODE = 'AP007';
+        END CASE;
+    EXCEPTION
+        WHEN unique_violation OR foreign_key_violation OR exclusion_violation THEN
+            RAISE EXCEPTION '%', SQLERRM USING ERRCODE = 'AP006';
+        WHEN check_violation OR not_null_violation OR string_data_right_truncation OR numeric_value_out_of_range THEN
+            RAISE EXCEPTION '%', SQLERRM USING ERRCODE = 'AP007';
+    END;
+
+    INSERT INTO catalog_audit_log (entity, entity_key, action, actor, before, after)
+    VALUES (p_entity, COALESCE(v_after->>v_key_column, p_key), p_action, p_actor, v_before, v_after)
+    RETURNING * INTO v_entry;
+
+    RETURN v_entry;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version, name) VALUES (20, 'promotions');
@@ -0,0 +1,73 @@
+-- Contract commitment terms and early termination fees
+-- A plan's monthly_price is its price without commitment. A plan can also be offered with
+-- commitment terms, each a number of months with a lower monthly price and the fee for
+-- terminating before they end: terms is a JSON array of
+-- {"months": 12, "monthly_price": 105.00, "termination_fee": 180.00}. NULL or an empty
+-- array offers the plan without commitment only. Terms are part of a plan version, so
+-- they change, and can be scheduled, with its price.
+--
+-- Quotes and orders keep the commitment they were priced with and its termination fee.
+
+ALTER TABLE mobile_plans ADD COLUMN terms JSONB CHECK (terms IS NULL OR jsonb_typeof(terms) = 'array');
+ALTER TABLE home_plans ADD COLUMN terms JSONB CHECK (terms IS NULL OR jsonb_typeof(terms) = 'array');
+ALTER TABLE tv_plans ADD COLUMN terms JSONB CHECK (terms IS NULL OR jsonb_typeof(terms) = 'array');
+
+-- Commitment offers of the seeded fiber plans and TV packages
+UPDATE home_plans SET terms = '[{"months": 12, "monthly_price": 79.90, "termination_fee": 150.00},
+    {"months": 24, "monthly_price": 69.90, "termination_fee": 300.00}]' WHERE home_id = 1;
+UPDATE home_plans SET terms = '[{"months": 12, "monthly_price": 104.90, "termination_fee": 180.00},
+    {"months": 24, "monthly_price": 94.90, "termination_fee": 360.00}]' WHERE home_id = 2;
+UPDATE home_plans SET terms = '[{"months": 12, "monthly_price": 139.90, "termination_fee": 240.00},
+    {"months": 24, "monthly_price": 124.90, "termination_fee": 480.00}]' WHERE home_id = 3;
+UPDATE tv_plans SET terms = '[{"months": 12, "monthly_price": 34.90, "termination_fee": 60.00}]' WHERE tv_id = 1;
+UPDATE tv_plans SET terms = '[{"months": 12, "monthly_price": 49.90, "termination_fee": 120.00}]' WHERE tv_id = 2;
+
+ALTER TABLE quotes ADD COLUMN commitment_months INTEGER NOT NULL DEFAULT 0 CHECK (commitment_months >= 0);
+ALTER TABLE quotes ADD COLUMN termination_fee NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (termination_fee >= 0);
+ALTER TABLE orders ADD COLUMN commitment_months INTEGER NOT NULL DEFAULT 0 CHECK (commitment_months >= 0);
+ALTER TABLE orders ADD COLUMN termination_fee NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (termination_fee >= 0);
+
+-- place_order now stores the order's commitment; it is otherwise unchanged
+DROP FUNCTION place_order(VARCHAR, INTEGER, VARCHAR, VARCHAR, VARCHAR, VARCHAR, NUMERIC, JSONB, NUMERIC, JSONB);
+
+CREATE OR REPLACE FUNCTION place_order(
+    p_order_id VARCHAR,
+    p_user_id INTEGER,
+    p_address_id VARCHAR,
+    p_slot_id VARCHAR,
+    p_tech VARCHAR,
+    p_combo_label VARCHAR,
+    p_monthly_total NUMERIC,
+    p_items JSONB,
+    p_upfront_amount NUMERIC,
+    p_promotions JSONB DEFAULT NULL,
+    p_commitment_months INTEGER DEFAULT 0,
+    p_termination_fee NUMERIC DEFAULT 0
+) RETURNS orders AS $$
+DECLARE
+    v_slot install_slots;
+    v_order orders;
+BEGIN
+    v_slot := claim_install_slot(p_slot_id, p_address_id, p_tech, NOW());
+
+    INSERT INTO orders (order_id, user_id, address_id, slot_id, tech, status, combo_label, monthly_total, items, upfront_amount, promotions,
+        commitment_months, termination_fee)
+    VALUES (p_order_id, p_user_id, p_address_id, p_slot_id, v_slot.tech, 'pending', p_combo_label, p_monthly_total, p_items, p_upfront_amount,
+        COALESCE(p_promotions, '[]'), p_commitment_months, p_termination_fee)
+    RETURNING * INTO v_order;
+
+    INSERT INTO appointment_history (order_id, action, new_slot_id)
+    VALUES (p_order_id, 'booked', p_slot_id);
+
+    PERFORM emit_event('SlotBooked', p_order_id, jsonb_build_object(
+        'slot_id', p_slot_id,
+        'previous_slot_id', NULL,
+        'slot_start', v_slot.slot_start,
+        'slot_end', v_slot.slot_end
+    ));
+
+    RETURN v_order;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version, name) VALUES (21, 'commitment_terms');
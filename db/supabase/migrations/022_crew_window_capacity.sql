@@ -0,0 +1,114 @@
+-- One booking per technician and crew window
+-- A generated slot's capacity is the number of the crew's technicians qualified for its
+-- technology, so a technician qualified for several technologies counted in each of the
+-- window's slots and could be booked in all of them. Slots now count their bookings,
+-- and claiming or releasing a crew slot recomputes the remaining capacity of every slot
+-- of its window: the bookings of any set of the window's technologies can be at most the
+-- crew's active technicians qualified for one of them. The backend's slot generator
+-- (services.WindowCapacity) follows the same rule.
+--
+-- Hand-seeded slots belong to an address, not a crew, and keep counting on their own.
+
+ALTER TABLE install_slots ADD COLUMN booked INTEGER NOT NULL DEFAULT 0;
+UPDATE install_slots SET booked = capacity - remaining_capacity;
+ALTER TABLE install_slots ADD CONSTRAINT valid_slot_bookings CHECK (booked >= 0 AND booked <= capacity);
+
+-- refresh_crew_window sets the remaining capacity of every slot of a crew window from the
+-- bookings of the window: for each technology, the least that any set of technologies
+-- containing it leaves, technicians qualified for one of the set less its bookings
+CREATE OR REPLACE FUNCTION refresh_crew_window(p_crew_id INTEGER, p_slot_start TIMESTAMPTZ)
+RETURNS VOID AS $$
+    WITH window_slots AS (
+        SELECT tech, booked FROM install_slots WHERE crew_id = p_crew_id AND slot_start = p_slot_start
+    ), window_techs AS (
+        SELECT array_agg(DISTINCT tech ORDER BY tech) AS techs FROM window_slots
+    ), tech_sets AS (
+        SELECT ARRAY(SELECT w.techs[i] FROM generate_subscripts(w.techs, 1) AS i WHERE set_bits & (1 << (i - 1)) <> 0) AS techs
+        FROM window_techs w, generate_series(1, (1 << cardinality(w.techs)) - 1) AS set_bits
+    ), set_slack AS (
+        SELECT s.techs,
+            (SELECT COUNT(*) FROM technicians t WHERE t.crew_id = p_crew_id AND t.active AND t.techs && s.techs)
+            - (SELECT COALESCE(SUM(booked), 0) FROM window_slots WHERE tech = ANY(s.techs)) AS slack
+        FROM tech_sets s
+    )
+    UPDATE install_slots i
+    SET remaining_capacity = GREATEST(0, LEAST(i.capacity - i.booked,
+        (SELECT MIN(slack) FROM set_slack WHERE i.tech = ANY(set_slack.techs))))
+    WHERE i.crew_id = p_crew_id AND i.slot_start = p_slot_start;
+$$ LANGUAGE sql;
+
+-- lock_crew_window locks every slot of a crew slot's window, in a fixed order, so that
+-- concurrent claims of the window's technologies see each other's bookings. Slots without
+-- a crew lock nothing.
+CREATE OR REPLACE FUNCTION lock_crew_window(p_slot_id VARCHAR)
+RETURNS install_slots AS $$
+DECLARE
+    v_slot install_slots;
+BEGIN
+    SELECT * INTO v_slot FROM install_slots WHERE slot_id = p_slot_id;
+    IF v_slot.crew_id IS NOT NULL THEN
+        PERFORM 1 FROM install_slots
+        WHERE crew_id = v_slot.crew_id AND slot_start = v_slot.slot_start
+        ORDER BY slot_id
+        FOR UPDATE;
+    END IF;
+    RETURN v_slot;
+END;
+$$ LANGUAGE plpgsql;
+
+-- claim_install_slot now books the slot and recomputes its crew window; it is otherwise
+-- unchanged
+CREATE OR REPLACE FUNCTION claim_install_slot(p_slot_id VARCHAR, p_address_id VARCHAR, p_tech VARCHAR, p_not_before TIMESTAMPTZ)
+RETURNS install_slots AS $$
+DECLARE
+    v_slot install_slots;
+BEGIN
+    PERFORM lock_crew_window(p_slot_id);
+
+    UPDATE install_slots s
+    SET remaining_capacity = s.remaining_capacity - 1,
+        booked = s.booked + 1
+    WHERE s.slot_id = p_slot_id
+      AND s.remaining_capacity > 0
+      AND s.slot_start > p_not_before
+      AND (p_tech IS NULL OR s.tech = p_tech)
+      AND EXISTS (SELECT 1 FROM address_install_slots a WHERE a.slot_id = p_slot_id AND a.address_id = p_address_id)
+    RETURNING s.* INTO v_slot;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'install slot % is not available for address %', p_slot_id, p_address_id USING ERRCODE = 'AP001';
+    END IF;
+
+    IF v_slot.crew_id IS NOT NULL THEN
+        PERFORM refresh_crew_window(v_slot.crew_id, v_slot.slot_start);
+        SELECT * INTO v_slot FROM install_slots WHERE slot_id = p_slot_id;
+    END IF;
+
+    RETURN v_slot;
+END;
+$$ LANGUAGE plpgsql;
+
+-- release_install_slot now gives the booking back and recomputes the crew window
+CREATE OR REPLACE FUNCTION release_install_slot(p_slot_id VARCHAR)
+RETURNS VOID AS $$
+DECLARE
+    v_slot install_slots;
+BEGIN
+    v_slot := lock_crew_window(p_slot_id);
+
+    UPDATE install_slots
+    SET remaining_capacity = LEAST(remaining_capacity + 1, capacity),
+        booked = GREATEST(booked - 1, 0)
+    WHERE slot_id = p_slot_id;
+
+    IF v_slot.crew_id IS NOT NULL THEN
+        PERFORM refresh_crew_window(v_slot.crew_id, v_slot.slot_start);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Windows already double-booked keep their bookings but take no more
+SELECT refresh_crew_window(crew_id, slot_start)
+FROM (SELECT DISTINCT crew_id, slot_start FROM install_slots WHERE crew_id IS NOT NULL) AS windows;
+
+INSERT INTO schema_version (version, name) VALUES (22, 'crew_window_capacity');
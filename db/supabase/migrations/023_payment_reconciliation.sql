@@ -0,0 +1,34 @@
+-- Payment reconciliation
+-- An order's payment can be left in a state checkout did not finish: the authorisation
+-- timed out, so the provider may hold money for an order the hold sweeper later cancels;
+-- the capture after confirmation failed; or the refund of a cancelled order failed. The
+-- backend's reconcile-payments job settles these orders with the provider.
+--
+-- Orders are now placed with payment_status 'unknown': a payment may exist under the order
+-- ID until the authorisation answers, and is looked up by it when the order is cancelled
+-- before then. 'failed' records that no payment was taken.
+
+ALTER TABLE orders DROP CONSTRAINT valid_payment_status;
+ALTER TABLE orders ADD CONSTRAINT valid_payment_status
+    CHECK (payment_status IN ('unknown', 'authorized', 'captured', 'refunded', 'failed'));
+ALTER TABLE orders ALTER COLUMN payment_status SET DEFAULT 'unknown';
+
+-- Orders waiting for reconciliation
+CREATE INDEX idx_orders_unreconciled_payments ON orders (updated_at)
+WHERE (status = 'cancelled' AND payment_status IN ('unknown', 'authorized', 'captured'))
+   OR (status = 'confirmed' AND payment_status = 'authorized');
+
+-- unreconciled_orders returns, least recently updated first, cancelled orders whose payment
+-- may still hold money and confirmed orders whose payment was not captured, that have not
+-- changed for p_min_age_seconds, so checkouts still in progress are left alone
+CREATE OR REPLACE FUNCTION unreconciled_orders(p_min_age_seconds INTEGER, p_limit INTEGER)
+RETURNS SETOF orders AS $$
+    SELECT * FROM orders
+    WHERE ((status = 'cancelled' AND payment_status IN ('unknown', 'authorized', 'captured'))
+        OR (status = 'confirmed' AND payment_status = 'authorized'))
+      AND updated_at <= NOW() - make_interval(secs => p_min_age_seconds)
+    ORDER BY updated_at
+    LIMIT p_limit;
+$$ LANGUAGE sql STABLE;
+
+INSERT INTO schema_version (version, name) VALUES (23, 'payment_reconciliation');
@@ -0,0 +1,37 @@
+-- Atomic API key rotation
+-- Rotating a key issued the replacement and then shortened the old key's expiry in a
+-- second call, so a failure in between left both keys working for good. rotate_api_key
+-- now does both in one transaction.
+
+-- rotate_api_key issues the replacement of key p_id, with its name, scopes, rate limit and
+-- expiry and the given prefix and hash, and lets the old key expire at p_expire_old_at
+-- unless it expires earlier. Raises AP008 when the key does not exist and AP009 when it
+-- is revoked.
+CREATE OR REPLACE FUNCTION rotate_api_key(p_id BIGINT, p_key_prefix VARCHAR, p_key_hash CHAR(64), p_expire_old_at TIMESTAMPTZ)
+RETURNS api_keys AS $$
+DECLARE
+    v_old api_keys;
+    v_new api_keys;
+BEGIN
+    SELECT * INTO v_old FROM api_keys WHERE id = p_id FOR UPDATE;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'API key % not found', p_id USING ERRCODE = 'AP008';
+    END IF;
+
+    IF v_old.revoked_at IS NOT NULL THEN
+        RAISE EXCEPTION 'API key % is revoked', p_id USING ERRCODE = 'AP009';
+    END IF;
+
+    INSERT INTO api_keys (name, key_prefix, key_hash, scopes, rate_limit_per_minute, rate_limit_burst, expires_at, rotated_from)
+    VALUES (v_old.name, p_key_prefix, p_key_hash, v_old.scopes, v_old.rate_limit_per_minute, v_old.rate_limit_burst, v_old.expires_at, v_old.id)
+    RETURNING * INTO v_new;
+
+    UPDATE api_keys
+    SET expires_at = LEAST(COALESCE(expires_at, p_expire_old_at), p_expire_old_at)
+    WHERE id = p_id;
+
+    RETURN v_new;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO schema_version (version, name) VALUES (24, 'api_key_rotation');
@@ -0,0 +1,9 @@
+-- Idempotency keys per client
+-- Idempotency-Key headers were stored as sent, so two clients choosing the same key
+-- shared one record and could be replayed each other's responses. Keys are now stored
+-- prefixed with the client that sent them (e.g. key:12:<header>), which needs room
+-- beyond the 255 characters of the header.
+
+ALTER TABLE idempotency_keys ALTER COLUMN idempotency_key TYPE VARCHAR(320);
+
+INSERT INTO schema_version (version, name) VALUES (25, 'idempotency_key_scope');
@@ -0,0 +1,10 @@
+-- Drop the cancelled slot sweeper
+-- cancel_order and expire_pending_orders release a future slot in the same transaction
+-- as the cancellation, and leave slot_released false only when the slot has already
+-- started. release_cancelled_slots only looked at future slots, so it never found any.
+-- slot_released stays, as OrderCancelled events report it.
+
+DROP FUNCTION IF EXISTS release_cancelled_slots();
+DROP INDEX IF EXISTS idx_orders_unreleased_cancelled;
+
+INSERT INTO schema_version (version, name) VALUES (26, 'drop_release_cancelled_slots');
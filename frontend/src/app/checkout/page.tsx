@@ -1,12 +1,34 @@
 'use client';
 
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useMemo } from 'react';
 import { useRouter } from 'next/navigation';
 import { useWizard } from '@/context/WizardContext';
 import { useCheckout } from '@/lib/hooks';
+import { ApiError } from '@/lib/api-client';
 import { SlotPicker } from '@/components/SlotPicker';
 import type { RecommendationCandidateDTO, CheckoutRequest } from '@/types/api';
 
+// checkoutErrorMessage explains quote and payment failures the customer can act on
+function checkoutErrorMessage(error: unknown): string {
+  if (error instanceof ApiError) {
+    switch (error.code) {
+      case 'QUOTE_EXPIRED':
+      case 'QUOTE_STALE':
+      case 'QUOTE_NOT_FOUND':
+        return 'Prices for this package have expired or changed. Please go back to recommendations for an updated offer.';
+      case 'INVALID_CARD':
+        return 'The card details are invalid. Please check the card number, expiry and CVC.';
+      case 'PAYMENT_DECLINED':
+        return 'Your card was declined. Please try another card.';
+      case 'PAYMENT_AUTHENTICATION_REQUIRED':
+        return 'This card requires 3-D Secure, which is not supported yet. Please try another card.';
+      case 'PAYMENT_TIMEOUT':
+        return 'The payment could not be confirmed in time. Your slot is held for a few minutes; please try again.';
+    }
+  }
+  return 'Order failed. Please try again or contact support.';
+}
+
 export default function CheckoutPage() {
   const router = useRouter();
   const { state } = useWizard();
@@ -14,9 +36,17 @@ export default function CheckoutPage() {
   const [selectedSlotId, setSelectedSlotId] = useState<string>('');
   const [isProcessing, setIsProcessing] = useState(false);
   const [orderCompleted, setOrderCompleted] = useState<string | null>(null);
+  const [card, setCard] = useState({ number: '', expiry: '', cvc: '', holderName: '' });
 
   const checkoutMutation = useCheckout();
 
+  // One idempotency key per combo and slot, so double clicks and retries of the same
+  // order are only placed once while a changed selection is a new checkout
+  const idempotencyKey = useMemo(
+    () => crypto.randomUUID(),
+    [selectedRecommendation, selectedSlotId]
+  );
+
   // Load selected recommendation from session storage
   useEffect(() => {
     const savedRecommendation = sessionStorage.getItem('selectedRecommendation');
@@ -52,22 +82,41 @@ export default function CheckoutPage() {
     setSelectedSlotId(slotId);
   };
 
+  // Expiry is entered as MM/YY
+  const parseExpiry = (expiry: string) => {
+    const match = expiry.match(/^(\d{1,2})\s*\/\s*(\d{2})$/);
+    if (!match) {
+      return null;
+    }
+    return { month: Number(match[1]), year: 2000 + Number(match[2]) };
+  };
+
+  const cardNumber = card.number.replace(/\s+/g, '');
+  const cardExpiry = parseExpiry(card.expiry);
+  const cardComplete = /^\d{12,19}$/.test(cardNumber) && cardExpiry !== null && /^\d{3,4}$/.test(card.cvc);
+
   const handleConfirmOrder = async () => {
-    if (!selectedRecommendation || !selectedSlotId || !state.addressId) {
+    if (!selectedRecommendation?.quote_id || !selectedSlotId || !cardExpiry) {
       return;
     }
 
     setIsProcessing(true);
 
+    // Customer, items and price are taken from the server-side quote
     const checkoutRequest: CheckoutRequest = {
-      user_id: state.userId || 1,
-      selected_combo: selectedRecommendation,
+      quote_id: selectedRecommendation.quote_id,
       slot_id: selectedSlotId,
-      address_id: state.addressId,
+      payment: {
+        card_number: cardNumber,
+        expiry_month: cardExpiry.month,
+        expiry_year: cardExpiry.year,
+        cvc: card.cvc,
+        holder_name: card.holderName || undefined,
+      },
     };
 
     try {
-      const result = await checkoutMutation.mutateAsync(checkoutRequest);
+      const result = await checkoutMutation.mutateAsync({ request: checkoutRequest, idempotencyKey });
       setOrderCompleted(result.order_id);
       
       // Clear the selected recommendation from session storage
@@ -148,7 +197,8 @@ export default function CheckoutPage() {
     return null; // Will redirect
   }
 
-  const canProceed = selectedSlotId && !isProcessing;
+  const canProceed = selectedSlotId && cardComplete && !isProcessing;
+  const upfrontAmount = selectedRecommendation.monthly_total + (selectedRecommendation.items.home?.install_fee ?? 0);
   const homeTech = selectedRecommendation.items.home?.tech || 'fiber';
 
   return (
@@ -246,6 +296,10 @@ export default function CheckoutPage() {
                     <span>Monthly Total</span>
                     <span>{formatPrice(selectedRecommendation.monthly_total)}</span>
                   </div>
+                  <div className="flex justify-between text-sm text-gray-600 mt-1">
+                    <span>Due today</span>
+                    <span>{formatPrice(upfrontAmount)}</span>
+                  </div>
                 </div>
 
                 {selectedRecommendation.items.home && (
@@ -271,6 +325,62 @@ export default function CheckoutPage() {
               />
             </div>
 
+            {/* Payment */}
+            <div className="bg-white rounded-lg shadow-sm p-6 mt-8">
+              <h3 className="text-lg font-medium text-gray-900 mb-1">Payment</h3>
+              <p className="text-sm text-gray-600 mb-4">
+                The installation fee and first month ({formatPrice(upfrontAmount)}) are charged when the order is confirmed.
+              </p>
+
+              <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+                <label className="sm:col-span-2 text-sm text-gray-700">
+                  Card number
+                  <input
+                    type="text"
+                    inputMode="numeric"
+                    autoComplete="cc-number"
+                    placeholder="4242 4242 4242 4242"
+                    value={card.number}
+                    onChange={(e) => setCard({ ...card, number: e.target.value })}
+                    className="mt-1 w-full px-3 py-2 border border-gray-300 rounded-md"
+                  />
+                </label>
+                <label className="text-sm text-gray-700">
+                  Expiry (MM/YY)
+                  <input
+                    type="text"
+                    autoComplete="cc-exp"
+                    placeholder="12/30"
+                    value={card.expiry}
+                    onChange={(e) => setCard({ ...card, expiry: e.target.value })}
+                    className="mt-1 w-full px-3 py-2 border border-gray-300 rounded-md"
+                  />
+                </label>
+                <label className="text-sm text-gray-700">
+                  CVC
+                  <input
+                    type="text"
+                    inputMode="numeric"
+                    autoComplete="cc-csc"
+                    placeholder="123"
+                    value={card.cvc}
+                    onChange={(e) => setCard({ ...card, cvc: e.target.value })}
+                    className="mt-1 w-full px-3 py-2 border border-gray-300 rounded-md"
+                  />
+                </label>
+                <label className="sm:col-span-2 text-sm text-gray-700">
+                  Name on card
+                  <input
+                    type="text"
+                    autoComplete="cc-name"
+                    value={card.holderName}
+                    onChange={(e) => setCard({ ...card, holderName: e.target.value })}
+                    className="mt-1 w-full px-3 py-2 border border-gray-300 rounded-md"
+                  />
+                </label>
+              </div>
+            </div>
+
             {/* Confirm Order */}
             <div className="bg-white rounded-lg shadow-sm p-6 mt-8">
               <div className="flex flex-col sm:flex-row sm:items-center sm:justify-between">
@@ -314,12 +424,19 @@ export default function CheckoutPage() {
                   </p>
                 </div>
               )}
+              {selectedSlotId && !cardComplete && (
+                <div className="mt-4 p-3 bg-yellow-50 border border-yellow-200 rounded-md">
+                  <p className="text-sm text-yellow-700">
+                    Please enter your card details to continue.
+                  </p>
+                </div>
+              )}
 
               {/* Error message */}
               {checkoutMutation.isError && (
                 <div className="mt-4 p-3 bg-red-50 border border-red-200 rounded-md">
                   <p className="text-sm text-red-700">
-                    Order failed. Please try again or contact support.
+                    {checkoutErrorMessage(checkoutMutation.error)}
                   </p>
                 </div>
               )}
@@ -118,7 +118,7 @@ export function SlotPicker({
     );
   }
 
-  const availableSlots = slotsData?.slots.filter(slot => slot.available) || [];
+  const availableSlots = slotsData?.slots.filter(slot => slot.remaining_capacity > 0) || [];
   const groupedSlots = groupSlotsByDate(availableSlots);
 
   if (availableSlots.length === 0) {
@@ -30,11 +30,11 @@ async function fetchApi<T>(
   const url = `${API_BASE_URL}${endpoint}`;
   
   const response = await fetch(url, {
+    ...options,
     headers: {
       'Content-Type': 'application/json',
       ...options.headers,
     },
-    ...options,
   });
 
   if (!response.ok) {
@@ -84,11 +84,12 @@ export const apiClient = {
     });
   },
 
-  // Process checkout
-  async checkout(request: CheckoutRequest): Promise<CheckoutResponse> {
+  // Process checkout. Retrying with the same idempotency key never creates a second order.
+  async checkout(request: CheckoutRequest, idempotencyKey?: string): Promise<CheckoutResponse> {
     return fetchApi<CheckoutResponse>('/api/checkout', {
       method: 'POST',
       body: JSON.stringify(request),
+      headers: idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined,
     });
   },
 };
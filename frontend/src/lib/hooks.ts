@@ -82,7 +82,8 @@ export function useCheckout() {
   const queryClient = useQueryClient();
 
   return useMutation({
-    mutationFn: (request: CheckoutRequest) => apiClient.checkout(request),
+    mutationFn: ({ request, idempotencyKey }: { request: CheckoutRequest; idempotencyKey?: string }) =>
+      apiClient.checkout(request, idempotencyKey),
     onSuccess: (data) => {
       // Invalidate relevant queries after successful checkout
       queryClient.invalidateQueries({ queryKey: ['installSlots'] });
@@ -25,6 +25,8 @@ export interface RecommendationCandidateDTO {
   savings: number;
   reasoning: string;
   discounts: RecommendationDiscountsDTO;
+  quote_id?: string; // redeemed at checkout until quote_expires_at
+  quote_expires_at?: string;
 }
 
 export interface RecommendationItemsDTO {
@@ -85,29 +87,43 @@ export interface CoverageInfo {
 
 export interface InstallSlot {
   slot_id: string;
-  address_id: string;
+  address_id?: string;
+  crew_id?: number;
   slot_start: string;
   slot_end: string;
   tech: string;
-  available: boolean;
+  capacity: number;
+  remaining_capacity: number;
 }
 
 export interface InstallSlotsResponse {
   address_id: string;
-  tech: string;
+  techs: string[];
   slots: InstallSlot[];
+  limit?: number;
+  offset?: number;
+  has_more: boolean;
+}
+
+export interface PaymentMethodDTO {
+  card_number: string;
+  expiry_month: number;
+  expiry_year: number;
+  cvc: string;
+  holder_name?: string;
 }
 
 export interface CheckoutRequest {
-  user_id: number;
-  selected_combo: RecommendationCandidateDTO;
+  quote_id: string;
   slot_id: string;
-  address_id: string;
+  payment: PaymentMethodDTO;
 }
 
 export interface CheckoutResponse {
   status: string;
   order_id: string;
+  upfront_amount: number;
+  payment_status: string;
 }
 
 export interface ErrorResponse {